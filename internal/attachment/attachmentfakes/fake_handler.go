@@ -19,33 +19,59 @@ type FakeHandler struct {
 	clearReturnsOnCall map[int]struct {
 		result1 error
 	}
-	GetStub        func(string) (io.Reader, string, error)
+	DeleteStub        func(string) error
+	deleteMutex       sync.RWMutex
+	deleteArgsForCall []struct {
+		arg1 string
+	}
+	deleteReturns struct {
+		result1 error
+	}
+	deleteReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetStub        func(string) (*attachment.File, error)
 	getMutex       sync.RWMutex
 	getArgsForCall []struct {
 		arg1 string
 	}
 	getReturns struct {
-		result1 io.Reader
-		result2 string
-		result3 error
+		result1 *attachment.File
+		result2 error
 	}
 	getReturnsOnCall map[int]struct {
+		result1 *attachment.File
+		result2 error
+	}
+	PreviewStub        func(string, int, int) (io.Reader, error)
+	previewMutex       sync.RWMutex
+	previewArgsForCall []struct {
+		arg1 string
+		arg2 int
+		arg3 int
+	}
+	previewReturns struct {
 		result1 io.Reader
-		result2 string
-		result3 error
+		result2 error
+	}
+	previewReturnsOnCall map[int]struct {
+		result1 io.Reader
+		result2 error
 	}
-	UploadStub        func([]byte) (string, error)
+	UploadStub        func(io.Reader) (string, int64, error)
 	uploadMutex       sync.RWMutex
 	uploadArgsForCall []struct {
-		arg1 []byte
+		arg1 io.Reader
 	}
 	uploadReturns struct {
 		result1 string
-		result2 error
+		result2 int64
+		result3 error
 	}
 	uploadReturnsOnCall map[int]struct {
 		result1 string
-		result2 error
+		result2 int64
+		result3 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
@@ -104,7 +130,68 @@ func (fake *FakeHandler) ClearReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeHandler) Get(arg1 string) (io.Reader, string, error) {
+func (fake *FakeHandler) Delete(arg1 string) error {
+	fake.deleteMutex.Lock()
+	ret, specificReturn := fake.deleteReturnsOnCall[len(fake.deleteArgsForCall)]
+	fake.deleteArgsForCall = append(fake.deleteArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DeleteStub
+	fakeReturns := fake.deleteReturns
+	fake.recordInvocation("Delete", []interface{}{arg1})
+	fake.deleteMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHandler) DeleteCallCount() int {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	return len(fake.deleteArgsForCall)
+}
+
+func (fake *FakeHandler) DeleteCalls(stub func(string) error) {
+	fake.deleteMutex.Lock()
+	defer fake.deleteMutex.Unlock()
+	fake.DeleteStub = stub
+}
+
+func (fake *FakeHandler) DeleteArgsForCall(i int) string {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	argsForCall := fake.deleteArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHandler) DeleteReturns(result1 error) {
+	fake.deleteMutex.Lock()
+	defer fake.deleteMutex.Unlock()
+	fake.DeleteStub = nil
+	fake.deleteReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) DeleteReturnsOnCall(i int, result1 error) {
+	fake.deleteMutex.Lock()
+	defer fake.deleteMutex.Unlock()
+	fake.DeleteStub = nil
+	if fake.deleteReturnsOnCall == nil {
+		fake.deleteReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) Get(arg1 string) (*attachment.File, error) {
 	fake.getMutex.Lock()
 	ret, specificReturn := fake.getReturnsOnCall[len(fake.getArgsForCall)]
 	fake.getArgsForCall = append(fake.getArgsForCall, struct {
@@ -118,9 +205,9 @@ func (fake *FakeHandler) Get(arg1 string) (io.Reader, string, error) {
 		return stub(arg1)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2, ret.result3
+		return ret.result1, ret.result2
 	}
-	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeHandler) GetCallCount() int {
@@ -129,7 +216,7 @@ func (fake *FakeHandler) GetCallCount() int {
 	return len(fake.getArgsForCall)
 }
 
-func (fake *FakeHandler) GetCalls(stub func(string) (io.Reader, string, error)) {
+func (fake *FakeHandler) GetCalls(stub func(string) (*attachment.File, error)) {
 	fake.getMutex.Lock()
 	defer fake.getMutex.Unlock()
 	fake.GetStub = stub
@@ -142,57 +229,115 @@ func (fake *FakeHandler) GetArgsForCall(i int) string {
 	return argsForCall.arg1
 }
 
-func (fake *FakeHandler) GetReturns(result1 io.Reader, result2 string, result3 error) {
+func (fake *FakeHandler) GetReturns(result1 *attachment.File, result2 error) {
 	fake.getMutex.Lock()
 	defer fake.getMutex.Unlock()
 	fake.GetStub = nil
 	fake.getReturns = struct {
-		result1 io.Reader
-		result2 string
-		result3 error
-	}{result1, result2, result3}
+		result1 *attachment.File
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeHandler) GetReturnsOnCall(i int, result1 io.Reader, result2 string, result3 error) {
+func (fake *FakeHandler) GetReturnsOnCall(i int, result1 *attachment.File, result2 error) {
 	fake.getMutex.Lock()
 	defer fake.getMutex.Unlock()
 	fake.GetStub = nil
 	if fake.getReturnsOnCall == nil {
 		fake.getReturnsOnCall = make(map[int]struct {
-			result1 io.Reader
-			result2 string
-			result3 error
+			result1 *attachment.File
+			result2 error
 		})
 	}
 	fake.getReturnsOnCall[i] = struct {
+		result1 *attachment.File
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) Preview(arg1 string, arg2 int, arg3 int) (io.Reader, error) {
+	fake.previewMutex.Lock()
+	ret, specificReturn := fake.previewReturnsOnCall[len(fake.previewArgsForCall)]
+	fake.previewArgsForCall = append(fake.previewArgsForCall, struct {
+		arg1 string
+		arg2 int
+		arg3 int
+	}{arg1, arg2, arg3})
+	stub := fake.PreviewStub
+	fakeReturns := fake.previewReturns
+	fake.recordInvocation("Preview", []interface{}{arg1, arg2, arg3})
+	fake.previewMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHandler) PreviewCallCount() int {
+	fake.previewMutex.RLock()
+	defer fake.previewMutex.RUnlock()
+	return len(fake.previewArgsForCall)
+}
+
+func (fake *FakeHandler) PreviewCalls(stub func(string, int, int) (io.Reader, error)) {
+	fake.previewMutex.Lock()
+	defer fake.previewMutex.Unlock()
+	fake.PreviewStub = stub
+}
+
+func (fake *FakeHandler) PreviewArgsForCall(i int) (string, int, int) {
+	fake.previewMutex.RLock()
+	defer fake.previewMutex.RUnlock()
+	argsForCall := fake.previewArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeHandler) PreviewReturns(result1 io.Reader, result2 error) {
+	fake.previewMutex.Lock()
+	defer fake.previewMutex.Unlock()
+	fake.PreviewStub = nil
+	fake.previewReturns = struct {
 		result1 io.Reader
-		result2 string
-		result3 error
-	}{result1, result2, result3}
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeHandler) Upload(arg1 []byte) (string, error) {
-	var arg1Copy []byte
-	if arg1 != nil {
-		arg1Copy = make([]byte, len(arg1))
-		copy(arg1Copy, arg1)
+func (fake *FakeHandler) PreviewReturnsOnCall(i int, result1 io.Reader, result2 error) {
+	fake.previewMutex.Lock()
+	defer fake.previewMutex.Unlock()
+	fake.PreviewStub = nil
+	if fake.previewReturnsOnCall == nil {
+		fake.previewReturnsOnCall = make(map[int]struct {
+			result1 io.Reader
+			result2 error
+		})
 	}
+	fake.previewReturnsOnCall[i] = struct {
+		result1 io.Reader
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) Upload(arg1 io.Reader) (string, int64, error) {
 	fake.uploadMutex.Lock()
 	ret, specificReturn := fake.uploadReturnsOnCall[len(fake.uploadArgsForCall)]
 	fake.uploadArgsForCall = append(fake.uploadArgsForCall, struct {
-		arg1 []byte
-	}{arg1Copy})
+		arg1 io.Reader
+	}{arg1})
 	stub := fake.UploadStub
 	fakeReturns := fake.uploadReturns
-	fake.recordInvocation("Upload", []interface{}{arg1Copy})
+	fake.recordInvocation("Upload", []interface{}{arg1})
 	fake.uploadMutex.Unlock()
 	if stub != nil {
 		return stub(arg1)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2
+		return ret.result1, ret.result2, ret.result3
 	}
-	return fakeReturns.result1, fakeReturns.result2
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
 }
 
 func (fake *FakeHandler) UploadCallCount() int {
@@ -201,43 +346,46 @@ func (fake *FakeHandler) UploadCallCount() int {
 	return len(fake.uploadArgsForCall)
 }
 
-func (fake *FakeHandler) UploadCalls(stub func([]byte) (string, error)) {
+func (fake *FakeHandler) UploadCalls(stub func(io.Reader) (string, int64, error)) {
 	fake.uploadMutex.Lock()
 	defer fake.uploadMutex.Unlock()
 	fake.UploadStub = stub
 }
 
-func (fake *FakeHandler) UploadArgsForCall(i int) []byte {
+func (fake *FakeHandler) UploadArgsForCall(i int) io.Reader {
 	fake.uploadMutex.RLock()
 	defer fake.uploadMutex.RUnlock()
 	argsForCall := fake.uploadArgsForCall[i]
 	return argsForCall.arg1
 }
 
-func (fake *FakeHandler) UploadReturns(result1 string, result2 error) {
+func (fake *FakeHandler) UploadReturns(result1 string, result2 int64, result3 error) {
 	fake.uploadMutex.Lock()
 	defer fake.uploadMutex.Unlock()
 	fake.UploadStub = nil
 	fake.uploadReturns = struct {
 		result1 string
-		result2 error
-	}{result1, result2}
+		result2 int64
+		result3 error
+	}{result1, result2, result3}
 }
 
-func (fake *FakeHandler) UploadReturnsOnCall(i int, result1 string, result2 error) {
+func (fake *FakeHandler) UploadReturnsOnCall(i int, result1 string, result2 int64, result3 error) {
 	fake.uploadMutex.Lock()
 	defer fake.uploadMutex.Unlock()
 	fake.UploadStub = nil
 	if fake.uploadReturnsOnCall == nil {
 		fake.uploadReturnsOnCall = make(map[int]struct {
 			result1 string
-			result2 error
+			result2 int64
+			result3 error
 		})
 	}
 	fake.uploadReturnsOnCall[i] = struct {
 		result1 string
-		result2 error
-	}{result1, result2}
+		result2 int64
+		result3 error
+	}{result1, result2, result3}
 }
 
 func (fake *FakeHandler) Invocations() map[string][][]interface{} {
@@ -245,8 +393,12 @@ func (fake *FakeHandler) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.clearMutex.RLock()
 	defer fake.clearMutex.RUnlock()
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
+	fake.previewMutex.RLock()
+	defer fake.previewMutex.RUnlock()
 	fake.uploadMutex.RLock()
 	defer fake.uploadMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
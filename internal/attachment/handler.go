@@ -1,15 +1,93 @@
 package attachment
 
-import "io"
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/boardsite-io/server/internal/config"
+	"github.com/boardsite-io/server/pkg/s3"
+)
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 //counterfeiter:generate . Handler
 type Handler interface {
-	Upload(data []byte) (string, error)
-	Get(attachID string) (io.Reader, string, error)
+	// Upload sanitizes and stores the data of the reader. It returns the
+	// attachment id and the size of the stored data.
+	//
+	// PDF documents are streamed to the storage, other files are sanitized in memory.
+	Upload(r io.Reader) (string, int64, error)
+	// Get returns the content of an attachment. The caller needs to close the file.
+	Get(attachID string) (*File, error)
+	// Preview returns the PNG preview of a page of a PDF attachment.
+	// The page is one-based and the width one of the preview widths.
+	Preview(attachID string, page, width int) (io.Reader, error)
+	// Delete deletes an attachment including its previews.
+	Delete(attachID string) error
 	Clear() error
 }
 
-type AttachmentResponse struct {
-	AttachID string `json:"attachId"`
+// File is the content of an attachment, which supports reading ranges.
+type File struct {
+	io.ReadSeekCloser
+	MIMEType string
+	Size     int64
+	ModTime  time.Time
+}
+
+// Hash returns the hex encoded SHA-256 hash of the content of the
+// attachment, which is the name of its id.
+func Hash(attachID string) string {
+	return strings.TrimSuffix(attachID, path.Ext(attachID))
+}
+
+// MIMEType returns the MIME type of the attachment or an empty string if
+// the type is not supported.
+func MIMEType(attachID string) string {
+	return mimeTypes[strings.TrimPrefix(path.Ext(attachID), ".")]
+}
+
+// Factory creates the attachment Handler of a session.
+type Factory func(sessionID string) Handler
+
+// NewFactory returns a Factory for the configured attachment backend.
+func NewFactory(cfg config.Attachments) (Factory, error) {
+	switch cfg.Backend {
+	case config.AttachmentBackendLocal, "":
+		dir := cfg.Local.Dir
+		if dir == "" {
+			dir = DefaultDir
+		}
+		return func(sessionID string) Handler {
+			return NewLocalHandler(dir, sessionID)
+		}, nil
+
+	case config.AttachmentBackendS3:
+		creds := s3.Credentials{AccessKey: cfg.S3.AccessKey, SecretKey: cfg.S3.SecretKey}
+		if creds.AccessKey == "" {
+			creds.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		}
+		if creds.SecretKey == "" {
+			creds.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		}
+		client, err := s3.New(s3.Config{
+			Endpoint:    cfg.S3.Endpoint,
+			Region:      cfg.S3.Region,
+			Bucket:      cfg.S3.Bucket,
+			PathStyle:   cfg.S3.PathStyle,
+			Credentials: creds,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return func(sessionID string) Handler {
+			return NewS3Handler(client, cfg.S3.Prefix, sessionID)
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown attachment backend: %s", cfg.Backend)
+	}
 }
@@ -0,0 +1,201 @@
+package attachment_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/attachment"
+	"github.com/boardsite-io/server/internal/config"
+	"github.com/boardsite-io/server/pkg/s3"
+	"github.com/boardsite-io/server/pkg/s3/s3test"
+)
+
+func newPNG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))))
+	return buf.Bytes()
+}
+
+// redPDF is a document with a red page of 200x100 points. The cross-reference
+// table is omitted, the objects are found by scanning the document.
+const redPDF = `%PDF-1.7
+1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj
+2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj
+3 0 obj << /Type /Page /Parent 2 0 R /MediaBox [0 0 200 100] /Contents 4 0 R >> endobj
+4 0 obj << /Length 25 >>
+stream
+1 0 0 rg 0 0 200 100 re f
+endstream
+endobj
+%%EOF`
+
+func testPreview(t *testing.T, h attachment.Handler) {
+	attachID, _, err := h.Upload(strings.NewReader(redPDF))
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		r, err := h.Preview(attachID, 1, 256)
+		require.NoError(t, err)
+		img, err := png.Decode(r)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 256, 128), img.Bounds())
+		red, _, _, _ := img.At(128, 64).RGBA()
+		assert.Equal(t, uint32(0xffff), red)
+	}
+
+	_, err = h.Preview(attachID, 2, 256)
+	assert.ErrorIs(t, err, attachment.ErrNotFound)
+	_, err = h.Preview(attachID, 1, 300)
+	assert.ErrorIs(t, err, attachment.ErrPreview)
+	pngID, _, err := h.Upload(bytes.NewReader(newPNG(t)))
+	require.NoError(t, err)
+	_, err = h.Preview(pngID, 1, 256)
+	assert.ErrorIs(t, err, attachment.ErrFileType)
+}
+
+func testHandler(t *testing.T, h, other attachment.Handler) {
+	pngData := newPNG(t)
+	attachID, size, err := h.Upload(bytes.NewReader(pngData))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(pngData)), size)
+	hash := sha256.Sum256(pngData)
+	assert.Equal(t, hex.EncodeToString(hash[:])+".png", attachID)
+	assert.Equal(t, hex.EncodeToString(hash[:]), attachment.Hash(attachID))
+	otherID, _, err := other.Upload(bytes.NewReader(pngData))
+	require.NoError(t, err)
+
+	// identical content is stored once
+	sameID, _, err := h.Upload(bytes.NewReader(pngData))
+	require.NoError(t, err)
+	assert.Equal(t, attachID, sameID)
+
+	file, err := h.Get(attachID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.Equal(t, pngData, data)
+	assert.Equal(t, "image/png", file.MIMEType)
+	assert.Equal(t, int64(len(pngData)), file.Size)
+	assert.False(t, file.ModTime.IsZero())
+	// ranges are read after seeking
+	_, err = file.Seek(4, io.SeekStart)
+	require.NoError(t, err)
+	data, err = io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.Equal(t, pngData[4:], data)
+	assert.NoError(t, file.Close())
+
+	_, _, err = h.Upload(strings.NewReader("plain text"))
+	assert.ErrorIs(t, err, attachment.ErrFileType)
+	_, err = h.Get("../other/" + otherID)
+	assert.ErrorIs(t, err, attachment.ErrNotFound)
+
+	// deleting removes the attachment and its previews only
+	pdfID, _, err := h.Upload(strings.NewReader(redPDF))
+	require.NoError(t, err)
+	_, err = h.Preview(pdfID, 1, 256)
+	require.NoError(t, err)
+	require.NoError(t, h.Delete(pdfID))
+	_, err = h.Get(pdfID)
+	assert.ErrorIs(t, err, attachment.ErrNotFound)
+	_, err = h.Preview(pdfID, 1, 256)
+	assert.ErrorIs(t, err, attachment.ErrNotFound)
+	assert.ErrorIs(t, h.Delete(pdfID), attachment.ErrNotFound)
+	assert.ErrorIs(t, h.Delete("../sid2/"+otherID), attachment.ErrNotFound)
+	_, err = h.Get(attachID)
+	assert.NoError(t, err)
+
+	// clearing does not affect other sessions
+	require.NoError(t, h.Clear())
+	_, err = h.Get(attachID)
+	assert.ErrorIs(t, err, attachment.ErrNotFound)
+	_, err = other.Get(otherID)
+	assert.NoError(t, err)
+}
+
+func TestLocalHandler(t *testing.T) {
+	var cfg config.Attachments
+	cfg.Backend = config.AttachmentBackendLocal
+	cfg.Local.Dir = t.TempDir()
+	newHandler, err := attachment.NewFactory(cfg)
+	require.NoError(t, err)
+
+	testHandler(t, newHandler("sid1"), newHandler("sid2"))
+}
+
+func TestLocalHandler_UploadLimit(t *testing.T) {
+	dir := t.TempDir()
+	h := attachment.NewLocalHandler(dir, "sid")
+
+	_, _, err := h.Upload(attachment.LimitReader(strings.NewReader(redPDF), 64))
+	assert.ErrorIs(t, err, attachment.ErrFileSize)
+	_, _, err = h.Upload(attachment.LimitReader(bytes.NewReader(newPNG(t)), 16))
+	assert.ErrorIs(t, err, attachment.ErrFileSize)
+	attachID, size, err := h.Upload(attachment.LimitReader(strings.NewReader(redPDF), int64(len(redPDF))))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(redPDF)), size)
+
+	// rejected uploads leave no files behind
+	files, err := os.ReadDir(filepath.Join(dir, "sid"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, attachID, files[0].Name())
+}
+
+func TestLocalHandler_Preview(t *testing.T) {
+	dir := t.TempDir()
+	h := attachment.NewLocalHandler(dir, "sid")
+
+	testPreview(t, h)
+
+	previews, err := filepath.Glob(filepath.Join(dir, "sid", "*.pdf.pages", "1-256.png"))
+	assert.NoError(t, err)
+	assert.Len(t, previews, 1)
+}
+
+func TestS3Handler(t *testing.T) {
+	server := s3test.NewServer()
+	defer server.Close()
+	client, err := s3.New(server.Config())
+	require.NoError(t, err)
+
+	testHandler(t, attachment.NewS3Handler(client, "attachments/", "sid1"), attachment.NewS3Handler(client, "attachments/", "sid2"))
+	keys := server.Keys()
+	require.Len(t, keys, 1)
+	assert.Regexp(t, `^attachments/sid2/`, keys[0])
+}
+
+func TestS3Handler_Preview(t *testing.T) {
+	server := s3test.NewServer()
+	defer server.Close()
+	client, err := s3.New(server.Config())
+	require.NoError(t, err)
+
+	testPreview(t, attachment.NewS3Handler(client, "", "sid"))
+
+	assert.Len(t, server.Keys(), 3)
+	assert.Contains(t, strings.Join(server.Keys(), ","), ".pdf.pages/1-256.png")
+}
+
+func TestPreviewWidth(t *testing.T) {
+	for width, want := range map[int]int{0: 512, 1: 256, 256: 256, 257: 512, 1024: 1024, 5000: 1024} {
+		assert.Equal(t, want, attachment.PreviewWidth(width), "width %d", width)
+	}
+}
+
+func TestNewFactory_UnknownBackend(t *testing.T) {
+	_, err := attachment.NewFactory(config.Attachments{Backend: "ftp"})
+
+	assert.Error(t, err)
+}
@@ -1,16 +1,23 @@
 package attachment
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"regexp"
 
-	"github.com/h2non/filetype"
 	gonanoid "github.com/matoous/go-nanoid"
 )
 
-const attachmentDir = "/tmp/attachment"
+// DefaultDir is the default directory of the local attachment handler.
+const DefaultDir = "/tmp/attachment"
+
+// attachIDExp matches the attachment ids, which prevents ids from escaping
+// the directory or prefix of the session.
+var attachIDExp = regexp.MustCompile(`^[0-9a-f]{64}\.[a-z0-9]+$`)
 
 var (
 	ErrCreate   = errors.New("cannot allocate resource")
@@ -23,63 +30,98 @@ type localAttachment struct {
 	baseDir string
 }
 
-// NewLocalHandler create a new attachment Handler for storing attachment in the local filesystem.
-func NewLocalHandler(sessionID string) Handler {
+// NewLocalHandler create a new attachment Handler for storing attachment
+// in the local filesystem in a subdirectory of dir.
+func NewLocalHandler(dir, sessionID string) Handler {
 	return &localAttachment{
-		baseDir: fmt.Sprintf("%s/%s", attachmentDir, sessionID),
+		baseDir: fmt.Sprintf("%s/%s", dir, sessionID),
 	}
 }
 
-func (a *localAttachment) Upload(data []byte) (string, error) {
-	fileExt, err := getFileExtension(data)
-	if err != nil {
-		return "", err
-	}
-	attachID := fmt.Sprintf("%s.%s", gonanoid.MustID(32), fileExt)
-
+func (a *localAttachment) Upload(r io.Reader) (string, int64, error) {
 	if err := os.MkdirAll(a.baseDir, 0666); err != nil {
-		return "", ErrCreate
+		return "", 0, ErrCreate
 	}
-	file, err := os.OpenFile(
-		fmt.Sprintf("%s/%s", a.baseDir, attachID),
-		os.O_CREATE|os.O_WRONLY,
-		0666,
-	)
+	// the file is renamed atomically, since identical content can be uploaded concurrently
+	file, attachID, size, err := spool(r, a.baseDir)
 	if err != nil {
-		return "", ErrCreate
+		return "", 0, err
 	}
-	defer file.Close()
-
-	if _, err := file.Write(data); err != nil {
-		return "", ErrWrite
+	file.Close()
+	name := fmt.Sprintf("%s/%s", a.baseDir, attachID)
+	// identical content is stored once
+	if _, err := os.Stat(name); err == nil {
+		_ = os.Remove(file.Name())
+		return attachID, size, nil
+	}
+	if err := os.Rename(file.Name(), name); err != nil {
+		_ = os.Remove(file.Name())
+		return "", 0, ErrWrite
 	}
-	return attachID, nil
+	return attachID, size, nil
 }
 
-func (a *localAttachment) Get(attachID string) (io.Reader, string, error) {
+func (a *localAttachment) Get(attachID string) (*File, error) {
+	if !attachIDExp.MatchString(attachID) {
+		return nil, ErrNotFound
+	}
+	mime := MIMEType(attachID)
+	if mime == "" {
+		return nil, ErrNotFound
+	}
 	f, err := os.Open(fmt.Sprintf("%s/%s", a.baseDir, attachID))
 	if err != nil {
-		return nil, "", ErrNotFound
+		return nil, ErrNotFound
 	}
-	fType, err := filetype.MatchReader(f)
+	info, err := f.Stat()
 	if err != nil {
-		return nil, "", ErrNotFound
+		f.Close()
+		return nil, ErrNotFound
 	}
-	_, err = f.Seek(0, 0)
-	return f, fType.MIME.Value, err
+	return &File{ReadSeekCloser: f, MIMEType: mime, Size: info.Size(), ModTime: info.ModTime()}, nil
 }
 
-func (a *localAttachment) Clear() error {
-	return os.RemoveAll(a.baseDir)
-}
+func (a *localAttachment) Preview(attachID string, page, width int) (io.Reader, error) {
+	if err := checkPreview(attachID, page, width); err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("%s/%s", a.baseDir, previewName(attachID, page, width))
+	if data, err := os.ReadFile(name); err == nil {
+		return bytes.NewReader(data), nil
+	}
 
-func getFileExtension(data []byte) (string, error) {
-	fType, err := filetype.Match(data)
+	data, err := os.ReadFile(fmt.Sprintf("%s/%s", a.baseDir, attachID))
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	preview, err := renderPreview(data, page, width)
 	if err != nil {
-		return "", ErrFileType
+		return nil, err
 	}
-	if fType.MIME.Value != "application/pdf" && fType.MIME.Value != "image/png" {
-		return "", ErrFileType
+	// the preview is written atomically, since it is read concurrently;
+	// failing to cache the preview is not an error
+	if err := os.MkdirAll(path.Dir(name), 0777); err == nil {
+		tmp := fmt.Sprintf("%s.%s", name, gonanoid.MustID(8))
+		if err := os.WriteFile(tmp, preview, 0666); err == nil {
+			_ = os.Rename(tmp, name)
+		}
+	}
+	return bytes.NewReader(preview), nil
+}
+
+func (a *localAttachment) Delete(attachID string) error {
+	if !attachIDExp.MatchString(attachID) {
+		return ErrNotFound
 	}
-	return fType.Extension, nil
+	name := fmt.Sprintf("%s/%s", a.baseDir, attachID)
+	if err := os.Remove(name); errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	return os.RemoveAll(name + ".pages")
+}
+
+func (a *localAttachment) Clear() error {
+	return os.RemoveAll(a.baseDir)
 }
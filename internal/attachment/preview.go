@@ -0,0 +1,80 @@
+package attachment
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"path"
+
+	"github.com/boardsite-io/server/pkg/pdf"
+)
+
+// DefaultPreviewWidth is the width of page previews if none is requested.
+const DefaultPreviewWidth = 512
+
+// previewWidths are the widths in which page previews are rendered.
+var previewWidths = []int{256, 512, 1024}
+
+// renderSlots limits the number of concurrently rendered previews.
+var renderSlots = make(chan struct{}, 2)
+
+var ErrPreview = errors.New("cannot render preview")
+
+// PreviewWidth returns the smallest preview width which is at least the
+// requested width. Larger widths are limited to the largest preview width.
+func PreviewWidth(width int) int {
+	if width <= 0 {
+		return DefaultPreviewWidth
+	}
+	for _, w := range previewWidths {
+		if width <= w {
+			return w
+		}
+	}
+	return previewWidths[len(previewWidths)-1]
+}
+
+// previewName returns the name of the cached preview relative to the attachment.
+func previewName(attachID string, page, width int) string {
+	return fmt.Sprintf("%s.pages/%d-%d.png", attachID, page, width)
+}
+
+// checkPreview validates the attachment id and the preview parameters.
+func checkPreview(attachID string, page, width int) error {
+	if !attachIDExp.MatchString(attachID) || page < 1 {
+		return ErrNotFound
+	}
+	if path.Ext(attachID) != ".pdf" {
+		return ErrFileType
+	}
+	if PreviewWidth(width) != width {
+		return ErrPreview
+	}
+	return nil
+}
+
+// renderPreview renders the one-based page of the PDF document to PNG.
+//
+// Pages exceeding the rendering limits are rendered partially.
+func renderPreview(data []byte, page, width int) ([]byte, error) {
+	renderSlots <- struct{}{}
+	defer func() { <-renderSlots }()
+
+	doc, err := pdf.Open(data)
+	if err != nil {
+		return nil, ErrPreview
+	}
+	img, err := doc.Render(page-1, width)
+	if errors.Is(err, pdf.ErrPageRange) {
+		return nil, ErrNotFound
+	}
+	if err != nil && !errors.Is(err, pdf.ErrTooComplex) {
+		return nil, ErrPreview
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, ErrPreview
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,186 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/boardsite-io/server/pkg/s3"
+)
+
+type s3Attachment struct {
+	client *s3.Client
+	prefix string
+}
+
+// NewS3Handler creates a new attachment Handler for storing attachments
+// in an S3-compatible bucket. The keys of the attachments are prefixed
+// with the prefix and the session id.
+func NewS3Handler(client *s3.Client, prefix, sessionID string) Handler {
+	return &s3Attachment{
+		client: client,
+		prefix: prefix + sessionID + "/",
+	}
+}
+
+func (a *s3Attachment) Upload(r io.Reader) (string, int64, error) {
+	// the content is spooled to a temporary file, since the key is the hash of the content
+	file, attachID, size, err := spool(r, "")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	err = a.client.PutObjectReader(context.Background(), a.prefix+attachID, file, size, Hash(attachID), MIMEType(attachID))
+	if err != nil {
+		return "", 0, ErrWrite
+	}
+	return attachID, size, nil
+}
+
+func (a *s3Attachment) Get(attachID string) (*File, error) {
+	if !attachIDExp.MatchString(attachID) {
+		return nil, ErrNotFound
+	}
+	key := a.prefix + attachID
+	info, err := a.client.HeadObject(context.Background(), key)
+	if errors.Is(err, s3.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	mime := info.ContentType
+	if mime == "" {
+		mime = MIMEType(attachID)
+	}
+	return &File{
+		ReadSeekCloser: &objectReader{client: a.client, key: key, size: info.Size},
+		MIMEType:       mime,
+		Size:           info.Size,
+		ModTime:        info.LastModified,
+	}, nil
+}
+
+func (a *s3Attachment) Preview(attachID string, page, width int) (io.Reader, error) {
+	if err := checkPreview(attachID, page, width); err != nil {
+		return nil, err
+	}
+	key := a.prefix + previewName(attachID, page, width)
+	if data, err := a.read(key); err == nil {
+		return bytes.NewReader(data), nil
+	}
+
+	data, err := a.read(a.prefix + attachID)
+	if errors.Is(err, s3.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	preview, err := renderPreview(data, page, width)
+	if err != nil {
+		return nil, err
+	}
+	// failing to cache the preview is not an error
+	_ = a.client.PutObject(context.Background(), key, preview, "image/png")
+	return bytes.NewReader(preview), nil
+}
+
+// read returns the data of the object.
+func (a *s3Attachment) read(key string) ([]byte, error) {
+	body, _, err := a.client.GetObject(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+func (a *s3Attachment) Delete(attachID string) error {
+	if !attachIDExp.MatchString(attachID) {
+		return ErrNotFound
+	}
+	// previews are listed with the attachment
+	n, err := a.deletePrefix(a.prefix + attachID)
+	if err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (a *s3Attachment) Clear() error {
+	_, err := a.deletePrefix(a.prefix)
+	return err
+}
+
+// deletePrefix deletes all objects whose key starts with prefix and returns
+// the number of deleted objects.
+func (a *s3Attachment) deletePrefix(prefix string) (int, error) {
+	ctx := context.Background()
+	keys, err := a.client.ListObjects(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	for i, k := range keys {
+		if err := a.client.DeleteObject(ctx, k); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
+
+// objectReader reads an object with ranged requests starting at the
+// current offset, such that seeking does not download skipped data.
+type objectReader struct {
+	client *s3.Client
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (o *objectReader) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		body, err := o.client.GetObjectRange(context.Background(), o.key, o.offset)
+		if err != nil {
+			return 0, err
+		}
+		o.body = body
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *objectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.size
+	}
+	if offset < 0 {
+		return 0, errors.New("s3: negative offset")
+	}
+	if offset != o.offset {
+		o.Close()
+		o.offset = offset
+	}
+	return offset, nil
+}
+
+func (o *objectReader) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
@@ -0,0 +1,176 @@
+package attachment
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/h2non/filetype"
+)
+
+const (
+	// maxImagePixels limits the dimensions of decoded images
+	maxImagePixels = 40_000_000
+	jpegQuality    = 92
+)
+
+// mimeTypes maps the file extensions of the supported attachments to their MIME type.
+var mimeTypes = map[string]string{
+	"pdf":  "application/pdf",
+	"png":  "image/png",
+	"jpg":  "image/jpeg",
+	"gif":  "image/gif",
+	"webp": "image/webp",
+	"svg":  "image/svg+xml",
+}
+
+// sanitize detects the type of the data and removes its metadata and
+// active content. It returns the file extension and the sanitized data.
+func sanitize(data []byte) (string, []byte, error) {
+	if isSVG(data) {
+		out, err := sanitizeSVG(data)
+		return "svg", out, err
+	}
+	fType, err := filetype.Match(data)
+	if err != nil {
+		return "", nil, ErrFileType
+	}
+
+	var out []byte
+	switch fType.Extension {
+	case "pdf":
+		out, err = data, nil
+	case "png":
+		out, err = reencodePNG(data)
+	case "jpg":
+		out, err = reencodeJPEG(data)
+	case "gif":
+		out, err = reencodeGIF(data)
+	case "webp":
+		out, err = stripWebP(data)
+	default:
+		return "", nil, ErrFileType
+	}
+	return fType.Extension, out, err
+}
+
+// checkDimensions rejects images which are too large to be decoded.
+func checkDimensions(data []byte) error {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ErrFileType
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return ErrFileType
+	}
+	return nil
+}
+
+func reencodePNG(data []byte) ([]byte, error) {
+	if err := checkDimensions(data); err != nil {
+		return nil, err
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrFileType
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, ErrFileType
+	}
+	return buf.Bytes(), nil
+}
+
+// reencodeJPEG re-encodes the image without its metadata. The EXIF
+// orientation is applied to the pixels, since it is lost with the metadata.
+func reencodeJPEG(data []byte) ([]byte, error) {
+	if err := checkDimensions(data); err != nil {
+		return nil, err
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrFileType
+	}
+	img = orient(img, exifOrientation(data))
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, ErrFileType
+	}
+	return buf.Bytes(), nil
+}
+
+// reencodeGIF re-encodes all frames of the image, which drops comment
+// and application extensions except for the loop count.
+func reencodeGIF(data []byte) ([]byte, error) {
+	if err := checkDimensions(data); err != nil {
+		return nil, err
+	}
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrFileType
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return nil, ErrFileType
+	}
+	return buf.Bytes(), nil
+}
+
+// WebP container flags of the VP8X chunk
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// stripWebP removes the EXIF and XMP chunks of a WebP container.
+//
+// There is no WebP encoder in the standard library, hence the
+// image data is retained as is.
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrFileType
+	}
+	size := int(binary.LittleEndian.Uint32(data[4:8]))
+	if size < 4 || size+8 > len(data) {
+		return nil, ErrFileType
+	}
+
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	hasImage := false
+	for rest := data[12 : size+8]; len(rest) > 0; {
+		if len(rest) < 8 {
+			return nil, ErrFileType
+		}
+		fourCC := string(rest[:4])
+		chunkSize := int(binary.LittleEndian.Uint32(rest[4:8]))
+		end := 8 + chunkSize + chunkSize%2
+		if chunkSize < 0 || end > len(rest) {
+			return nil, ErrFileType
+		}
+		chunk := rest[:end]
+		rest = rest[end:]
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+			continue
+		case "VP8X":
+			if chunkSize < 10 {
+				return nil, ErrFileType
+			}
+			chunk = append([]byte(nil), chunk...)
+			chunk[8] &^= webpFlagEXIF | webpFlagXMP
+		case "VP8 ", "VP8L", "ANIM":
+			hasImage = true
+		}
+		out = append(out, chunk...)
+	}
+	if !hasImage {
+		return nil, ErrFileType
+	}
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, nil
+}
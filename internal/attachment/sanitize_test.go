@@ -0,0 +1,230 @@
+package attachment_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/attachment"
+)
+
+func upload(t *testing.T, data []byte) ([]byte, string, error) {
+	h := attachment.NewLocalHandler(t.TempDir(), "sid")
+	attachID, _, err := h.Upload(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	file, err := h.Get(attachID)
+	require.NoError(t, err)
+	defer file.Close()
+	out, err := io.ReadAll(file)
+	require.NoError(t, err)
+	return out, file.MIMEType, nil
+}
+
+// exifSegment returns an APP1 segment with the orientation and a GPS marker.
+func exifSegment(orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("II")
+	_ = binary.Write(&tiff, binary.LittleEndian, []uint16{42})
+	_ = binary.Write(&tiff, binary.LittleEndian, []uint32{8})
+	_ = binary.Write(&tiff, binary.LittleEndian, []uint16{1, 0x0112, 3})
+	_ = binary.Write(&tiff, binary.LittleEndian, []uint32{1})
+	_ = binary.Write(&tiff, binary.LittleEndian, []uint16{orientation, 0})
+	_ = binary.Write(&tiff, binary.LittleEndian, []uint32{0})
+	tiff.WriteString("GPS 52.5200N 13.4050E")
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func TestUpload_JPEG(t *testing.T) {
+	// left half red, right half blue
+	img := image.NewRGBA(image.Rect(0, 0, 32, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 32; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 16 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	encoded := buf.Bytes()
+	data := append(append(append([]byte{}, encoded[:2]...), exifSegment(6)...), encoded[2:]...)
+
+	out, mime, err := upload(t, data)
+
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", mime)
+	assert.NotContains(t, string(out), "Exif")
+	assert.NotContains(t, string(out), "GPS")
+	decoded, err := jpeg.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	// rotated by 90 degrees clockwise
+	assert.Equal(t, image.Rect(0, 0, 16, 32), decoded.Bounds())
+	r, _, b, _ := decoded.At(8, 4).RGBA()
+	assert.Greater(t, r, b)
+	r, _, b, _ = decoded.At(8, 28).RGBA()
+	assert.Greater(t, b, r)
+}
+
+func TestUpload_GIF(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			image.NewPaletted(image.Rect(0, 0, 4, 4), palette),
+			image.NewPaletted(image.Rect(0, 0, 4, 4), palette),
+		},
+		Delay: []int{10, 10},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, gif.EncodeAll(&buf, g))
+
+	out, mime, err := upload(t, buf.Bytes())
+
+	require.NoError(t, err)
+	assert.Equal(t, "image/gif", mime)
+	decoded, err := gif.DecodeAll(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.Len(t, decoded.Image, 2)
+}
+
+func webpChunk(fourCC string, data []byte) []byte {
+	chunk := append([]byte(fourCC), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(data)))
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func TestUpload_WebP(t *testing.T) {
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x08 | 0x04
+	var body []byte
+	body = append(body, webpChunk("VP8X", vp8x)...)
+	body = append(body, webpChunk("VP8L", []byte{0x2f, 0, 0, 0, 0})...)
+	body = append(body, webpChunk("EXIF", []byte("GPS 52.5200N"))...)
+	body = append(body, webpChunk("XMP ", []byte("<x:xmpmeta/>"))...)
+	data := append([]byte("RIFF\x00\x00\x00\x00WEBP"), body...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(body)+4))
+
+	out, mime, err := upload(t, data)
+
+	require.NoError(t, err)
+	assert.Equal(t, "image/webp", mime)
+	assert.NotContains(t, string(out), "GPS")
+	assert.NotContains(t, string(out), "xmpmeta")
+	assert.Equal(t, uint32(len(out)-8), binary.LittleEndian.Uint32(out[4:]))
+	assert.Equal(t, byte(0), out[20]&(0x08|0x04))
+}
+
+func TestUpload_SVG(t *testing.T) {
+	tests := []struct {
+		name     string
+		svg      string
+		contains []string
+		excludes []string
+	}{
+		{
+			name: "script",
+			svg:  `<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script><rect width="1"/></svg>`,
+			contains: []string{
+				`<svg xmlns="http://www.w3.org/2000/svg">`,
+				`<rect width="1"></rect>`,
+			},
+			excludes: []string{"script", "alert"},
+		},
+		{
+			name:     "event handlers",
+			svg:      `<svg><circle onclick="alert(1)" ONLOAD="alert(2)" r="5"/></svg>`,
+			contains: []string{`<circle r="5"></circle>`},
+			excludes: []string{"alert"},
+		},
+		{
+			name: "external references",
+			svg: `<svg xmlns:xlink="http://www.w3.org/1999/xlink">` +
+				`<image xlink:href="https://example.com/track.png"/>` +
+				`<use href="#shape"/>` +
+				`<a href=" java&#x9;script:alert(1)">x</a>` +
+				`<image href="data:image/png;base64,AAAA"/></svg>`,
+			contains: []string{
+				`<image></image>`,
+				`<use href="#shape"></use>`,
+				`<a>x</a>`,
+				`<image href="data:image/png;base64,AAAA"></image>`,
+			},
+			excludes: []string{"example.com", "alert"},
+		},
+		{
+			name: "styles",
+			svg: `<svg><style>@import url(https://example.com/a.css); rect { fill: url(#grad); background: url('https://example.com/b.png') }</style>` +
+				`<rect style="fill: url(https://example.com/c.svg)" filter="url(#blur)"/></svg>`,
+			contains: []string{
+				`rect { fill: url(#grad); background: none }`,
+				`style="fill: none"`,
+				`filter="url(#blur)"`,
+			},
+			excludes: []string{"example.com", "@import"},
+		},
+		{
+			name:     "foreign object and animations",
+			svg:      `<svg><foreignObject><div>html</div></foreignObject><a><set attributeName="href" to="javascript:alert(1)"/></a></svg>`,
+			contains: []string{`<svg><a></a></svg>`},
+		},
+		{
+			name:     "doctype and comments",
+			svg:      `<!DOCTYPE svg [<!ENTITY x SYSTEM "file:///etc/passwd">]><!-- comment --><svg>&lt;text&gt;</svg>`,
+			contains: []string{`<svg>&lt;text&gt;</svg>`},
+			excludes: []string{"DOCTYPE", "passwd", "comment"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, mime, err := upload(t, []byte(tt.svg))
+
+			require.NoError(t, err)
+			assert.Equal(t, "image/svg+xml", mime)
+			for _, s := range tt.contains {
+				assert.Contains(t, string(out), s)
+			}
+			for _, s := range tt.excludes {
+				assert.NotContains(t, string(out), s)
+			}
+		})
+	}
+}
+
+func TestUpload_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "xml without svg root", data: []byte(`<?xml version="1.0"?><html></html>`)},
+		{name: "malformed svg", data: []byte(`<svg><rect></svg>`)},
+		{name: "undefined entity", data: []byte(`<!DOCTYPE svg [<!ENTITY x "y">]><svg>&x;</svg>`)},
+		{name: "truncated png", data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")},
+		{name: "webp without image", data: []byte("RIFF\x04\x00\x00\x00WEBP")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := upload(t, tt.data)
+
+			assert.ErrorIs(t, err, attachment.ErrFileType)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package attachment
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// forbiddenElements are removed from SVG documents including their content.
+// Animations are removed, since they can change the references of other elements.
+var forbiddenElements = map[string]bool{
+	"script":           true,
+	"foreignobject":    true,
+	"iframe":           true,
+	"embed":            true,
+	"object":           true,
+	"audio":            true,
+	"video":            true,
+	"handler":          true,
+	"listener":         true,
+	"set":              true,
+	"animate":          true,
+	"animatemotion":    true,
+	"animatetransform": true,
+	"animatecolor":     true,
+}
+
+var (
+	cssImportExp  = regexp.MustCompile(`(?i)@import[^;]*;?`)
+	cssURLExp     = regexp.MustCompile(`(?i)url\(\s*['"]?([^'")]*)['"]?\s*\)`)
+	dataImageExp  = regexp.MustCompile(`^data:image/(png|jpeg|gif|webp);base64,`)
+	textEscaper   = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper   = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+	errNotSVGRoot = errors.New("root element is not svg")
+)
+
+// isSVG reports whether the data is an XML document with an svg root element.
+func isSVG(data []byte) bool {
+	trimmed := bytes.TrimLeftFunc(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), unicode.IsSpace)
+	if !bytes.HasPrefix(trimmed, []byte("<")) {
+		return false
+	}
+	_, err := rootElement(xml.NewDecoder(bytes.NewReader(data)))
+	return err == nil
+}
+
+// rootElement returns the first element of the document, if it is an svg element.
+func rootElement(d *xml.Decoder) (xml.StartElement, error) {
+	for {
+		tok, err := d.RawToken()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "svg" {
+				return xml.StartElement{}, errNotSVGRoot
+			}
+			return t, nil
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) > 0 {
+				return xml.StartElement{}, errNotSVGRoot
+			}
+		}
+	}
+}
+
+// sanitizeSVG rewrites the SVG document without scripts, event handlers
+// and references to external resources. Comments, processing instructions
+// and directives such as DOCTYPE declarations are removed as well.
+func sanitizeSVG(data []byte) ([]byte, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Entity = xml.HTMLEntity
+
+	var (
+		buf   bytes.Buffer
+		stack []xml.Name
+		// skip is the depth inside of a removed element
+		skip int
+	)
+	root, err := rootElement(d)
+	if err != nil {
+		return nil, ErrFileType
+	}
+	for tok := xml.Token(root); ; {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 || forbiddenElements[strings.ToLower(t.Name.Local)] {
+				skip++
+				break
+			}
+			stack = append(stack, t.Name)
+			buf.WriteString("<" + qualifiedName(t.Name))
+			for _, attr := range t.Attr {
+				if value, ok := sanitizeAttr(attr); ok {
+					buf.WriteString(" " + qualifiedName(attr.Name) + `="` + attrEscaper.Replace(value) + `"`)
+				}
+			}
+			buf.WriteString(">")
+
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				break
+			}
+			if len(stack) == 0 || stack[len(stack)-1] != t.Name {
+				return nil, ErrFileType
+			}
+			stack = stack[:len(stack)-1]
+			buf.WriteString("</" + qualifiedName(t.Name) + ">")
+
+		case xml.CharData:
+			if skip > 0 || len(stack) == 0 {
+				break
+			}
+			text := string(t)
+			if strings.EqualFold(stack[len(stack)-1].Local, "style") {
+				text = sanitizeCSS(text)
+			}
+			buf.WriteString(textEscaper.Replace(text))
+		}
+
+		if tok, err = d.RawToken(); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, ErrFileType
+		}
+	}
+	if len(stack) > 0 || skip > 0 {
+		return nil, ErrFileType
+	}
+	return buf.Bytes(), nil
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// sanitizeAttr returns the sanitized value of the attribute or false if the
+// attribute is removed.
+func sanitizeAttr(attr xml.Attr) (string, bool) {
+	name := strings.ToLower(attr.Name.Local)
+	if strings.HasPrefix(name, "on") {
+		return "", false
+	}
+	// ignore whitespace and control characters, which are skipped in URL schemes
+	compact := strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, attr.Value))
+	if strings.Contains(compact, "javascript:") || strings.Contains(compact, "vbscript:") {
+		return "", false
+	}
+	switch name {
+	case "href", "src":
+		return attr.Value, isLocalRef(attr.Value)
+	case "style":
+		return sanitizeCSS(attr.Value), true
+	}
+	if strings.Contains(compact, "url(") {
+		return sanitizeCSS(attr.Value), true
+	}
+	return attr.Value, true
+}
+
+// isLocalRef reports whether the reference points into the document or is an inline image.
+func isLocalRef(ref string) bool {
+	ref = strings.TrimSpace(ref)
+	return strings.HasPrefix(ref, "#") || dataImageExp.MatchString(strings.ToLower(ref))
+}
+
+// sanitizeCSS removes imports and replaces references to external resources.
+// Style sheets with escape sequences are dropped, since escapes can hide
+// the references.
+func sanitizeCSS(css string) string {
+	if strings.Contains(css, `\`) {
+		return ""
+	}
+	css = cssImportExp.ReplaceAllString(css, "")
+	return cssURLExp.ReplaceAllStringFunc(css, func(match string) string {
+		if isLocalRef(cssURLExp.FindStringSubmatch(match)[1]) {
+			return match
+		}
+		return "none"
+	})
+}
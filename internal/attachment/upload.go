@@ -0,0 +1,90 @@
+package attachment
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/h2non/filetype"
+)
+
+// sniffLen is the number of bytes used to detect streamed file types.
+const sniffLen = 512
+
+// ErrFileSize indicates that an upload exceeds the maximum file size.
+var ErrFileSize = errors.New("file size exceeded")
+
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+// LimitReader returns a Reader that fails with ErrFileSize once more than n
+// bytes are read from r. A non-positive n disables the limit.
+func LimitReader(r io.Reader, n int64) io.Reader {
+	if n <= 0 {
+		return r
+	}
+	return &limitReader{r: r, n: n}
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrFileSize
+	}
+	return n, err
+}
+
+// spool sanitizes the upload and writes the content to a temporary file in
+// dir, which is positioned at the start. It returns the file, the attachment
+// id with the file extension and the size of the content.
+//
+// Attachments are addressed by the SHA-256 hash of their sanitized content,
+// such that identical uploads share the same id.
+func spool(r io.Reader, dir string) (*os.File, string, int64, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, "", 0, err
+	}
+
+	ext := "pdf"
+	var content io.Reader = br
+	// PDF documents are stored as is, which does not require buffering
+	if !filetype.Is(head, ext) {
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		if ext, data, err = sanitize(data); err != nil {
+			return nil, "", 0, err
+		}
+		content = bytes.NewReader(data)
+	}
+
+	file, err := os.CreateTemp(dir, "upload-*")
+	if err != nil {
+		return nil, "", 0, ErrCreate
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), content)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		_ = os.Remove(file.Name())
+		if errors.Is(err, ErrFileSize) {
+			return nil, "", 0, err
+		}
+		return nil, "", 0, ErrWrite
+	}
+	return file, fmt.Sprintf("%s.%s", hex.EncodeToString(hash.Sum(nil)), ext), size, nil
+}
@@ -0,0 +1,40 @@
+package attachment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrSignature = errors.New("invalid signature")
+	ErrExpired   = errors.New("signature expired")
+)
+
+// Sign returns the signature which grants read access to an attachment of
+// a session until the expiry, given as unix time in seconds.
+func Sign(key []byte, sessionID, attachID string, expires int64) string {
+	mac := hmac.New(sha256.New, key)
+	// the fields cannot contain the separator
+	mac.Write([]byte(sessionID + "\n" + attachID + "\n" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of an attachment of a session created by Sign.
+func Verify(key []byte, sessionID, attachID string, expires int64, signature string, now time.Time) error {
+	got, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrSignature
+	}
+	want, _ := base64.RawURLEncoding.DecodeString(Sign(key, sessionID, attachID, expires))
+	if !hmac.Equal(got, want) {
+		return ErrSignature
+	}
+	if now.Unix() >= expires {
+		return ErrExpired
+	}
+	return nil
+}
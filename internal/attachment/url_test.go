@@ -0,0 +1,24 @@
+package attachment_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/boardsite-io/server/internal/attachment"
+)
+
+func TestSign(t *testing.T) {
+	key := []byte("key")
+	now := time.Unix(1000, 0)
+	signature := attachment.Sign(key, "sid", "hash.pdf", 1060)
+
+	assert.NoError(t, attachment.Verify(key, "sid", "hash.pdf", 1060, signature, now))
+	assert.ErrorIs(t, attachment.Verify(key, "sid", "hash.pdf", 1060, signature, now.Add(time.Minute)), attachment.ErrExpired)
+	assert.ErrorIs(t, attachment.Verify(key, "sid", "hash.pdf", 1061, signature, now), attachment.ErrSignature)
+	assert.ErrorIs(t, attachment.Verify(key, "sid", "other.pdf", 1060, signature, now), attachment.ErrSignature)
+	assert.ErrorIs(t, attachment.Verify(key, "sid2", "hash.pdf", 1060, signature, now), attachment.ErrSignature)
+	assert.ErrorIs(t, attachment.Verify([]byte("other"), "sid", "hash.pdf", 1060, signature, now), attachment.ErrSignature)
+	assert.ErrorIs(t, attachment.Verify(key, "sid", "hash.pdf", 1060, "not base64!", now), attachment.ErrSignature)
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -17,8 +19,10 @@ type Configuration struct {
 		Port uint16 `yaml:"port"`
 	} `yaml:"cache"`
 
-	Server  `yaml:"server"`
-	Session `yaml:"session"`
+	Server      `yaml:"server"`
+	Session     `yaml:"session"`
+	Websocket   `yaml:"websocket"`
+	Attachments `yaml:"attachments"`
 }
 
 type Server struct {
@@ -37,6 +41,102 @@ type Server struct {
 type Session struct {
 	MaxUsers int  `yaml:"max_users" json:"maxUsers"`
 	ReadOnly bool `yaml:"read_only" json:"readOnly"`
+	// SimplifyTolerance is the maximum deviation of simplified freehand strokes
+	// in page coordinates. Zero disables the simplification.
+	SimplifyTolerance float64          `yaml:"simplify_tolerance" json:"simplifyTolerance"`
+	Strokes           StrokeLimits     `yaml:"strokes" json:"-"`
+	Chat              ChatLimits       `yaml:"chat" json:"-"`
+	Presence          Presence         `yaml:"presence" json:"-"`
+	Attachments       AttachmentLimits `yaml:"attachments" json:"-"`
+}
+
+// StrokeLimits declares the limits of strokes. Zero values disable a limit.
+type StrokeLimits struct {
+	MaxPoints     int   `yaml:"max_points"`
+	MaxTextLength int   `yaml:"max_text_length"`
+	AllowedTypes  []int `yaml:"allowed_types"`
+	// FreehandTypes are simplified and cut into pieces by the eraser
+	FreehandTypes []int `yaml:"freehand_types"`
+	// TextfieldTypes are searched and drawn as words in thumbnails
+	TextfieldTypes []int `yaml:"textfield_types"`
+	// PageMargin is the distance coordinates may exceed the page bounds
+	// relative to the page size
+	PageMargin float64 `yaml:"page_margin"`
+}
+
+// ChatLimits declares the limits of the session chat. Zero values disable a limit.
+type ChatLimits struct {
+	// HistorySize is the number of chat messages kept per session
+	HistorySize int `yaml:"history_size"`
+	MaxLength   int `yaml:"max_length"`
+}
+
+// Presence declares after which time without messages users are considered
+// idle or away. Zero values disable a state.
+type Presence struct {
+	IdleAfter time.Duration `yaml:"idle_after"`
+	AwayAfter time.Duration `yaml:"away_after"`
+}
+
+// AttachmentLimits declares the limits of attachments. Zero sizes disable a limit.
+type AttachmentLimits struct {
+	// MaxFileSize is the size of uploaded files in bytes
+	MaxFileSize int64 `yaml:"max_file_size"`
+	// MaxSessionSize is the total size of the stored attachments of a session in bytes
+	MaxSessionSize int64 `yaml:"max_session_size"`
+	// URLExpiry is the validity of signed attachment URLs
+	URLExpiry time.Duration `yaml:"url_expiry"`
+}
+
+// DefaultPingInterval is the interval of the websocket pings if none is configured.
+const DefaultPingInterval = 30 * time.Second
+
+type Websocket struct {
+	QueueSize      int           `yaml:"queue_size"`
+	Overflow       string        `yaml:"overflow"`
+	PingInterval   time.Duration `yaml:"ping_interval"`
+	PongTimeout    time.Duration `yaml:"pong_timeout"`
+	WriteTimeout   time.Duration `yaml:"write_timeout"`
+	MaxMessageSize int64         `yaml:"max_message_size"`
+	RateLimit      struct {
+		RateLimit     `yaml:",inline"`
+		MaxViolations int                  `yaml:"max_violations"`
+		Types         map[string]RateLimit `yaml:"types"`
+	} `yaml:"rate_limit"`
+}
+
+// RateLimit declares a token bucket of messages.
+// A zero rate disables the limit.
+type RateLimit struct {
+	Rate    float64 `yaml:"rate"`
+	Burst   int     `yaml:"burst"`
+	MaxSize int     `yaml:"max_size"`
+}
+
+// Attachment backends
+const (
+	AttachmentBackendLocal = "local"
+	AttachmentBackendS3    = "s3"
+)
+
+type Attachments struct {
+	// Backend is either local or s3
+	Backend string `yaml:"backend"`
+	Local   struct {
+		Dir string `yaml:"dir"`
+	} `yaml:"local"`
+	S3 struct {
+		Endpoint string `yaml:"endpoint"`
+		Region   string `yaml:"region"`
+		Bucket   string `yaml:"bucket"`
+		// Prefix is prepended to the keys of all objects
+		Prefix    string `yaml:"prefix"`
+		PathStyle bool   `yaml:"path_style"`
+		// AccessKey and SecretKey default to the environment variables
+		// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+		AccessKey string `yaml:"access_key"`
+		SecretKey string `yaml:"secret_key"`
+	} `yaml:"s3"`
 }
 
 func New(path string) (*Configuration, error) {
@@ -47,6 +147,21 @@ func New(path string) (*Configuration, error) {
 	defer file.Close()
 
 	cfg := &Configuration{}
-	err = yaml.NewDecoder(file).Decode(cfg)
-	return cfg, err
+	if err := yaml.NewDecoder(file).Decode(cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Websocket.validate()
+}
+
+// validate checks whether clients can answer the pings of the server
+// before the pong timeout.
+func (w *Websocket) validate() error {
+	pingInterval := w.PingInterval
+	if pingInterval <= 0 {
+		pingInterval = DefaultPingInterval
+	}
+	if w.PongTimeout > 0 && w.PongTimeout <= pingInterval {
+		return fmt.Errorf("websocket pong_timeout %v must be greater than ping_interval %v", w.PongTimeout, pingInterval)
+	}
+	return nil
 }
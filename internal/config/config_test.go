@@ -1,9 +1,13 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -22,9 +26,81 @@ func TestNew(t *testing.T) {
 	want.Cache.Port = 6379
 	want.Session.MaxUsers = 4
 	want.Session.ReadOnly = false
+	want.Session.SimplifyTolerance = 0
+	want.Session.Strokes = StrokeLimits{
+		MaxPoints:      20000,
+		MaxTextLength:  10000,
+		AllowedTypes:   []int{1, 2, 3, 4, 5, 6, 7},
+		FreehandTypes:  []int{1, 6},
+		TextfieldTypes: []int{7},
+		PageMargin:     1,
+	}
+	want.Session.Chat = ChatLimits{
+		HistorySize: 500,
+		MaxLength:   2000,
+	}
+	want.Session.Presence = Presence{
+		IdleAfter: time.Minute,
+		AwayAfter: 5 * time.Minute,
+	}
+	want.Session.Attachments = AttachmentLimits{
+		MaxFileSize:    10 << 20,
+		MaxSessionSize: 100 << 20,
+		URLExpiry:      15 * time.Minute,
+	}
+	want.Websocket.QueueSize = 256
+	want.Websocket.Overflow = "drop"
+	want.Websocket.PingInterval = 30 * time.Second
+	want.Websocket.PongTimeout = 60 * time.Second
+	want.Websocket.WriteTimeout = 10 * time.Second
+	want.Websocket.MaxMessageSize = 1 << 20
+	want.Websocket.RateLimit.Rate = 60
+	want.Websocket.RateLimit.Burst = 120
+	want.Websocket.RateLimit.MaxViolations = 30
+	want.Websocket.RateLimit.Types = map[string]RateLimit{
+		"stroke":    {Rate: 30, Burst: 60, MaxSize: 512 << 10},
+		"mmove":     {Rate: 30, Burst: 30, MaxSize: 512},
+		"viewport":  {Rate: 30, Burst: 30, MaxSize: 512},
+		"chat":      {Rate: 1, Burst: 5, MaxSize: 16 << 10},
+		"erase":     {Rate: 10, Burst: 20, MaxSize: 64 << 10},
+		"transform": {Rate: 10, Burst: 20, MaxSize: 128 << 10},
+	}
+	want.Attachments.Backend = "local"
+	want.Attachments.Local.Dir = "/tmp/attachment"
+	want.Attachments.S3.Endpoint = "https://s3.amazonaws.com"
+	want.Attachments.S3.Region = "us-east-1"
+	want.Attachments.S3.Bucket = "boardsite"
+	want.Attachments.S3.Prefix = "attachments/"
 
 	got, err := New("./../../config.yaml")
 
 	assert.NoError(t, err)
 	assert.Equal(t, want, got)
 }
+
+func TestNew_PongTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{name: "greater than ping interval", config: "ping_interval: 10s\n  pong_timeout: 20s"},
+		{name: "derived from ping interval", config: "ping_interval: 10s"},
+		{name: "equal to ping interval", config: "ping_interval: 10s\n  pong_timeout: 10s", wantErr: true},
+		{name: "less than default ping interval", config: "pong_timeout: 20s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte("websocket:\n  "+tt.config+"\n"), 0600))
+
+			_, err := New(path)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -24,6 +24,8 @@ type handler struct {
 	numReq      *echoProm.Metric
 	numSessions *echoProm.Metric
 	numUsers    *echoProm.Metric
+	numQueued   *echoProm.Metric
+	maxQueued   *echoProm.Metric
 }
 
 func NewHandler(dispatcher session.Dispatcher) Handler {
@@ -47,11 +49,21 @@ func NewHandler(dispatcher session.Dispatcher) Handler {
 			Name:        "num_users",
 			Description: "The total number of active users across all sessions.",
 			Type:        "gauge"},
+		numQueued: &echoProm.Metric{
+			ID:          "numQueued",
+			Name:        "num_queued_messages",
+			Description: "The total number of messages in the outbound queues of all connections.",
+			Type:        "gauge"},
+		maxQueued: &echoProm.Metric{
+			ID:          "maxQueued",
+			Name:        "max_queue_depth",
+			Description: "The number of queued messages of the fullest outbound queue.",
+			Type:        "gauge"},
 	}
 	h.prom = echoProm.NewPrometheus(
 		"boardsite",
 		skipper,
-		[]*echoProm.Metric{h.numReq, h.numSessions, h.numUsers})
+		[]*echoProm.Metric{h.numReq, h.numSessions, h.numUsers, h.numQueued, h.maxQueued})
 	h.promHandler = promhttp.Handler()
 	return &h
 }
@@ -67,6 +79,17 @@ func (h *handler) GetMetrics(c echo.Context) error {
 		numUsers.Set(float64(h.dispatcher.NumUsers()))
 	}
 
+	queueStats := h.dispatcher.QueueStats()
+	numQueued, ok := h.numQueued.MetricCollector.(prometheus.Gauge)
+	if ok {
+		numQueued.Set(float64(queueStats.Messages))
+	}
+
+	maxQueued, ok := h.maxQueued.MetricCollector.(prometheus.Gauge)
+	if ok {
+		maxQueued.Set(float64(queueStats.MaxDepth))
+	}
+
 	h.promHandler.ServeHTTP(c.Response(), c.Request())
 	return nil
 }
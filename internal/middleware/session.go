@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"strconv"
+
 	"github.com/labstack/echo/v4"
 
 	"github.com/boardsite-io/server/internal/session"
@@ -10,6 +12,16 @@ import (
 )
 
 func Session(dispatcher session.Dispatcher) echo.MiddlewareFunc {
+	return authorizeSession(dispatcher, sessionHttp.AllowUser)
+}
+
+// Participant authorizes requests like Session, but also allows all users
+// of read-only sessions to write.
+func Participant(dispatcher session.Dispatcher) echo.MiddlewareFunc {
+	return authorizeSession(dispatcher, sessionHttp.AllowParticipant)
+}
+
+func authorizeSession(dispatcher session.Dispatcher, allow func(c echo.Context) bool) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			sessionId := c.Param("id")
@@ -34,10 +46,43 @@ func Session(dispatcher session.Dispatcher) echo.MiddlewareFunc {
 			c.Set(sessionHttp.UserCtxKey, user)
 			c.Set(sessionHttp.SecretCtxKey, c.Request().Header.Get(constant.HeaderSessionSecret))
 
-			if !sessionHttp.AllowUser(c) {
+			if !allow(c) {
+				c.Error(libErr.ErrForbidden)
+				return nil
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// Attachment authorizes requests of attachments either by a signed URL,
+// which does not require headers, or like Session.
+func Attachment(dispatcher session.Dispatcher) echo.MiddlewareFunc {
+	withSession := Session(dispatcher)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		nextWithSession := withSession(next)
+		return func(c echo.Context) error {
+			signature := c.QueryParam(session.QueryKeySignature)
+			if signature == "" {
+				return nextWithSession(c)
+			}
+
+			scb, err := dispatcher.GetSCB(c.Param("id"))
+			if err != nil {
+				c.Error(libErr.ErrNotFound)
+				return nil
+			}
+			expires, err := strconv.ParseInt(c.QueryParam(session.QueryKeyExpires), 10, 64)
+			if err != nil {
 				c.Error(libErr.ErrForbidden)
 				return nil
 			}
+			if err := scb.VerifyAttachmentURL(c.Param("attachId"), expires, signature); err != nil {
+				c.Error(err)
+				return nil
+			}
+			c.Set(sessionHttp.SessionCtxKey, scb)
 
 			return next(c)
 		}
@@ -3,17 +3,22 @@ package middleware_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/boardsite-io/server/internal/config"
 	"github.com/boardsite-io/server/internal/middleware"
 	"github.com/boardsite-io/server/internal/session"
 	sessionHttp "github.com/boardsite-io/server/internal/session/http"
 	"github.com/boardsite-io/server/internal/session/sessionfakes"
 	"github.com/boardsite-io/server/pkg/constant"
 	libmw "github.com/boardsite-io/server/pkg/middleware"
+	"github.com/boardsite-io/server/pkg/redis/redisfakes"
 )
 
 func TestSession(t *testing.T) {
@@ -105,6 +110,42 @@ func TestSession(t *testing.T) {
 	})
 }
 
+func TestParticipant(t *testing.T) {
+	scb := &sessionfakes.FakeController{}
+	scb.ConfigReturns(session.Config{ID: "sid1", Host: "host", Secret: "secret", Session: config.Session{ReadOnly: true}})
+	scb.GetUsersReturns(map[string]*session.User{"user1": {ID: "user1"}})
+	scb.AllowReturns(true)
+	dispatcher := &sessionfakes.FakeDispatcher{}
+	dispatcher.GetSCBReturns(scb, nil)
+
+	tests := []struct {
+		name       string
+		middleware echo.MiddlewareFunc
+		wantStatus int
+	}{
+		{name: "session rejects writes in read-only sessions", middleware: middleware.Session(dispatcher), wantStatus: http.StatusForbidden},
+		{name: "participant allows writes in read-only sessions", middleware: middleware.Participant(dispatcher), wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = libmw.NewErrorHandler()
+			e.POST("/b/:id", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, tt.middleware)
+			s := httptest.NewServer(e)
+			defer s.Close()
+
+			r, _ := http.NewRequest(http.MethodPost, s.URL+"/b/sid1", nil)
+			r.Header.Set(constant.HeaderUserID, "user1")
+			resp, err := http.DefaultClient.Do(r)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
 func TestHost(t *testing.T) {
 	e := echo.New()
 	cfg := session.Config{
@@ -183,3 +224,51 @@ func TestHost(t *testing.T) {
 		})
 	}
 }
+
+func TestAttachment(t *testing.T) {
+	scb, err := session.NewControlBlock(session.Config{ID: "sid1"},
+		session.WithCache(&redisfakes.FakeHandler{}), session.WithDispatcher(&sessionfakes.FakeDispatcher{}))
+	require.NoError(t, err)
+	dispatcher := &sessionfakes.FakeDispatcher{}
+	dispatcher.GetSCBReturns(scb, nil)
+	e := echo.New()
+	e.HTTPErrorHandler = libmw.NewErrorHandler()
+	handler := func(c echo.Context) error {
+		assert.Equal(t, "sid1", c.Get(sessionHttp.SessionCtxKey).(session.Controller).ID())
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET("/b/:id/attachments/:attachId", handler, middleware.Attachment(dispatcher))
+	e.GET("/b/:id/attachments/:attachId/pages/:n", handler, middleware.Attachment(dispatcher))
+	s := httptest.NewServer(e)
+	defer s.Close()
+
+	signed := scb.AttachmentURL("hash.pdf").URL
+	other := scb.AttachmentURL("other.pdf").URL
+	tampered, err := url.Parse(signed)
+	require.NoError(t, err)
+	query := tampered.Query()
+	query.Set(session.QueryKeyExpires, "9999999999")
+	tampered.RawQuery = query.Encode()
+	path, rawQuery, _ := strings.Cut(signed, "?")
+
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+	}{
+		{name: "signed", url: signed, wantStatus: http.StatusOK},
+		{name: "signed preview", url: path + "/pages/1?" + rawQuery, wantStatus: http.StatusOK},
+		{name: "other attachment", url: path + "?" + strings.SplitN(other, "?", 2)[1], wantStatus: http.StatusForbidden},
+		{name: "tampered expiry", url: tampered.String(), wantStatus: http.StatusForbidden},
+		{name: "without signature", url: path, wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(s.URL + tt.url)
+
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
 	echomw "github.com/labstack/echo/v4/middleware"
 
 	apimw "github.com/boardsite-io/server/internal/middleware"
@@ -9,7 +12,8 @@ import (
 
 // setRoutes sets the api routes
 func (s *Server) setRoutes() {
-	boardGroup := s.echo.Group("/b", echomw.Gzip(), libmw.RequestLogger())
+	boardGroup := s.echo.Group("/b", echomw.GzipWithConfig(echomw.GzipConfig{Skipper: skipCompression}),
+		libmw.RequestLogger())
 
 	createGroup := boardGroup.Group("/create", libmw.RateLimiting(s.cfg.Server.RPM, libmw.WithIP()))
 	createGroup.POST( /**/ "", s.session.PostCreateSession)
@@ -21,6 +25,7 @@ func (s *Server) setRoutes() {
 	hostGroup := boardGroup.Group("", apimw.Session(s.dispatcher), apimw.Host())
 	hostGroup.PUT("/:id/config", s.session.PutSessionConfig)
 	hostGroup.PUT("/:id/users/:userId", s.session.PutKickUser)
+	hostGroup.DELETE("/:id/chat/:messageId", s.session.DeleteChatMessage)
 
 	usersGroup := boardGroup.Group("/:id/users")
 	usersGroup.POST( /* */ "", s.session.PostUsers)
@@ -32,13 +37,38 @@ func (s *Server) setRoutes() {
 	pagesGroup.POST( /* */ "", s.session.PostPages)
 	pagesGroup.PUT( /*  */ "", s.session.PutPages)
 	pagesGroup.GET( /*  */ "/:pageId", s.session.GetPage)
+	pagesGroup.GET( /*  */ "/:pageId/strokes", s.session.GetPageStrokes)
+	pagesGroup.GET( /*  */ "/:pageId/thumbnail", s.session.GetPageThumbnail)
+	pagesGroup.POST( /* */ "/:pageId/strokes/hittest", s.session.PostStrokesHitTest)
+	pagesGroup.GET( /*  */ "/:pageId/layers", s.session.GetLayers)
+	pagesGroup.POST( /* */ "/:pageId/layers", s.session.PostLayer)
+	pagesGroup.PUT( /*  */ "/:pageId/layers/:layerId", s.session.PutLayer)
+	pagesGroup.DELETE( /**/ "/:pageId/layers/:layerId", s.session.DeleteLayer)
 	pagesGroup.GET( /*  */ "/sync", s.session.GetPageSync)
 	pagesGroup.POST( /* */ "/sync", s.session.PostPageSync)
 
-	attachGroup := boardGroup.Group("/:id/attachments", apimw.Session(s.dispatcher))
-	attachGroup.POST( /**/ "", s.session.PostAttachment,
+	// users of read-only sessions can still comment
+	commentsGroup := boardGroup.Group("/:id/pages/:pageId/comments", apimw.Participant(s.dispatcher))
+	commentsGroup.GET( /*  */ "", s.session.GetComments)
+	commentsGroup.POST( /* */ "", s.session.PostCommentThread)
+	commentsGroup.POST( /* */ "/:threadId", s.session.PostComment)
+	commentsGroup.PUT( /*  */ "/:threadId", s.session.PutCommentThread)
+	commentsGroup.DELETE( /**/ "/:threadId", s.session.DeleteCommentThread)
+
+	searchGroup := boardGroup.Group("/:id/search", apimw.Session(s.dispatcher))
+	searchGroup.GET("", s.session.GetSearch)
+
+	chatGroup := boardGroup.Group("/:id/chat", apimw.Session(s.dispatcher))
+	chatGroup.GET("", s.session.GetChat)
+
+	attachGroup := boardGroup.Group("/:id/attachments")
+	attachGroup.GET( /*  */ "", s.session.GetAttachments, apimw.Session(s.dispatcher))
+	attachGroup.POST( /* */ "", s.session.PostAttachment, apimw.Session(s.dispatcher),
 		libmw.RateLimiting(s.cfg.Server.RPM, libmw.WithUserIP()))
-	attachGroup.GET( /* */ "/:attachId", s.session.GetAttachment)
+	attachGroup.GET( /*  */ "/:attachId", s.session.GetAttachment, apimw.Attachment(s.dispatcher))
+	attachGroup.DELETE( /**/ "/:attachId", s.session.DeleteAttachment, apimw.Session(s.dispatcher))
+	attachGroup.GET( /*  */ "/:attachId/url", s.session.GetAttachmentURL, apimw.Session(s.dispatcher))
+	attachGroup.GET( /*  */ "/:attachId/pages/:n", s.session.GetAttachmentPage, apimw.Attachment(s.dispatcher))
 
 	if s.cfg.Server.Metrics.Enabled {
 		s.setMetricsRoutes()
@@ -46,6 +76,16 @@ func (s *Server) setRoutes() {
 
 }
 
+// skipCompression skips the compression of attachment downloads, which are
+// served in ranges with their length and are mostly compressed already, as
+// well as of page thumbnails.
+func skipCompression(c echo.Context) bool {
+	if c.Request().Method != http.MethodGet {
+		return false
+	}
+	return c.Path() == "/b/:id/attachments/:attachId" || c.Path() == "/b/:id/pages/:pageId/thumbnail"
+}
+
 func (s *Server) setMetricsRoutes() {
 	metricsGroup := s.echo.Group(s.cfg.Server.Metrics.Route,
 		libmw.BasicAuth(s.cfg.Server.Metrics.User, s.cfg.Server.Metrics.Password))
@@ -7,6 +7,7 @@ import (
 	"github.com/labstack/echo/v4"
 	echomw "github.com/labstack/echo/v4/middleware"
 
+	"github.com/boardsite-io/server/internal/attachment"
 	"github.com/boardsite-io/server/internal/config"
 	"github.com/boardsite-io/server/internal/metrics"
 	apimw "github.com/boardsite-io/server/internal/middleware"
@@ -42,10 +43,16 @@ func (s *Server) Serve(ctx context.Context) (func() error, func() error) {
 	}
 	log.Global().Info("Redis connection pool initialized.")
 
-	s.dispatcher = session.NewDispatcher(cache)
+	// setup attachment backend
+	newAttachments, err := attachment.NewFactory(s.cfg.Attachments)
+	if err != nil {
+		log.Global().Fatalf("attachments: %v", err)
+	}
+
+	s.dispatcher = session.NewDispatcher(cache, newAttachments)
 
 	// set up session dispatcher/handler
-	s.session = sessionHttp.NewHandler(s.cfg.Session, s.dispatcher)
+	s.session = sessionHttp.NewHandler(s.cfg, s.dispatcher)
 
 	s.echo.Use(
 		echomw.Recover(),
@@ -0,0 +1,251 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boardsite-io/server/internal/attachment"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+)
+
+// Attachment declares the meta data of an attachment of the session.
+type Attachment struct {
+	AttachID string `json:"attachId"`
+	// Hash is the hex encoded SHA-256 hash of the content
+	Hash     string `json:"hash"`
+	MIMEType string `json:"mimeType"`
+	// Size is the size of the stored content in bytes
+	Size   int64  `json:"size"`
+	UserID string `json:"userId"`
+	Alias  string `json:"alias"`
+	// Time is the unix time in milliseconds
+	Time int64 `json:"time"`
+	// PageIDs are the pages which use the attachment as background
+	PageIDs []string `json:"pageIds,omitempty"`
+}
+
+// defaultURLExpiry is the validity of signed attachment URLs if none is configured.
+const defaultURLExpiry = 15 * time.Minute
+
+// AttachmentURL declares a signed URL, which grants read access to an
+// attachment and its page previews without further authorization.
+type AttachmentURL struct {
+	URL string `json:"url"`
+	// Expires is the unix time in seconds
+	Expires int64 `json:"expires"`
+}
+
+// ContentAttachmentDelete declares the content of attachment delete messages.
+type ContentAttachmentDelete struct {
+	AttachID string `json:"attachId"`
+}
+
+// UploadAttachment stores the file of the user and broadcasts the meta data
+// of the attachment to all users.
+//
+// Identical content is stored once and keeps its uploader. Uploads which
+// exceed the maximum file size or the attachment quota of the session are rejected.
+func (scb *controlBlock) UploadAttachment(ctx context.Context, r io.Reader, user User) (*Attachment, error) {
+	// the content is stored before locking, such that slow uploads do not
+	// block the attachments of the session
+	maxFileSize := scb.cfg.Attachments.MaxFileSize
+	attachID, size, err := scb.attachments.Upload(attachment.LimitReader(r, maxFileSize))
+	if errors.Is(err, attachment.ErrFileSize) {
+		return nil, libErr.From(libErr.AttachmentSizeExceeded).Wrap(
+			libErr.WithErrorf("file exceeds %d bytes", maxFileSize))
+	}
+	if errors.Is(err, attachment.ErrFileType) {
+		return nil, libErr.ErrBadRequest.Wrap(libErr.WithError(err))
+	}
+	if err != nil {
+		return nil, libErr.ErrInternalServerError.Wrap(libErr.WithError(err))
+	}
+
+	scb.muAttachments.Lock()
+	defer scb.muAttachments.Unlock()
+
+	attachments, err := scb.getAttachments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var total int64
+	for _, a := range attachments {
+		if a.AttachID == attachID {
+			return a, nil
+		}
+		total += a.Size
+	}
+	if maxSize := scb.cfg.Attachments.MaxSessionSize; maxSize > 0 && total+size > maxSize {
+		_ = scb.attachments.Delete(attachID)
+		return nil, libErr.From(libErr.AttachmentQuotaExceeded).Wrap(
+			libErr.WithErrorf("attachments of the session exceed %d bytes", maxSize))
+	}
+	// identical content might have been deleted in the meantime
+	f, err := scb.attachments.Get(attachID)
+	if err != nil {
+		return nil, libErr.ErrInternalServerError.Wrap(libErr.WithErrorf("attachment was deleted during the upload"))
+	}
+	f.Close()
+
+	a := &Attachment{
+		AttachID: attachID,
+		Hash:     attachment.Hash(attachID),
+		MIMEType: attachment.MIMEType(attachID),
+		Size:     size,
+		UserID:   user.ID,
+		Alias:    user.Alias,
+		Time:     time.Now().UnixMilli(),
+	}
+	if err := scb.cache.SetAttachment(ctx, scb.cfg.ID, attachID, a); err != nil {
+		return nil, err
+	}
+
+	scb.broadcaster.Broadcast() <- Message{
+		Type:    MessageTypeAttachment,
+		Content: a,
+	}
+	return a, nil
+}
+
+// GetAttachments returns the attachments of the session in order of upload
+// including the pages which use them as background.
+func (scb *controlBlock) GetAttachments(ctx context.Context) ([]*Attachment, error) {
+	attachments, err := scb.getAttachments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := scb.attachmentPages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range attachments {
+		a.PageIDs = pages[a.AttachID]
+	}
+	sort.SliceStable(attachments, func(i, j int) bool {
+		return attachments[i].Time < attachments[j].Time
+	})
+	return attachments, nil
+}
+
+// DeleteAttachment deletes the attachment and broadcasts the deletion to all users.
+//
+// Attachments can only be deleted by the uploader or the host. Attachments
+// which are used as page background are only deleted if forced.
+func (scb *controlBlock) DeleteAttachment(ctx context.Context, attachID, userID string, host, force bool) error {
+	scb.muAttachments.Lock()
+	defer scb.muAttachments.Unlock()
+
+	attachments, err := scb.getAttachments(ctx)
+	if err != nil {
+		return err
+	}
+	var a *Attachment
+	for _, other := range attachments {
+		if other.AttachID == attachID {
+			a = other
+			break
+		}
+	}
+	if a == nil {
+		return libErr.ErrNotFound.Wrap(libErr.WithErrorf("attachment %s does not exist", attachID))
+	}
+	if !host && a.UserID != userID {
+		return libErr.ErrForbidden.Wrap(libErr.WithErrorf("only the uploader or the host can delete attachments"))
+	}
+	pages, err := scb.attachmentPages(ctx)
+	if err != nil {
+		return err
+	}
+	pageIDs := pages[attachID]
+	if len(pageIDs) > 0 && !force {
+		return libErr.From(libErr.AttachmentReferenced).Wrap(
+			libErr.WithErrorf("attachment is the background of the pages %s", strings.Join(pageIDs, ", ")))
+	}
+
+	if err := scb.attachments.Delete(attachID); err != nil && !errors.Is(err, attachment.ErrNotFound) {
+		return err
+	}
+	if _, err := scb.cache.DeleteAttachment(ctx, scb.cfg.ID, attachID); err != nil {
+		return err
+	}
+	// the backgrounds of the pages are gone
+	scb.dropThumbnails(pageIDs...)
+
+	scb.broadcaster.Broadcast() <- Message{
+		Type:    MessageTypeAttachmentDelete,
+		Content: ContentAttachmentDelete{AttachID: attachID},
+	}
+	return nil
+}
+
+// AttachmentURL returns a signed URL of the attachment, which is relative to
+// the API root.
+func (scb *controlBlock) AttachmentURL(attachID string) *AttachmentURL {
+	expiry := scb.cfg.Attachments.URLExpiry
+	if expiry <= 0 {
+		expiry = defaultURLExpiry
+	}
+	expires := time.Now().Add(expiry).Unix()
+	query := url.Values{
+		QueryKeyExpires:   {strconv.FormatInt(expires, 10)},
+		QueryKeySignature: {attachment.Sign(scb.urlKey, scb.cfg.ID, attachID, expires)},
+	}
+	return &AttachmentURL{
+		URL:     fmt.Sprintf("/b/%s/attachments/%s?%s", url.PathEscape(scb.cfg.ID), url.PathEscape(attachID), query.Encode()),
+		Expires: expires,
+	}
+}
+
+// VerifyAttachmentURL checks the signature of an URL created by AttachmentURL.
+func (scb *controlBlock) VerifyAttachmentURL(attachID string, expires int64, signature string) error {
+	err := attachment.Verify(scb.urlKey, scb.cfg.ID, attachID, expires, signature, time.Now())
+	if err != nil {
+		return libErr.ErrForbidden.Wrap(libErr.WithError(err))
+	}
+	return nil
+}
+
+// getAttachments returns the attachments of the session in no particular order.
+func (scb *controlBlock) getAttachments(ctx context.Context) ([]*Attachment, error) {
+	data, err := scb.cache.GetAttachments(ctx, scb.cfg.ID)
+	if err != nil {
+		return nil, err
+	}
+	attachments := make([]*Attachment, len(data))
+	for i, d := range data {
+		var a Attachment
+		if err := json.Unmarshal(d, &a); err != nil {
+			return nil, err
+		}
+		attachments[i] = &a
+	}
+	return attachments, nil
+}
+
+// attachmentPages returns the ids of the pages in order by the attachment
+// of their background.
+func (scb *controlBlock) attachmentPages(ctx context.Context) (map[string][]string, error) {
+	pageRank, err := scb.GetPageRank(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pages := make(map[string][]string)
+	for _, pid := range pageRank {
+		var meta PageMeta
+		if err := scb.cache.GetPageMeta(ctx, scb.cfg.ID, pid, &meta); err != nil {
+			return nil, err
+		}
+		if attachID := meta.Background.AttachId; attachID != "" {
+			pages[attachID] = append(pages[attachID], pid)
+		}
+	}
+	return pages, nil
+}
@@ -0,0 +1,234 @@
+package session_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/attachment"
+	"github.com/boardsite-io/server/internal/config"
+	"github.com/boardsite-io/server/internal/session"
+	"github.com/boardsite-io/server/internal/session/sessionfakes"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/redis/redisfakes"
+)
+
+// setupAttachmentSession returns a session with the pages "pid1" and "pid2",
+// where the cache stores the attachment meta data and the background of
+// "pid1" is the attachment background.
+func setupAttachmentSession(t *testing.T, limits config.AttachmentLimits, background string) (session.Controller, attachment.Handler, chan session.Message) {
+	fakeCache := &redisfakes.FakeHandler{}
+	fakeCache.GetPageRankReturns([]string{"pid1", "pid2"}, nil)
+	fakeCache.GetPageMetaCalls(func(_ context.Context, _, pid string, meta any) error {
+		if pid == "pid1" {
+			meta.(*session.PageMeta).Background.AttachId = background
+		}
+		return nil
+	})
+	stored := make(map[string][]byte)
+	fakeCache.GetAttachmentsCalls(func(_ context.Context, _ string) ([][]byte, error) {
+		attachments := make([][]byte, 0, len(stored))
+		for _, a := range stored {
+			attachments = append(attachments, a)
+		}
+		return attachments, nil
+	})
+	fakeCache.SetAttachmentCalls(func(_ context.Context, _, id string, v any) error {
+		stored[id], _ = json.Marshal(v)
+		return nil
+	})
+	fakeCache.DeleteAttachmentCalls(func(_ context.Context, _, id string) (bool, error) {
+		_, ok := stored[id]
+		delete(stored, id)
+		return ok, nil
+	})
+	broadcast := make(chan session.Message, 10)
+	fakeBroadcaster := &sessionfakes.FakeBroadcaster{}
+	fakeBroadcaster.BroadcastReturns(broadcast)
+	attachments := attachment.NewLocalHandler(t.TempDir(), "sid1")
+	cfg := session.Config{ID: "sid1", Host: "host"}
+	cfg.Attachments = limits
+	scb, err := session.NewControlBlock(cfg, session.WithCache(fakeCache), session.WithAttachments(attachments),
+		session.WithDispatcher(&sessionfakes.FakeDispatcher{}), session.WithBroadcaster(fakeBroadcaster))
+	require.NoError(t, err)
+	return scb, attachments, broadcast
+}
+
+func newPNG(t *testing.T, size int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, size, size))))
+	return buf.Bytes()
+}
+
+func Test_controlBlock_Attachments(t *testing.T) {
+	ctx := context.Background()
+	scb, attachments, broadcast := setupAttachmentSession(t, config.AttachmentLimits{}, "")
+	uploader := session.User{ID: "user1", Alias: "uploader"}
+
+	a, err := scb.UploadAttachment(ctx, bytes.NewReader(newPNG(t, 4)), uploader)
+
+	require.NoError(t, err)
+	assert.Equal(t, attachment.Hash(a.AttachID), a.Hash)
+	assert.Equal(t, "image/png", a.MIMEType)
+	assert.Equal(t, int64(len(newPNG(t, 4))), a.Size)
+	assert.Equal(t, "user1", a.UserID)
+	assert.Equal(t, "uploader", a.Alias)
+	msg := <-broadcast
+	assert.Equal(t, session.MessageTypeAttachment, msg.Type)
+
+	// identical content keeps its uploader
+	same, err := scb.UploadAttachment(ctx, bytes.NewReader(newPNG(t, 4)), session.User{ID: "user2"})
+	require.NoError(t, err)
+	assert.Equal(t, a, same)
+	other, err := scb.UploadAttachment(ctx, bytes.NewReader(newPNG(t, 8)), session.User{ID: "user2"})
+	require.NoError(t, err)
+
+	list, err := scb.GetAttachments(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.ElementsMatch(t, []string{a.AttachID, other.AttachID}, []string{list[0].AttachID, list[1].AttachID})
+
+	// only the uploader or the host can delete the attachment
+	err = scb.DeleteAttachment(ctx, a.AttachID, "user2", false, false)
+	assert.ErrorIs(t, err, libErr.ErrForbidden)
+	err = scb.DeleteAttachment(ctx, a.AttachID, "user1", false, false)
+	require.NoError(t, err)
+	<-broadcast
+	msg = <-broadcast
+	assert.Equal(t, session.MessageTypeAttachmentDelete, msg.Type)
+	assert.Equal(t, session.ContentAttachmentDelete{AttachID: a.AttachID}, msg.Content)
+	_, err = attachments.Get(a.AttachID)
+	assert.ErrorIs(t, err, attachment.ErrNotFound)
+	err = scb.DeleteAttachment(ctx, a.AttachID, "user1", true, false)
+	assert.ErrorIs(t, err, libErr.ErrNotFound)
+
+	list, err = scb.GetAttachments(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, other.AttachID, list[0].AttachID)
+}
+
+func Test_controlBlock_DeleteAttachment_Referenced(t *testing.T) {
+	ctx := context.Background()
+	data := newPNG(t, 4)
+	attachID, _, err := attachment.NewLocalHandler(t.TempDir(), "sid").Upload(bytes.NewReader(data))
+	require.NoError(t, err)
+	scb, _, _ := setupAttachmentSession(t, config.AttachmentLimits{}, attachID)
+	_, err = scb.UploadAttachment(ctx, bytes.NewReader(data), session.User{ID: "user1"})
+	require.NoError(t, err)
+
+	list, err := scb.GetAttachments(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, []string{"pid1"}, list[0].PageIDs)
+
+	err = scb.DeleteAttachment(ctx, attachID, "host", true, false)
+	assert.ErrorIs(t, err, libErr.From(libErr.AttachmentReferenced))
+	err = scb.DeleteAttachment(ctx, attachID, "host", true, true)
+	assert.NoError(t, err)
+}
+
+func Test_controlBlock_UploadAttachment_Quota(t *testing.T) {
+	ctx := context.Background()
+	first, second := newPNG(t, 4), newPNG(t, 8)
+	secondID, _, err := attachment.NewLocalHandler(t.TempDir(), "sid").Upload(bytes.NewReader(second))
+	require.NoError(t, err)
+	scb, attachments, _ := setupAttachmentSession(t, config.AttachmentLimits{MaxSessionSize: int64(len(first) + len(second) - 1)}, "")
+
+	a, err := scb.UploadAttachment(ctx, bytes.NewReader(first), session.User{ID: "user1"})
+	require.NoError(t, err)
+	_, err = scb.UploadAttachment(ctx, bytes.NewReader(second), session.User{ID: "user1"})
+	assert.ErrorIs(t, err, libErr.From(libErr.AttachmentQuotaExceeded))
+	// identical content does not count towards the quota
+	_, err = scb.UploadAttachment(ctx, bytes.NewReader(first), session.User{ID: "user1"})
+	assert.NoError(t, err)
+	_, err = scb.UploadAttachment(ctx, strings.NewReader("plain text"), session.User{ID: "user1"})
+	assert.ErrorIs(t, err, libErr.ErrBadRequest)
+
+	list, err := scb.GetAttachments(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, a.AttachID, list[0].AttachID)
+	// the rejected file is not stored
+	_, err = attachments.Get(secondID)
+	assert.ErrorIs(t, err, attachment.ErrNotFound)
+}
+
+func Test_controlBlock_UploadAttachment_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	scb, _, broadcast := setupAttachmentSession(t, config.AttachmentLimits{}, "")
+	slow := newPNG(t, 4)
+	r, w := io.Pipe()
+	slowDone := make(chan error, 1)
+	go func() {
+		_, err := scb.UploadAttachment(ctx, r, session.User{ID: "user1"})
+		slowDone <- err
+	}()
+	_, err := w.Write(slow[:len(slow)/2])
+	require.NoError(t, err)
+
+	// the stalled upload does not block others
+	done := make(chan error, 1)
+	go func() {
+		_, err := scb.UploadAttachment(ctx, bytes.NewReader(newPNG(t, 8)), session.User{ID: "user2"})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("upload blocked by stalled upload")
+	}
+	<-broadcast
+
+	_, err = w.Write(slow[len(slow)/2:])
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, <-slowDone)
+	list, err := scb.GetAttachments(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+}
+
+func Test_controlBlock_UploadAttachment_FileSize(t *testing.T) {
+	ctx := context.Background()
+	data := newPNG(t, 4)
+	scb, _, _ := setupAttachmentSession(t, config.AttachmentLimits{MaxFileSize: int64(len(data) - 1)}, "")
+
+	_, err := scb.UploadAttachment(ctx, bytes.NewReader(data), session.User{ID: "user1"})
+
+	assert.ErrorIs(t, err, libErr.From(libErr.AttachmentSizeExceeded))
+	list, err := scb.GetAttachments(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func Test_controlBlock_AttachmentURL(t *testing.T) {
+	scb, _, _ := setupAttachmentSession(t, config.AttachmentLimits{URLExpiry: time.Minute}, "")
+
+	u := scb.AttachmentURL("hash.pdf")
+
+	parsed, err := url.Parse(u.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "/b/sid1/attachments/hash.pdf", parsed.Path)
+	assert.InDelta(t, time.Now().Add(time.Minute).Unix(), u.Expires, 2)
+	query := parsed.Query()
+	assert.Equal(t, strconv.FormatInt(u.Expires, 10), query.Get(session.QueryKeyExpires))
+	signature := query.Get(session.QueryKeySignature)
+	assert.NoError(t, scb.VerifyAttachmentURL("hash.pdf", u.Expires, signature))
+	assert.ErrorIs(t, scb.VerifyAttachmentURL("other.pdf", u.Expires, signature), libErr.ErrForbidden)
+	// the signatures are bound to the session
+	other, _, _ := setupAttachmentSession(t, config.AttachmentLimits{}, "")
+	assert.ErrorIs(t, other.VerifyAttachmentURL("hash.pdf", u.Expires, signature), libErr.ErrForbidden)
+}
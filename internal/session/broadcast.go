@@ -6,8 +6,6 @@ import (
 	"fmt"
 	"runtime"
 
-	gws "github.com/gorilla/websocket"
-
 	"github.com/boardsite-io/server/pkg/log"
 	"github.com/boardsite-io/server/pkg/redis"
 )
@@ -24,8 +22,6 @@ type Broadcaster interface {
 	Send() chan<- Message
 	// Control returns a channel for close messages to sent to a specific client
 	Control() chan<- Message
-	// Cache returns a channel for strokes to be stored in the cache
-	Cache() chan<- []redis.Stroke
 	// Close the broadcaster and cleans up all goroutines
 	Close()
 }
@@ -34,22 +30,23 @@ type broadcaster struct {
 	scb   Controller
 	cache redis.Handler
 
-	broadcast   chan Message
-	send        chan Message
-	control     chan Message
-	cacheUpdate chan []redis.Stroke
-	close       chan struct{}
+	broadcast chan Message
+	send      chan Message
+	control   chan Message
+	close     chan struct{}
 }
 
+// broadcastBufferSize is the capacity of the broadcast and send channels
+const broadcastBufferSize = 64
+
 // NewBroadcaster creates a new Broadcaster for a given session
 func NewBroadcaster(cache redis.Handler) Broadcaster {
 	return &broadcaster{
-		cache:       cache,
-		broadcast:   make(chan Message),
-		send:        make(chan Message),
-		control:     make(chan Message),
-		cacheUpdate: make(chan []redis.Stroke),
-		close:       make(chan struct{}),
+		cache:     cache,
+		broadcast: make(chan Message, broadcastBufferSize),
+		send:      make(chan Message, broadcastBufferSize),
+		control:   make(chan Message),
+		close:     make(chan struct{}),
 	}
 }
 
@@ -58,9 +55,9 @@ func (b *broadcaster) Bind(scb Controller) Broadcaster {
 		return nil
 	}
 	b.scb = scb
-	// start goroutines for broadcasting and saving changes to board
+	// start goroutines for broadcasting and clearing the board
 	go b.broadcastLoop()
-	go b.cacheUpdateLoop()
+	go b.clearOnClose()
 
 	return b
 }
@@ -77,10 +74,6 @@ func (b *broadcaster) Control() chan<- Message {
 	return b.control
 }
 
-func (b *broadcaster) Cache() chan<- []redis.Stroke {
-	return b.cacheUpdate
-}
-
 func (b *broadcaster) Close() {
 	close(b.close)
 }
@@ -118,10 +111,11 @@ func (b *broadcaster) broadcastToUser(users map[string]*User) error {
 
 	select {
 	case data := <-b.broadcast:
+		msg := newEncodedMessage(&data)
 		for userID, user := range users { // Send to all connected clients
 			// except the origin, i.e. the initiator of message
 			if userID != data.Sender {
-				if err := user.Conn.WriteJSON(data); err != nil {
+				if err := msg.writeTo(user.Conn); err != nil {
 					log.Global().Warnf("cannot broadcast to %s: %v",
 						user.Conn.RemoteAddr(), err)
 				}
@@ -132,35 +126,58 @@ func (b *broadcaster) broadcastToUser(users map[string]*User) error {
 		if !ok {
 			return fmt.Errorf("send: unkown receiver: %v", data.Receiver)
 		}
-		if err := u.Conn.WriteJSON(data); err != nil {
-			return fmt.Errorf("send: writeJSON: %w", err)
+		if err := u.Conn.Send(&data); err != nil {
+			return fmt.Errorf("send: %w", err)
 		}
 	case data := <-b.control:
 		u, ok := users[data.Receiver]
 		if !ok {
 			return fmt.Errorf("control: unkown receiver: %v", data.Receiver)
 		}
-		msg := gws.FormatCloseMessage(gws.CloseNormalClosure, fmt.Sprintf("%v", data.Content))
-		_ = u.Conn.WriteMessage(gws.CloseMessage, msg)
+		u.Conn.CloseWith(fmt.Sprintf("%v", data.Content))
 	case <-b.close:
 		return ErrBroadcasterClosed
 	}
 	return nil
 }
 
-// dbUpdateLoop updates database according to given Stroke values
-func (b *broadcaster) cacheUpdateLoop() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	for {
-		select {
-		case strokes := <-b.cacheUpdate:
-			if err := b.cache.UpdateStrokes(ctx, b.scb.ID(), strokes...); err != nil {
-				log.Global().Warnf("error in dbUpdateLoop: %v", err)
-			}
-		case <-b.close:
-			_ = b.cache.ClearSession(ctx, b.scb.ID())
-			return
+// encodedMessage encodes a message at most once per codec,
+// such that broadcasts are transcoded per connection.
+//
+// Messages with an ephemeral type may be dropped by slow connections.
+type encodedMessage struct {
+	msg  *Message
+	data map[string][]byte
+}
+
+func newEncodedMessage(msg *Message) *encodedMessage {
+	return &encodedMessage{
+		msg:  msg,
+		data: make(map[string][]byte, len(Codecs)),
+	}
+}
+
+func (m *encodedMessage) writeTo(c *Conn) error {
+	codec := c.Codec()
+	data, ok := m.data[codec.Name()]
+	if !ok {
+		var err error
+		if data, err = codec.Marshal(m.msg); err != nil {
+			return fmt.Errorf("encode %s: %w", codec.Name(), err)
 		}
+		m.data[codec.Name()] = data
 	}
+	return c.enqueue(outboundMessage{
+		frameType: codec.FrameType(),
+		data:      data,
+	}, isEphemeral(m.msg.Type))
+}
+
+// clearOnClose clears the session from the cache once the broadcaster is closed.
+func (b *broadcaster) clearOnClose() {
+	<-b.close
+	ctx := context.Background()
+	_ = b.cache.ClearSession(ctx, b.scb.ID())
+	_ = b.cache.ClearChat(ctx, b.scb.ID())
+	_ = b.cache.ClearAttachments(ctx, b.scb.ID())
 }
@@ -0,0 +1,119 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	libErr "github.com/boardsite-io/server/pkg/errors"
+)
+
+// ContentChat declares the content of chat messages sent by users.
+type ContentChat struct {
+	Text string `json:"text"`
+}
+
+// ChatMessage declares a message of the session chat.
+type ChatMessage struct {
+	ID     string `json:"id"`
+	UserID string `json:"userId"`
+	Alias  string `json:"alias"`
+	Text   string `json:"text"`
+	// Time is the unix time in milliseconds
+	Time int64 `json:"time"`
+}
+
+// ContentChatDelete declares the content of chat delete messages.
+type ContentChatDelete struct {
+	ID string `json:"id"`
+}
+
+// chat stores the chat message and broadcasts it to all users including the sender.
+//
+// Chat messages are also accepted in read-only sessions.
+func (scb *controlBlock) chat(ctx context.Context, msg *Message) error {
+	var content ContentChat
+	if err := msg.UnmarshalContent(&content); err != nil {
+		return err
+	}
+	text := strings.TrimSpace(content.Text)
+	if text == "" {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("empty chat message"))
+	}
+	if maxLength := scb.cfg.Chat.MaxLength; maxLength > 0 && utf8.RuneCountInString(text) > maxLength {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("chat message exceeds %d characters", maxLength))
+	}
+
+	message := ChatMessage{
+		ID:     uuid.NewString(),
+		UserID: msg.Sender,
+		Text:   text,
+		Time:   time.Now().UnixMilli(),
+	}
+	if u, ok := scb.GetUsers()[msg.Sender]; ok {
+		message.Alias = u.Alias
+	}
+	if err := scb.cache.AppendChat(ctx, scb.cfg.ID, message, scb.cfg.Chat.HistorySize); err != nil {
+		return err
+	}
+
+	scb.broadcaster.Broadcast() <- Message{
+		Type:    MessageTypeChat,
+		Content: message,
+	}
+	return nil
+}
+
+func (scb *controlBlock) GetChat(ctx context.Context) ([]*ChatMessage, error) {
+	messages, _, err := scb.getChat(ctx)
+	return messages, err
+}
+
+// getChat returns the chat history and the encoding of the messages in the cache.
+func (scb *controlBlock) getChat(ctx context.Context) ([]*ChatMessage, [][]byte, error) {
+	history, err := scb.cache.GetChat(ctx, scb.cfg.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	messages := make([]*ChatMessage, len(history))
+	for i, m := range history {
+		var message ChatMessage
+		if err := json.Unmarshal(m, &message); err != nil {
+			return nil, nil, err
+		}
+		messages[i] = &message
+	}
+	return messages, history, nil
+}
+
+// DeleteChatMessage removes a message from the chat history and broadcasts
+// the deletion to all users.
+func (scb *controlBlock) DeleteChatMessage(ctx context.Context, messageID string) error {
+	messages, history, err := scb.getChat(ctx)
+	if err != nil {
+		return err
+	}
+	found := false
+	for i, m := range messages {
+		if m.ID != messageID {
+			continue
+		}
+		if found, err = scb.cache.RemoveChat(ctx, scb.cfg.ID, history[i]); err != nil {
+			return err
+		}
+		break
+	}
+	if !found {
+		return libErr.ErrNotFound.Wrap(libErr.WithErrorf("chat message %s does not exist", messageID))
+	}
+
+	scb.broadcaster.Broadcast() <- Message{
+		Type:    MessageTypeChatDelete,
+		Content: ContentChatDelete{ID: messageID},
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package session_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/heat1q/opt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/config"
+	"github.com/boardsite-io/server/internal/session"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/redis/redisfakes"
+)
+
+func setupChatSession(t *testing.T) (session.Controller, *redisfakes.FakeHandler, chan session.Message) {
+	var history [][]byte
+	cfg := session.Config{ID: "sid1", Session: config.Session{Chat: config.ChatLimits{HistorySize: 100, MaxLength: 10}}}
+	return newTestSession(t, cfg, func(fakeCache *redisfakes.FakeHandler) {
+		fakeCache.AppendChatCalls(func(_ context.Context, _ string, message any, _ int) error {
+			b, _ := json.Marshal(message)
+			history = append(history, b)
+			return nil
+		})
+		fakeCache.GetChatCalls(func(context.Context, string) ([][]byte, error) {
+			return history, nil
+		})
+		fakeCache.RemoveChatReturns(true, nil)
+	})
+}
+
+func newChatMessage(t *testing.T, text string) *session.Message {
+	data, err := session.JSONCodec.Marshal(session.NewMessage(session.ContentChat{Text: text}, session.MessageTypeChat, "user1"))
+	require.NoError(t, err)
+	msg, err := session.JSONCodec.Unmarshal(data)
+	require.NoError(t, err)
+	return msg
+}
+
+func Test_controlBlock_Receive_Chat(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores and broadcasts message", func(t *testing.T) {
+		scb, fakeCache, broadcast := setupChatSession(t)
+
+		err := scb.Receive(ctx, newChatMessage(t, "  hello  "), "user1")
+
+		require.NoError(t, err)
+		_, sid, stored, maxLen := fakeCache.AppendChatArgsForCall(0)
+		assert.Equal(t, "sid1", sid)
+		assert.Equal(t, 100, maxLen)
+		message := stored.(session.ChatMessage)
+		assert.NotEmpty(t, message.ID)
+		assert.Equal(t, "user1", message.UserID)
+		assert.Equal(t, "hello", message.Text)
+		assert.NotZero(t, message.Time)
+		msg := <-broadcast
+		assert.Equal(t, session.MessageTypeChat, msg.Type)
+		assert.Empty(t, msg.Sender)
+		assert.Equal(t, message, msg.Content)
+	})
+
+	t.Run("accepts message in read-only session", func(t *testing.T) {
+		scb, fakeCache, _ := setupChatSession(t)
+		require.NoError(t, scb.SetConfig(&session.ConfigRequest{ReadOnly: opt.New(true)}))
+
+		err := scb.Receive(ctx, newChatMessage(t, "hello"), "user1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, fakeCache.AppendChatCallCount())
+	})
+
+	for name, text := range map[string]string{
+		"rejects empty message":    " \n ",
+		"rejects too long message": strings.Repeat("ä", 11),
+	} {
+		t.Run(name, func(t *testing.T) {
+			scb, fakeCache, broadcast := setupChatSession(t)
+
+			err := scb.Receive(ctx, newChatMessage(t, text), "user1")
+
+			assert.ErrorIs(t, err, libErr.ErrBadRequest)
+			assert.Equal(t, 0, fakeCache.AppendChatCallCount())
+			assert.Len(t, broadcast, 0)
+		})
+	}
+}
+
+func Test_controlBlock_DeleteChatMessage(t *testing.T) {
+	ctx := context.Background()
+	scb, fakeCache, broadcast := setupChatSession(t)
+	for _, text := range []string{"first", "second"} {
+		require.NoError(t, scb.Receive(ctx, newChatMessage(t, text), "user1"))
+		<-broadcast
+	}
+	history, err := scb.GetChat(ctx)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	t.Run("deletes message", func(t *testing.T) {
+		err := scb.DeleteChatMessage(ctx, history[1].ID)
+
+		assert.NoError(t, err)
+		_, sid, removed := fakeCache.RemoveChatArgsForCall(0)
+		assert.Equal(t, "sid1", sid)
+		var message session.ChatMessage
+		require.NoError(t, json.Unmarshal(removed, &message))
+		assert.Equal(t, *history[1], message)
+		msg := <-broadcast
+		assert.Equal(t, session.MessageTypeChatDelete, msg.Type)
+		assert.Equal(t, session.ContentChatDelete{ID: history[1].ID}, msg.Content)
+	})
+
+	t.Run("unknown message", func(t *testing.T) {
+		err := scb.DeleteChatMessage(ctx, "unknown")
+
+		assert.ErrorIs(t, err, libErr.ErrNotFound)
+		assert.Len(t, broadcast, 0)
+	})
+}
@@ -0,0 +1,114 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+
+	gws "github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Websocket subprotocols for the supported message encodings.
+const (
+	SubprotocolJSON    = "boardsite.json"
+	SubprotocolMsgpack = "boardsite.msgpack"
+)
+
+// Codec declares the wire encoding of websocket messages
+// for a single connection.
+type Codec interface {
+	// Name returns the name of the encoding
+	Name() string
+	// FrameType returns the websocket frame type used for encoded messages
+	FrameType() int
+	// Marshal encodes a message
+	Marshal(msg *Message) ([]byte, error)
+	// Unmarshal decodes a message. The content field is not parsed
+	// and can later be decoded via Message.UnmarshalContent.
+	Unmarshal(data []byte) (*Message, error)
+}
+
+var (
+	// JSONCodec encodes messages as JSON text frames.
+	JSONCodec Codec = jsonCodec{}
+	// MsgpackCodec encodes messages as MessagePack binary frames.
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+// Codecs lists all supported codecs in order of preference.
+var Codecs = []Codec{MsgpackCodec, JSONCodec}
+
+// CodecByName returns the codec for the given encoding name
+// or subprotocol.
+func CodecByName(name string) (Codec, bool) {
+	switch name {
+	case JSONCodec.Name(), SubprotocolJSON:
+		return JSONCodec, true
+	case MsgpackCodec.Name(), SubprotocolMsgpack:
+		return MsgpackCodec, true
+	}
+	return nil, false
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func (jsonCodec) FrameType() int {
+	return gws.TextMessage
+}
+
+func (jsonCodec) Marshal(msg *Message) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+func (jsonCodec) Unmarshal(data []byte) (*Message, error) {
+	return UnmarshalMessage(data)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string {
+	return "msgpack"
+}
+
+func (msgpackCodec) FrameType() int {
+	return gws.BinaryMessage
+}
+
+func (msgpackCodec) Marshal(msg *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	// reuse the json tags to keep the field names of both encodings in sync
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	enc.UseCompactFloats(true)
+	if err := enc.Encode(msg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte) (*Message, error) {
+	var wire struct {
+		Type    string             `msgpack:"type"`
+		Sender  string             `msgpack:"sender"`
+		Content msgpack.RawMessage `msgpack:"content"`
+	}
+	if err := msgpack.Unmarshal(data, &wire); err != nil {
+		return nil, err
+	}
+	return &Message{
+		Type:    wire.Type,
+		Sender:  wire.Sender,
+		Content: wire.Content,
+	}, nil
+}
+
+func unmarshalMsgpack(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
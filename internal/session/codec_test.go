@@ -0,0 +1,93 @@
+package session_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/boardsite-io/server/internal/session"
+)
+
+func TestCodecByName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    session.Codec
+		wantErr bool
+	}{
+		{name: "json", want: session.JSONCodec},
+		{name: session.SubprotocolJSON, want: session.JSONCodec},
+		{name: "msgpack", want: session.MsgpackCodec},
+		{name: session.SubprotocolMsgpack, want: session.MsgpackCodec},
+		{name: "protobuf", wantErr: true},
+		{name: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := session.CodecByName(tt.name)
+			assert.Equal(t, !tt.wantErr, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	strokes := []*session.Stroke{
+		{
+			Type:   1,
+			ID:     "stroke1",
+			PageID: "pid1",
+			UserID: "user1",
+			X:      12,
+			Y:      -3.5,
+			ScaleX: 1,
+			ScaleY: 1,
+			Points: session.Points{0, 0, 10.5, 20.25, 30, 40},
+			Style:  session.Style{Color: "#00beef", Width: 3, Opacity: 0.5},
+		},
+	}
+
+	for _, codec := range session.Codecs {
+		t.Run(codec.Name(), func(t *testing.T) {
+			data, err := codec.Marshal(session.NewMessage(strokes, session.MessageTypeStroke, "user1"))
+			assert.NoError(t, err)
+
+			msg, err := codec.Unmarshal(data)
+			assert.NoError(t, err)
+			assert.Equal(t, session.MessageTypeStroke, msg.Type)
+			assert.Equal(t, "user1", msg.Sender)
+
+			var got []*session.Stroke
+			err = msg.UnmarshalContent(&got)
+			assert.NoError(t, err)
+			assert.Equal(t, strokes, got)
+		})
+	}
+}
+
+func TestMsgpackCodec_Size(t *testing.T) {
+	points := make(session.Points, 2000)
+	for i := range points {
+		points[i] = float64(i) * 1.2345678901234
+	}
+	msg := session.NewMessage([]*session.Stroke{{Type: 1, ID: "stroke1", Points: points}}, session.MessageTypeStroke)
+
+	jsonData, err := session.JSONCodec.Marshal(msg)
+	assert.NoError(t, err)
+	msgpackData, err := session.MsgpackCodec.Marshal(msg)
+	assert.NoError(t, err)
+
+	assert.Less(t, len(msgpackData), len(jsonData)/2)
+}
+
+func TestMessage_UnmarshalContent_Msgpack(t *testing.T) {
+	data, err := session.MsgpackCodec.Marshal(session.NewMessage(session.ContentMouseMove{X: 1, Y: 2}, session.MessageTypeMouseMove))
+	assert.NoError(t, err)
+	msg, err := session.MsgpackCodec.Unmarshal(data)
+	assert.NoError(t, err)
+
+	var got session.ContentMouseMove
+	err = msg.UnmarshalContent(&got)
+
+	assert.NoError(t, err)
+	assert.Equal(t, session.ContentMouseMove{X: 1, Y: 2}, got)
+}
@@ -0,0 +1,267 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	libErr "github.com/boardsite-io/server/pkg/errors"
+)
+
+const (
+	maxCommentThreads = 256
+	maxThreadComments = 256
+	maxCommentLength  = 2000
+)
+
+// Comment declares a comment of a comment thread.
+type Comment struct {
+	ID     string `json:"id"`
+	UserID string `json:"userId"`
+	Alias  string `json:"alias"`
+	Text   string `json:"text"`
+	// Time is the unix time in milliseconds
+	Time int64 `json:"time"`
+}
+
+// CommentThread declares a thread of comments anchored to a position on a page.
+//
+// Threads can optionally be anchored to a stroke of the page.
+type CommentThread struct {
+	ID       string     `json:"id"`
+	PageID   string     `json:"pageId"`
+	X        float64    `json:"x"`
+	Y        float64    `json:"y"`
+	StrokeID string     `json:"strokeId,omitempty"`
+	Resolved bool       `json:"resolved"`
+	Comments []*Comment `json:"comments"`
+}
+
+// created returns the time of the first comment of the thread.
+func (t *CommentThread) created() int64 {
+	if len(t.Comments) == 0 {
+		return 0
+	}
+	return t.Comments[0].Time
+}
+
+// isAuthor checks whether the user wrote the first comment of the thread.
+func (t *CommentThread) isAuthor(userID string) bool {
+	return len(t.Comments) > 0 && t.Comments[0].UserID == userID
+}
+
+// CommentThreadRequest declares the request content for creating comment threads.
+type CommentThreadRequest struct {
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	StrokeID string  `json:"strokeId,omitempty"`
+	Text     string  `json:"text"`
+}
+
+// CommentRequest declares the request content for replies to comment threads.
+type CommentRequest struct {
+	Text string `json:"text"`
+}
+
+// CommentResolveRequest declares the request content for resolving and reopening comment threads.
+type CommentResolveRequest struct {
+	Resolved bool `json:"resolved"`
+}
+
+// ContentCommentDelete declares the content of comment delete messages.
+type ContentCommentDelete struct {
+	PageID string `json:"pageId"`
+	ID     string `json:"id"`
+}
+
+// newComment creates a comment of the user with the trimmed text.
+func newComment(user User, text string) (*Comment, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > maxCommentLength {
+		return nil, libErr.ErrBadRequest.Wrap(libErr.WithErrorf("comment must have 1 to %d characters", maxCommentLength))
+	}
+	return &Comment{
+		ID:     uuid.NewString(),
+		UserID: user.ID,
+		Alias:  user.Alias,
+		Text:   text,
+		Time:   time.Now().UnixMilli(),
+	}, nil
+}
+
+// GetComments returns the comment threads of a page ordered by their creation.
+func (scb *controlBlock) GetComments(ctx context.Context, pageID string) ([]*CommentThread, error) {
+	if !scb.IsValidPage(ctx, pageID) {
+		return nil, libErr.ErrNotFound.Wrap(libErr.WithErrorf("page %s does not exist", pageID))
+	}
+	return scb.getComments(ctx, pageID)
+}
+
+func (scb *controlBlock) getComments(ctx context.Context, pageID string) ([]*CommentThread, error) {
+	data, err := scb.cache.GetComments(ctx, scb.cfg.ID, pageID)
+	if err != nil {
+		return nil, err
+	}
+	threads := make([]*CommentThread, len(data))
+	for i, d := range data {
+		var thread CommentThread
+		if err := json.Unmarshal(d, &thread); err != nil {
+			return nil, err
+		}
+		threads[i] = &thread
+	}
+	sort.Slice(threads, func(i, j int) bool {
+		ti, tj := threads[i].created(), threads[j].created()
+		if ti != tj {
+			return ti < tj
+		}
+		return threads[i].ID < threads[j].ID
+	})
+	return threads, nil
+}
+
+// getCommentThread returns the comment thread with given id.
+func (scb *controlBlock) getCommentThread(ctx context.Context, pageID, threadID string) (*CommentThread, error) {
+	threads, err := scb.GetComments(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range threads {
+		if t.ID == threadID {
+			return t, nil
+		}
+	}
+	return nil, libErr.ErrNotFound.Wrap(libErr.WithErrorf("comment thread %s does not exist", threadID))
+}
+
+// AddCommentThread starts a comment thread on the page and broadcasts it to all users.
+func (scb *controlBlock) AddCommentThread(ctx context.Context, pageID string, user User, req CommentThreadRequest) (*CommentThread, error) {
+	if !isFinite(req.X) || !isFinite(req.Y) {
+		return nil, libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid comment position"))
+	}
+	comment, err := newComment(user, req.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	scb.muComments.Lock()
+	defer scb.muComments.Unlock()
+
+	threads, err := scb.GetComments(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if len(threads) >= maxCommentThreads {
+		return nil, libErr.ErrBadRequest.Wrap(libErr.WithErrorf("page cannot have more than %d comment threads", maxCommentThreads))
+	}
+	if req.StrokeID != "" {
+		strokes, err := scb.cache.GetStrokes(ctx, scb.cfg.ID, pageID, req.StrokeID)
+		if err != nil {
+			return nil, err
+		}
+		if len(strokes) == 0 {
+			return nil, libErr.ErrBadRequest.Wrap(libErr.WithErrorf("stroke %s does not exist", req.StrokeID))
+		}
+	}
+
+	thread := &CommentThread{
+		ID:       uuid.NewString(),
+		PageID:   pageID,
+		X:        req.X,
+		Y:        req.Y,
+		StrokeID: req.StrokeID,
+		Comments: []*Comment{comment},
+	}
+	if err := scb.setCommentThread(ctx, thread); err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+// ReplyComment adds a comment to the thread and broadcasts the thread to all users.
+func (scb *controlBlock) ReplyComment(ctx context.Context, pageID, threadID string, user User, req CommentRequest) (*Comment, error) {
+	comment, err := newComment(user, req.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	scb.muComments.Lock()
+	defer scb.muComments.Unlock()
+
+	thread, err := scb.getCommentThread(ctx, pageID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(thread.Comments) >= maxThreadComments {
+		return nil, libErr.ErrBadRequest.Wrap(libErr.WithErrorf("comment thread cannot have more than %d comments", maxThreadComments))
+	}
+	thread.Comments = append(thread.Comments, comment)
+	if err := scb.setCommentThread(ctx, thread); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// ResolveCommentThread resolves or reopens the thread and broadcasts the thread to all users.
+//
+// Threads can only be resolved and reopened by the author of the first comment or the host.
+func (scb *controlBlock) ResolveCommentThread(ctx context.Context, pageID, threadID, userID string, host bool, resolved bool) error {
+	scb.muComments.Lock()
+	defer scb.muComments.Unlock()
+
+	thread, err := scb.getCommentThread(ctx, pageID, threadID)
+	if err != nil {
+		return err
+	}
+	if !host && !thread.isAuthor(userID) {
+		return libErr.ErrForbidden.Wrap(libErr.WithErrorf("only the author or the host can resolve comment threads"))
+	}
+	if thread.Resolved == resolved {
+		return nil
+	}
+	thread.Resolved = resolved
+	return scb.setCommentThread(ctx, thread)
+}
+
+// DeleteCommentThread deletes the thread and broadcasts the deletion to all users.
+//
+// Threads can only be deleted by the author of the first comment or the host.
+func (scb *controlBlock) DeleteCommentThread(ctx context.Context, pageID, threadID, userID string, host bool) error {
+	scb.muComments.Lock()
+	defer scb.muComments.Unlock()
+
+	thread, err := scb.getCommentThread(ctx, pageID, threadID)
+	if err != nil {
+		return err
+	}
+	if !host && !thread.isAuthor(userID) {
+		return libErr.ErrForbidden.Wrap(libErr.WithErrorf("only the author or the host can delete comment threads"))
+	}
+	if _, err := scb.cache.DeleteComment(ctx, scb.cfg.ID, pageID, threadID); err != nil {
+		return err
+	}
+
+	scb.broadcaster.Broadcast() <- Message{
+		Type:    MessageTypeCommentDelete,
+		Content: ContentCommentDelete{PageID: pageID, ID: threadID},
+	}
+	return nil
+}
+
+// setCommentThread stores the thread and broadcasts it to all users.
+func (scb *controlBlock) setCommentThread(ctx context.Context, thread *CommentThread) error {
+	if err := scb.cache.SetComment(ctx, scb.cfg.ID, thread.PageID, thread.ID, thread); err != nil {
+		return err
+	}
+
+	scb.broadcaster.Broadcast() <- Message{
+		Type:    MessageTypeComment,
+		Content: thread,
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package session_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/session"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/redis/redisfakes"
+)
+
+// setupCommentSession returns a session with page "pid1", where the cache
+// stores the comment threads of the page.
+func setupCommentSession(t *testing.T) (session.Controller, *redisfakes.FakeHandler, chan session.Message) {
+	stored := make(map[string][]byte)
+	return newTestSession(t, session.Config{ID: "sid1", Host: "host"}, withPages("pid1"), func(fakeCache *redisfakes.FakeHandler) {
+		fakeCache.GetCommentsCalls(func(_ context.Context, _, _ string) ([][]byte, error) {
+			threads := make([][]byte, 0, len(stored))
+			for _, t := range stored {
+				threads = append(threads, t)
+			}
+			return threads, nil
+		})
+		fakeCache.SetCommentCalls(func(_ context.Context, _, _, id string, v any) error {
+			stored[id], _ = json.Marshal(v)
+			return nil
+		})
+		fakeCache.DeleteCommentCalls(func(_ context.Context, _, _, id string) (bool, error) {
+			_, ok := stored[id]
+			delete(stored, id)
+			return ok, nil
+		})
+	})
+}
+
+func Test_controlBlock_CommentThread(t *testing.T) {
+	ctx := context.Background()
+	scb, _, broadcast := setupCommentSession(t)
+	author := session.User{ID: "user1", Alias: "author"}
+
+	thread, err := scb.AddCommentThread(ctx, "pid1", author, session.CommentThreadRequest{X: 10, Y: 20, Text: " first "})
+
+	require.NoError(t, err)
+	require.Len(t, thread.Comments, 1)
+	assert.Equal(t, "first", thread.Comments[0].Text)
+	assert.Equal(t, "author", thread.Comments[0].Alias)
+	msg := <-broadcast
+	assert.Equal(t, session.MessageTypeComment, msg.Type)
+	assert.Empty(t, msg.Sender)
+
+	reply, err := scb.ReplyComment(ctx, "pid1", thread.ID, session.User{ID: "user2", Alias: "reviewer"}, session.CommentRequest{Text: "reply"})
+	require.NoError(t, err)
+	assert.Equal(t, "user2", reply.UserID)
+	// only the author or the host can resolve the thread
+	err = scb.ResolveCommentThread(ctx, "pid1", thread.ID, "user2", false, true)
+	assert.ErrorIs(t, err, libErr.ErrForbidden)
+	require.NoError(t, scb.ResolveCommentThread(ctx, "pid1", thread.ID, "user1", false, true))
+
+	threads, err := scb.GetComments(ctx, "pid1")
+	require.NoError(t, err)
+	require.Len(t, threads, 1)
+	assert.True(t, threads[0].Resolved)
+	assert.Len(t, threads[0].Comments, 2)
+	<-broadcast
+	msg = <-broadcast
+	assert.True(t, msg.Content.(*session.CommentThread).Resolved)
+
+	// only the author or the host can delete the thread
+	err = scb.DeleteCommentThread(ctx, "pid1", thread.ID, "user2", false)
+	assert.ErrorIs(t, err, libErr.ErrForbidden)
+	err = scb.DeleteCommentThread(ctx, "pid1", thread.ID, "user1", false)
+	require.NoError(t, err)
+	msg = <-broadcast
+	assert.Equal(t, session.MessageTypeCommentDelete, msg.Type)
+	assert.Equal(t, session.ContentCommentDelete{PageID: "pid1", ID: thread.ID}, msg.Content)
+	threads, err = scb.GetComments(ctx, "pid1")
+	require.NoError(t, err)
+	assert.Empty(t, threads)
+}
+
+func Test_controlBlock_AddCommentThread(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		pageID  string
+		req     session.CommentThreadRequest
+		strokes [][]byte
+		wantErr error
+	}{
+		{name: "stroke anchor", pageID: "pid1", req: session.CommentThreadRequest{StrokeID: "stroke1", Text: "text"}, strokes: [][]byte{[]byte("{}")}},
+		{name: "unknown stroke", pageID: "pid1", req: session.CommentThreadRequest{StrokeID: "stroke1", Text: "text"}, wantErr: libErr.ErrBadRequest},
+		{name: "unknown page", pageID: "pid2", req: session.CommentThreadRequest{Text: "text"}, wantErr: libErr.ErrNotFound},
+		{name: "empty text", pageID: "pid1", req: session.CommentThreadRequest{Text: "  "}, wantErr: libErr.ErrBadRequest},
+		{name: "invalid position", pageID: "pid1", req: session.CommentThreadRequest{X: math.NaN(), Text: "text"}, wantErr: libErr.ErrBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scb, fakeCache, _ := setupCommentSession(t)
+			fakeCache.GetStrokesReturns(tt.strokes, nil)
+
+			thread, err := scb.AddCommentThread(ctx, tt.pageID, session.User{ID: "user1"}, tt.req)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, 0, fakeCache.SetCommentCallCount())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.req.StrokeID, thread.StrokeID)
+		})
+	}
+}
+
+func Test_controlBlock_ReplyComment_UnknownThread(t *testing.T) {
+	scb, fakeCache, _ := setupCommentSession(t)
+
+	_, err := scb.ReplyComment(context.Background(), "pid1", "thread1", session.User{ID: "user1"}, session.CommentRequest{Text: "reply"})
+
+	assert.ErrorIs(t, err, libErr.ErrNotFound)
+	assert.Equal(t, 0, fakeCache.SetCommentCallCount())
+}
@@ -10,6 +10,8 @@ import (
 // TODO move to config
 const maxUsers = 50
 
+const maxSimplifyTolerance = 10
+
 type Config struct {
 	ID     string `json:"id"`
 	Host   string `json:"host,omitempty"`
@@ -17,6 +19,8 @@ type Config struct {
 
 	config.Session
 	Password string `json:"password"`
+	// FollowHost forces all participants to follow the viewport of the host
+	FollowHost bool `json:"followHost"`
 }
 
 func (c *Config) Update(incoming *ConfigRequest) error {
@@ -35,13 +39,21 @@ func (c *Config) Update(incoming *ConfigRequest) error {
 	if pw, ok := incoming.Password.Some(); ok {
 		c.Password = pw
 	}
+	if tolerance, ok := incoming.SimplifyTolerance.Some(); ok {
+		c.SimplifyTolerance = tolerance
+	}
+	if follow, ok := incoming.FollowHost.Some(); ok {
+		c.FollowHost = follow
+	}
 	return nil
 }
 
 type ConfigRequest struct {
-	MaxUsers opt.Option[int]    `json:"maxUsers,omitempty"`
-	ReadOnly opt.Option[bool]   `json:"readOnly,omitempty"`
-	Password opt.Option[string] `json:"password,omitempty"`
+	MaxUsers          opt.Option[int]     `json:"maxUsers,omitempty"`
+	ReadOnly          opt.Option[bool]    `json:"readOnly,omitempty"`
+	Password          opt.Option[string]  `json:"password,omitempty"`
+	SimplifyTolerance opt.Option[float64] `json:"simplifyTolerance,omitempty"`
+	FollowHost        opt.Option[bool]    `json:"followHost,omitempty"`
 }
 
 func (c *ConfigRequest) Validate() error {
@@ -51,5 +63,8 @@ func (c *ConfigRequest) Validate() error {
 	if numUsers, ok := c.MaxUsers.Some(); ok && (numUsers < 1 || numUsers > maxUsers) {
 		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("incorrect maxUsers"))
 	}
+	if tolerance, ok := c.SimplifyTolerance.Some(); ok && !(tolerance >= 0 && tolerance <= maxSimplifyTolerance) {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("simplifyTolerance must be between 0 and %d", maxSimplifyTolerance))
+	}
 	return nil
 }
@@ -60,6 +60,18 @@ func TestConfig_Update(t *testing.T) {
 			incoming: session.ConfigRequest{MaxUsers: opt.New[int](10)},
 			want:     session.Config{Session: config.Session{MaxUsers: 10}},
 		},
+		{
+			name:     "set simplifyTolerance",
+			config:   session.Config{},
+			incoming: session.ConfigRequest{SimplifyTolerance: opt.New[float64](0.5)},
+			want:     session.Config{Session: config.Session{SimplifyTolerance: 0.5}},
+		},
+		{
+			name:     "set invalid simplifyTolerance returns error",
+			config:   session.Config{},
+			incoming: session.ConfigRequest{SimplifyTolerance: opt.New[float64](-1)},
+			wantErr:  true,
+		},
 		{
 			name:     "set invalid maxUsers returs error",
 			config:   session.Config{Session: config.Session{MaxUsers: 5}},
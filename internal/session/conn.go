@@ -0,0 +1,210 @@
+package session
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	gws "github.com/gorilla/websocket"
+
+	"github.com/boardsite-io/server/internal/config"
+	"github.com/boardsite-io/server/pkg/log"
+)
+
+// Overflow policies of the outbound message queue.
+const (
+	// OverflowDrop drops ephemeral messages when the queue is full
+	// and disconnects the client for any other message.
+	OverflowDrop = "drop"
+	// OverflowDisconnect disconnects the client whenever the queue is full.
+	OverflowDisconnect = "disconnect"
+)
+
+const (
+	defaultQueueSize    = 256
+	defaultWriteTimeout = 10 * time.Second
+	closeWriteWait      = time.Second
+)
+
+var (
+	ErrQueueOverflow = errors.New("outbound queue overflow")
+	ErrConnClosed    = errors.New("connection closed")
+)
+
+type outboundMessage struct {
+	frameType int
+	data      []byte
+}
+
+// Conn wraps the websocket connection of a user.
+//
+// Outgoing messages are kept in a bounded queue which is drained by
+// a dedicated writer goroutine, such that a slow client cannot stall
+// the other users of the session. The writer also pings the client
+// periodically to detect dead connections.
+type Conn struct {
+	ws           *gws.Conn
+	codec        Codec
+	overflow     string
+	pingInterval time.Duration
+	writeTimeout time.Duration
+
+	queue      chan outboundMessage
+	done       chan struct{}
+	once       sync.Once
+	onOverflow sync.Once
+}
+
+// NewConn wraps the websocket connection and starts the writer goroutine.
+// Messages are encoded with the given codec.
+func NewConn(ws *gws.Conn, codec Codec, cfg config.Websocket) *Conn {
+	size := cfg.QueueSize
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	overflow := cfg.Overflow
+	if overflow != OverflowDisconnect {
+		overflow = OverflowDrop
+	}
+	pingInterval := cfg.PingInterval
+	if pingInterval <= 0 {
+		pingInterval = config.DefaultPingInterval
+	}
+	writeTimeout := cfg.WriteTimeout
+	if writeTimeout <= 0 {
+		writeTimeout = defaultWriteTimeout
+	}
+	c := &Conn{
+		ws:           ws,
+		codec:        codec,
+		overflow:     overflow,
+		pingInterval: pingInterval,
+		writeTimeout: writeTimeout,
+		queue:        make(chan outboundMessage, size),
+		done:         make(chan struct{}),
+	}
+	go c.writeLoop()
+	return c
+}
+
+// Codec returns the codec of the connection.
+func (c *Conn) Codec() Codec {
+	return c.codec
+}
+
+// RemoteAddr returns the remote network address.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.ws.RemoteAddr()
+}
+
+// Len returns the number of queued messages.
+func (c *Conn) Len() int {
+	return len(c.queue)
+}
+
+// Done returns a channel that is closed when the connection is closed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Send encodes a message and adds it to the outbound queue.
+func (c *Conn) Send(msg *Message) error {
+	return newEncodedMessage(msg).writeTo(c)
+}
+
+// Close closes the connection and stops the writer goroutine.
+func (c *Conn) Close() {
+	c.once.Do(func() {
+		close(c.done)
+		_ = c.ws.Close()
+	})
+}
+
+// CloseWith queues a close frame with the given reason. The writer closes
+// the connection once the frame is written, or at the latest after the
+// write timeout. The connection is closed right away if the frame cannot
+// be queued.
+func (c *Conn) CloseWith(reason string) {
+	err := c.enqueue(outboundMessage{
+		frameType: gws.CloseMessage,
+		data:      gws.FormatCloseMessage(gws.CloseNormalClosure, reason),
+	}, false)
+	if err != nil {
+		c.Close()
+		return
+	}
+	time.AfterFunc(c.writeTimeout, c.Close)
+}
+
+// enqueue adds a message to the queue without blocking.
+//
+// If the queue is full, ephemeral messages are dropped depending on the overflow policy.
+// Otherwise the client is disconnected.
+func (c *Conn) enqueue(msg outboundMessage, ephemeral bool) error {
+	select {
+	case <-c.done:
+		return ErrConnClosed
+	default:
+	}
+
+	select {
+	case c.queue <- msg:
+		return nil
+	default:
+	}
+
+	if ephemeral && c.overflow == OverflowDrop {
+		return nil
+	}
+
+	c.onOverflow.Do(func() {
+		log.Global().Warnf("disconnect %s: %v", c.RemoteAddr(), ErrQueueOverflow)
+		// the writer might be stuck, hence do not block the caller
+		go func() {
+			_ = c.ws.WriteControl(gws.CloseMessage,
+				gws.FormatCloseMessage(gws.ClosePolicyViolation, ErrQueueOverflow.Error()),
+				time.Now().Add(closeWriteWait))
+			c.Close()
+		}()
+	})
+	return ErrQueueOverflow
+}
+
+// writeLoop writes the queued messages to the websocket connection
+// and pings the client in regular intervals.
+//
+// The connection is closed if a write does not complete within the write timeout.
+func (c *Conn) writeLoop() {
+	ticker := time.NewTicker(c.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg := <-c.queue:
+			if err := c.write(msg.frameType, msg.data); err != nil {
+				log.Global().Warnf("cannot write to %s: %v", c.RemoteAddr(), err)
+				c.Close()
+				return
+			}
+			if msg.frameType == gws.CloseMessage {
+				c.Close()
+				return
+			}
+		case <-ticker.C:
+			if err := c.write(gws.PingMessage, nil); err != nil {
+				log.Global().Warnf("cannot ping %s: %v", c.RemoteAddr(), err)
+				c.Close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *Conn) write(frameType int, data []byte) error {
+	if err := c.ws.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+		return err
+	}
+	return c.ws.WriteMessage(frameType, data)
+}
@@ -0,0 +1,121 @@
+package session_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/config"
+	"github.com/boardsite-io/server/internal/session"
+)
+
+// newTestConn returns a server side websocket connection whose client
+// never reads from the socket.
+func newTestConn(t *testing.T) *gws.Conn {
+	conn, _ := newTestConnPair(t)
+	return conn
+}
+
+// newTestConnPair returns a server side websocket connection and its client.
+func newTestConnPair(t *testing.T) (*gws.Conn, *gws.Conn) {
+	conns := make(chan *gws.Conn, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&gws.Upgrader{}).Upgrade(w, r, nil)
+		require.NoError(t, err)
+		conns <- conn
+	}))
+	t.Cleanup(s.Close)
+
+	client, _, err := gws.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return <-conns, client
+}
+
+// fillQueue sends large messages until the writer is stalled and the queue is full.
+func fillQueue(t *testing.T, conn *session.Conn, size int) {
+	large := session.NewMessage(strings.Repeat("a", 8<<20), session.MessageTypeStroke)
+	for i := 0; conn.Len() < size; i++ {
+		require.Less(t, i, 2*size+10, "queue does not fill up")
+		require.NoError(t, conn.Send(large))
+	}
+}
+
+// overflowQueue fills the queue and sends msg until it is rejected,
+// since the writer may take one more message before it stalls.
+func overflowQueue(t *testing.T, conn *session.Conn, size int, msg *session.Message) error {
+	var err error
+	for i := 0; i < 3 && err == nil; i++ {
+		fillQueue(t, conn, size)
+		err = conn.Send(msg)
+	}
+	return err
+}
+
+func TestConn_Send(t *testing.T) {
+	t.Run("drops ephemeral messages", func(t *testing.T) {
+		const size = 2
+		conn := session.NewConn(newTestConn(t), session.JSONCodec, config.Websocket{QueueSize: size, Overflow: session.OverflowDrop})
+		defer conn.Close()
+		fillQueue(t, conn, size)
+
+		err := conn.Send(session.NewMessage(session.ContentMouseMove{X: 1, Y: 2}, session.MessageTypeMouseMove))
+
+		assert.NoError(t, err)
+		assert.Equal(t, size, conn.Len())
+		select {
+		case <-conn.Done():
+			t.Fatal("connection closed")
+		default:
+		}
+	})
+
+	t.Run("disconnects on overflow", func(t *testing.T) {
+		const size = 2
+		conn := session.NewConn(newTestConn(t), session.JSONCodec, config.Websocket{QueueSize: size, Overflow: session.OverflowDrop})
+		err := overflowQueue(t, conn, size, session.NewMessage("update", session.MessageTypeStroke))
+
+		assert.ErrorIs(t, err, session.ErrQueueOverflow)
+		select {
+		case <-conn.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("connection not closed")
+		}
+		assert.ErrorIs(t, conn.Send(session.NewMessage("update", session.MessageTypeStroke)), session.ErrConnClosed)
+	})
+
+	t.Run("disconnect policy", func(t *testing.T) {
+		const size = 2
+		conn := session.NewConn(newTestConn(t), session.JSONCodec, config.Websocket{QueueSize: size, Overflow: session.OverflowDisconnect})
+
+		err := overflowQueue(t, conn, size, session.NewMessage(session.ContentMouseMove{X: 1, Y: 2}, session.MessageTypeMouseMove))
+
+		assert.ErrorIs(t, err, session.ErrQueueOverflow)
+	})
+}
+
+func TestConn_CloseWith(t *testing.T) {
+	server, client := newTestConnPair(t)
+	conn := session.NewConn(server, session.JSONCodec, config.Websocket{QueueSize: 2})
+	require.NoError(t, conn.Send(session.NewMessage("update", session.MessageTypeStroke)))
+
+	conn.CloseWith("Closed by server")
+
+	_, _, err := client.ReadMessage()
+	require.NoError(t, err, "queued message is written first")
+	_, _, err = client.ReadMessage()
+	assert.True(t, gws.IsCloseError(err, gws.CloseNormalClosure))
+	assert.Contains(t, err.Error(), "Closed by server")
+	select {
+	case <-conn.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not closed")
+	}
+}
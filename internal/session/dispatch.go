@@ -9,6 +9,7 @@ import (
 
 	gonanoid "github.com/matoous/go-nanoid/v2"
 
+	"github.com/boardsite-io/server/internal/attachment"
 	"github.com/boardsite-io/server/pkg/log"
 	"github.com/boardsite-io/server/pkg/redis"
 )
@@ -34,20 +35,41 @@ type Dispatcher interface {
 	NumSessions() int
 	// NumUsers returns the number of active users in the session
 	NumUsers() int
+	// QueueStats returns statistics of the outbound queues across all sessions
+	QueueStats() QueueStats
+}
+
+// QueueStats declares statistics of the outbound message queues.
+type QueueStats struct {
+	// Messages is the total number of queued messages
+	Messages int
+	// MaxDepth is the number of queued messages of the fullest queue
+	MaxDepth int
+}
+
+func (s *QueueStats) add(other QueueStats) {
+	s.Messages += other.Messages
+	if other.MaxDepth > s.MaxDepth {
+		s.MaxDepth = other.MaxDepth
+	}
 }
 
 type sessionsDispatcher struct {
-	mu            sync.RWMutex
-	activeSession map[string]Controller
-	cache         redis.Handler
+	mu             sync.RWMutex
+	activeSession  map[string]Controller
+	cache          redis.Handler
+	newAttachments attachment.Factory
 }
 
 var _ Dispatcher = (*sessionsDispatcher)(nil)
 
-func NewDispatcher(cache redis.Handler) Dispatcher {
+// NewDispatcher creates a new Dispatcher. The attachment handlers of the
+// sessions are created by newAttachments.
+func NewDispatcher(cache redis.Handler, newAttachments attachment.Factory) Dispatcher {
 	return &sessionsDispatcher{
-		activeSession: make(map[string]Controller),
-		cache:         cache,
+		activeSession:  make(map[string]Controller),
+		cache:          cache,
+		newAttachments: newAttachments,
 	}
 }
 
@@ -74,7 +96,7 @@ func (d *sessionsDispatcher) Create(ctx context.Context, cfg Config) (Controller
 		}
 	}
 
-	scb, err := NewControlBlock(cfg, WithCache(d.cache), WithDispatcher(d))
+	scb, err := NewControlBlock(cfg, WithCache(d.cache), WithDispatcher(d), WithAttachments(d.newAttachments(cfg.ID)))
 	if err != nil {
 		return nil, fmt.Errorf("new session control: %w", err)
 	}
@@ -128,3 +150,13 @@ func (d *sessionsDispatcher) NumUsers() int {
 	}
 	return numUsers
 }
+
+func (d *sessionsDispatcher) QueueStats() QueueStats {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var stats QueueStats
+	for _, scb := range d.activeSession {
+		stats.add(scb.QueueStats())
+	}
+	return stats
+}
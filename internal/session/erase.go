@@ -0,0 +1,225 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/geometry"
+	"github.com/boardsite-io/server/pkg/redis"
+)
+
+const (
+	maxEraserRadius = 1000
+	// maxEraseWork bounds the pairs of stroke and eraser segments
+	// which are tested by a single erase message
+	maxEraseWork = 1 << 20
+)
+
+// ContentErase declares the content of erase messages.
+//
+// The eraser path is given in absolute page coordinates.
+type ContentErase struct {
+	PageID string  `json:"pageId"`
+	Points Points  `json:"points"`
+	Radius float64 `json:"radius"`
+}
+
+func (c *ContentErase) validate(maxPoints int) error {
+	if !(c.Radius > 0 && c.Radius <= maxEraserRadius) {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("eraser radius must be between 0 and %d", maxEraserRadius))
+	}
+	if len(c.Points) < 2 || len(c.Points)%2 != 0 {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid eraser path"))
+	}
+	if maxPoints > 0 && len(c.Points) > maxPoints {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("eraser path exceeds %d points", maxPoints))
+	}
+	for _, v := range c.Points {
+		if !isFinite(v) {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid number"))
+		}
+	}
+	return nil
+}
+
+func (c *ContentErase) path() []geometry.Point {
+	return (&Stroke{Points: c.Points}).path()
+}
+
+// erase removes the parts of the strokes on the page which are covered by the eraser.
+//
+// Freehand strokes are cut at the boundary of the eraser into their remaining pieces,
+// which are added with new ids after they are simplified and validated like new strokes.
+// Other strokes are deleted as a whole once they are hit. All resulting updates are
+// stored in a single transaction and broadcasted in a single message to all users.
+func (scb *controlBlock) erase(ctx context.Context, msg *Message) error {
+	if !scb.Allow(msg.Sender) {
+		return errors.New("not allowed")
+	}
+
+	var content ContentErase
+	if err := msg.UnmarshalContent(&content); err != nil {
+		return err
+	}
+	if err := content.validate(scb.cfg.Strokes.MaxPoints); err != nil {
+		return err
+	}
+	if !scb.IsValidPage(ctx, content.PageID) {
+		return libErr.ErrNotFound.Wrap(libErr.WithErrorf("page %s does not exist", content.PageID))
+	}
+
+	// overlapping edits operate on the result of each other
+	scb.muEdit.Lock()
+	defer scb.muEdit.Unlock()
+
+	eraser := content.path()
+	candidates, err := scb.GetStrokesInRect(ctx, content.PageID, geometry.Bounds(eraser...).Grow(content.Radius))
+	if err != nil {
+		return err
+	}
+
+	work := 0
+	for _, s := range candidates {
+		work += len(s.Points) / 2 * len(eraser)
+	}
+	if work > maxEraseWork {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("eraser path too complex"))
+	}
+
+	locked, err := scb.lockedLayers(ctx, content.PageID, msg.Sender)
+	if err != nil {
+		return err
+	}
+	size := scb.getPageSize(ctx, content.PageID)
+	updates := make([]redis.Stroke, 0, len(candidates))
+	for _, s := range candidates {
+		if _, ok := locked[s.LayerID]; ok {
+			continue
+		}
+		for _, u := range s.erase(eraser, content.Radius, scb.isFreehand(s)) {
+			// the pieces are stored like strokes of the users
+			piece := u.(*Stroke)
+			if !piece.IsDeleted() {
+				piece.simplify(scb.cfg.SimplifyTolerance)
+			}
+			if err := piece.validate(scb.cfg.Strokes, size); err != nil {
+				return err
+			}
+			updates = append(updates, piece)
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	// the sender receives the pieces as well
+	return scb.updateStrokes(ctx, "", updates)
+}
+
+// erase returns the updates of the stroke when erased by the eraser path with given radius.
+// Freehand strokes are cut into pieces, others are deleted. The updates are empty if the
+// stroke is not hit.
+func (s *Stroke) erase(eraser []geometry.Point, radius float64, freehand bool) []redis.Stroke {
+	if s.IsDeleted() {
+		return nil
+	}
+	reach := radius + s.lineWidth()/2
+
+	if !freehand || len(s.Points) < 4 {
+		path := s.path()
+		for i, p := range path {
+			if geometry.PolylineDist(p, eraser) <= reach ||
+				(i > 0 && geometry.PolylineSegmentDist(path[i-1], p, eraser) <= reach) {
+				return []redis.Stroke{s.deleted()}
+			}
+		}
+		return nil
+	}
+
+	cuts := s.cuts(eraser, reach)
+	if len(cuts) == 0 {
+		return nil
+	}
+
+	updates := []redis.Stroke{s.deleted()}
+	last := float64(len(s.Points)/2 - 1)
+	from := 0.0
+	for _, c := range append(cuts, [2]float64{last, last}) {
+		// pieces without extent are too small to be kept
+		if c[0] > from {
+			piece := *s
+			piece.ID = uuid.NewString()
+			piece.Points = s.section(from, c[0])
+			updates = append(updates, &piece)
+		}
+		from = math.Max(from, c[1])
+	}
+	return updates
+}
+
+// cuts returns the merged sections of the stroke which are within reach of the
+// eraser in order. Sections are given as positions along the points, where
+// the integer part is the index of the segment and the fraction the position
+// within the segment.
+func (s *Stroke) cuts(eraser []geometry.Point, reach float64) [][2]float64 {
+	if len(eraser) == 1 {
+		eraser = []geometry.Point{eraser[0], eraser[0]}
+	}
+	path := s.path()
+	var cuts [][2]float64
+	for i := 0; i+1 < len(path); i++ {
+		for j := 0; j+1 < len(eraser); j++ {
+			t0, t1, ok := geometry.CapsuleInterval(path[i], path[i+1], eraser[j], eraser[j+1], reach)
+			if ok {
+				cuts = append(cuts, [2]float64{float64(i) + t0, float64(i) + t1})
+			}
+		}
+	}
+	sort.Slice(cuts, func(i, j int) bool { return cuts[i][0] < cuts[j][0] })
+
+	merged := cuts[:0]
+	for _, c := range cuts {
+		if n := len(merged); n > 0 && c[0] <= merged[n-1][1] {
+			merged[n-1][1] = math.Max(merged[n-1][1], c[1])
+			continue
+		}
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+// section returns the relative points of the stroke between the positions
+// from and to. Both ends are interpolated, such that at most two vertices
+// are added to the section.
+func (s *Stroke) section(from, to float64) Points {
+	at := func(pos float64) (float64, float64) {
+		i := int(pos)
+		if 2*i+3 >= len(s.Points) {
+			return s.Points[len(s.Points)-2], s.Points[len(s.Points)-1]
+		}
+		t := pos - float64(i)
+		return s.Points[2*i] + t*(s.Points[2*i+2]-s.Points[2*i]),
+			s.Points[2*i+1] + t*(s.Points[2*i+3]-s.Points[2*i+1])
+	}
+	x, y := at(from)
+	points := Points{x, y}
+	for i := int(math.Floor(from)) + 1; float64(i) < to; i++ {
+		points = append(points, s.Points[2*i], s.Points[2*i+1])
+	}
+	x, y = at(to)
+	return append(points, x, y)
+}
+
+// deleted returns the update which deletes the stroke.
+func (s *Stroke) deleted() *Stroke {
+	return &Stroke{
+		Type:   StrokeTypeDelete,
+		ID:     s.ID,
+		PageID: s.PageID,
+		UserID: s.UserID,
+	}
+}
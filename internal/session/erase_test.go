@@ -0,0 +1,177 @@
+package session_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/session"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/geometry"
+	"github.com/boardsite-io/server/pkg/redis"
+)
+
+func newEraseMessage(t *testing.T, content session.ContentErase) *session.Message {
+	data, err := session.JSONCodec.Marshal(session.NewMessage(content, session.MessageTypeErase, "user1"))
+	require.NoError(t, err)
+	msg, err := session.JSONCodec.Unmarshal(data)
+	require.NoError(t, err)
+	return msg
+}
+
+// horizontalPen returns a pen stroke from (0,y) to (100,y) with a vertex every step.
+func horizontalPen(id string, y, step float64) *session.Stroke {
+	var points session.Points
+	for x := 0.0; x <= 100; x += step {
+		points = append(points, x, 0)
+	}
+	return &session.Stroke{Type: strokeTypePen, ID: id, PageID: "pid1", UserID: "user2", Y: y, Points: points, Style: session.Style{Color: "#00beef", Width: 2}}
+}
+
+func Test_controlBlock_Receive_Erase(t *testing.T) {
+	ctx := context.Background()
+	// vertical eraser path crossing x = 50
+	eraser := session.ContentErase{PageID: "pid1", Points: session.Points{50, -100, 50, 1000}, Radius: 4}
+
+	tests := []struct {
+		name       string
+		stroke     *session.Stroke
+		wantPieces int
+	}{
+		{name: "splits dense stroke", stroke: horizontalPen("dense", 0, 1), wantPieces: 2},
+		{name: "splits sparse stroke", stroke: horizontalPen("sparse", 0, 100), wantPieces: 2},
+		{name: "deletes covered stroke", stroke: &session.Stroke{Type: strokeTypePen, ID: "covered", PageID: "pid1", UserID: "user2", X: 48, Points: session.Points{0, 0, 1, 5, 2, 10}}},
+		{name: "deletes hit shape", stroke: &session.Stroke{Type: strokeTypeRectangle, ID: "rect", PageID: "pid1", UserID: "user2", X: 0, Y: 0, Points: session.Points{0, 0, 100, 100}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scb, fakeCache, broadcast := setupStrokeSession(t, tt.stroke)
+
+			err := scb.Receive(ctx, newEraseMessage(t, eraser), "user1")
+
+			require.NoError(t, err)
+			require.Equal(t, 1, fakeCache.UpdateStrokesCallCount())
+			_, _, updates := fakeCache.UpdateStrokesArgsForCall(0)
+			require.Len(t, updates, 1+tt.wantPieces)
+			assert.True(t, updates[0].IsDeleted())
+			assert.Equal(t, tt.stroke.ID, updates[0].Id())
+
+			var coveredX []float64
+			for _, u := range updates[1:] {
+				piece := u.(*session.Stroke)
+				assert.NotEqual(t, tt.stroke.ID, piece.ID)
+				assert.Equal(t, tt.stroke.UserID, piece.UserID)
+				assert.Equal(t, tt.stroke.Style, piece.Style)
+				for i := 0; i < len(piece.Points); i += 2 {
+					// remaining points are out of reach of the eraser or on its boundary
+					assert.GreaterOrEqual(t, geometry.PolylineDist(
+						geometry.Point{X: piece.X + piece.Points[i], Y: piece.Y + piece.Points[i+1]},
+						[]geometry.Point{{X: 50, Y: -100}, {X: 50, Y: 1000}}), eraser.Radius+tt.stroke.Style.Width/2-1e-9)
+				}
+				coveredX = append(coveredX, piece.Points[0], piece.Points[len(piece.Points)-2])
+			}
+			if tt.wantPieces == 2 {
+				// the pieces retain both ends of the stroke and are cut at the boundary of the eraser
+				assert.Equal(t, 0.0, coveredX[0])
+				assert.InDelta(t, 45, coveredX[1], 1e-9)
+				assert.InDelta(t, 55, coveredX[2], 1e-9)
+				assert.Equal(t, 100.0, coveredX[3])
+			}
+
+			msg := <-broadcast
+			assert.Equal(t, session.MessageTypeStroke, msg.Type)
+			assert.Empty(t, msg.Sender)
+			assert.Equal(t, updates, msg.Content.([]redis.Stroke))
+		})
+	}
+
+	t.Run("ignores missed strokes", func(t *testing.T) {
+		scb, fakeCache, broadcast := setupStrokeSession(t, horizontalPen("missed", 500, 1))
+
+		err := scb.Receive(ctx, newEraseMessage(t, session.ContentErase{PageID: "pid1", Points: session.Points{0, 0, 100, 0}, Radius: 4}), "user1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, fakeCache.UpdateStrokesCallCount())
+		assert.Len(t, broadcast, 0)
+	})
+
+	t.Run("indexes pieces", func(t *testing.T) {
+		scb, fakeCache, _ := setupStrokeSession(t, horizontalPen("dense", 0, 1))
+		err := scb.Receive(ctx, newEraseMessage(t, eraser), "user1")
+		require.NoError(t, err)
+
+		_, _, updates := fakeCache.UpdateStrokesArgsForCall(0)
+		ids := make([]string, 0, 2)
+		for _, u := range updates[1:] {
+			ids = append(ids, u.Id())
+		}
+		got, err := scb.GetStrokesInRect(ctx, "pid1", geometry.Rect{Min: geometry.Point{X: -10, Y: -10}, Max: geometry.Point{X: 110, Y: 10}})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, ids, strokeIDs(got))
+	})
+
+	t.Run("erases stroke sent right before", func(t *testing.T) {
+		scb, fakeCache, broadcast := setupStrokeSession(t)
+		stroke := horizontalPen("dense", 0, 1)
+		stroke.UserID = "user1"
+
+		err := scb.Receive(ctx, newStrokeMessage(t, session.JSONCodec, stroke), "user1")
+		require.NoError(t, err)
+		err = scb.Receive(ctx, newEraseMessage(t, eraser), "user1")
+		require.NoError(t, err)
+
+		// the stroke is stored before it is erased
+		require.Equal(t, 2, fakeCache.UpdateStrokesCallCount())
+		_, _, updates := fakeCache.UpdateStrokesArgsForCall(1)
+		require.Len(t, updates, 3)
+		assert.True(t, updates[0].IsDeleted())
+		assert.Equal(t, stroke.ID, updates[0].Id())
+		got, err := scb.GetStrokesInRect(ctx, "pid1", geometry.Rect{Min: geometry.Point{X: -10, Y: -10}, Max: geometry.Point{X: 110, Y: 10}})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{updates[1].Id(), updates[2].Id()}, strokeIDs(got))
+		assert.Equal(t, session.MessageTypeStroke, (<-broadcast).Type)
+		assert.Equal(t, updates, (<-broadcast).Content.([]redis.Stroke))
+	})
+
+	t.Run("adds only the cut vertices", func(t *testing.T) {
+		scb, fakeCache, _ := setupStrokeSession(t, horizontalPen("sparse", 0, 100))
+		// zigzag eraser crossing the stroke between 10 and 90
+		var zigzag session.Points
+		for x := 10.0; x <= 90; x += 10 {
+			zigzag = append(zigzag, x, 10-20*float64(int(x/10)%2))
+		}
+
+		err := scb.Receive(ctx, newEraseMessage(t, session.ContentErase{PageID: "pid1", Points: zigzag, Radius: 1}), "user1")
+
+		require.NoError(t, err)
+		_, _, updates := fakeCache.UpdateStrokesArgsForCall(0)
+		assert.Len(t, updates, 1+9)
+		for _, u := range updates[1:] {
+			assert.Len(t, u.(*session.Stroke).Points, 4)
+		}
+	})
+
+	t.Run("rejects complex eraser path", func(t *testing.T) {
+		scb, fakeCache, _ := setupStrokeSession(t, horizontalPen("dense", 0, 1))
+		var points session.Points
+		for i := 0; i < 20000; i++ {
+			points = append(points, 50, float64(i%2))
+		}
+
+		err := scb.Receive(ctx, newEraseMessage(t, session.ContentErase{PageID: "pid1", Points: points, Radius: 4}), "user1")
+
+		assert.ErrorIs(t, err, libErr.ErrBadRequest)
+		assert.Equal(t, 0, fakeCache.UpdateStrokesCallCount())
+	})
+
+	t.Run("invalid eraser", func(t *testing.T) {
+		scb, fakeCache, _ := setupStrokeSession(t, horizontalPen("dense", 0, 1))
+
+		err := scb.Receive(ctx, newEraseMessage(t, session.ContentErase{PageID: "pid1", Points: session.Points{50, 0}, Radius: 0}), "user1")
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, fakeCache.UpdateStrokesCallCount())
+	})
+}
@@ -1,9 +1,16 @@
 package http
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
@@ -12,6 +19,7 @@ import (
 	"github.com/boardsite-io/server/internal/session"
 	"github.com/boardsite-io/server/internal/websocket"
 	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/geometry"
 	"github.com/boardsite-io/server/pkg/log"
 )
 
@@ -28,18 +36,40 @@ type Handler interface {
 	PostPages(c echo.Context) error
 	PutPages(c echo.Context) error
 	GetPage(c echo.Context) error
+	GetPageStrokes(c echo.Context) error
+	GetPageThumbnail(c echo.Context) error
+	PostStrokesHitTest(c echo.Context) error
+	GetSearch(c echo.Context) error
+	GetLayers(c echo.Context) error
+	PostLayer(c echo.Context) error
+	PutLayer(c echo.Context) error
+	DeleteLayer(c echo.Context) error
+	GetComments(c echo.Context) error
+	PostCommentThread(c echo.Context) error
+	PostComment(c echo.Context) error
+	PutCommentThread(c echo.Context) error
+	DeleteCommentThread(c echo.Context) error
 	GetPageSync(c echo.Context) error
+	GetChat(c echo.Context) error
+	DeleteChatMessage(c echo.Context) error
 	PostPageSync(c echo.Context) error
 	PostAttachment(c echo.Context) error
+	GetAttachments(c echo.Context) error
 	GetAttachment(c echo.Context) error
+	GetAttachmentURL(c echo.Context) error
+	DeleteAttachment(c echo.Context) error
+	GetAttachmentPage(c echo.Context) error
 }
 
+// attachmentCSP restricts attachments to inline styles and inline images.
+const attachmentCSP = "default-src 'none'; style-src 'unsafe-inline'; img-src data:; sandbox"
+
 type handler struct {
-	cfg        config.Session
+	cfg        *config.Configuration
 	dispatcher session.Dispatcher
 }
 
-func NewHandler(cfg config.Session, dispatcher session.Dispatcher) Handler {
+func NewHandler(cfg *config.Configuration, dispatcher session.Dispatcher) Handler {
 	return &handler{
 		cfg:        cfg,
 		dispatcher: dispatcher,
@@ -49,7 +79,7 @@ func NewHandler(cfg config.Session, dispatcher session.Dispatcher) Handler {
 // PostCreateSession handles the request for creating a new session.
 // Responds with the unique sessionID of the new session.
 func (h *handler) PostCreateSession(c echo.Context) error {
-	scb, err := h.dispatcher.Create(c.Request().Context(), session.NewConfig(h.cfg))
+	scb, err := h.dispatcher.Create(c.Request().Context(), session.NewConfig(h.cfg.Session))
 	if err != nil {
 		return err
 	}
@@ -60,7 +90,7 @@ func (h *handler) PostCreateSession(c echo.Context) error {
 }
 
 func (h *handler) PostCreateSessionConfig(c echo.Context) error {
-	cfg := session.NewConfig(h.cfg)
+	cfg := session.NewConfig(h.cfg.Session)
 	var req session.CreateSessionRequest
 	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
 		return libErr.ErrBadRequest.Wrap(libErr.WithError(err))
@@ -102,7 +132,7 @@ func (h *handler) GetSessionConfig(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, session.GetConfigResponse{Users: scb.GetUsers(), Config: scb.Config()})
+	return c.JSON(http.StatusOK, session.GetConfigResponse{Users: scb.GetUsers(), Config: scb.Config(), Viewport: scb.HostViewport()})
 }
 
 func (h *handler) PostUsers(c echo.Context) error {
@@ -168,7 +198,7 @@ func (h *handler) GetSocket(c echo.Context) error {
 	if err := scb.UserCanJoin(userID); err != nil {
 		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("user cannot join: %w", err))
 	}
-	err = websocket.Subscribe(c, scb, c.Param("userId"))
+	err = websocket.Subscribe(c, h.cfg.Websocket, scb, c.Param("userId"))
 	if err != nil {
 		log.Ctx(c.Request().Context()).Errorf("websocket subscribe: %v", err)
 	}
@@ -222,7 +252,7 @@ func (h *handler) PutPages(c echo.Context) error {
 		return libErr.ErrBadRequest.Wrap(libErr.WithError(err))
 	}
 
-	if err := scb.UpdatePages(c.Request().Context(), data, op); err != nil {
+	if err := scb.UpdatePages(c.Request().Context(), data, op, isHost(c, scb)); err != nil {
 		return err
 	}
 
@@ -248,6 +278,281 @@ func (h *handler) GetPage(c echo.Context) error {
 	return c.JSON(http.StatusOK, page)
 }
 
+// GetPageThumbnail responds with a PNG thumbnail of a page, which is
+// revalidated by its ETag.
+func (h *handler) GetPageThumbnail(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+
+	pageID := c.Param("pageId")
+	if !scb.IsValidPage(c.Request().Context(), pageID) {
+		return libErr.ErrNotFound.Wrap(libErr.WithErrorf("page %s does not exist", pageID))
+	}
+	var width int
+	if w := c.QueryParam(session.QueryKeyWidth); w != "" {
+		if width, err = strconv.Atoi(w); err != nil || width <= 0 {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid query parameter %s", session.QueryKeyWidth))
+		}
+	}
+
+	thumbnail, err := scb.PageThumbnail(c.Request().Context(), pageID, width)
+	if err != nil {
+		return err
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, "image/png")
+	header.Set("ETag", thumbnail.ETag)
+	header.Set("Cache-Control", "private, no-cache")
+	http.ServeContent(c.Response(), c.Request(), "", time.Time{}, bytes.NewReader(thumbnail.Data))
+	return nil
+}
+
+// GetPageStrokes returns the strokes of a page intersecting the
+// rectangle given by the query parameters.
+func (h *handler) GetPageStrokes(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+
+	var rect geometry.Rect
+	for key, v := range map[string]*float64{
+		session.QueryKeyMinX: &rect.Min.X,
+		session.QueryKeyMinY: &rect.Min.Y,
+		session.QueryKeyMaxX: &rect.Max.X,
+		session.QueryKeyMaxY: &rect.Max.Y,
+	} {
+		if *v, err = strconv.ParseFloat(c.QueryParam(key), 64); err != nil {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid query parameter %s", key))
+		}
+	}
+
+	strokes, err := scb.GetStrokesInRect(c.Request().Context(), c.Param("pageId"), rect)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, strokes)
+}
+
+// PostStrokesHitTest returns the strokes of a page hit by the polygon.
+func (h *handler) PostStrokesHitTest(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+
+	var req session.HitTestRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return libErr.ErrBadRequest.Wrap(libErr.WithError(err))
+	}
+
+	strokes, err := scb.GetStrokesInPolygon(c.Request().Context(), c.Param("pageId"), req.Polygon)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, strokes)
+}
+
+// GetSearch returns the textfields of the session matching the query parameter.
+func (h *handler) GetSearch(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+
+	var limit int
+	if l := c.QueryParam(session.QueryKeyLimit); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid query parameter %s", session.QueryKeyLimit))
+		}
+	}
+
+	results, err := scb.Search(c.Request().Context(), c.QueryParam(session.QueryKeyQuery), limit)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// GetLayers returns the ordered layers of a page.
+func (h *handler) GetLayers(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+
+	layers, err := scb.GetLayers(c.Request().Context(), c.Param("pageId"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, layers)
+}
+
+// PostLayer adds a layer to a page and responds with the new layer.
+func (h *handler) PostLayer(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+
+	var req session.LayerRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return libErr.ErrBadRequest.Wrap(libErr.WithError(err))
+	}
+
+	layer, err := scb.AddLayer(c.Request().Context(), c.Param("pageId"), req, isHost(c, scb))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, layer)
+}
+
+// PutLayer renames, reorders, hides or locks a layer.
+func (h *handler) PutLayer(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+
+	var req session.LayerRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return libErr.ErrBadRequest.Wrap(libErr.WithError(err))
+	}
+
+	if err := scb.UpdateLayer(c.Request().Context(), c.Param("pageId"), c.Param("layerId"), req, isHost(c, scb)); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DeleteLayer deletes a layer including its strokes.
+func (h *handler) DeleteLayer(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+
+	if err := scb.DeleteLayer(c.Request().Context(), c.Param("pageId"), c.Param("layerId"), isHost(c, scb)); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetComments returns the comment threads of a page.
+func (h *handler) GetComments(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+
+	threads, err := scb.GetComments(c.Request().Context(), c.Param("pageId"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, threads)
+}
+
+// PostCommentThread starts a comment thread on a page and responds with the new thread.
+func (h *handler) PostCommentThread(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req session.CommentThreadRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return libErr.ErrBadRequest.Wrap(libErr.WithError(err))
+	}
+
+	thread, err := scb.AddCommentThread(c.Request().Context(), c.Param("pageId"), *user, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, thread)
+}
+
+// PostComment replies to a comment thread and responds with the new comment.
+func (h *handler) PostComment(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req session.CommentRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return libErr.ErrBadRequest.Wrap(libErr.WithError(err))
+	}
+
+	comment, err := scb.ReplyComment(c.Request().Context(), c.Param("pageId"), c.Param("threadId"), *user, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, comment)
+}
+
+// PutCommentThread resolves or reopens a comment thread.
+func (h *handler) PutCommentThread(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req session.CommentResolveRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return libErr.ErrBadRequest.Wrap(libErr.WithError(err))
+	}
+
+	if err := scb.ResolveCommentThread(c.Request().Context(), c.Param("pageId"), c.Param("threadId"), user.ID, isHost(c, scb), req.Resolved); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DeleteCommentThread deletes a comment thread.
+func (h *handler) DeleteCommentThread(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+
+	if err := scb.DeleteCommentThread(c.Request().Context(), c.Param("pageId"), c.Param("threadId"), user.ID, isHost(c, scb)); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (h *handler) GetPageSync(c echo.Context) error {
 	scb, err := getSCB(c)
 	if err != nil {
@@ -263,10 +568,42 @@ func (h *handler) GetPageSync(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	if sync.Chat, err = scb.GetChat(c.Request().Context()); err != nil {
+		return err
+	}
 
 	return c.JSON(http.StatusOK, sync)
 }
 
+// GetChat returns the chat history of the session.
+func (h *handler) GetChat(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+
+	messages, err := scb.GetChat(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, messages)
+}
+
+// DeleteChatMessage deletes a message from the chat history.
+func (h *handler) DeleteChatMessage(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+
+	if err := scb.DeleteChatMessage(c.Request().Context(), c.Param("messageId")); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (h *handler) PostPageSync(c echo.Context) error {
 	scb, err := getSCB(c)
 	if err != nil {
@@ -285,45 +622,156 @@ func (h *handler) PostPageSync(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// PostAttachment streams the uploaded file of a multipart form to the
+// storage of the session.
 func (h *handler) PostAttachment(c echo.Context) error {
 	scb, err := getSCB(c)
 	if err != nil {
 		return err
 	}
-
-	if err := c.Request().ParseMultipartForm(2 << 20); err != nil {
-		return libErr.From(libErr.AttachmentSizeExceeded).Wrap(
-			libErr.WithError(err))
+	user, err := getUser(c)
+	if err != nil {
+		return err
 	}
-	file, _, err := c.Request().FormFile("file")
+
+	mr, err := c.Request().MultipartReader()
 	if err != nil {
 		return libErr.ErrBadRequest.Wrap(libErr.WithError(err))
 	}
-	defer file.Close()
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("missing form file"))
+		}
+		if err != nil {
+			return libErr.ErrBadRequest.Wrap(libErr.WithError(err))
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			continue
+		}
+
+		a, err := scb.UploadAttachment(c.Request().Context(), part, *user)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusCreated, a)
+	}
+}
 
-	data, err := io.ReadAll(file)
+// GetAttachments responds with the attachments of the session.
+func (h *handler) GetAttachments(c echo.Context) error {
+	scb, err := getSCB(c)
 	if err != nil {
-		return libErr.ErrBadRequest.Wrap(libErr.WithError(err))
+		return err
 	}
 
-	attachID, err := scb.Attachments().Upload(data)
+	attachments, err := scb.GetAttachments(c.Request().Context())
 	if err != nil {
-		return libErr.ErrInternalServerError.Wrap(libErr.WithError(err))
+		return err
 	}
 
-	return c.JSON(http.StatusCreated, attachment.AttachmentResponse{AttachID: attachID})
+	return c.JSON(http.StatusOK, attachments)
 }
 
+// DeleteAttachment deletes an attachment of the session.
+func (h *handler) DeleteAttachment(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+	user, err := getUser(c)
+	if err != nil {
+		return err
+	}
+	var force bool
+	if f := c.QueryParam(session.QueryKeyForce); f != "" {
+		if force, err = strconv.ParseBool(f); err != nil {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid query parameter %s", session.QueryKeyForce))
+		}
+	}
+
+	if err := scb.DeleteAttachment(c.Request().Context(), c.Param("attachId"), user.ID, isHost(c, scb), force); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetAttachment responds with the content of an attachment. Ranges and
+// conditional requests are supported.
 func (h *handler) GetAttachment(c echo.Context) error {
 	scb, err := getSCB(c)
 	if err != nil {
 		return err
 	}
 	attachID := c.Param("attachId")
-	data, MIMEType, err := scb.Attachments().Get(attachID)
+	file, err := scb.Attachments().Get(attachID)
 	if err != nil {
 		return libErr.ErrNotFound.Wrap(libErr.WithError(err))
 	}
+	defer file.Close()
+
+	// the content of an attachment never changes, since it is addressed by its hash
+	hash := attachment.Hash(attachID)
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, file.MIMEType)
+	header.Set("ETag", strconv.Quote(hash))
+	header.Set("Cache-Control", "private, max-age=31536000, immutable")
+	if digest, err := hex.DecodeString(hash); err == nil {
+		header.Set("Repr-Digest", fmt.Sprintf("sha-256=:%s:", base64.StdEncoding.EncodeToString(digest)))
+	}
+	// attachments are displayed as documents, e.g. SVG opened in a new tab,
+	// which must neither run scripts nor load other resources
+	header.Set("Content-Security-Policy", attachmentCSP)
+
+	http.ServeContent(c.Response(), c.Request(), attachID, file.ModTime, file)
+	return nil
+}
+
+// GetAttachmentURL responds with a signed URL of an attachment, which can
+// be loaded without headers, e.g. as source of images.
+func (h *handler) GetAttachmentURL(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+	attachID := c.Param("attachId")
+	file, err := scb.Attachments().Get(attachID)
+	if err != nil {
+		return libErr.ErrNotFound.Wrap(libErr.WithError(err))
+	}
+	file.Close()
+
+	return c.JSON(http.StatusOK, scb.AttachmentURL(attachID))
+}
+
+// GetAttachmentPage responds with the PNG preview of a page of a PDF attachment.
+func (h *handler) GetAttachmentPage(c echo.Context) error {
+	scb, err := getSCB(c)
+	if err != nil {
+		return err
+	}
+	page, err := strconv.Atoi(c.Param("n"))
+	if err != nil || page < 1 {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid page number"))
+	}
+	var width int
+	if w := c.QueryParam(session.QueryKeyWidth); w != "" {
+		if width, err = strconv.Atoi(w); err != nil || width <= 0 {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid query parameter %s", session.QueryKeyWidth))
+		}
+	}
+
+	data, err := scb.Attachments().Preview(c.Param("attachId"), page, attachment.PreviewWidth(width))
+	switch {
+	case errors.Is(err, attachment.ErrNotFound):
+		return libErr.ErrNotFound.Wrap(libErr.WithError(err))
+	case errors.Is(err, attachment.ErrFileType), errors.Is(err, attachment.ErrPreview):
+		return libErr.ErrBadRequest.Wrap(libErr.WithError(err))
+	case err != nil:
+		return libErr.ErrInternalServerError.Wrap(libErr.WithError(err))
+	}
 
-	return c.Stream(http.StatusOK, MIMEType, data)
+	c.Response().Header().Set("Content-Security-Policy", attachmentCSP)
+	return c.Stream(http.StatusOK, "image/png", data)
 }
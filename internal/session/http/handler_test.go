@@ -1,19 +1,32 @@
 package http_test
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/boardsite-io/server/internal/attachment"
+	"github.com/boardsite-io/server/internal/attachment/attachmentfakes"
 	"github.com/boardsite-io/server/internal/config"
 	"github.com/boardsite-io/server/internal/session"
 	sessionHttp "github.com/boardsite-io/server/internal/session/http"
 	"github.com/boardsite-io/server/internal/session/sessionfakes"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/geometry"
 )
 
 func Test_handler_PostCreateSession(t *testing.T) {
@@ -27,7 +40,7 @@ func Test_handler_PostCreateSession(t *testing.T) {
 	scb.ConfigReturns(cfg)
 	dispatcher := &sessionfakes.FakeDispatcher{}
 	dispatcher.CreateReturns(scb, nil)
-	handler := sessionHttp.NewHandler(cfg.Session, dispatcher)
+	handler := sessionHttp.NewHandler(&config.Configuration{Session: cfg.Session}, dispatcher)
 
 	r := httptest.NewRequest(http.MethodPost, "/", nil)
 	rr := httptest.NewRecorder()
@@ -61,7 +74,7 @@ func Test_handler_PostCreateSessionConfig(t *testing.T) {
 	scb.ConfigReturns(wantCfg)
 	dispatcher := &sessionfakes.FakeDispatcher{}
 	dispatcher.CreateReturns(scb, nil)
-	handler := sessionHttp.NewHandler(config.Session{}, dispatcher)
+	handler := sessionHttp.NewHandler(&config.Configuration{}, dispatcher)
 
 	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(req))
 	rr := httptest.NewRecorder()
@@ -89,7 +102,7 @@ func Test_handler_PutSessionConfig(t *testing.T) {
 	scb.ConfigReturns(cfg)
 	dispatcher := &sessionfakes.FakeDispatcher{}
 	dispatcher.CreateReturns(scb, nil)
-	handler := sessionHttp.NewHandler(cfg.Session, dispatcher)
+	handler := sessionHttp.NewHandler(&config.Configuration{Session: cfg.Session}, dispatcher)
 	r := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"maxUsers": 20}`))
 	r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 	rr := httptest.NewRecorder()
@@ -100,3 +113,429 @@ func Test_handler_PutSessionConfig(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func Test_handler_GetPageStrokes(t *testing.T) {
+	e := echo.New()
+	scb := &sessionfakes.FakeController{}
+	scb.GetStrokesInRectReturns([]*session.Stroke{{ID: "stroke1"}}, nil)
+	handler := sessionHttp.NewHandler(&config.Configuration{}, &sessionfakes.FakeDispatcher{})
+
+	t.Run("successful", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/?minX=-10&minY=0&maxX=100.5&maxY=200", nil)
+		rr := httptest.NewRecorder()
+		c := e.NewContext(r, rr)
+		c.SetParamNames("pageId")
+		c.SetParamValues("pid1")
+		c.Set(sessionHttp.SessionCtxKey, scb)
+
+		err := handler.GetPageStrokes(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		_, pageID, rect := scb.GetStrokesInRectArgsForCall(0)
+		assert.Equal(t, "pid1", pageID)
+		assert.Equal(t, geometry.Rect{Min: geometry.Point{X: -10, Y: 0}, Max: geometry.Point{X: 100.5, Y: 200}}, rect)
+	})
+
+	t.Run("missing query parameter", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/?minX=0&minY=0&maxX=100", nil)
+		c := e.NewContext(r, httptest.NewRecorder())
+		c.Set(sessionHttp.SessionCtxKey, scb)
+
+		err := handler.GetPageStrokes(c)
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, scb.GetStrokesInRectCallCount())
+	})
+}
+
+func Test_handler_PostLayer(t *testing.T) {
+	e := echo.New()
+	scb := &sessionfakes.FakeController{}
+	scb.ConfigReturns(session.Config{Host: "host", Secret: "secret"})
+	scb.AddLayerReturns(&session.Layer{ID: "layer1", Name: "ink", Visible: true}, nil)
+	handler := sessionHttp.NewHandler(&config.Configuration{}, &sessionfakes.FakeDispatcher{})
+
+	tests := []struct {
+		name     string
+		userID   string
+		secret   string
+		wantHost bool
+	}{
+		{name: "host", userID: "host", secret: "secret", wantHost: true},
+		{name: "host without secret", userID: "host"},
+		{name: "user", userID: "user1", secret: "secret"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "ink"}`))
+			rr := httptest.NewRecorder()
+			c := e.NewContext(r, rr)
+			c.SetParamNames("pageId")
+			c.SetParamValues("pid1")
+			c.Set(sessionHttp.SessionCtxKey, scb)
+			c.Set(sessionHttp.UserCtxKey, &session.User{ID: tt.userID})
+			c.Set(sessionHttp.SecretCtxKey, tt.secret)
+
+			err := handler.PostLayer(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusCreated, rr.Code)
+			_, pageID, req, host := scb.AddLayerArgsForCall(i)
+			assert.Equal(t, "pid1", pageID)
+			assert.Equal(t, "ink", req.Name.Unwrap())
+			assert.Equal(t, tt.wantHost, host)
+			var got session.Layer
+			_ = json.NewDecoder(rr.Body).Decode(&got)
+			assert.Equal(t, "layer1", got.ID)
+		})
+	}
+}
+
+func Test_handler_PostCommentThread(t *testing.T) {
+	e := echo.New()
+	scb := &sessionfakes.FakeController{}
+	scb.AddCommentThreadReturns(&session.CommentThread{ID: "thread1", PageID: "pid1"}, nil)
+	handler := sessionHttp.NewHandler(&config.Configuration{}, &sessionfakes.FakeDispatcher{})
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"x": 1.5, "y": 2, "text": "looks good"}`))
+	rr := httptest.NewRecorder()
+	c := e.NewContext(r, rr)
+	c.SetParamNames("pageId")
+	c.SetParamValues("pid1")
+	c.Set(sessionHttp.SessionCtxKey, scb)
+	c.Set(sessionHttp.UserCtxKey, &session.User{ID: "user1", Alias: "author"})
+
+	err := handler.PostCommentThread(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	_, pageID, user, req := scb.AddCommentThreadArgsForCall(0)
+	assert.Equal(t, "pid1", pageID)
+	assert.Equal(t, "author", user.Alias)
+	assert.Equal(t, session.CommentThreadRequest{X: 1.5, Y: 2, Text: "looks good"}, req)
+	var got session.CommentThread
+	_ = json.NewDecoder(rr.Body).Decode(&got)
+	assert.Equal(t, "thread1", got.ID)
+}
+
+func Test_handler_GetAttachmentPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		page       string
+		query      string
+		previewErr error
+		wantWidth  int
+		wantErr    error
+	}{
+		{name: "default width", page: "1", wantWidth: attachment.DefaultPreviewWidth},
+		{name: "width rounded up", page: "2", query: "?width=300", wantWidth: 512},
+		{name: "invalid page", page: "0", wantErr: libErr.ErrBadRequest},
+		{name: "invalid width", page: "1", query: "?width=abc", wantErr: libErr.ErrBadRequest},
+		{name: "not a pdf", page: "1", previewErr: attachment.ErrFileType, wantWidth: 512, wantErr: libErr.ErrBadRequest},
+		{name: "page not found", page: "9", previewErr: attachment.ErrNotFound, wantWidth: 512, wantErr: libErr.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			scb := &sessionfakes.FakeController{}
+			attachments := &attachmentfakes.FakeHandler{}
+			attachments.PreviewReturns(strings.NewReader("png"), tt.previewErr)
+			scb.AttachmentsReturns(attachments)
+			handler := sessionHttp.NewHandler(&config.Configuration{}, &sessionfakes.FakeDispatcher{})
+			r := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			c := e.NewContext(r, rr)
+			c.SetParamNames("attachId", "n")
+			c.SetParamValues("doc.pdf", tt.page)
+			c.Set(sessionHttp.SessionCtxKey, scb)
+
+			err := handler.GetAttachmentPage(c)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "image/png", rr.Header().Get(echo.HeaderContentType))
+			}
+			if tt.wantWidth > 0 {
+				attachID, page, width := attachments.PreviewArgsForCall(0)
+				assert.Equal(t, "doc.pdf", attachID)
+				assert.Equal(t, tt.page, strconv.Itoa(page))
+				assert.Equal(t, tt.wantWidth, width)
+			} else {
+				assert.Zero(t, attachments.PreviewCallCount())
+			}
+		})
+	}
+}
+
+func Test_handler_GetAttachment(t *testing.T) {
+	data := []byte("0123456789")
+	hash := sha256.Sum256(data)
+	attachID := hex.EncodeToString(hash[:]) + ".png"
+	etag := `"` + hex.EncodeToString(hash[:]) + `"`
+	modTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		header     map[string]string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "without condition", wantStatus: http.StatusOK, wantBody: "0123456789"},
+		{name: "changed", header: map[string]string{"If-None-Match": `"other"`}, wantStatus: http.StatusOK, wantBody: "0123456789"},
+		{name: "not modified", header: map[string]string{"If-None-Match": `"other", ` + etag}, wantStatus: http.StatusNotModified},
+		{name: "not modified since", header: map[string]string{"If-Modified-Since": modTime.Format(http.TimeFormat)}, wantStatus: http.StatusNotModified},
+		{name: "range", header: map[string]string{"Range": "bytes=2-5"}, wantStatus: http.StatusPartialContent, wantBody: "2345"},
+		{name: "unsatisfiable range", header: map[string]string{"Range": "bytes=20-"}, wantStatus: http.StatusRequestedRangeNotSatisfiable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			scb := &sessionfakes.FakeController{}
+			attachments := &attachmentfakes.FakeHandler{}
+			attachments.GetReturns(&attachment.File{
+				ReadSeekCloser: nopCloser{bytes.NewReader(data)},
+				MIMEType:       "image/png",
+				Size:           int64(len(data)),
+				ModTime:        modTime,
+			}, nil)
+			scb.AttachmentsReturns(attachments)
+			handler := sessionHttp.NewHandler(&config.Configuration{}, &sessionfakes.FakeDispatcher{})
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			c := e.NewContext(r, rr)
+			c.SetParamNames("attachId")
+			c.SetParamValues(attachID)
+			c.Set(sessionHttp.SessionCtxKey, scb)
+
+			err := handler.GetAttachment(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			assert.Equal(t, etag, rr.Header().Get("ETag"))
+			assert.Equal(t, "sha-256=:"+base64.StdEncoding.EncodeToString(hash[:])+":", rr.Header().Get("Repr-Digest"))
+			assert.Contains(t, rr.Header().Get("Cache-Control"), "immutable")
+			if tt.wantStatus != http.StatusRequestedRangeNotSatisfiable {
+				assert.Equal(t, tt.wantBody, rr.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, "image/png", rr.Header().Get(echo.HeaderContentType))
+				assert.Equal(t, "10", rr.Header().Get(echo.HeaderContentLength))
+				assert.Equal(t, modTime.Format(http.TimeFormat), rr.Header().Get(echo.HeaderLastModified))
+				assert.Equal(t, "bytes", rr.Header().Get("Accept-Ranges"))
+			}
+		})
+	}
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error { return nil }
+
+func Test_handler_PostAttachment(t *testing.T) {
+	multipartBody := func(field, filename string) (*bytes.Buffer, string) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		_ = mw.WriteField("other", "value")
+		fw, _ := mw.CreateFormFile(field, filename)
+		_, _ = fw.Write([]byte("content"))
+		_ = mw.Close()
+		return &body, mw.FormDataContentType()
+	}
+	tests := []struct {
+		name        string
+		field       string
+		contentType string
+		wantErr     error
+	}{
+		{name: "file", field: "file"},
+		{name: "missing file", field: "document", wantErr: libErr.ErrBadRequest},
+		{name: "not multipart", field: "file", contentType: "application/octet-stream", wantErr: libErr.ErrBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			scb := &sessionfakes.FakeController{}
+			var uploaded []byte
+			scb.UploadAttachmentCalls(func(_ context.Context, r io.Reader, _ session.User) (*session.Attachment, error) {
+				uploaded, _ = io.ReadAll(r)
+				return &session.Attachment{AttachID: "hash.png", Hash: "hash"}, nil
+			})
+			handler := sessionHttp.NewHandler(&config.Configuration{}, &sessionfakes.FakeDispatcher{})
+			body, contentType := multipartBody(tt.field, "file.png")
+			if tt.contentType != "" {
+				contentType = tt.contentType
+			}
+			r := httptest.NewRequest(http.MethodPost, "/", body)
+			r.Header.Set(echo.HeaderContentType, contentType)
+			rr := httptest.NewRecorder()
+			c := e.NewContext(r, rr)
+			c.Set(sessionHttp.SessionCtxKey, scb)
+			c.Set(sessionHttp.UserCtxKey, &session.User{ID: "user1"})
+
+			err := handler.PostAttachment(c)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Zero(t, scb.UploadAttachmentCallCount())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusCreated, rr.Code)
+			assert.Equal(t, "content", string(uploaded))
+			_, _, user := scb.UploadAttachmentArgsForCall(0)
+			assert.Equal(t, "user1", user.ID)
+			var got session.Attachment
+			_ = json.NewDecoder(rr.Body).Decode(&got)
+			assert.Equal(t, "hash", got.Hash)
+		})
+	}
+}
+
+func Test_handler_DeleteAttachment(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantForce bool
+		wantErr   error
+	}{
+		{name: "default", query: ""},
+		{name: "force", query: "?force=true", wantForce: true},
+		{name: "invalid force", query: "?force=maybe", wantErr: libErr.ErrBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			scb := &sessionfakes.FakeController{}
+			scb.ConfigReturns(session.Config{Host: "host", Secret: "secret"})
+			handler := sessionHttp.NewHandler(&config.Configuration{}, &sessionfakes.FakeDispatcher{})
+			r := httptest.NewRequest(http.MethodDelete, "/"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			c := e.NewContext(r, rr)
+			c.SetParamNames("attachId")
+			c.SetParamValues("hash.png")
+			c.Set(sessionHttp.SessionCtxKey, scb)
+			c.Set(sessionHttp.UserCtxKey, &session.User{ID: "host"})
+			c.Set(sessionHttp.SecretCtxKey, "secret")
+
+			err := handler.DeleteAttachment(c)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Zero(t, scb.DeleteAttachmentCallCount())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+			_, attachID, userID, host, force := scb.DeleteAttachmentArgsForCall(0)
+			assert.Equal(t, "hash.png", attachID)
+			assert.Equal(t, "host", userID)
+			assert.True(t, host)
+			assert.Equal(t, tt.wantForce, force)
+		})
+	}
+}
+
+func Test_handler_GetAttachmentURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		getErr  error
+		wantErr error
+	}{
+		{name: "existing attachment"},
+		{name: "missing attachment", getErr: attachment.ErrNotFound, wantErr: libErr.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			scb := &sessionfakes.FakeController{}
+			attachments := &attachmentfakes.FakeHandler{}
+			if tt.getErr != nil {
+				attachments.GetReturns(nil, tt.getErr)
+			} else {
+				attachments.GetReturns(&attachment.File{ReadSeekCloser: nopCloser{bytes.NewReader(nil)}}, nil)
+			}
+			scb.AttachmentsReturns(attachments)
+			scb.AttachmentURLReturns(&session.AttachmentURL{URL: "/b/sid/attachments/hash.png?expires=1&signature=sig", Expires: 1})
+			handler := sessionHttp.NewHandler(&config.Configuration{}, &sessionfakes.FakeDispatcher{})
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			rr := httptest.NewRecorder()
+			c := e.NewContext(r, rr)
+			c.SetParamNames("attachId")
+			c.SetParamValues("hash.png")
+			c.Set(sessionHttp.SessionCtxKey, scb)
+
+			err := handler.GetAttachmentURL(c)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Zero(t, scb.AttachmentURLCallCount())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "hash.png", scb.AttachmentURLArgsForCall(0))
+			assert.JSONEq(t, `{"url":"/b/sid/attachments/hash.png?expires=1&signature=sig","expires":1}`, rr.Body.String())
+		})
+	}
+}
+
+func Test_handler_GetPageThumbnail(t *testing.T) {
+	thumbnail := &session.Thumbnail{Data: []byte("png"), ETag: `"etag"`}
+	tests := []struct {
+		name       string
+		query      string
+		header     map[string]string
+		validPage  bool
+		wantWidth  int
+		wantStatus int
+		wantErr    error
+	}{
+		{name: "default width", validPage: true, wantStatus: http.StatusOK},
+		{name: "width", query: "?width=100", validPage: true, wantWidth: 100, wantStatus: http.StatusOK},
+		{name: "not modified", header: map[string]string{"If-None-Match": `"etag"`}, validPage: true, wantStatus: http.StatusNotModified},
+		{name: "invalid width", query: "?width=-1", validPage: true, wantErr: libErr.ErrBadRequest},
+		{name: "missing page", wantErr: libErr.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			scb := &sessionfakes.FakeController{}
+			scb.IsValidPageReturns(tt.validPage)
+			scb.PageThumbnailReturns(thumbnail, nil)
+			handler := sessionHttp.NewHandler(&config.Configuration{}, &sessionfakes.FakeDispatcher{})
+			r := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			c := e.NewContext(r, rr)
+			c.SetParamNames("pageId")
+			c.SetParamValues("pid1")
+			c.Set(sessionHttp.SessionCtxKey, scb)
+
+			err := handler.GetPageThumbnail(c)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Zero(t, scb.PageThumbnailCallCount())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			_, pageID, width := scb.PageThumbnailArgsForCall(0)
+			assert.Equal(t, "pid1", pageID)
+			assert.Equal(t, tt.wantWidth, width)
+			assert.Equal(t, `"etag"`, rr.Header().Get("ETag"))
+			assert.Equal(t, "private, no-cache", rr.Header().Get("Cache-Control"))
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, "image/png", rr.Header().Get(echo.HeaderContentType))
+				assert.Equal(t, "png", rr.Body.String())
+			}
+		})
+	}
+}
@@ -16,6 +16,16 @@ const (
 )
 
 func AllowUser(c echo.Context) bool {
+	return allowUser(c, false)
+}
+
+// AllowParticipant is like AllowUser, but allows all users of read-only
+// sessions to write, e.g. to comment on the pages.
+func AllowParticipant(c echo.Context) bool {
+	return allowUser(c, true)
+}
+
+func allowUser(c echo.Context, participate bool) bool {
 	scb, err := getSCB(c)
 	if err != nil {
 		return false
@@ -27,7 +37,7 @@ func AllowUser(c echo.Context) bool {
 	secret, _ := c.Get(SecretCtxKey).(string)
 
 	// request additionally need to check for correct secret
-	if scb.Config().ReadOnly && c.Request().Method != http.MethodGet &&
+	if !participate && scb.Config().ReadOnly && c.Request().Method != http.MethodGet &&
 		(user.ID != scb.Config().Host || secret != scb.Config().Secret) {
 		return false
 	}
@@ -35,6 +45,16 @@ func AllowUser(c echo.Context) bool {
 	return scb.Allow(scb.Config().Host) // only check pw
 }
 
+// isHost checks whether the request is authorized by the host of the session.
+func isHost(c echo.Context, scb session.Controller) bool {
+	user, ok := c.Get(UserCtxKey).(*session.User)
+	if !ok {
+		return false
+	}
+	secret, _ := c.Get(SecretCtxKey).(string)
+	return user.ID == scb.Config().Host && secret == scb.Config().Secret
+}
+
 func getSCB(c echo.Context) (session.Controller, error) {
 	scb, ok := c.Get(SessionCtxKey).(session.Controller)
 	if !ok {
@@ -0,0 +1,321 @@
+package session
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/geometry"
+	"github.com/boardsite-io/server/pkg/redis"
+)
+
+const (
+	// indexCellSize is the edge length of a grid cell in page coordinates
+	indexCellSize = 256
+	// indexMaxCells is the maximum number of cells a stroke is stored in.
+	// Larger strokes are kept in a separate list.
+	indexMaxCells = 64
+	// indexMaxCoord clamps coordinates such that cell indices cannot overflow
+	indexMaxCoord = 1 << 50
+)
+
+// HitTestRequest declares the request content of polygon hit-tests,
+// e.g. of lasso selections.
+type HitTestRequest struct {
+	Polygon geometry.Polygon `json:"polygon"`
+}
+
+type cell struct {
+	x, y int64
+}
+
+// strokeIndex is a spatial index of the stroke bounding boxes of a page.
+//
+// The index is a sparse uniform grid, which suits pages of any size
+// including infinite canvases. The text of textfields is indexed separately.
+// The index further keeps the layers of the page and of its strokes, which
+// are checked for every stroke message.
+type strokeIndex struct {
+	bounds map[string]geometry.Rect
+	cells  map[cell]map[string]struct{}
+	large  map[string]struct{}
+	text   *textIndex
+	// strokeLayers are the layer ids of the strokes outside of the base layer
+	strokeLayers map[string]string
+	// layers are the layers of the page or nil if not loaded yet
+	layers Layers
+}
+
+func newStrokeIndex() *strokeIndex {
+	return &strokeIndex{
+		bounds:       make(map[string]geometry.Rect),
+		cells:        make(map[cell]map[string]struct{}),
+		large:        make(map[string]struct{}),
+		text:         newTextIndex(),
+		strokeLayers: make(map[string]string),
+	}
+}
+
+// cellRange returns the cells covered by the rectangle and their number.
+func cellRange(r geometry.Rect) (min, max cell, n float64) {
+	toCell := func(v float64) int64 {
+		v = math.Max(-indexMaxCoord, math.Min(indexMaxCoord, v))
+		return int64(math.Floor(v / indexCellSize))
+	}
+	min = cell{x: toCell(r.Min.X), y: toCell(r.Min.Y)}
+	max = cell{x: toCell(r.Max.X), y: toCell(r.Max.Y)}
+	n = float64(max.x-min.x+1) * float64(max.y-min.y+1)
+	return min, max, n
+}
+
+// insert adds or replaces the bounding box of a stroke.
+func (idx *strokeIndex) insert(id string, r geometry.Rect) {
+	idx.remove(id)
+	idx.bounds[id] = r
+
+	min, max, n := cellRange(r)
+	if n > indexMaxCells {
+		idx.large[id] = struct{}{}
+		return
+	}
+	for x := min.x; x <= max.x; x++ {
+		for y := min.y; y <= max.y; y++ {
+			c := cell{x: x, y: y}
+			ids, ok := idx.cells[c]
+			if !ok {
+				ids = make(map[string]struct{})
+				idx.cells[c] = ids
+			}
+			ids[id] = struct{}{}
+		}
+	}
+}
+
+// remove deletes a stroke from the index.
+func (idx *strokeIndex) remove(id string) {
+	r, ok := idx.bounds[id]
+	if !ok {
+		return
+	}
+	delete(idx.bounds, id)
+	if _, ok := idx.large[id]; ok {
+		delete(idx.large, id)
+		return
+	}
+	min, max, _ := cellRange(r)
+	for x := min.x; x <= max.x; x++ {
+		for y := min.y; y <= max.y; y++ {
+			c := cell{x: x, y: y}
+			delete(idx.cells[c], id)
+			if len(idx.cells[c]) == 0 {
+				delete(idx.cells, c)
+			}
+		}
+	}
+}
+
+// query returns the sorted ids of all strokes whose bounding box
+// intersects the rectangle.
+func (idx *strokeIndex) query(r geometry.Rect) []string {
+	if r.Empty() {
+		return []string{}
+	}
+	found := make(map[string]struct{})
+	add := func(id string) {
+		if idx.bounds[id].Intersects(r) {
+			found[id] = struct{}{}
+		}
+	}
+
+	min, max, n := cellRange(r)
+	if n > float64(len(idx.cells)) {
+		// scanning all strokes is cheaper than visiting the cells
+		for id := range idx.bounds {
+			add(id)
+		}
+	} else {
+		for x := min.x; x <= max.x; x++ {
+			for y := min.y; y <= max.y; y++ {
+				for id := range idx.cells[cell{x: x, y: y}] {
+					add(id)
+				}
+			}
+		}
+		for id := range idx.large {
+			add(id)
+		}
+	}
+
+	ids := make([]string, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// indexStrokes updates the spatial and text index of the pages of the strokes
+// and drops their thumbnails.
+//
+// The index is maintained in memory alongside the cache, since all
+// changes of the strokes pass through the session.
+func (scb *controlBlock) indexStrokes(strokes ...redis.Stroke) {
+	scb.dropThumbnails(strokePages(strokes)...)
+	scb.muIdx.Lock()
+	defer scb.muIdx.Unlock()
+	for _, s := range strokes {
+		stroke, ok := s.(*Stroke)
+		if !ok {
+			continue
+		}
+		idx, ok := scb.indexes[stroke.PageID]
+		if !ok {
+			if stroke.IsDeleted() {
+				continue
+			}
+			idx = newStrokeIndex()
+			scb.indexes[stroke.PageID] = idx
+		}
+		delete(idx.strokeLayers, stroke.ID)
+		if stroke.IsDeleted() {
+			idx.remove(stroke.ID)
+			idx.text.remove(stroke.ID)
+			continue
+		}
+		idx.insert(stroke.ID, stroke.Bounds())
+		if scb.isTextfield(stroke) {
+			idx.text.insert(stroke)
+		} else {
+			idx.text.remove(stroke.ID)
+		}
+		if stroke.LayerID != "" {
+			idx.strokeLayers[stroke.ID] = stroke.LayerID
+		}
+	}
+}
+
+// strokeLayer returns the layer id of the stored stroke, which is
+// empty for strokes of the base layer and strokes not stored yet.
+func (scb *controlBlock) strokeLayer(pageID, strokeID string) string {
+	scb.muIdx.RLock()
+	defer scb.muIdx.RUnlock()
+	if idx, ok := scb.indexes[pageID]; ok {
+		return idx.strokeLayers[strokeID]
+	}
+	return ""
+}
+
+// indexedLayers returns a copy of the layers of the page and the generation
+// of the index, where the layers are nil if they are not loaded yet.
+func (scb *controlBlock) indexedLayers(pageID string) (Layers, uint64) {
+	scb.muIdx.RLock()
+	defer scb.muIdx.RUnlock()
+	if idx, ok := scb.indexes[pageID]; ok && idx.layers != nil {
+		return idx.layers.clone(), scb.indexGen
+	}
+	return nil, scb.indexGen
+}
+
+// indexLayers keeps a copy of the layers of the page after they are stored.
+func (scb *controlBlock) indexLayers(pageID string, layers Layers) {
+	scb.muIdx.Lock()
+	defer scb.muIdx.Unlock()
+	scb.setIndexedLayers(pageID, layers)
+}
+
+// indexLoadedLayers keeps a copy of the layers of the page loaded from the cache,
+// unless the index changed since the generation of the index, when they were loaded.
+func (scb *controlBlock) indexLoadedLayers(pageID string, layers Layers, gen uint64) {
+	scb.muIdx.Lock()
+	defer scb.muIdx.Unlock()
+	if gen == scb.indexGen {
+		scb.setIndexedLayers(pageID, layers)
+	}
+}
+
+func (scb *controlBlock) setIndexedLayers(pageID string, layers Layers) {
+	scb.indexGen++
+	idx, ok := scb.indexes[pageID]
+	if !ok {
+		idx = newStrokeIndex()
+		scb.indexes[pageID] = idx
+	}
+	idx.layers = layers.clone()
+}
+
+// strokePages returns the ids of the pages of the strokes.
+func strokePages(strokes []redis.Stroke) []string {
+	seen := make(map[string]struct{})
+	pageIDs := make([]string, 0, 1)
+	for _, s := range strokes {
+		stroke, ok := s.(*Stroke)
+		if !ok {
+			continue
+		}
+		if _, ok := seen[stroke.PageID]; !ok {
+			seen[stroke.PageID] = struct{}{}
+			pageIDs = append(pageIDs, stroke.PageID)
+		}
+	}
+	return pageIDs
+}
+
+// dropIndex removes the spatial index of the pages.
+func (scb *controlBlock) dropIndex(pageIDs ...string) {
+	scb.muIdx.Lock()
+	defer scb.muIdx.Unlock()
+	scb.indexGen++
+	for _, pid := range pageIDs {
+		delete(scb.indexes, pid)
+	}
+}
+
+// resetIndex removes the spatial index of all pages.
+func (scb *controlBlock) resetIndex() {
+	scb.muIdx.Lock()
+	defer scb.muIdx.Unlock()
+	scb.indexGen++
+	scb.indexes = make(map[string]*strokeIndex)
+}
+
+// queryIndex returns the ids of the strokes on the page whose
+// bounding box intersects the rectangle.
+func (scb *controlBlock) queryIndex(pageID string, rect geometry.Rect) []string {
+	scb.muIdx.RLock()
+	defer scb.muIdx.RUnlock()
+	idx, ok := scb.indexes[pageID]
+	if !ok {
+		return []string{}
+	}
+	return idx.query(rect)
+}
+
+func (scb *controlBlock) GetStrokesInRect(ctx context.Context, pageID string, rect geometry.Rect) ([]*Stroke, error) {
+	if !scb.IsValidPage(ctx, pageID) {
+		return nil, libErr.ErrNotFound.Wrap(libErr.WithErrorf("page %s does not exist", pageID))
+	}
+	ids := scb.queryIndex(pageID, rect)
+	strokeBytes, err := scb.cache.GetStrokes(ctx, scb.cfg.ID, pageID, ids...)
+	if err != nil {
+		return nil, err
+	}
+	return parseStrokes(strokeBytes)
+}
+
+func (scb *controlBlock) GetStrokesInPolygon(ctx context.Context, pageID string, polygon geometry.Polygon) ([]*Stroke, error) {
+	if len(polygon) < 3 {
+		return nil, libErr.ErrBadRequest.Wrap(libErr.WithErrorf("polygon requires at least 3 vertices"))
+	}
+	candidates, err := scb.GetStrokesInRect(ctx, pageID, polygon.Bounds())
+	if err != nil {
+		return nil, err
+	}
+	strokes := make([]*Stroke, 0, len(candidates))
+	for _, s := range candidates {
+		if polygon.IntersectsPolyline(s.path()) {
+			strokes = append(strokes, s)
+		}
+	}
+	return strokes, nil
+}
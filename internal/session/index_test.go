@@ -0,0 +1,130 @@
+package session_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/session"
+	"github.com/boardsite-io/server/pkg/geometry"
+	"github.com/boardsite-io/server/pkg/redis"
+	"github.com/boardsite-io/server/pkg/redis/redisfakes"
+)
+
+func strokeIDs(strokes []*session.Stroke) []string {
+	ids := make([]string, len(strokes))
+	for i, s := range strokes {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+// setupStrokeSession creates a session with a single page pid1 which holds the strokes.
+//
+// The cache stores the updated strokes, while it only records the calls after the setup.
+func setupStrokeSession(t *testing.T, strokes ...*session.Stroke) (session.Controller, *redisfakes.FakeHandler, chan session.Message) {
+	cached := make(map[string][]byte)
+	stub := func(fakeCache *redisfakes.FakeHandler) {
+		fakeCache.GetPageRankReturns([]string{"pid1"}, nil)
+		fakeCache.GetStrokesCalls(func(_ context.Context, _, _ string, ids ...string) ([][]byte, error) {
+			strokes := make([][]byte, 0, len(ids))
+			for _, id := range ids {
+				if s, ok := cached[id]; ok {
+					strokes = append(strokes, s)
+				}
+			}
+			return strokes, nil
+		})
+		fakeCache.UpdateStrokesCalls(func(_ context.Context, _ string, strokes ...redis.Stroke) error {
+			for _, s := range strokes {
+				if s.IsDeleted() {
+					delete(cached, s.Id())
+					continue
+				}
+				cached[s.Id()], _ = json.Marshal(s)
+			}
+			return nil
+		})
+	}
+	cfg := session.Config{ID: "sid1"}
+	cfg.Strokes = strokeTypes
+	scb, fakeCache, broadcast := newTestSession(t, cfg, stub)
+
+	for _, s := range strokes {
+		msg := newStrokeMessage(t, session.JSONCodec, s)
+		msg.Sender = s.UserID
+		err := scb.Receive(context.Background(), msg, s.UserID)
+		require.NoError(t, err)
+		<-broadcast
+	}
+	// forget the calls of the setup
+	*fakeCache = redisfakes.FakeHandler{}
+	stub(fakeCache)
+
+	return scb, fakeCache, broadcast
+}
+
+func Test_controlBlock_GetStrokesInRect(t *testing.T) {
+	ctx := context.Background()
+	strokes := []*session.Stroke{
+		{Type: strokeTypePen, ID: "small", PageID: "pid1", UserID: "user1", X: 10, Y: 10, Points: session.Points{0, 0, 20, 20}},
+		{Type: strokeTypePen, ID: "far", PageID: "pid1", UserID: "user1", X: 5000, Y: 5000, Points: session.Points{0, 0, 100, 0, 50, 100}},
+		{Type: strokeTypeLine, ID: "large", PageID: "pid1", UserID: "user1", X: -1e6, Y: 50, Points: session.Points{0, 0, 2e6, 0}},
+		{Type: strokeTypeTextfield, ID: "text", PageID: "pid1", UserID: "user1", X: 60, Y: 2000},
+	}
+	scb, _, _ := setupStrokeSession(t, strokes...)
+
+	t.Run("returns intersecting strokes", func(t *testing.T) {
+		got, err := scb.GetStrokesInRect(ctx, "pid1", geometry.Rect{Max: geometry.Point{X: 100, Y: 100}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"large", "small"}, strokeIDs(got))
+	})
+
+	t.Run("returns strokes of large rectangles", func(t *testing.T) {
+		got, err := scb.GetStrokesInRect(ctx, "pid1", geometry.Rect{Min: geometry.Point{X: -1e9, Y: -1e9}, Max: geometry.Point{X: 1e9, Y: 1e9}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"far", "large", "small", "text"}, strokeIDs(got))
+	})
+
+	t.Run("hit-tests polygon", func(t *testing.T) {
+		// the bounding box of the triangle contains the textfield
+		// but the triangle only covers the far stroke
+		triangle := geometry.Polygon{{X: 0, Y: 6000}, {X: 6000, Y: 6000}, {X: 6000, Y: 100}}
+
+		got, err := scb.GetStrokesInPolygon(ctx, "pid1", triangle)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"far"}, strokeIDs(got))
+	})
+
+	t.Run("unknown page", func(t *testing.T) {
+		_, err := scb.GetStrokesInRect(ctx, "pid2", geometry.Rect{Max: geometry.Point{X: 100, Y: 100}})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("removes deleted strokes", func(t *testing.T) {
+		err := scb.Receive(ctx, newStrokeMessage(t, session.JSONCodec, &session.Stroke{ID: "small", PageID: "pid1", UserID: "user1"}), "user1")
+		require.NoError(t, err)
+
+		got, err := scb.GetStrokesInRect(ctx, "pid1", geometry.Rect{Max: geometry.Point{X: 100, Y: 100}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"large"}, strokeIDs(got))
+	})
+
+	t.Run("clears index of page", func(t *testing.T) {
+		err := scb.UpdatePages(ctx, session.PageRequest{PageID: []string{"pid1"}}, "clear", true)
+		require.NoError(t, err)
+
+		got, err := scb.GetStrokesInRect(ctx, "pid1", geometry.Rect{Max: geometry.Point{X: 100, Y: 100}})
+
+		assert.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
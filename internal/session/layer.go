@@ -0,0 +1,300 @@
+package session
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/heat1q/opt"
+
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/redis"
+)
+
+const (
+	maxLayers          = 64
+	maxLayerNameLength = 64
+)
+
+// Layer declares a named layer of a page.
+//
+// The order of the layers of a page determines their drawing order.
+// Strokes without a layer id belong to the implicit base layer.
+type Layer struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Visible bool   `json:"visible"`
+	Locked  bool   `json:"locked"`
+}
+
+// LayerRequest declares the request content for creating and updating layers.
+type LayerRequest struct {
+	Name    opt.Option[string] `json:"name,omitempty"`
+	Index   opt.Option[int]    `json:"index,omitempty"`
+	Visible opt.Option[bool]   `json:"visible,omitempty"`
+	Locked  opt.Option[bool]   `json:"locked,omitempty"`
+}
+
+func (r *LayerRequest) Validate() error {
+	if name, ok := r.Name.Some(); ok && (name == "" || utf8.RuneCountInString(name) > maxLayerNameLength) {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("layer name must have 1 to %d characters", maxLayerNameLength))
+	}
+	return nil
+}
+
+// Layers declares the ordered layers of a page.
+type Layers []*Layer
+
+// find returns the index of the layer with given id or -1.
+func (l Layers) find(layerID string) int {
+	for i, layer := range l {
+		if layer.ID == layerID {
+			return i
+		}
+	}
+	return -1
+}
+
+// clone returns a deep copy of the layers, which is never nil.
+func (l Layers) clone() Layers {
+	layers := make(Layers, len(l))
+	for i, layer := range l {
+		c := *layer
+		layers[i] = &c
+	}
+	return layers
+}
+
+// move moves the layer at index i to index j.
+func (l Layers) move(i, j int) Layers {
+	if j < 0 || j >= len(l) {
+		j = len(l) - 1
+	}
+	layer := l[i]
+	l = append(l[:i], l[i+1:]...)
+	l = append(l[:j], append(Layers{layer}, l[j:]...)...)
+	return l
+}
+
+// checkStroke checks whether the user may add the stroke to its layer.
+func (l Layers) checkStroke(stroke *Stroke, host bool) error {
+	if stroke.LayerID == "" {
+		return nil
+	}
+	i := l.find(stroke.LayerID)
+	if i < 0 {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("layer %s does not exist", stroke.LayerID))
+	}
+	if l[i].Locked && !host {
+		return libErr.ErrForbidden.Wrap(libErr.WithErrorf("layer %s is locked", stroke.LayerID))
+	}
+	return nil
+}
+
+func (scb *controlBlock) GetLayers(ctx context.Context, pageID string) (Layers, error) {
+	if !scb.IsValidPage(ctx, pageID) {
+		return nil, libErr.ErrNotFound.Wrap(libErr.WithErrorf("page %s does not exist", pageID))
+	}
+	return scb.getLayers(ctx, pageID)
+}
+
+// getLayers returns the layers of the page, which are loaded from the cache
+// once and then kept in the index of the page.
+func (scb *controlBlock) getLayers(ctx context.Context, pageID string) (Layers, error) {
+	layers, gen := scb.indexedLayers(pageID)
+	if layers != nil {
+		return layers, nil
+	}
+	layers = Layers{}
+	if err := scb.cache.GetPageLayers(ctx, scb.cfg.ID, pageID, &layers); err != nil {
+		return nil, err
+	}
+	scb.indexLoadedLayers(pageID, layers, gen)
+	return layers, nil
+}
+
+// setLayers stores the layers of the page and keeps them in the index.
+func (scb *controlBlock) setLayers(ctx context.Context, pageID string, layers Layers) error {
+	if err := scb.cache.SetPageLayers(ctx, scb.cfg.ID, pageID, layers); err != nil {
+		return err
+	}
+	scb.indexLayers(pageID, layers)
+	return nil
+}
+
+// AddLayer adds a layer to the page and broadcasts the change to all connected clients.
+//
+// The layer is appended unless an index is given. Only hosts can add locked layers.
+func (scb *controlBlock) AddLayer(ctx context.Context, pageID string, req LayerRequest, host bool) (*Layer, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	name, ok := req.Name.Some()
+	if !ok {
+		return nil, libErr.ErrBadRequest.Wrap(libErr.WithErrorf("layer name required"))
+	}
+	if req.Locked.UnwrapOr(false) && !host {
+		return nil, libErr.ErrForbidden.Wrap(libErr.WithErrorf("only the host can lock layers"))
+	}
+
+	scb.muLayers.Lock()
+	defer scb.muLayers.Unlock()
+
+	layers, err := scb.GetLayers(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if len(layers) >= maxLayers {
+		return nil, libErr.ErrBadRequest.Wrap(libErr.WithErrorf("page cannot have more than %d layers", maxLayers))
+	}
+
+	layer := &Layer{
+		ID:      uuid.NewString(),
+		Name:    name,
+		Visible: req.Visible.UnwrapOr(true),
+		Locked:  req.Locked.UnwrapOr(false),
+	}
+	layers = append(layers, layer)
+	if index, ok := req.Index.Some(); ok {
+		layers = layers.move(len(layers)-1, index)
+	}
+	if err := scb.setLayers(ctx, pageID, layers); err != nil {
+		return nil, err
+	}
+	scb.dropThumbnails(pageID)
+
+	scb.broadcastPageSync(ctx, []string{pageID}, false)
+	return layer, nil
+}
+
+// UpdateLayer renames, reorders, hides or locks a layer and broadcasts the
+// change to all connected clients.
+//
+// Only hosts can change the lock or any other property of locked layers.
+func (scb *controlBlock) UpdateLayer(ctx context.Context, pageID, layerID string, req LayerRequest, host bool) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	scb.muLayers.Lock()
+	defer scb.muLayers.Unlock()
+
+	layers, err := scb.GetLayers(ctx, pageID)
+	if err != nil {
+		return err
+	}
+	i := layers.find(layerID)
+	if i < 0 {
+		return libErr.ErrNotFound.Wrap(libErr.WithErrorf("layer %s does not exist", layerID))
+	}
+	layer := layers[i]
+	if (layer.Locked || !req.Locked.None()) && !host {
+		return libErr.ErrForbidden.Wrap(libErr.WithErrorf("only the host can change locked layers"))
+	}
+
+	if name, ok := req.Name.Some(); ok {
+		layer.Name = name
+	}
+	if visible, ok := req.Visible.Some(); ok {
+		layer.Visible = visible
+	}
+	if locked, ok := req.Locked.Some(); ok {
+		layer.Locked = locked
+	}
+	if index, ok := req.Index.Some(); ok {
+		layers = layers.move(i, index)
+	}
+	if err := scb.setLayers(ctx, pageID, layers); err != nil {
+		return err
+	}
+	scb.dropThumbnails(pageID)
+
+	scb.broadcastPageSync(ctx, []string{pageID}, false)
+	return nil
+}
+
+// DeleteLayer deletes a layer including its strokes and broadcasts the
+// change to all connected clients.
+//
+// Only hosts can delete locked layers.
+func (scb *controlBlock) DeleteLayer(ctx context.Context, pageID, layerID string, host bool) error {
+	scb.muLayers.Lock()
+	defer scb.muLayers.Unlock()
+
+	layers, err := scb.GetLayers(ctx, pageID)
+	if err != nil {
+		return err
+	}
+	i := layers.find(layerID)
+	if i < 0 {
+		return libErr.ErrNotFound.Wrap(libErr.WithErrorf("layer %s does not exist", layerID))
+	}
+	if layers[i].Locked && !host {
+		return libErr.ErrForbidden.Wrap(libErr.WithErrorf("only the host can delete locked layers"))
+	}
+
+	scb.muEdit.Lock()
+	defer scb.muEdit.Unlock()
+
+	strokes, err := scb.getStrokes(ctx, pageID)
+	if err != nil {
+		return err
+	}
+	deleted := make([]redis.Stroke, 0, len(strokes))
+	for _, s := range strokes {
+		if s.LayerID == layerID {
+			deleted = append(deleted, s.deleted())
+		}
+	}
+	if err := scb.cache.UpdateStrokes(ctx, scb.cfg.ID, deleted...); err != nil {
+		return err
+	}
+	scb.indexStrokes(deleted...)
+
+	layers = append(layers[:i], layers[i+1:]...)
+	if err := scb.setLayers(ctx, pageID, layers); err != nil {
+		return err
+	}
+	scb.dropThumbnails(pageID)
+
+	scb.broadcastPageSync(ctx, []string{pageID}, true)
+	return nil
+}
+
+// lockedLayers returns the ids of the layers of the page, which are locked for the user.
+func (scb *controlBlock) lockedLayers(ctx context.Context, pageID, userID string) (map[string]struct{}, error) {
+	locked := make(map[string]struct{})
+	if userID == scb.cfg.Host {
+		return locked, nil
+	}
+	layers, err := scb.getLayers(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range layers {
+		if l.Locked {
+			locked[l.ID] = struct{}{}
+		}
+	}
+	return locked, nil
+}
+
+// checkUnlockedPages checks whether the user may remove all strokes of the pages,
+// i.e. the user is the host or the pages have no locked layers.
+func (scb *controlBlock) checkUnlockedPages(ctx context.Context, host bool, pageIDs ...string) error {
+	if host {
+		return nil
+	}
+	for _, pid := range pageIDs {
+		layers, err := scb.getLayers(ctx, pid)
+		if err != nil {
+			return err
+		}
+		for _, l := range layers {
+			if l.Locked {
+				return libErr.ErrForbidden.Wrap(libErr.WithErrorf("page %s has locked layers", pid))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package session_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/heat1q/opt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/session"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/redis/redisfakes"
+)
+
+// setupLayerSession returns a session hosted by "host" with page "pid1",
+// where the cache stores the layers of the page.
+func setupLayerSession(t *testing.T, layers ...*session.Layer) (session.Controller, *redisfakes.FakeHandler, chan session.Message) {
+	stored, _ := json.Marshal(layers)
+	return newTestSession(t, session.Config{ID: "sid1", Host: "host"}, withPages("pid1"), func(fakeCache *redisfakes.FakeHandler) {
+		fakeCache.GetPageLayersCalls(func(_ context.Context, _, _ string, v any) error {
+			return json.Unmarshal(stored, v)
+		})
+		fakeCache.SetPageLayersCalls(func(_ context.Context, _, _ string, v any) error {
+			stored, _ = json.Marshal(v)
+			return nil
+		})
+	})
+}
+
+func layerNames(layers session.Layers) []string {
+	names := make([]string, len(layers))
+	for i, l := range layers {
+		names[i] = l.Name
+	}
+	return names
+}
+
+func Test_controlBlock_AddLayer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("appends and inserts layers", func(t *testing.T) {
+		scb, _, broadcast := setupLayerSession(t)
+
+		first, err := scb.AddLayer(ctx, "pid1", session.LayerRequest{Name: opt.New("first")}, false)
+		require.NoError(t, err)
+		_, err = scb.AddLayer(ctx, "pid1", session.LayerRequest{Name: opt.New("second")}, false)
+		require.NoError(t, err)
+		_, err = scb.AddLayer(ctx, "pid1", session.LayerRequest{Name: opt.New("zeroth"), Index: opt.New(0)}, false)
+		require.NoError(t, err)
+
+		assert.NotEmpty(t, first.ID)
+		assert.True(t, first.Visible)
+		assert.False(t, first.Locked)
+		layers, err := scb.GetLayers(ctx, "pid1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"zeroth", "first", "second"}, layerNames(layers))
+		msg := <-broadcast
+		assert.Equal(t, session.MessageTypePageSync, msg.Type)
+	})
+
+	tests := []struct {
+		name    string
+		pageID  string
+		req     session.LayerRequest
+		host    bool
+		wantErr error
+	}{
+		{name: "missing name", pageID: "pid1", req: session.LayerRequest{}, wantErr: libErr.ErrBadRequest},
+		{name: "empty name", pageID: "pid1", req: session.LayerRequest{Name: opt.New("")}, wantErr: libErr.ErrBadRequest},
+		{name: "invalid page", pageID: "pid2", req: session.LayerRequest{Name: opt.New("layer")}, wantErr: libErr.ErrNotFound},
+		{name: "locked by user", pageID: "pid1", req: session.LayerRequest{Name: opt.New("layer"), Locked: opt.New(true)}, wantErr: libErr.ErrForbidden},
+		{name: "locked by host", pageID: "pid1", req: session.LayerRequest{Name: opt.New("layer"), Locked: opt.New(true)}, host: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scb, fakeCache, _ := setupLayerSession(t)
+
+			_, err := scb.AddLayer(ctx, tt.pageID, tt.req, tt.host)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, 0, fakeCache.SetPageLayersCallCount())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_controlBlock_UpdateLayer(t *testing.T) {
+	ctx := context.Background()
+	newLayers := func() []*session.Layer {
+		return []*session.Layer{
+			{ID: "layer1", Name: "first", Visible: true},
+			{ID: "layer2", Name: "second", Visible: true},
+			{ID: "layer3", Name: "locked", Visible: true, Locked: true},
+		}
+	}
+
+	t.Run("renames and reorders layer", func(t *testing.T) {
+		scb, _, _ := setupLayerSession(t, newLayers()...)
+
+		err := scb.UpdateLayer(ctx, "pid1", "layer1", session.LayerRequest{Name: opt.New("renamed"), Index: opt.New(1), Visible: opt.New(false)}, false)
+
+		require.NoError(t, err)
+		layers, err := scb.GetLayers(ctx, "pid1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"second", "renamed", "locked"}, layerNames(layers))
+		assert.False(t, layers[1].Visible)
+	})
+
+	tests := []struct {
+		name    string
+		layerID string
+		req     session.LayerRequest
+		host    bool
+		wantErr error
+	}{
+		{name: "unknown layer", layerID: "layer4", req: session.LayerRequest{Name: opt.New("name")}, wantErr: libErr.ErrNotFound},
+		{name: "lock by user", layerID: "layer1", req: session.LayerRequest{Locked: opt.New(true)}, wantErr: libErr.ErrForbidden},
+		{name: "rename locked by user", layerID: "layer3", req: session.LayerRequest{Name: opt.New("name")}, wantErr: libErr.ErrForbidden},
+		{name: "lock by host", layerID: "layer1", req: session.LayerRequest{Locked: opt.New(true)}, host: true},
+		{name: "unlock by host", layerID: "layer3", req: session.LayerRequest{Locked: opt.New(false)}, host: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scb, fakeCache, _ := setupLayerSession(t, newLayers()...)
+
+			err := scb.UpdateLayer(ctx, "pid1", tt.layerID, tt.req, tt.host)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, 0, fakeCache.SetPageLayersCallCount())
+				return
+			}
+			require.NoError(t, err)
+			layers, err := scb.GetLayers(ctx, "pid1")
+			require.NoError(t, err)
+			for _, l := range layers {
+				if l.ID == tt.layerID {
+					assert.Equal(t, tt.req.Locked.Unwrap(), l.Locked)
+				}
+			}
+		})
+	}
+}
+
+func Test_controlBlock_DeleteLayer(t *testing.T) {
+	ctx := context.Background()
+	newLayers := func() []*session.Layer {
+		return []*session.Layer{
+			{ID: "layer1", Name: "first", Visible: true},
+			{ID: "layer2", Name: "locked", Visible: true, Locked: true},
+		}
+	}
+
+	t.Run("deletes layer with strokes", func(t *testing.T) {
+		scb, fakeCache, _ := setupLayerSession(t, newLayers()...)
+		var strokes [][]byte
+		for _, s := range []*session.Stroke{
+			{Type: strokeTypePen, ID: "stroke1", PageID: "pid1", LayerID: "layer1", UserID: "user1"},
+			{Type: strokeTypePen, ID: "stroke2", PageID: "pid1", UserID: "user1"},
+			{Type: strokeTypePen, ID: "stroke3", PageID: "pid1", LayerID: "layer2", UserID: "user1"},
+		} {
+			b, _ := json.Marshal(s)
+			strokes = append(strokes, b)
+		}
+		fakeCache.GetPageStrokesReturns(strokes, nil)
+
+		err := scb.DeleteLayer(ctx, "pid1", "layer1", false)
+
+		require.NoError(t, err)
+		_, _, deleted := fakeCache.UpdateStrokesArgsForCall(0)
+		require.Len(t, deleted, 1)
+		assert.Equal(t, "stroke1", deleted[0].Id())
+		assert.True(t, deleted[0].IsDeleted())
+		layers, err := scb.GetLayers(ctx, "pid1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"locked"}, layerNames(layers))
+	})
+
+	t.Run("locked layer by user", func(t *testing.T) {
+		scb, fakeCache, _ := setupLayerSession(t, newLayers()...)
+
+		err := scb.DeleteLayer(ctx, "pid1", "layer2", false)
+
+		assert.ErrorIs(t, err, libErr.ErrForbidden)
+		assert.Equal(t, 0, fakeCache.UpdateStrokesCallCount())
+	})
+
+	t.Run("locked layer by host", func(t *testing.T) {
+		scb, _, _ := setupLayerSession(t, newLayers()...)
+
+		err := scb.DeleteLayer(ctx, "pid1", "layer2", true)
+
+		assert.NoError(t, err)
+	})
+}
+
+func Test_controlBlock_Receive_StrokeLayers(t *testing.T) {
+	ctx := context.Background()
+	layers := []*session.Layer{
+		{ID: "open", Name: "open", Visible: true},
+		{ID: "locked", Name: "locked", Visible: true, Locked: true},
+	}
+
+	tests := []struct {
+		name    string
+		layerID string
+		sender  string
+		wantErr bool
+	}{
+		{name: "base layer", layerID: "", sender: "user1"},
+		{name: "open layer", layerID: "open", sender: "user1"},
+		{name: "locked layer by user", layerID: "locked", sender: "user1", wantErr: true},
+		{name: "locked layer by host", layerID: "locked", sender: "host"},
+		{name: "unknown layer", layerID: "unknown", sender: "host", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scb, _, broadcast := setupLayerSession(t, layers...)
+			stroke := &session.Stroke{Type: strokeTypePen, ID: "stroke1", PageID: "pid1", LayerID: tt.layerID, UserID: tt.sender, Points: session.Points{0, 0, 1, 1}}
+			msg := newStrokeMessage(t, session.JSONCodec, stroke)
+			msg.Sender = tt.sender
+
+			err := scb.Receive(ctx, msg, tt.sender)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Len(t, broadcast, 0)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, broadcast, 1)
+		})
+	}
+}
+
+func Test_controlBlock_Receive_StoredStrokeLayers(t *testing.T) {
+	ctx := context.Background()
+	layers := []*session.Layer{
+		{ID: "open", Name: "open", Visible: true},
+		{ID: "locked", Name: "locked", Visible: true},
+	}
+	stored := &session.Stroke{Type: strokeTypePen, ID: "stroke1", PageID: "pid1", LayerID: "locked", UserID: "user1", Points: session.Points{0, 0, 1, 1}}
+
+	tests := []struct {
+		name    string
+		stroke  session.Stroke
+		sender  string
+		wantErr bool
+	}{
+		{name: "delete by user", stroke: session.Stroke{Type: session.StrokeTypeDelete, ID: "stroke1", PageID: "pid1", UserID: "user1"}, sender: "user1", wantErr: true},
+		{name: "move to open layer by user", stroke: session.Stroke{Type: strokeTypePen, ID: "stroke1", PageID: "pid1", LayerID: "open", UserID: "user1", Points: session.Points{0, 0, 1, 1}}, sender: "user1", wantErr: true},
+		{name: "delete by host", stroke: session.Stroke{Type: session.StrokeTypeDelete, ID: "stroke1", PageID: "pid1", UserID: "host"}, sender: "host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scb, fakeCache, broadcast := setupLayerSession(t, layers...)
+			// the stroke is drawn before the host locks its layer
+			err := scb.Receive(ctx, newStrokeMessage(t, session.JSONCodec, stored), "user1")
+			require.NoError(t, err)
+			err = scb.UpdateLayer(ctx, "pid1", "locked", session.LayerRequest{Locked: opt.New(true)}, true)
+			require.NoError(t, err)
+			for len(broadcast) > 0 {
+				<-broadcast
+			}
+			msg := newStrokeMessage(t, session.JSONCodec, &tt.stroke)
+			msg.Sender = tt.sender
+
+			err = scb.Receive(ctx, msg, tt.sender)
+
+			// the layers are loaded once
+			assert.Equal(t, 1, fakeCache.GetPageLayersCallCount())
+			if tt.wantErr {
+				assert.ErrorIs(t, err, libErr.ErrForbidden)
+				assert.Len(t, broadcast, 0)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, broadcast, 1)
+		})
+	}
+
+	t.Run("fails without layers", func(t *testing.T) {
+		scb, fakeCache, broadcast := setupLayerSession(t, layers...)
+		fakeCache.GetPageLayersReturns(errors.New("unavailable"))
+
+		err := scb.Receive(ctx, newStrokeMessage(t, session.JSONCodec, stored), "user1")
+
+		assert.Error(t, err)
+		assert.Len(t, broadcast, 0)
+		assert.Equal(t, 0, fakeCache.UpdateStrokesCallCount())
+	})
+}
+
+func Test_controlBlock_UpdatePages_LockedLayers(t *testing.T) {
+	ctx := context.Background()
+	for _, op := range []string{"clear", "delete"} {
+		t.Run(op, func(t *testing.T) {
+			scb, fakeCache, _ := setupLayerSession(t, &session.Layer{ID: "locked", Name: "locked", Locked: true})
+			req := session.PageRequest{PageID: []string{"pid1"}}
+
+			err := scb.UpdatePages(ctx, req, op, false)
+
+			assert.ErrorIs(t, err, libErr.ErrForbidden)
+			assert.Zero(t, fakeCache.ClearPageCallCount()+fakeCache.DeletePageCallCount())
+
+			err = scb.UpdatePages(ctx, req, op, true)
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, fakeCache.ClearPageCallCount()+fakeCache.DeletePageCallCount())
+		})
+	}
+}
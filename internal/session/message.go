@@ -5,6 +5,8 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 // Message declares the generic message envelope
@@ -64,15 +66,17 @@ func UnmarshalMessage(data []byte) (*Message, error) {
 	return &msg, nil
 }
 
-// UnmarshalContent parses the JSON-encoded content of a Message and
+// UnmarshalContent parses the encoded content of a Message and
 // stores the result in the value pointed to by v.
+//
+// The content is decoded with the codec the message was received with.
 func (m *Message) UnmarshalContent(v any) error {
-	c, ok := (m.Content.(*json.RawMessage))
-	if !ok {
+	switch c := m.Content.(type) {
+	case *json.RawMessage:
+		return json.Unmarshal(*c, v)
+	case msgpack.RawMessage:
+		return unmarshalMsgpack(c, v)
+	default:
 		return errors.New("cannot unmarshal content")
 	}
-	if err := json.Unmarshal(*c, v); err != nil {
-		return err
-	}
-	return nil
 }
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/google/uuid"
+
 	libErr "github.com/boardsite-io/server/pkg/errors"
 	"github.com/boardsite-io/server/pkg/log"
 	"github.com/boardsite-io/server/pkg/redis"
@@ -14,12 +16,25 @@ import (
 
 const (
 	QueryKeyUpdate = "update"
+	QueryKeyMinX   = "minX"
+	QueryKeyMinY   = "minY"
+	QueryKeyMaxX   = "maxX"
+	QueryKeyMaxY   = "maxY"
+	QueryKeyQuery  = "q"
+	QueryKeyLimit  = "limit"
+	QueryKeyWidth  = "width"
+	QueryKeyForce  = "force"
+	// QueryKeyExpires and QueryKeySignature authorize signed attachment URLs
+	QueryKeyExpires   = "expires"
+	QueryKeySignature = "signature"
 )
 
 const (
-	updateOperationMeta   = "meta"
-	updateOperationClear  = "clear"
-	updateOperationDelete = "delete"
+	updateOperationMeta      = "meta"
+	updateOperationClear     = "clear"
+	updateOperationDelete    = "delete"
+	updateOperationMove      = "move"
+	updateOperationDuplicate = "duplicate"
 )
 
 // PageStyle declares the style of the page background.
@@ -44,6 +59,7 @@ type PageMeta struct {
 type Page struct {
 	PageId  string     `json:"pageId"`
 	Meta    *PageMeta  `json:"meta"`
+	Layers  Layers     `json:"layers,omitempty"`
 	Strokes *[]*Stroke `json:"strokes,omitempty"` //nullable
 }
 
@@ -53,11 +69,15 @@ type PageRequest struct {
 	Index   []int                          `json:"index,omitempty"`
 	Meta    map[string]*PageMeta           `json:"meta"`
 	Strokes *map[string]map[string]*Stroke `json:"strokes,omitempty"`
+	// NewPageID declares the ids of duplicated pages
+	NewPageID []string `json:"newPageId,omitempty"`
 }
 
 type PageSync struct {
 	PageRank []string         `json:"pageRank"`
 	Pages    map[string]*Page `json:"pages"`
+	// Chat is only part of board exports
+	Chat []*ChatMessage `json:"chat,omitempty"`
 }
 
 func (scb *controlBlock) GetPageRank(ctx context.Context) ([]string, error) {
@@ -75,6 +95,13 @@ func (scb *controlBlock) GetPage(ctx context.Context, pageId string, withStrokes
 		return nil, err
 	}
 
+	if page.Layers, err = scb.getLayers(ctx, pageId); err != nil {
+		return nil, err
+	}
+	if len(page.Layers) == 0 {
+		page.Layers = nil
+	}
+
 	if withStrokes {
 		strokes, err := scb.getStrokes(ctx, pageId)
 		if err != nil {
@@ -96,13 +123,29 @@ func (scb *controlBlock) AddPages(ctx context.Context, pageRequest PageRequest)
 		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("some pages already exist"))
 	}
 
-	defer scb.broadcastPageSync(ctx, pageRequest.PageID, pageRequest.Strokes != nil)
-
-	for i, pid := range pageRequest.PageID {
+	for _, pid := range pageRequest.PageID {
 		pMeta, ok := pageRequest.Meta[pid]
 		if !ok {
 			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("no meta given for page %s", pid))
 		}
+		if pageRequest.Strokes == nil {
+			continue
+		}
+		for _, s := range (*pageRequest.Strokes)[pid] {
+			if err := scb.validatePageStroke(pid, pMeta, s); err != nil {
+				return err
+			}
+			// new pages have no layers yet
+			if err := (Layers{}).checkStroke(s, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	defer scb.broadcastPageSync(ctx, pageRequest.PageID, pageRequest.Strokes != nil)
+
+	for i, pid := range pageRequest.PageID {
+		pMeta := pageRequest.Meta[pid]
 		if err := scb.cache.AddPage(ctx, scb.cfg.ID, pid, pageRequest.Index[i], pMeta); err != nil {
 			return errors.New("cannot add page")
 		}
@@ -118,6 +161,7 @@ func (scb *controlBlock) AddPages(ctx context.Context, pageRequest PageRequest)
 			if err := scb.cache.UpdateStrokes(ctx, scb.cfg.ID, strokes...); err != nil {
 				return fmt.Errorf("update page strokes: %w", err)
 			}
+			scb.indexStrokes(strokes...)
 		}
 	}
 
@@ -125,17 +169,31 @@ func (scb *controlBlock) AddPages(ctx context.Context, pageRequest PageRequest)
 }
 
 // UpdatePages modifies the page meta data and/or clears the content.
-func (scb *controlBlock) UpdatePages(ctx context.Context, pageRequest PageRequest, operation string) error {
+//
+// Pages with locked layers can only be cleared or deleted by the host.
+func (scb *controlBlock) UpdatePages(ctx context.Context, pageRequest PageRequest, operation string, host bool) error {
 	switch operation {
 	case updateOperationMeta:
 		return scb.updatePagesMeta(ctx, pageRequest.Meta)
 
 	case updateOperationDelete:
+		if err := scb.checkUnlockedPages(ctx, host, pageRequest.PageID...); err != nil {
+			return err
+		}
 		return scb.deletePages(ctx, pageRequest.PageID...)
 
 	case updateOperationClear:
+		if err := scb.checkUnlockedPages(ctx, host, pageRequest.PageID...); err != nil {
+			return err
+		}
 		return scb.clearPages(ctx, pageRequest.PageID...)
 
+	case updateOperationMove:
+		return scb.movePages(ctx, pageRequest.PageID, pageRequest.Index)
+
+	case updateOperationDuplicate:
+		return scb.duplicatePages(ctx, pageRequest.PageID, pageRequest.NewPageID, pageRequest.Index)
+
 	default:
 		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("unknown operation: %s", operation))
 	}
@@ -161,21 +219,45 @@ func (scb *controlBlock) GetPageSync(ctx context.Context, pageIds []string, with
 }
 
 func (scb *controlBlock) SyncSession(ctx context.Context, sync PageSync) error {
+	for _, pid := range sync.PageRank {
+		page, ok := sync.Pages[pid]
+		if !ok {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("page %s not found", pid))
+		}
+		if len(page.Layers) > maxLayers {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("page cannot have more than %d layers", maxLayers))
+		}
+		if page.Strokes == nil {
+			continue
+		}
+		for _, s := range *page.Strokes {
+			if err := scb.validatePageStroke(pid, page.Meta, s); err != nil {
+				return err
+			}
+			if err := page.Layers.checkStroke(s, true); err != nil {
+				return err
+			}
+		}
+	}
+
 	if err := scb.cache.ClearSession(ctx, scb.cfg.ID); err != nil {
 		return err
 	}
+	scb.resetIndex()
+	scb.resetThumbnails()
 
 	defer scb.broadcastPageSync(ctx, sync.PageRank, true)
 
 	for _, pid := range sync.PageRank {
-		page, ok := sync.Pages[pid]
-		if !ok {
-			return fmt.Errorf("page %s not found", pid)
-		}
-
+		page := sync.Pages[pid]
 		if err := scb.cache.AddPage(ctx, scb.cfg.ID, pid, -1, page.Meta); err != nil {
 			return err
 		}
+		if len(page.Layers) > 0 {
+			if err := scb.setLayers(ctx, pid, page.Layers); err != nil {
+				return err
+			}
+		}
 
 		var strokes []redis.Stroke
 		if page.Strokes != nil {
@@ -188,6 +270,7 @@ func (scb *controlBlock) SyncSession(ctx context.Context, sync PageSync) error {
 		if err := scb.cache.UpdateStrokes(ctx, scb.cfg.ID, strokes...); err != nil {
 			return err
 		}
+		scb.indexStrokes(strokes...)
 	}
 
 	return nil
@@ -211,7 +294,11 @@ func (scb *controlBlock) getStrokes(ctx context.Context, pageId string) ([]*Stro
 	if err != nil {
 		return nil, err
 	}
+	return parseStrokes(strokeBytes)
+}
 
+// parseStrokes decodes the JSON encoded strokes of the cache.
+func parseStrokes(strokeBytes [][]byte) ([]*Stroke, error) {
 	strokes := make([]*Stroke, len(strokeBytes))
 	for i, s := range strokeBytes {
 		var stroke Stroke
@@ -235,6 +322,34 @@ func (scb *controlBlock) IsValidPage(ctx context.Context, pageID ...string) bool
 	return true
 }
 
+// validatePageStroke validates a stroke that is added along with its page.
+//
+// Strokes without a pageId are assigned to the page.
+func (scb *controlBlock) validatePageStroke(pageID string, meta *PageMeta, stroke *Stroke) error {
+	if stroke == nil {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid stroke on page %s", pageID))
+	}
+	if stroke.PageID == "" {
+		stroke.PageID = pageID
+	} else if stroke.PageID != pageID {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("stroke %s does not belong to page %s", stroke.ID, pageID))
+	}
+	var size PageSize
+	if meta != nil {
+		size = meta.PageSize
+	}
+	return stroke.validate(scb.cfg.Strokes, size)
+}
+
+// getPageSize returns the size of the page or the zero size if unknown.
+func (scb *controlBlock) getPageSize(ctx context.Context, pageID string) PageSize {
+	var meta PageMeta
+	if err := scb.cache.GetPageMeta(ctx, scb.cfg.ID, pageID, &meta); err != nil {
+		return PageSize{}
+	}
+	return meta.PageSize
+}
+
 // GetPagesSet returns all pageIDs in a map for fast verification.
 func (scb *controlBlock) getPagesSet(ctx context.Context) map[string]struct{} {
 	pageIDs, _ := scb.cache.GetPageRank(ctx, scb.cfg.ID)
@@ -270,6 +385,7 @@ func (scb *controlBlock) updatePagesMeta(ctx context.Context, meta map[string]*P
 		if err := scb.cache.SetPageMeta(ctx, scb.cfg.ID, pid, newMeta); err != nil {
 			return err
 		}
+		scb.dropThumbnails(pid)
 
 		updates = append(updates, pid)
 	}
@@ -293,7 +409,10 @@ func (scb *controlBlock) deletePages(ctx context.Context, pageID ...string) erro
 		}
 		if err := scb.cache.DeletePage(ctx, scb.cfg.ID, pid); err != nil {
 			sb.WriteString(fmt.Sprintf(": cannot delete page %s", pageID))
+			continue
 		}
+		scb.dropIndex(pid)
+		scb.dropThumbnails(pid)
 	}
 
 	if sb.Len() > 0 {
@@ -309,6 +428,76 @@ func (scb *controlBlock) clearPages(ctx context.Context, pageIds ...string) erro
 		if err := scb.cache.ClearPage(ctx, scb.cfg.ID, pid); err != nil {
 			return fmt.Errorf("clear page %s: %w", pid, err)
 		}
+		scb.dropIndex(pid)
+		scb.dropThumbnails(pid)
+	}
+	return nil
+}
+
+// movePages moves the pages to the given indices in order and broadcasts
+// the new page rank to all connected clients.
+func (scb *controlBlock) movePages(ctx context.Context, pageIds []string, index []int) error {
+	if len(pageIds) != len(index) {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("cannot find page index"))
+	}
+	if !scb.IsValidPage(ctx, pageIds...) {
+		return libErr.ErrNotFound.Wrap(libErr.WithErrorf("some pages do not exist"))
+	}
+
+	defer scb.broadcastPageSync(ctx, nil, false)
+	for i, pid := range pageIds {
+		if err := scb.cache.MovePage(ctx, scb.cfg.ID, pid, index[i]); err != nil {
+			return fmt.Errorf("move page %s: %w", pid, err)
+		}
+	}
+	return nil
+}
+
+// duplicatePages copies the pages including their strokes to new pages at
+// the given indices and broadcasts the new pages to all connected clients.
+//
+// The ids of the new pages are generated if not given.
+func (scb *controlBlock) duplicatePages(ctx context.Context, pageIds, newPageIds []string, index []int) error {
+	if len(pageIds) != len(index) {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("cannot find page index"))
+	}
+	if len(newPageIds) == 0 {
+		newPageIds = make([]string, len(pageIds))
+		for i := range newPageIds {
+			newPageIds[i] = uuid.NewString()
+		}
+	}
+	if len(pageIds) != len(newPageIds) {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("cannot find new page id"))
+	}
+	if !scb.IsValidPage(ctx, pageIds...) {
+		return libErr.ErrNotFound.Wrap(libErr.WithErrorf("some pages do not exist"))
+	}
+	pageIDSet := scb.getPagesSet(ctx)
+	for _, pid := range newPageIds {
+		if _, ok := pageIDSet[pid]; ok || pid == "" {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid new page id %s", pid))
+		}
+		pageIDSet[pid] = struct{}{}
+	}
+
+	duplicated := make([]string, 0, len(pageIds))
+	defer func() { scb.broadcastPageSync(ctx, duplicated, true) }()
+	for i, pid := range pageIds {
+		newPid := newPageIds[i]
+		if err := scb.cache.DuplicatePage(ctx, scb.cfg.ID, pid, newPid, index[i]); err != nil {
+			return fmt.Errorf("duplicate page %s: %w", pid, err)
+		}
+		duplicated = append(duplicated, newPid)
+		strokes, err := scb.getStrokes(ctx, newPid)
+		if err != nil {
+			return err
+		}
+		updates := make([]redis.Stroke, len(strokes))
+		for j, s := range strokes {
+			updates[j] = s
+		}
+		scb.indexStrokes(updates...)
 	}
 	return nil
 }
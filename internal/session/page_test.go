@@ -6,10 +6,13 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/boardsite-io/server/internal/attachment/attachmentfakes"
 	"github.com/boardsite-io/server/internal/session"
 	"github.com/boardsite-io/server/internal/session/sessionfakes"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/geometry"
 	"github.com/boardsite-io/server/pkg/redis"
 	"github.com/boardsite-io/server/pkg/redis/redisfakes"
 )
@@ -78,6 +81,25 @@ func Test_controlBlock_AddPages(t *testing.T) {
 		err := scb.AddPages(ctx, pageRequest)
 
 		assert.NoError(t, err)
+		assert.Equal(t, "pid1", mockStroke.PageID)
+	})
+
+	t.Run("rejects invalid strokes", func(t *testing.T) {
+		addPageCalls := fakeCache.AddPageCallCount()
+		meta := &session.PageMeta{PageSize: session.PageSize{768, 1024}}
+		pageRequest := session.PageRequest{
+			PageID: []string{"pid2"},
+			Index:  []int{-1},
+			Meta:   map[string]*session.PageMeta{"pid2": meta},
+			Strokes: &map[string]map[string]*session.Stroke{
+				"pid2": {"stroke1": {Type: strokeTypePen, ID: "stroke1", Style: session.Style{Color: "blue"}}},
+			},
+		}
+
+		err := scb.AddPages(ctx, pageRequest)
+
+		assert.Error(t, err)
+		assert.Equal(t, addPageCalls, fakeCache.AddPageCallCount())
 	})
 }
 
@@ -180,7 +202,7 @@ func Test_controlBlock_UpdatePages(t *testing.T) {
 			return nil
 		})
 
-		err = scb.UpdatePages(ctx, pageRequest, "meta")
+		err = scb.UpdatePages(ctx, pageRequest, "meta", true)
 
 		assert.NoError(t, err)
 		assert.Equal(t, 2, calls)
@@ -199,7 +221,7 @@ func Test_controlBlock_UpdatePages(t *testing.T) {
 		fakeCache.GetPageRankReturnsOnCall(1, []string{"pid1", "pid2"}, nil)
 		fakeCache.GetPageRankReturnsOnCall(2, []string{}, nil)
 
-		err = scb.UpdatePages(ctx, pageRequest, "delete")
+		err = scb.UpdatePages(ctx, pageRequest, "delete", true)
 
 		assert.NoError(t, err)
 
@@ -225,7 +247,7 @@ func Test_controlBlock_UpdatePages(t *testing.T) {
 		}
 		fakeCache.GetPageRankReturns([]string{"pid1", "pid2"}, nil)
 
-		err = scb.UpdatePages(ctx, pageRequest, "clear")
+		err = scb.UpdatePages(ctx, pageRequest, "clear", true)
 
 		assert.NoError(t, err)
 
@@ -240,13 +262,120 @@ func Test_controlBlock_UpdatePages(t *testing.T) {
 		assert.Equal(t, 2, fakeCache.GetPageMetaCallCount())
 	})
 
+	t.Run("moves pages", func(t *testing.T) {
+		for len(broadcast) > 0 { // drain previous broadcasts
+			<-broadcast
+		}
+		fakeCache := &redisfakes.FakeHandler{}
+		scb, err := session.NewControlBlock(session.Config{ID: sessionId}, session.WithCache(fakeCache), session.WithAttachments(fakeAttachments),
+			session.WithDispatcher(fakeDispatcher), session.WithBroadcaster(fakeBroadcaster))
+		assert.NoError(t, err)
+
+		pageRequest := session.PageRequest{
+			PageID: []string{"pid2", "pid1"},
+			Index:  []int{0, -1},
+		}
+		fakeCache.GetPageRankReturns([]string{"pid1", "pid2"}, nil)
+
+		err = scb.UpdatePages(ctx, pageRequest, "move", true)
+
+		assert.NoError(t, err)
+		require.Equal(t, 2, fakeCache.MovePageCallCount())
+		_, sid, pid, index := fakeCache.MovePageArgsForCall(0)
+		assert.Equal(t, sessionId, sid)
+		assert.Equal(t, "pid2", pid)
+		assert.Equal(t, 0, index)
+		_, _, pid, index = fakeCache.MovePageArgsForCall(1)
+		assert.Equal(t, "pid1", pid)
+		assert.Equal(t, -1, index)
+		msg := <-broadcast
+		assert.Equal(t, session.MessageTypePageSync, msg.Type)
+		assert.Len(t, broadcast, 0)
+	})
+
+	t.Run("move rejects unknown pages", func(t *testing.T) {
+		fakeCache := &redisfakes.FakeHandler{}
+		scb, err := session.NewControlBlock(session.Config{ID: sessionId}, session.WithCache(fakeCache), session.WithAttachments(fakeAttachments),
+			session.WithDispatcher(fakeDispatcher), session.WithBroadcaster(fakeBroadcaster))
+		assert.NoError(t, err)
+		fakeCache.GetPageRankReturns([]string{"pid1"}, nil)
+
+		err = scb.UpdatePages(ctx, session.PageRequest{PageID: []string{"pid2"}, Index: []int{0}}, "move", true)
+
+		assert.ErrorIs(t, err, libErr.ErrNotFound)
+		assert.Equal(t, 0, fakeCache.MovePageCallCount())
+	})
+
+	t.Run("duplicates pages", func(t *testing.T) {
+		for len(broadcast) > 0 { // drain previous broadcasts
+			<-broadcast
+		}
+		fakeCache := &redisfakes.FakeHandler{}
+		scb, err := session.NewControlBlock(session.Config{ID: sessionId}, session.WithCache(fakeCache), session.WithAttachments(fakeAttachments),
+			session.WithDispatcher(fakeDispatcher), session.WithBroadcaster(fakeBroadcaster))
+		assert.NoError(t, err)
+
+		fakeCache.GetPageRankReturns([]string{"pid1", "pid2"}, nil)
+		stroke, _ := json.Marshal(&session.Stroke{Type: strokeTypePen, ID: "stroke1", PageID: "pid3", Points: session.Points{0, 0, 1, 1}})
+		fakeCache.GetPageStrokesReturns([][]byte{stroke}, nil)
+
+		err = scb.UpdatePages(ctx, session.PageRequest{PageID: []string{"pid1"}, NewPageID: []string{"pid3"}, Index: []int{1}}, "duplicate", true)
+
+		assert.NoError(t, err)
+		require.Equal(t, 1, fakeCache.DuplicatePageCallCount())
+		_, sid, pid, newPid, index := fakeCache.DuplicatePageArgsForCall(0)
+		assert.Equal(t, sessionId, sid)
+		assert.Equal(t, "pid1", pid)
+		assert.Equal(t, "pid3", newPid)
+		assert.Equal(t, 1, index)
+		msg := <-broadcast
+		assert.Equal(t, session.MessageTypePageSync, msg.Type)
+		assert.Contains(t, msg.Content.(*session.PageSync).Pages, "pid3")
+
+		// the strokes of the new page are indexed
+		fakeCache.GetPageRankReturns([]string{"pid1", "pid3", "pid2"}, nil)
+		_, err = scb.GetStrokesInRect(ctx, "pid3", geometry.Rect{Max: geometry.Point{X: 10, Y: 10}})
+		require.NoError(t, err)
+		_, _, queriedPid, queried := fakeCache.GetStrokesArgsForCall(0)
+		assert.Equal(t, "pid3", queriedPid)
+		assert.Equal(t, []string{"stroke1"}, queried)
+	})
+
+	t.Run("duplicate generates page ids", func(t *testing.T) {
+		fakeCache := &redisfakes.FakeHandler{}
+		scb, err := session.NewControlBlock(session.Config{ID: sessionId}, session.WithCache(fakeCache), session.WithAttachments(fakeAttachments),
+			session.WithDispatcher(fakeDispatcher), session.WithBroadcaster(fakeBroadcaster))
+		assert.NoError(t, err)
+		fakeCache.GetPageRankReturns([]string{"pid1"}, nil)
+
+		err = scb.UpdatePages(ctx, session.PageRequest{PageID: []string{"pid1"}, Index: []int{-1}}, "duplicate", true)
+
+		assert.NoError(t, err)
+		_, _, _, newPid, _ := fakeCache.DuplicatePageArgsForCall(0)
+		assert.NotEmpty(t, newPid)
+		<-broadcast
+	})
+
+	t.Run("duplicate rejects existing page ids", func(t *testing.T) {
+		fakeCache := &redisfakes.FakeHandler{}
+		scb, err := session.NewControlBlock(session.Config{ID: sessionId}, session.WithCache(fakeCache), session.WithAttachments(fakeAttachments),
+			session.WithDispatcher(fakeDispatcher), session.WithBroadcaster(fakeBroadcaster))
+		assert.NoError(t, err)
+		fakeCache.GetPageRankReturns([]string{"pid1", "pid2"}, nil)
+
+		err = scb.UpdatePages(ctx, session.PageRequest{PageID: []string{"pid1"}, NewPageID: []string{"pid2"}, Index: []int{0}}, "duplicate", true)
+
+		assert.ErrorIs(t, err, libErr.ErrBadRequest)
+		assert.Equal(t, 0, fakeCache.DuplicatePageCallCount())
+	})
+
 	t.Run("unknown operation", func(t *testing.T) {
 		fakeCache := &redisfakes.FakeHandler{}
 		scb, err := session.NewControlBlock(session.Config{ID: sessionId}, session.WithCache(fakeCache), session.WithAttachments(fakeAttachments),
 			session.WithDispatcher(fakeDispatcher), session.WithBroadcaster(fakeBroadcaster))
 		assert.NoError(t, err)
 
-		err = scb.UpdatePages(ctx, session.PageRequest{}, "test")
+		err = scb.UpdatePages(ctx, session.PageRequest{}, "test", true)
 		assert.Error(t, err)
 	})
 }
@@ -307,3 +436,32 @@ func Test_controlBlock_SyncSession(t *testing.T) {
 	assert.Equal(t, sessionId, sid)
 	assert.Equal(t, (*sync.Pages["pid2"].Strokes)[0], strokes[0].(*session.Stroke))
 }
+
+func Test_controlBlock_SyncSession_InvalidStrokes(t *testing.T) {
+	ctx := context.Background()
+	fakeCache := &redisfakes.FakeHandler{}
+	fakeBroadcaster := &sessionfakes.FakeBroadcaster{}
+
+	scb, err := session.NewControlBlock(session.Config{ID: "sid1"}, session.WithCache(fakeCache), session.WithAttachments(&attachmentfakes.FakeHandler{}),
+		session.WithDispatcher(&sessionfakes.FakeDispatcher{}), session.WithBroadcaster(fakeBroadcaster))
+	assert.NoError(t, err)
+
+	sync := session.PageSync{
+		PageRank: []string{"pid1"},
+		Pages: map[string]*session.Page{
+			"pid1": {
+				PageId: "pid1",
+				Meta:   &session.PageMeta{PageSize: session.PageSize{768, 1024}},
+				Strokes: &[]*session.Stroke{
+					{Type: strokeTypePen, ID: "stroke1", Points: session.Points{0, 0, 5000, 0}},
+				},
+			},
+		},
+	}
+
+	err = scb.SyncSession(ctx, sync)
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, fakeCache.ClearSessionCallCount())
+	assert.Equal(t, 0, fakeCache.AddPageCallCount())
+}
@@ -0,0 +1,129 @@
+package session
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/boardsite-io/server/internal/config"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+)
+
+// Activity states of users, which are derived from their message traffic.
+const (
+	ActivityActive = "active"
+	ActivityIdle   = "idle"
+	ActivityAway   = "away"
+)
+
+// maxToolLength is the maximum length of the tool name in runes
+const maxToolLength = 32
+
+// Presence declares where users are on the board and what they are doing.
+type Presence struct {
+	// PageID is the page of the user's cursor
+	PageID string `json:"pageId,omitempty"`
+	// Tool is the active tool of the user
+	Tool     string `json:"tool,omitempty"`
+	Activity string `json:"activity,omitempty"`
+	// lastActive is the time of the last message of the user
+	lastActive time.Time
+}
+
+// activityAt returns the activity state of the presence at the given time.
+func (p *Presence) activityAt(now time.Time, cfg config.Presence) string {
+	inactive := now.Sub(p.lastActive)
+	switch {
+	case cfg.AwayAfter > 0 && inactive >= cfg.AwayAfter:
+		return ActivityAway
+	case cfg.IdleAfter > 0 && inactive >= cfg.IdleAfter:
+		return ActivityIdle
+	default:
+		return ActivityActive
+	}
+}
+
+func validateTool(tool string) error {
+	if utf8.RuneCountInString(tool) > maxToolLength {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("tool exceeds %d characters", maxToolLength))
+	}
+	return nil
+}
+
+// touch marks the user as active. The page and tool of the user are updated
+// unless empty. Any change of the presence is broadcast as usersync delta.
+func (scb *controlBlock) touch(userID, pageID, tool string) {
+	scb.muUsr.Lock()
+	u, ok := scb.users[userID]
+	if !ok {
+		scb.muUsr.Unlock()
+		return
+	}
+	u.lastActive = time.Now()
+	changed := u.Activity != ActivityActive
+	u.Activity = ActivityActive
+	if pageID != "" && pageID != u.PageID {
+		u.PageID = pageID
+		changed = true
+	}
+	if tool != "" && tool != u.Tool {
+		u.Tool = tool
+		changed = true
+	}
+	user := *u
+	scb.muUsr.Unlock()
+
+	if changed {
+		scb.broadcastUsers(map[string]*User{userID: &user})
+	}
+}
+
+// updateActivity demotes users to idle or away once they have not sent
+// messages for the configured thresholds.
+func (scb *controlBlock) updateActivity(now time.Time) {
+	delta := make(map[string]*User)
+	scb.muUsr.Lock()
+	for id, u := range scb.users {
+		if activity := u.activityAt(now, scb.cfg.Presence); activity != u.Activity {
+			u.Activity = activity
+			user := *u
+			delta[id] = &user
+		}
+	}
+	scb.muUsr.Unlock()
+
+	if len(delta) > 0 {
+		scb.broadcastUsers(delta)
+	}
+}
+
+// presenceLoop periodically updates the activity state of the users
+// until the session is closed.
+func (scb *controlBlock) presenceLoop() {
+	interval := scb.cfg.Presence.IdleAfter
+	if away := scb.cfg.Presence.AwayAfter; interval <= 0 || (away > 0 && away < interval) {
+		interval = away
+	}
+	if interval <= 0 { // activity states are disabled
+		return
+	}
+
+	ticker := time.NewTicker(interval / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			scb.updateActivity(now)
+		case <-scb.done:
+			return
+		}
+	}
+}
+
+// broadcastUsers broadcasts the given users to all users. Clients merge
+// the users of usersync messages by their id.
+func (scb *controlBlock) broadcastUsers(users map[string]*User) {
+	scb.broadcaster.Broadcast() <- Message{
+		Type:    MessageTypeUserSync,
+		Content: users,
+	}
+}
@@ -0,0 +1,121 @@
+package session_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/attachment/attachmentfakes"
+	"github.com/boardsite-io/server/internal/config"
+	"github.com/boardsite-io/server/internal/session"
+	"github.com/boardsite-io/server/internal/session/sessionfakes"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/redis/redisfakes"
+)
+
+// setupPresenceSession returns a session with page "pid1" and a connected user.
+func setupPresenceSession(t *testing.T, presence config.Presence) (session.Controller, *session.User, chan session.Message) {
+	fakeCache := &redisfakes.FakeHandler{}
+	fakeCache.GetPageRankReturns([]string{"pid1"}, nil)
+	broadcast := make(chan session.Message, 10)
+	fakeBroadcaster := &sessionfakes.FakeBroadcaster{}
+	fakeBroadcaster.BroadcastReturns(broadcast)
+	fakeBroadcaster.SendReturns(make(chan session.Message, 10))
+	scb, err := session.NewControlBlock(session.Config{ID: "sid1", Session: config.Session{MaxUsers: 10, Presence: presence}}, session.WithCache(fakeCache),
+		session.WithAttachments(&attachmentfakes.FakeHandler{}), session.WithDispatcher(&sessionfakes.FakeDispatcher{}), session.WithBroadcaster(fakeBroadcaster))
+	require.NoError(t, err)
+	user, err := scb.NewUser(session.UserRequest{User: session.User{Alias: "user", Color: "#00ff00"}})
+	require.NoError(t, err)
+	require.NoError(t, scb.UserConnect(user.ID, nil))
+	msg := <-broadcast
+	require.Equal(t, session.MessageTypeUserConnected, msg.Type)
+	assert.Equal(t, session.ActivityActive, msg.Content.(*session.User).Activity)
+	return scb, user, broadcast
+}
+
+func newMouseMoveMessage(t *testing.T, content session.ContentMouseMove, sender string) *session.Message {
+	data, err := session.JSONCodec.Marshal(session.NewMessage(content, session.MessageTypeMouseMove, sender))
+	require.NoError(t, err)
+	msg, err := session.JSONCodec.Unmarshal(data)
+	require.NoError(t, err)
+	return msg
+}
+
+func Test_controlBlock_Receive_MouseMovePresence(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("broadcasts presence changes", func(t *testing.T) {
+		scb, user, broadcast := setupPresenceSession(t, config.Presence{})
+		content := session.ContentMouseMove{X: 1, Y: 2, PageID: "pid1", Tool: "pen"}
+
+		err := scb.Receive(ctx, newMouseMoveMessage(t, content, user.ID), user.ID)
+
+		require.NoError(t, err)
+		got := scb.GetUsers()[user.ID]
+		assert.Equal(t, "pid1", got.PageID)
+		assert.Equal(t, "pen", got.Tool)
+		msg := <-broadcast
+		assert.Equal(t, session.MessageTypeUserSync, msg.Type)
+		delta := msg.Content.(map[string]*session.User)
+		assert.Len(t, delta, 1)
+		assert.Equal(t, "pid1", delta[user.ID].PageID)
+		msg = <-broadcast
+		assert.Equal(t, session.MessageTypeMouseMove, msg.Type)
+		assert.Equal(t, content, msg.Content)
+
+		// unchanged presence is not broadcast again
+		err = scb.Receive(ctx, newMouseMoveMessage(t, session.ContentMouseMove{X: 3, Y: 4, PageID: "pid1"}, user.ID), user.ID)
+
+		require.NoError(t, err)
+		msg = <-broadcast
+		assert.Equal(t, session.MessageTypeMouseMove, msg.Type)
+		assert.Len(t, broadcast, 0)
+		assert.Equal(t, "pen", scb.GetUsers()[user.ID].Tool)
+	})
+
+	tests := []struct {
+		name    string
+		content session.ContentMouseMove
+		wantErr error
+	}{
+		{name: "unknown page", content: session.ContentMouseMove{PageID: "pid2"}, wantErr: libErr.ErrNotFound},
+		{name: "long tool", content: session.ContentMouseMove{PageID: "pid1", Tool: strings.Repeat("t", 33)}, wantErr: libErr.ErrBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scb, user, broadcast := setupPresenceSession(t, config.Presence{})
+
+			err := scb.Receive(ctx, newMouseMoveMessage(t, tt.content, user.ID), user.ID)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Empty(t, scb.GetUsers()[user.ID].PageID)
+			// the user remains active without a presence change
+			assert.Len(t, broadcast, 0)
+		})
+	}
+}
+
+func Test_controlBlock_Activity(t *testing.T) {
+	ctx := context.Background()
+	scb, user, broadcast := setupPresenceSession(t, config.Presence{IdleAfter: 20 * time.Millisecond, AwayAfter: 60 * time.Millisecond})
+	defer scb.Close()
+	activity := func(t *testing.T) string {
+		msg := <-broadcast
+		require.Equal(t, session.MessageTypeUserSync, msg.Type)
+		return msg.Content.(map[string]*session.User)[user.ID].Activity
+	}
+
+	assert.Equal(t, session.ActivityIdle, activity(t))
+	assert.Equal(t, session.ActivityAway, activity(t))
+	assert.Equal(t, session.ActivityAway, scb.GetUsers()[user.ID].Activity)
+
+	// any message traffic returns the user to active
+	err := scb.Receive(ctx, newMouseMoveMessage(t, session.ContentMouseMove{X: 1, Y: 2}, user.ID), user.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, session.ActivityActive, activity(t))
+}
@@ -2,15 +2,17 @@ package session
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
+	"io"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
-	gws "github.com/gorilla/websocket"
 
 	"github.com/boardsite-io/server/internal/attachment"
 	"github.com/boardsite-io/server/internal/config"
+	"github.com/boardsite-io/server/pkg/geometry"
 	"github.com/boardsite-io/server/pkg/redis"
 )
 
@@ -25,6 +27,8 @@ type CreateSessionResponse struct {
 type GetConfigResponse struct {
 	Users  map[string]*User `json:"users"`
 	Config `json:"config"`
+	// Viewport is the last known viewport of the host
+	Viewport *ContentViewport `json:"viewport,omitempty"`
 }
 
 //counterfeiter:generate . Controller
@@ -44,13 +48,59 @@ type Controller interface {
 	AddPages(ctx context.Context, pageRequest PageRequest) error
 	// UpdatePages perform an operation on the given pages.
 	// Operations include: clear, delete and update meta data
-	UpdatePages(ctx context.Context, pageRequest PageRequest, operation string) error
+	UpdatePages(ctx context.Context, pageRequest PageRequest, operation string, host bool) error
 	// GetPageSync returns the page rank and all pages from the session (optionally with all strokes)
 	GetPageSync(ctx context.Context, pageIds []string, withStrokes bool) (*PageSync, error)
 	// SyncSession synchronizes the session with the given page rank and pages
 	SyncSession(ctx context.Context, sync PageSync) error
 	// IsValidPage checks if the given page ids are valid pages
 	IsValidPage(ctx context.Context, pageID ...string) bool
+	// GetStrokesInRect returns the strokes of a page intersecting the rectangle
+	GetStrokesInRect(ctx context.Context, pageId string, rect geometry.Rect) ([]*Stroke, error)
+	// GetStrokesInPolygon returns the strokes of a page hit by the polygon
+	GetStrokesInPolygon(ctx context.Context, pageId string, polygon geometry.Polygon) ([]*Stroke, error)
+	// PageThumbnail returns a PNG thumbnail of a page
+	PageThumbnail(ctx context.Context, pageId string, width int) (*Thumbnail, error)
+	// Search finds the textfields containing the words of the query across all pages
+	Search(ctx context.Context, query string, limit int) ([]*SearchResult, error)
+	// GetLayers returns the ordered layers of a page
+	GetLayers(ctx context.Context, pageId string) (Layers, error)
+	// AddLayer adds a layer to a page
+	AddLayer(ctx context.Context, pageId string, req LayerRequest, host bool) (*Layer, error)
+	// UpdateLayer renames, reorders, hides or locks a layer
+	UpdateLayer(ctx context.Context, pageId, layerId string, req LayerRequest, host bool) error
+	// DeleteLayer deletes a layer and its strokes
+	DeleteLayer(ctx context.Context, pageId, layerId string, host bool) error
+
+	// GetComments returns the comment threads of a page
+	GetComments(ctx context.Context, pageId string) ([]*CommentThread, error)
+	// AddCommentThread starts a comment thread on a page
+	AddCommentThread(ctx context.Context, pageId string, user User, req CommentThreadRequest) (*CommentThread, error)
+	// ReplyComment adds a comment to a comment thread
+	ReplyComment(ctx context.Context, pageId, threadId string, user User, req CommentRequest) (*Comment, error)
+	// ResolveCommentThread resolves or reopens a comment thread
+	ResolveCommentThread(ctx context.Context, pageId, threadId, userId string, host bool, resolved bool) error
+	// DeleteCommentThread deletes a comment thread
+	DeleteCommentThread(ctx context.Context, pageId, threadId, userId string, host bool) error
+
+	// GetChat returns the chat history of the session
+	GetChat(ctx context.Context) ([]*ChatMessage, error)
+	// DeleteChatMessage deletes a message from the chat history
+	DeleteChatMessage(ctx context.Context, messageId string) error
+
+	// UploadAttachment stores an attachment uploaded by the user
+	UploadAttachment(ctx context.Context, r io.Reader, user User) (*Attachment, error)
+	// GetAttachments returns the attachments of the session
+	GetAttachments(ctx context.Context) ([]*Attachment, error)
+	// DeleteAttachment deletes an attachment
+	DeleteAttachment(ctx context.Context, attachId, userId string, host, force bool) error
+	// AttachmentURL returns a signed URL of an attachment
+	AttachmentURL(attachId string) *AttachmentURL
+	// VerifyAttachmentURL checks the signature of an attachment URL
+	VerifyAttachmentURL(attachId string, expires int64, signature string) error
+
+	// HostViewport returns the last known viewport of the host or nil
+	HostViewport() *ContentViewport
 
 	// NewUser creates a new ready user for the session
 	NewUser(userReq UserRequest) (*User, error)
@@ -59,7 +109,7 @@ type Controller interface {
 	// UserCanJoin check if a user can join the session
 	UserCanJoin(userID string) error
 	// UserConnect connects a ready user to the session
-	UserConnect(userID string, conn *gws.Conn) error
+	UserConnect(userID string, conn *Conn) error
 	// UserDisconnect disconnects a user from the session
 	UserDisconnect(ctx context.Context, userID string)
 	// KickUser removes a user from the session
@@ -79,6 +129,8 @@ type Controller interface {
 	Broadcaster() Broadcaster
 	// NumUsers returns the number of active users in the session
 	NumUsers() int
+	// QueueStats returns statistics of the outbound queues of the connected users
+	QueueStats() QueueStats
 	// Allow checks whether a user is allowed to modify the session
 	Allow(userID string) bool
 }
@@ -101,6 +153,11 @@ type controlBlock struct {
 	// close timer
 	timer *time.Timer
 
+	startOnce sync.Once
+	closeOnce sync.Once
+	// done is closed when the session is closed
+	done chan struct{}
+
 	cache redis.Handler
 
 	muRdyUsr sync.RWMutex
@@ -113,6 +170,37 @@ type controlBlock struct {
 	// and have an intact WS connection
 	users    map[string]*User
 	numUsers int
+
+	muIdx sync.RWMutex
+	// spatial index of the strokes per page
+	indexes map[string]*strokeIndex
+	// indexGen counts the changes of the indexed layers
+	indexGen uint64
+
+	muThumbnails sync.Mutex
+	// rendered thumbnails per page and width
+	thumbnails map[string]*Thumbnail
+	// thumbnailGen counts the invalidations of the thumbnails
+	thumbnailGen uint64
+
+	// muEdit serializes the changes of the stored strokes, such that
+	// each change operates on the result of the previous ones
+	muEdit sync.Mutex
+
+	// muLayers serializes the changes of the page layers
+	muLayers sync.Mutex
+
+	// muComments serializes the changes of the comment threads
+	muComments sync.Mutex
+
+	// muAttachments serializes the uploads and deletions of attachments
+	muAttachments sync.Mutex
+	// urlKey signs the attachment URLs of the session
+	urlKey []byte
+
+	muViewport sync.RWMutex
+	// last known viewport of the host
+	hostViewport *ContentViewport
 }
 
 var _ Controller = (*controlBlock)(nil)
@@ -157,6 +245,13 @@ func NewControlBlock(cfg Config, options ...ControlBlockOption) (*controlBlock,
 		cfg:        cfg,
 		usersReady: make(map[string]*User),
 		users:      make(map[string]*User),
+		indexes:    make(map[string]*strokeIndex),
+		thumbnails: make(map[string]*Thumbnail),
+		done:       make(chan struct{}),
+		urlKey:     make([]byte, 32),
+	}
+	if _, err := rand.Read(scb.urlKey); err != nil {
+		return nil, err
 	}
 
 	for _, o := range options {
@@ -168,7 +263,7 @@ func NewControlBlock(cfg Config, options ...ControlBlockOption) (*controlBlock,
 	}
 
 	if scb.attachments == nil {
-		scb.attachments = attachment.NewLocalHandler(cfg.ID)
+		scb.attachments = attachment.NewLocalHandler(attachment.DefaultDir, cfg.ID)
 	}
 
 	if scb.broadcaster == nil {
@@ -182,10 +277,16 @@ func NewControlBlock(cfg Config, options ...ControlBlockOption) (*controlBlock,
 // It binds the broadcaster and starts its goroutines.
 func (scb *controlBlock) Start() {
 	scb.broadcaster.Bind(scb)
+	scb.startOnce.Do(func() {
+		go scb.presenceLoop()
+	})
 }
 
 // Close sends a close signal
 func (scb *controlBlock) Close() {
+	scb.closeOnce.Do(func() {
+		close(scb.done)
+	})
 	scb.broadcaster.Close()
 }
 
@@ -220,6 +321,14 @@ func (scb *controlBlock) NumUsers() int {
 	return scb.numUsers
 }
 
+func (scb *controlBlock) QueueStats() QueueStats {
+	var stats QueueStats
+	for _, u := range scb.GetUsers() {
+		stats.add(QueueStats{Messages: u.Conn.Len(), MaxDepth: u.Conn.Len()})
+	}
+	return stats
+}
+
 func (scb *controlBlock) Config() Config {
 	return scb.cfg
 }
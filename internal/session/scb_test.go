@@ -7,12 +7,36 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/boardsite-io/server/internal/attachment/attachmentfakes"
 	"github.com/boardsite-io/server/internal/config"
 	"github.com/boardsite-io/server/internal/session"
 	"github.com/boardsite-io/server/internal/session/sessionfakes"
 	"github.com/boardsite-io/server/pkg/redis/redisfakes"
 )
 
+// newTestSession creates a session on a fake cache, which is set up by the stubs.
+// The broadcasts of the session are buffered in the returned channel.
+func newTestSession(t *testing.T, cfg session.Config, stubs ...func(fakeCache *redisfakes.FakeHandler)) (session.Controller, *redisfakes.FakeHandler, chan session.Message) {
+	fakeCache := &redisfakes.FakeHandler{}
+	for _, stub := range stubs {
+		stub(fakeCache)
+	}
+	broadcast := make(chan session.Message, 10)
+	fakeBroadcaster := &sessionfakes.FakeBroadcaster{}
+	fakeBroadcaster.BroadcastReturns(broadcast)
+	scb, err := session.NewControlBlock(cfg, session.WithCache(fakeCache), session.WithAttachments(&attachmentfakes.FakeHandler{}),
+		session.WithDispatcher(&sessionfakes.FakeDispatcher{}), session.WithBroadcaster(fakeBroadcaster))
+	require.NoError(t, err)
+	return scb, fakeCache, broadcast
+}
+
+// withPages stubs the page rank of the session.
+func withPages(pageIDs ...string) func(fakeCache *redisfakes.FakeHandler) {
+	return func(fakeCache *redisfakes.FakeHandler) {
+		fakeCache.GetPageRankReturns(pageIDs, nil)
+	}
+}
+
 func Test_controlBlock_SetConfig(t *testing.T) {
 	fakeDispatcher := &sessionfakes.FakeDispatcher{}
 	fakeCache := &redisfakes.FakeHandler{}
@@ -64,6 +88,21 @@ func Test_controlBlock_SetConfig(t *testing.T) {
 				Password: "",
 			},
 		},
+		{
+			name: "force participants to follow the host",
+			set:  `{"followHost":true}`,
+			want: session.Config{
+				ID:     "1234",
+				Host:   "beef",
+				Secret: "potato",
+				Session: config.Session{
+					MaxUsers: 10,
+					ReadOnly: true,
+				},
+				Password:   "test1234",
+				FollowHost: true,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
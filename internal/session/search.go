@@ -0,0 +1,198 @@
+package session
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	libErr "github.com/boardsite-io/server/pkg/errors"
+)
+
+const (
+	maxSearchQueryLength = 256
+	defaultSearchLimit   = 50
+	maxSearchLimit       = 200
+	// snippetContext is the number of characters around the match in snippets
+	snippetContext = 40
+)
+
+// SearchResult declares a textfield matching a search query.
+type SearchResult struct {
+	PageID   string  `json:"pageId"`
+	StrokeID string  `json:"strokeId"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Snippet  string  `json:"snippet"`
+}
+
+type textEntry struct {
+	text string
+	x, y float64
+}
+
+// textIndex is an inverted index of the words of the textfields of a page.
+type textIndex struct {
+	entries map[string]textEntry
+	words   map[string]map[string]struct{}
+}
+
+func newTextIndex() *textIndex {
+	return &textIndex{
+		entries: make(map[string]textEntry),
+		words:   make(map[string]map[string]struct{}),
+	}
+}
+
+// normalize lower cases the text rune by rune, such that the
+// positions of the runes are retained.
+func normalize(text string) string {
+	return strings.Map(unicode.ToLower, text)
+}
+
+// tokenize splits the text into normalized words.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(normalize(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// insert adds or replaces the text of a textfield.
+func (idx *textIndex) insert(stroke *Stroke) {
+	idx.remove(stroke.ID)
+	words := tokenize(stroke.Textfield.Text)
+	if len(words) == 0 {
+		return
+	}
+	idx.entries[stroke.ID] = textEntry{text: stroke.Textfield.Text, x: stroke.X, y: stroke.Y}
+	for _, w := range words {
+		ids, ok := idx.words[w]
+		if !ok {
+			ids = make(map[string]struct{})
+			idx.words[w] = ids
+		}
+		ids[stroke.ID] = struct{}{}
+	}
+}
+
+// remove deletes the text of a stroke from the index.
+func (idx *textIndex) remove(id string) {
+	entry, ok := idx.entries[id]
+	if !ok {
+		return
+	}
+	delete(idx.entries, id)
+	for _, w := range tokenize(entry.text) {
+		delete(idx.words[w], id)
+		if len(idx.words[w]) == 0 {
+			delete(idx.words, w)
+		}
+	}
+}
+
+// search returns the ids of the strokes containing words
+// starting with each of the query words.
+func (idx *textIndex) search(query []string) []string {
+	var found map[string]struct{}
+	for _, q := range query {
+		matches := make(map[string]struct{})
+		for w, ids := range idx.words {
+			if !strings.HasPrefix(w, q) {
+				continue
+			}
+			for id := range ids {
+				if _, ok := found[id]; ok || found == nil {
+					matches[id] = struct{}{}
+				}
+			}
+		}
+		if len(matches) == 0 {
+			return nil
+		}
+		found = matches
+	}
+
+	ids := make([]string, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// snippet returns the part of the text around the first occurrence of the word.
+func snippet(text, word string) string {
+	runes := []rune(text)
+	normalized := normalize(text)
+	start := 0
+	if i := strings.Index(normalized, word); i >= 0 {
+		start = utf8.RuneCountInString(normalized[:i])
+	}
+	from, to := start-snippetContext, start+utf8.RuneCountInString(word)+snippetContext
+	prefix, suffix := "…", "…"
+	if from <= 0 {
+		from, prefix = 0, ""
+	}
+	if to >= len(runes) {
+		to, suffix = len(runes), ""
+	}
+	return prefix + strings.Join(strings.Fields(string(runes[from:to])), " ") + suffix
+}
+
+// Search finds the textfields of the session containing the words of the query.
+//
+// Results are ordered by the page rank and the position on the page.
+func (scb *controlBlock) Search(ctx context.Context, query string, limit int) ([]*SearchResult, error) {
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		return nil, libErr.ErrBadRequest.Wrap(libErr.WithErrorf("query exceeds %d characters", maxSearchQueryLength))
+	}
+	words := tokenize(query)
+	if len(words) == 0 {
+		return nil, libErr.ErrBadRequest.Wrap(libErr.WithErrorf("empty query"))
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	pageRank, err := scb.GetPageRank(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scb.muIdx.RLock()
+	defer scb.muIdx.RUnlock()
+	results := make([]*SearchResult, 0)
+	for _, pid := range pageRank {
+		idx, ok := scb.indexes[pid]
+		if !ok {
+			continue
+		}
+		pageResults := make([]*SearchResult, 0)
+		for _, id := range idx.text.search(words) {
+			entry := idx.text.entries[id]
+			pageResults = append(pageResults, &SearchResult{
+				PageID:   pid,
+				StrokeID: id,
+				X:        entry.x,
+				Y:        entry.y,
+				Snippet:  snippet(entry.text, words[0]),
+			})
+		}
+		// read order on the page
+		sort.SliceStable(pageResults, func(i, j int) bool {
+			if pageResults[i].Y != pageResults[j].Y {
+				return pageResults[i].Y < pageResults[j].Y
+			}
+			return pageResults[i].X < pageResults[j].X
+		})
+		results = append(results, pageResults...)
+		if len(results) >= limit {
+			return results[:limit], nil
+		}
+	}
+	return results, nil
+}
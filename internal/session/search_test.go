@@ -0,0 +1,92 @@
+package session_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/session"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+)
+
+func searchIDs(results []*session.SearchResult) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.StrokeID
+	}
+	return ids
+}
+
+func newTextfield(id, text string, x, y float64) *session.Stroke {
+	return &session.Stroke{Type: strokeTypeTextfield, ID: id, PageID: "pid1", UserID: "user1", X: x, Y: y, Textfield: session.Textfield{Text: text}}
+}
+
+func Test_controlBlock_Search(t *testing.T) {
+	ctx := context.Background()
+	scb, _, broadcast := setupStrokeSession(t,
+		newTextfield("notes", "Meeting notes: Hello World", 10, 200),
+		newTextfield("greeting", "hello again", 50, 100),
+		newTextfield("other", "Unrelated text", 0, 0),
+		&session.Stroke{Type: strokeTypePen, ID: "pen", PageID: "pid1", UserID: "user1", Points: session.Points{0, 0, 1, 1}},
+	)
+
+	t.Run("finds words by prefix in read order", func(t *testing.T) {
+		results, err := scb.Search(ctx, "HEL", 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"greeting", "notes"}, searchIDs(results))
+		assert.Equal(t, session.SearchResult{PageID: "pid1", StrokeID: "greeting", X: 50, Y: 100, Snippet: "hello again"}, *results[0])
+	})
+
+	t.Run("requires all words", func(t *testing.T) {
+		results, err := scb.Search(ctx, "hello, wor", 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"notes"}, searchIDs(results))
+	})
+
+	t.Run("limits results", func(t *testing.T) {
+		results, err := scb.Search(ctx, "hello", 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"greeting"}, searchIDs(results))
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		_, err := scb.Search(ctx, " ?! ", 0)
+
+		assert.ErrorIs(t, err, libErr.ErrBadRequest)
+	})
+
+	t.Run("updates index incrementally", func(t *testing.T) {
+		for _, s := range []*session.Stroke{
+			newTextfield("greeting", "goodbye", 50, 100),
+			{Type: session.StrokeTypeDelete, ID: "notes", PageID: "pid1", UserID: "user1"},
+		} {
+			msg := newStrokeMessage(t, session.JSONCodec, s)
+			require.NoError(t, scb.Receive(ctx, msg, "user1"))
+			<-broadcast
+		}
+
+		results, err := scb.Search(ctx, "hello", 0)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		results, err = scb.Search(ctx, "goodbye", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"greeting"}, searchIDs(results))
+	})
+}
+
+func Test_controlBlock_Search_Snippet(t *testing.T) {
+	text := strings.Repeat("lorem ipsum ", 10) + "Needle\n\n in the " + strings.Repeat("dolor sit ", 10)
+	scb, _, _ := setupStrokeSession(t, newTextfield("text", text, 0, 0))
+
+	results, err := scb.Search(context.Background(), "needle", 0)
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "…sum lorem ipsum lorem ipsum lorem ipsum Needle in the dolor sit dolor sit dolor sit…", results[0].Snippet)
+}
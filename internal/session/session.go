@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	libErr "github.com/boardsite-io/server/pkg/errors"
 	"github.com/boardsite-io/server/pkg/redis"
 )
 
@@ -19,12 +20,63 @@ const (
 	MessageTypeUserKick         = "userkick"
 	MessageTypePageSync         = "pagesync"
 	MessageTypeMouseMove        = "mmove"
+	MessageTypeError            = "error"
+	MessageTypeErase            = "erase"
+	MessageTypeTransform        = "transform"
+	MessageTypeChat             = "chat"
+	MessageTypeChatDelete       = "chatdelete"
+	MessageTypeViewport         = "viewport"
+	MessageTypeComment          = "comment"
+	MessageTypeCommentDelete    = "commentdelete"
+	MessageTypeAttachment       = "attachment"
+	MessageTypeAttachmentDelete = "attachmentdelete"
 )
 
+// ephemeralMessageTypes are message types which may be dropped
+// by slow connections without causing an inconsistent board state.
+var ephemeralMessageTypes = map[string]struct{}{
+	MessageTypeMouseMove: {},
+	MessageTypeViewport:  {},
+}
+
+func isEphemeral(msgType string) bool {
+	_, ok := ephemeralMessageTypes[msgType]
+	return ok
+}
+
+// ContentError declares the content of error messages.
+type ContentError struct {
+	Code    libErr.Code `json:"code,omitempty"`
+	Message string      `json:"message"`
+}
+
+// NewErrorMessage creates an error message for the receiver.
+// The error code is set if err wraps an HTTPError.
+func NewErrorMessage(err error, receiver string) Message {
+	content := ContentError{Message: err.Error()}
+	var httpErr *libErr.HTTPError
+	if errors.As(err, &httpErr) {
+		content.Code = httpErr.Code
+		content.Message = httpErr.Message
+		if internal := httpErr.Unwrap(); internal != nil {
+			content.Message = internal.Error()
+		}
+	}
+	return Message{
+		Type:     MessageTypeError,
+		Receiver: receiver,
+		Content:  content,
+	}
+}
+
 // ContentMouseMove declares mouse move updates.
+//
+// PageID and Tool update the presence of the user if set.
 type ContentMouseMove struct {
-	X float64 `json:"x"`
-	Y float64 `json:"y"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	PageID string  `json:"pageId,omitempty"`
+	Tool   string  `json:"tool,omitempty"`
 }
 
 // Receive is the entry point when a message is received in
@@ -40,17 +92,37 @@ func (scb *controlBlock) Receive(ctx context.Context, msg *Message, userID strin
 		err = scb.sanitizeStrokes(ctx, msg)
 
 	case MessageTypeMouseMove:
-		err = scb.mouseMove(msg)
+		err = scb.mouseMove(ctx, msg)
+
+	case MessageTypeErase:
+		err = scb.erase(ctx, msg)
+
+	case MessageTypeTransform:
+		err = scb.transform(ctx, msg)
+
+	case MessageTypeChat:
+		err = scb.chat(ctx, msg)
+
+	case MessageTypeViewport:
+		err = scb.presentViewport(ctx, msg)
 
 	default:
 		err = fmt.Errorf("message type not recognized: %s", msg.Type)
 	}
+
+	// any message traffic keeps the user active, the cursor
+	// updates the presence itself
+	if msg.Type != MessageTypeMouseMove {
+		scb.touch(userID, "", "")
+	}
 	return err
 }
 
 // sanitizeStrokes parses the stroke content of the message.
 //
-// It further checks if the strokes have a valid pageId and userId.
+// It further checks if the strokes have a valid pageId and userId
+// and satisfy the stroke limits. Invalid strokes are discarded.
+// Freehand strokes are simplified if enabled for the session.
 func (scb *controlBlock) sanitizeStrokes(ctx context.Context, msg *Message) error {
 	if !scb.Allow(msg.Sender) {
 		return errors.New("not allowed")
@@ -61,19 +133,71 @@ func (scb *controlBlock) sanitizeStrokes(ctx context.Context, msg *Message) erro
 		return err
 	}
 
-	validStrokes := make([]redis.Stroke, 0, len(strokes))
+	// the strokes are checked against and stored in order
+	// with the edits of the server
+	scb.muEdit.Lock()
+	defer scb.muEdit.Unlock()
+
+	candidates := make([]*Stroke, 0, len(strokes))
 	pageIDs := scb.getPagesSet(ctx)
+	pageSizes := make(map[string]PageSize)
+	var lastErr error
 
 	for _, stroke := range strokes {
-		if _, ok := pageIDs[stroke.PageId()]; ok { // valid pageID
-			if stroke.UserId() == msg.Sender { // valid userID
-				validStrokes = append(validStrokes, stroke)
+		if _, ok := pageIDs[stroke.PageId()]; !ok { // invalid pageID
+			continue
+		}
+		if stroke.UserId() != msg.Sender { // invalid userID
+			continue
+		}
+		size, ok := pageSizes[stroke.PageId()]
+		if !ok {
+			size = scb.getPageSize(ctx, stroke.PageId())
+			pageSizes[stroke.PageId()] = size
+		}
+		if err := stroke.validate(scb.cfg.Strokes, size); err != nil {
+			lastErr = err
+			continue
+		}
+		candidates = append(candidates, stroke)
+	}
+
+	// strokes of locked layers can neither be changed, moved to
+	// another layer nor deleted by other users than the host
+	validStrokes := make([]redis.Stroke, 0, len(candidates))
+	pageLayers := make(map[string]Layers)
+	host := msg.Sender == scb.cfg.Host
+	for _, stroke := range candidates {
+		layers, ok := pageLayers[stroke.PageId()]
+		if !ok {
+			var err error
+			if layers, err = scb.getLayers(ctx, stroke.PageId()); err != nil {
+				return err
+			}
+			pageLayers[stroke.PageId()] = layers
+		}
+		if layerID := scb.strokeLayer(stroke.PageId(), stroke.ID); layerID != "" {
+			if i := layers.find(layerID); i >= 0 && layers[i].Locked && !host {
+				lastErr = libErr.ErrForbidden.Wrap(libErr.WithErrorf("layer %s is locked", layerID))
+				continue
 			}
 		}
+		if !stroke.IsDeleted() {
+			if err := layers.checkStroke(stroke, host); err != nil {
+				lastErr = err
+				continue
+			}
+		}
+		if scb.isFreehand(stroke) {
+			stroke.simplify(scb.cfg.SimplifyTolerance)
+		}
+		validStrokes = append(validStrokes, stroke)
 	}
 	if len(validStrokes) > 0 {
-		scb.updateStrokes(msg.Sender, validStrokes)
-		return nil
+		return scb.updateStrokes(ctx, msg.Sender, validStrokes)
+	}
+	if lastErr != nil {
+		return lastErr
 	}
 	return errors.New("strokes not validated")
 }
@@ -81,26 +205,43 @@ func (scb *controlBlock) sanitizeStrokes(ctx context.Context, msg *Message) erro
 // updateStrokes updates the strokes in the session with sessionID.
 //
 // userID indicates the initiator of the message, which is
-// to be excluded in the broadcast. The strokes are scheduled for an
-// update to Redis.
-func (scb *controlBlock) updateStrokes(userID string, strokes []redis.Stroke) {
+// to be excluded in the broadcast. The strokes are stored in Redis
+// before they are broadcasted, such that subsequent edits operate on
+// the stored strokes. The caller must hold muEdit.
+func (scb *controlBlock) updateStrokes(ctx context.Context, userID string, strokes []redis.Stroke) error {
+	if err := scb.cache.UpdateStrokes(ctx, scb.cfg.ID, strokes...); err != nil {
+		return err
+	}
+	scb.indexStrokes(strokes...)
+
 	// broadcast changes
 	scb.broadcaster.Broadcast() <- Message{
 		Type:    MessageTypeStroke,
 		Sender:  userID,
 		Content: strokes,
 	}
-
-	// save to database
-	scb.broadcaster.Cache() <- strokes
+	return nil
 }
 
-// mouseMove broadcast mouse move events.
-func (scb *controlBlock) mouseMove(msg *Message) error {
+// mouseMove broadcast mouse move events and updates the presence of the user.
+//
+// The page of the cursor is only validated if it has changed.
+func (scb *controlBlock) mouseMove(ctx context.Context, msg *Message) error {
 	var mouseUpdate ContentMouseMove
 	if err := msg.UnmarshalContent(&mouseUpdate); err != nil {
 		return err
 	}
+	if err := validateTool(mouseUpdate.Tool); err != nil {
+		return err
+	}
+	if mouseUpdate.PageID != "" {
+		u, ok := scb.GetUsers()[msg.Sender]
+		if ok && u.PageID != mouseUpdate.PageID && !scb.IsValidPage(ctx, mouseUpdate.PageID) {
+			return libErr.ErrNotFound.Wrap(libErr.WithErrorf("page %s does not exist", mouseUpdate.PageID))
+		}
+	}
+	scb.touch(msg.Sender, mouseUpdate.PageID, mouseUpdate.Tool)
+
 	scb.broadcaster.Broadcast() <- Message{
 		Type:    MessageTypeMouseMove,
 		Sender:  msg.Sender,
@@ -5,7 +5,6 @@ import (
 	"sync"
 
 	"github.com/boardsite-io/server/internal/session"
-	"github.com/boardsite-io/server/pkg/redis"
 )
 
 type FakeBroadcaster struct {
@@ -30,16 +29,6 @@ type FakeBroadcaster struct {
 	broadcastReturnsOnCall map[int]struct {
 		result1 chan<- session.Message
 	}
-	CacheStub        func() chan<- []redis.Stroke
-	cacheMutex       sync.RWMutex
-	cacheArgsForCall []struct {
-	}
-	cacheReturns struct {
-		result1 chan<- []redis.Stroke
-	}
-	cacheReturnsOnCall map[int]struct {
-		result1 chan<- []redis.Stroke
-	}
 	CloseStub        func()
 	closeMutex       sync.RWMutex
 	closeArgsForCall []struct {
@@ -182,59 +171,6 @@ func (fake *FakeBroadcaster) BroadcastReturnsOnCall(i int, result1 chan<- sessio
 	}{result1}
 }
 
-func (fake *FakeBroadcaster) Cache() chan<- []redis.Stroke {
-	fake.cacheMutex.Lock()
-	ret, specificReturn := fake.cacheReturnsOnCall[len(fake.cacheArgsForCall)]
-	fake.cacheArgsForCall = append(fake.cacheArgsForCall, struct {
-	}{})
-	stub := fake.CacheStub
-	fakeReturns := fake.cacheReturns
-	fake.recordInvocation("Cache", []interface{}{})
-	fake.cacheMutex.Unlock()
-	if stub != nil {
-		return stub()
-	}
-	if specificReturn {
-		return ret.result1
-	}
-	return fakeReturns.result1
-}
-
-func (fake *FakeBroadcaster) CacheCallCount() int {
-	fake.cacheMutex.RLock()
-	defer fake.cacheMutex.RUnlock()
-	return len(fake.cacheArgsForCall)
-}
-
-func (fake *FakeBroadcaster) CacheCalls(stub func() chan<- []redis.Stroke) {
-	fake.cacheMutex.Lock()
-	defer fake.cacheMutex.Unlock()
-	fake.CacheStub = stub
-}
-
-func (fake *FakeBroadcaster) CacheReturns(result1 chan<- []redis.Stroke) {
-	fake.cacheMutex.Lock()
-	defer fake.cacheMutex.Unlock()
-	fake.CacheStub = nil
-	fake.cacheReturns = struct {
-		result1 chan<- []redis.Stroke
-	}{result1}
-}
-
-func (fake *FakeBroadcaster) CacheReturnsOnCall(i int, result1 chan<- []redis.Stroke) {
-	fake.cacheMutex.Lock()
-	defer fake.cacheMutex.Unlock()
-	fake.CacheStub = nil
-	if fake.cacheReturnsOnCall == nil {
-		fake.cacheReturnsOnCall = make(map[int]struct {
-			result1 chan<- []redis.Stroke
-		})
-	}
-	fake.cacheReturnsOnCall[i] = struct {
-		result1 chan<- []redis.Stroke
-	}{result1}
-}
-
 func (fake *FakeBroadcaster) Close() {
 	fake.closeMutex.Lock()
 	fake.closeArgsForCall = append(fake.closeArgsForCall, struct {
@@ -372,8 +308,6 @@ func (fake *FakeBroadcaster) Invocations() map[string][][]interface{} {
 	defer fake.bindMutex.RUnlock()
 	fake.broadcastMutex.RLock()
 	defer fake.broadcastMutex.RUnlock()
-	fake.cacheMutex.RLock()
-	defer fake.cacheMutex.RUnlock()
 	fake.closeMutex.RLock()
 	defer fake.closeMutex.RUnlock()
 	fake.controlMutex.RLock()
@@ -3,16 +3,48 @@ package sessionfakes
 
 import (
 	"context"
+	"io"
 	"sync"
 	"time"
 
-	"github.com/gorilla/websocket"
-
 	"github.com/boardsite-io/server/internal/attachment"
 	"github.com/boardsite-io/server/internal/session"
+	"github.com/boardsite-io/server/pkg/geometry"
 )
 
 type FakeController struct {
+	AddCommentThreadStub        func(context.Context, string, session.User, session.CommentThreadRequest) (*session.CommentThread, error)
+	addCommentThreadMutex       sync.RWMutex
+	addCommentThreadArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 session.User
+		arg4 session.CommentThreadRequest
+	}
+	addCommentThreadReturns struct {
+		result1 *session.CommentThread
+		result2 error
+	}
+	addCommentThreadReturnsOnCall map[int]struct {
+		result1 *session.CommentThread
+		result2 error
+	}
+	AddLayerStub        func(context.Context, string, session.LayerRequest, bool) (*session.Layer, error)
+	addLayerMutex       sync.RWMutex
+	addLayerArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 session.LayerRequest
+		arg4 bool
+	}
+	addLayerReturns struct {
+		result1 *session.Layer
+		result2 error
+	}
+	addLayerReturnsOnCall map[int]struct {
+		result1 *session.Layer
+		result2 error
+	}
 	AddPagesStub        func(context.Context, session.PageRequest) error
 	addPagesMutex       sync.RWMutex
 	addPagesArgsForCall []struct {
@@ -36,6 +68,17 @@ type FakeController struct {
 	allowReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	AttachmentURLStub        func(string) *session.AttachmentURL
+	attachmentURLMutex       sync.RWMutex
+	attachmentURLArgsForCall []struct {
+		arg1 string
+	}
+	attachmentURLReturns struct {
+		result1 *session.AttachmentURL
+	}
+	attachmentURLReturnsOnCall map[int]struct {
+		result1 *session.AttachmentURL
+	}
 	AttachmentsStub        func() attachment.Handler
 	attachmentsMutex       sync.RWMutex
 	attachmentsArgsForCall []struct {
@@ -76,6 +119,116 @@ type FakeController struct {
 	configReturnsOnCall map[int]struct {
 		result1 session.Config
 	}
+	DeleteAttachmentStub        func(context.Context, string, string, bool, bool) error
+	deleteAttachmentMutex       sync.RWMutex
+	deleteAttachmentArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 bool
+		arg5 bool
+	}
+	deleteAttachmentReturns struct {
+		result1 error
+	}
+	deleteAttachmentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteChatMessageStub        func(context.Context, string) error
+	deleteChatMessageMutex       sync.RWMutex
+	deleteChatMessageArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	deleteChatMessageReturns struct {
+		result1 error
+	}
+	deleteChatMessageReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteCommentThreadStub        func(context.Context, string, string, string, bool) error
+	deleteCommentThreadMutex       sync.RWMutex
+	deleteCommentThreadArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 bool
+	}
+	deleteCommentThreadReturns struct {
+		result1 error
+	}
+	deleteCommentThreadReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteLayerStub        func(context.Context, string, string, bool) error
+	deleteLayerMutex       sync.RWMutex
+	deleteLayerArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 bool
+	}
+	deleteLayerReturns struct {
+		result1 error
+	}
+	deleteLayerReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetAttachmentsStub        func(context.Context) ([]*session.Attachment, error)
+	getAttachmentsMutex       sync.RWMutex
+	getAttachmentsArgsForCall []struct {
+		arg1 context.Context
+	}
+	getAttachmentsReturns struct {
+		result1 []*session.Attachment
+		result2 error
+	}
+	getAttachmentsReturnsOnCall map[int]struct {
+		result1 []*session.Attachment
+		result2 error
+	}
+	GetChatStub        func(context.Context) ([]*session.ChatMessage, error)
+	getChatMutex       sync.RWMutex
+	getChatArgsForCall []struct {
+		arg1 context.Context
+	}
+	getChatReturns struct {
+		result1 []*session.ChatMessage
+		result2 error
+	}
+	getChatReturnsOnCall map[int]struct {
+		result1 []*session.ChatMessage
+		result2 error
+	}
+	GetCommentsStub        func(context.Context, string) ([]*session.CommentThread, error)
+	getCommentsMutex       sync.RWMutex
+	getCommentsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	getCommentsReturns struct {
+		result1 []*session.CommentThread
+		result2 error
+	}
+	getCommentsReturnsOnCall map[int]struct {
+		result1 []*session.CommentThread
+		result2 error
+	}
+	GetLayersStub        func(context.Context, string) (session.Layers, error)
+	getLayersMutex       sync.RWMutex
+	getLayersArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	getLayersReturns struct {
+		result1 session.Layers
+		result2 error
+	}
+	getLayersReturnsOnCall map[int]struct {
+		result1 session.Layers
+		result2 error
+	}
 	GetPageStub        func(context.Context, string, bool) (*session.Page, error)
 	getPageMutex       sync.RWMutex
 	getPageArgsForCall []struct {
@@ -119,6 +272,36 @@ type FakeController struct {
 		result1 *session.PageSync
 		result2 error
 	}
+	GetStrokesInPolygonStub        func(context.Context, string, geometry.Polygon) ([]*session.Stroke, error)
+	getStrokesInPolygonMutex       sync.RWMutex
+	getStrokesInPolygonArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 geometry.Polygon
+	}
+	getStrokesInPolygonReturns struct {
+		result1 []*session.Stroke
+		result2 error
+	}
+	getStrokesInPolygonReturnsOnCall map[int]struct {
+		result1 []*session.Stroke
+		result2 error
+	}
+	GetStrokesInRectStub        func(context.Context, string, geometry.Rect) ([]*session.Stroke, error)
+	getStrokesInRectMutex       sync.RWMutex
+	getStrokesInRectArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 geometry.Rect
+	}
+	getStrokesInRectReturns struct {
+		result1 []*session.Stroke
+		result2 error
+	}
+	getStrokesInRectReturnsOnCall map[int]struct {
+		result1 []*session.Stroke
+		result2 error
+	}
 	GetUsersStub        func() map[string]*session.User
 	getUsersMutex       sync.RWMutex
 	getUsersArgsForCall []struct {
@@ -129,6 +312,16 @@ type FakeController struct {
 	getUsersReturnsOnCall map[int]struct {
 		result1 map[string]*session.User
 	}
+	HostViewportStub        func() *session.ContentViewport
+	hostViewportMutex       sync.RWMutex
+	hostViewportArgsForCall []struct {
+	}
+	hostViewportReturns struct {
+		result1 *session.ContentViewport
+	}
+	hostViewportReturnsOnCall map[int]struct {
+		result1 *session.ContentViewport
+	}
 	IDStub        func() string
 	iDMutex       sync.RWMutex
 	iDArgsForCall []struct {
@@ -185,6 +378,31 @@ type FakeController struct {
 	numUsersReturnsOnCall map[int]struct {
 		result1 int
 	}
+	PageThumbnailStub        func(context.Context, string, int) (*session.Thumbnail, error)
+	pageThumbnailMutex       sync.RWMutex
+	pageThumbnailArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+	}
+	pageThumbnailReturns struct {
+		result1 *session.Thumbnail
+		result2 error
+	}
+	pageThumbnailReturnsOnCall map[int]struct {
+		result1 *session.Thumbnail
+		result2 error
+	}
+	QueueStatsStub        func() session.QueueStats
+	queueStatsMutex       sync.RWMutex
+	queueStatsArgsForCall []struct {
+	}
+	queueStatsReturns struct {
+		result1 session.QueueStats
+	}
+	queueStatsReturnsOnCall map[int]struct {
+		result1 session.QueueStats
+	}
 	ReceiveStub        func(context.Context, *session.Message, string) error
 	receiveMutex       sync.RWMutex
 	receiveArgsForCall []struct {
@@ -198,6 +416,54 @@ type FakeController struct {
 	receiveReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ReplyCommentStub        func(context.Context, string, string, session.User, session.CommentRequest) (*session.Comment, error)
+	replyCommentMutex       sync.RWMutex
+	replyCommentArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 session.User
+		arg5 session.CommentRequest
+	}
+	replyCommentReturns struct {
+		result1 *session.Comment
+		result2 error
+	}
+	replyCommentReturnsOnCall map[int]struct {
+		result1 *session.Comment
+		result2 error
+	}
+	ResolveCommentThreadStub        func(context.Context, string, string, string, bool, bool) error
+	resolveCommentThreadMutex       sync.RWMutex
+	resolveCommentThreadArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 bool
+		arg6 bool
+	}
+	resolveCommentThreadReturns struct {
+		result1 error
+	}
+	resolveCommentThreadReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SearchStub        func(context.Context, string, int) ([]*session.SearchResult, error)
+	searchMutex       sync.RWMutex
+	searchArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+	}
+	searchReturns struct {
+		result1 []*session.SearchResult
+		result2 error
+	}
+	searchReturnsOnCall map[int]struct {
+		result1 []*session.SearchResult
+		result2 error
+	}
 	SetConfigStub        func(*session.ConfigRequest) error
 	setConfigMutex       sync.RWMutex
 	setConfigArgsForCall []struct {
@@ -221,12 +487,28 @@ type FakeController struct {
 	syncSessionReturnsOnCall map[int]struct {
 		result1 error
 	}
-	UpdatePagesStub        func(context.Context, session.PageRequest, string) error
+	UpdateLayerStub        func(context.Context, string, string, session.LayerRequest, bool) error
+	updateLayerMutex       sync.RWMutex
+	updateLayerArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 session.LayerRequest
+		arg5 bool
+	}
+	updateLayerReturns struct {
+		result1 error
+	}
+	updateLayerReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdatePagesStub        func(context.Context, session.PageRequest, string, bool) error
 	updatePagesMutex       sync.RWMutex
 	updatePagesArgsForCall []struct {
 		arg1 context.Context
 		arg2 session.PageRequest
 		arg3 string
+		arg4 bool
 	}
 	updatePagesReturns struct {
 		result1 error
@@ -246,6 +528,21 @@ type FakeController struct {
 	updateUserReturnsOnCall map[int]struct {
 		result1 error
 	}
+	UploadAttachmentStub        func(context.Context, io.Reader, session.User) (*session.Attachment, error)
+	uploadAttachmentMutex       sync.RWMutex
+	uploadAttachmentArgsForCall []struct {
+		arg1 context.Context
+		arg2 io.Reader
+		arg3 session.User
+	}
+	uploadAttachmentReturns struct {
+		result1 *session.Attachment
+		result2 error
+	}
+	uploadAttachmentReturnsOnCall map[int]struct {
+		result1 *session.Attachment
+		result2 error
+	}
 	UserCanJoinStub        func(string) error
 	userCanJoinMutex       sync.RWMutex
 	userCanJoinArgsForCall []struct {
@@ -257,11 +554,11 @@ type FakeController struct {
 	userCanJoinReturnsOnCall map[int]struct {
 		result1 error
 	}
-	UserConnectStub        func(string, *websocket.Conn) error
+	UserConnectStub        func(string, *session.Conn) error
 	userConnectMutex       sync.RWMutex
 	userConnectArgsForCall []struct {
 		arg1 string
-		arg2 *websocket.Conn
+		arg2 *session.Conn
 	}
 	userConnectReturns struct {
 		result1 error
@@ -275,10 +572,157 @@ type FakeController struct {
 		arg1 context.Context
 		arg2 string
 	}
+	VerifyAttachmentURLStub        func(string, int64, string) error
+	verifyAttachmentURLMutex       sync.RWMutex
+	verifyAttachmentURLArgsForCall []struct {
+		arg1 string
+		arg2 int64
+		arg3 string
+	}
+	verifyAttachmentURLReturns struct {
+		result1 error
+	}
+	verifyAttachmentURLReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeController) AddCommentThread(arg1 context.Context, arg2 string, arg3 session.User, arg4 session.CommentThreadRequest) (*session.CommentThread, error) {
+	fake.addCommentThreadMutex.Lock()
+	ret, specificReturn := fake.addCommentThreadReturnsOnCall[len(fake.addCommentThreadArgsForCall)]
+	fake.addCommentThreadArgsForCall = append(fake.addCommentThreadArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 session.User
+		arg4 session.CommentThreadRequest
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.AddCommentThreadStub
+	fakeReturns := fake.addCommentThreadReturns
+	fake.recordInvocation("AddCommentThread", []interface{}{arg1, arg2, arg3, arg4})
+	fake.addCommentThreadMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeController) AddCommentThreadCallCount() int {
+	fake.addCommentThreadMutex.RLock()
+	defer fake.addCommentThreadMutex.RUnlock()
+	return len(fake.addCommentThreadArgsForCall)
+}
+
+func (fake *FakeController) AddCommentThreadCalls(stub func(context.Context, string, session.User, session.CommentThreadRequest) (*session.CommentThread, error)) {
+	fake.addCommentThreadMutex.Lock()
+	defer fake.addCommentThreadMutex.Unlock()
+	fake.AddCommentThreadStub = stub
+}
+
+func (fake *FakeController) AddCommentThreadArgsForCall(i int) (context.Context, string, session.User, session.CommentThreadRequest) {
+	fake.addCommentThreadMutex.RLock()
+	defer fake.addCommentThreadMutex.RUnlock()
+	argsForCall := fake.addCommentThreadArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeController) AddCommentThreadReturns(result1 *session.CommentThread, result2 error) {
+	fake.addCommentThreadMutex.Lock()
+	defer fake.addCommentThreadMutex.Unlock()
+	fake.AddCommentThreadStub = nil
+	fake.addCommentThreadReturns = struct {
+		result1 *session.CommentThread
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) AddCommentThreadReturnsOnCall(i int, result1 *session.CommentThread, result2 error) {
+	fake.addCommentThreadMutex.Lock()
+	defer fake.addCommentThreadMutex.Unlock()
+	fake.AddCommentThreadStub = nil
+	if fake.addCommentThreadReturnsOnCall == nil {
+		fake.addCommentThreadReturnsOnCall = make(map[int]struct {
+			result1 *session.CommentThread
+			result2 error
+		})
+	}
+	fake.addCommentThreadReturnsOnCall[i] = struct {
+		result1 *session.CommentThread
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) AddLayer(arg1 context.Context, arg2 string, arg3 session.LayerRequest, arg4 bool) (*session.Layer, error) {
+	fake.addLayerMutex.Lock()
+	ret, specificReturn := fake.addLayerReturnsOnCall[len(fake.addLayerArgsForCall)]
+	fake.addLayerArgsForCall = append(fake.addLayerArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 session.LayerRequest
+		arg4 bool
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.AddLayerStub
+	fakeReturns := fake.addLayerReturns
+	fake.recordInvocation("AddLayer", []interface{}{arg1, arg2, arg3, arg4})
+	fake.addLayerMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeController) AddLayerCallCount() int {
+	fake.addLayerMutex.RLock()
+	defer fake.addLayerMutex.RUnlock()
+	return len(fake.addLayerArgsForCall)
+}
+
+func (fake *FakeController) AddLayerCalls(stub func(context.Context, string, session.LayerRequest, bool) (*session.Layer, error)) {
+	fake.addLayerMutex.Lock()
+	defer fake.addLayerMutex.Unlock()
+	fake.AddLayerStub = stub
+}
+
+func (fake *FakeController) AddLayerArgsForCall(i int) (context.Context, string, session.LayerRequest, bool) {
+	fake.addLayerMutex.RLock()
+	defer fake.addLayerMutex.RUnlock()
+	argsForCall := fake.addLayerArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeController) AddLayerReturns(result1 *session.Layer, result2 error) {
+	fake.addLayerMutex.Lock()
+	defer fake.addLayerMutex.Unlock()
+	fake.AddLayerStub = nil
+	fake.addLayerReturns = struct {
+		result1 *session.Layer
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) AddLayerReturnsOnCall(i int, result1 *session.Layer, result2 error) {
+	fake.addLayerMutex.Lock()
+	defer fake.addLayerMutex.Unlock()
+	fake.AddLayerStub = nil
+	if fake.addLayerReturnsOnCall == nil {
+		fake.addLayerReturnsOnCall = make(map[int]struct {
+			result1 *session.Layer
+			result2 error
+		})
+	}
+	fake.addLayerReturnsOnCall[i] = struct {
+		result1 *session.Layer
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeController) AddPages(arg1 context.Context, arg2 session.PageRequest) error {
 	fake.addPagesMutex.Lock()
 	ret, specificReturn := fake.addPagesReturnsOnCall[len(fake.addPagesArgsForCall)]
@@ -402,6 +846,67 @@ func (fake *FakeController) AllowReturnsOnCall(i int, result1 bool) {
 	}{result1}
 }
 
+func (fake *FakeController) AttachmentURL(arg1 string) *session.AttachmentURL {
+	fake.attachmentURLMutex.Lock()
+	ret, specificReturn := fake.attachmentURLReturnsOnCall[len(fake.attachmentURLArgsForCall)]
+	fake.attachmentURLArgsForCall = append(fake.attachmentURLArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.AttachmentURLStub
+	fakeReturns := fake.attachmentURLReturns
+	fake.recordInvocation("AttachmentURL", []interface{}{arg1})
+	fake.attachmentURLMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeController) AttachmentURLCallCount() int {
+	fake.attachmentURLMutex.RLock()
+	defer fake.attachmentURLMutex.RUnlock()
+	return len(fake.attachmentURLArgsForCall)
+}
+
+func (fake *FakeController) AttachmentURLCalls(stub func(string) *session.AttachmentURL) {
+	fake.attachmentURLMutex.Lock()
+	defer fake.attachmentURLMutex.Unlock()
+	fake.AttachmentURLStub = stub
+}
+
+func (fake *FakeController) AttachmentURLArgsForCall(i int) string {
+	fake.attachmentURLMutex.RLock()
+	defer fake.attachmentURLMutex.RUnlock()
+	argsForCall := fake.attachmentURLArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeController) AttachmentURLReturns(result1 *session.AttachmentURL) {
+	fake.attachmentURLMutex.Lock()
+	defer fake.attachmentURLMutex.Unlock()
+	fake.AttachmentURLStub = nil
+	fake.attachmentURLReturns = struct {
+		result1 *session.AttachmentURL
+	}{result1}
+}
+
+func (fake *FakeController) AttachmentURLReturnsOnCall(i int, result1 *session.AttachmentURL) {
+	fake.attachmentURLMutex.Lock()
+	defer fake.attachmentURLMutex.Unlock()
+	fake.AttachmentURLStub = nil
+	if fake.attachmentURLReturnsOnCall == nil {
+		fake.attachmentURLReturnsOnCall = make(map[int]struct {
+			result1 *session.AttachmentURL
+		})
+	}
+	fake.attachmentURLReturnsOnCall[i] = struct {
+		result1 *session.AttachmentURL
+	}{result1}
+}
+
 func (fake *FakeController) Attachments() attachment.Handler {
 	fake.attachmentsMutex.Lock()
 	ret, specificReturn := fake.attachmentsReturnsOnCall[len(fake.attachmentsArgsForCall)]
@@ -618,44 +1123,558 @@ func (fake *FakeController) ConfigReturnsOnCall(i int, result1 session.Config) {
 	}{result1}
 }
 
-func (fake *FakeController) GetPage(arg1 context.Context, arg2 string, arg3 bool) (*session.Page, error) {
-	fake.getPageMutex.Lock()
-	ret, specificReturn := fake.getPageReturnsOnCall[len(fake.getPageArgsForCall)]
-	fake.getPageArgsForCall = append(fake.getPageArgsForCall, struct {
+func (fake *FakeController) DeleteAttachment(arg1 context.Context, arg2 string, arg3 string, arg4 bool, arg5 bool) error {
+	fake.deleteAttachmentMutex.Lock()
+	ret, specificReturn := fake.deleteAttachmentReturnsOnCall[len(fake.deleteAttachmentArgsForCall)]
+	fake.deleteAttachmentArgsForCall = append(fake.deleteAttachmentArgsForCall, struct {
 		arg1 context.Context
 		arg2 string
-		arg3 bool
-	}{arg1, arg2, arg3})
-	stub := fake.GetPageStub
-	fakeReturns := fake.getPageReturns
-	fake.recordInvocation("GetPage", []interface{}{arg1, arg2, arg3})
-	fake.getPageMutex.Unlock()
+		arg3 string
+		arg4 bool
+		arg5 bool
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.DeleteAttachmentStub
+	fakeReturns := fake.deleteAttachmentReturns
+	fake.recordInvocation("DeleteAttachment", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.deleteAttachmentMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3)
+		return stub(arg1, arg2, arg3, arg4, arg5)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2
+		return ret.result1
 	}
-	return fakeReturns.result1, fakeReturns.result2
+	return fakeReturns.result1
 }
 
-func (fake *FakeController) GetPageCallCount() int {
-	fake.getPageMutex.RLock()
-	defer fake.getPageMutex.RUnlock()
-	return len(fake.getPageArgsForCall)
+func (fake *FakeController) DeleteAttachmentCallCount() int {
+	fake.deleteAttachmentMutex.RLock()
+	defer fake.deleteAttachmentMutex.RUnlock()
+	return len(fake.deleteAttachmentArgsForCall)
 }
 
-func (fake *FakeController) GetPageCalls(stub func(context.Context, string, bool) (*session.Page, error)) {
-	fake.getPageMutex.Lock()
-	defer fake.getPageMutex.Unlock()
-	fake.GetPageStub = stub
+func (fake *FakeController) DeleteAttachmentCalls(stub func(context.Context, string, string, bool, bool) error) {
+	fake.deleteAttachmentMutex.Lock()
+	defer fake.deleteAttachmentMutex.Unlock()
+	fake.DeleteAttachmentStub = stub
 }
 
-func (fake *FakeController) GetPageArgsForCall(i int) (context.Context, string, bool) {
-	fake.getPageMutex.RLock()
-	defer fake.getPageMutex.RUnlock()
-	argsForCall := fake.getPageArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+func (fake *FakeController) DeleteAttachmentArgsForCall(i int) (context.Context, string, string, bool, bool) {
+	fake.deleteAttachmentMutex.RLock()
+	defer fake.deleteAttachmentMutex.RUnlock()
+	argsForCall := fake.deleteAttachmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeController) DeleteAttachmentReturns(result1 error) {
+	fake.deleteAttachmentMutex.Lock()
+	defer fake.deleteAttachmentMutex.Unlock()
+	fake.DeleteAttachmentStub = nil
+	fake.deleteAttachmentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeController) DeleteAttachmentReturnsOnCall(i int, result1 error) {
+	fake.deleteAttachmentMutex.Lock()
+	defer fake.deleteAttachmentMutex.Unlock()
+	fake.DeleteAttachmentStub = nil
+	if fake.deleteAttachmentReturnsOnCall == nil {
+		fake.deleteAttachmentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteAttachmentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeController) DeleteChatMessage(arg1 context.Context, arg2 string) error {
+	fake.deleteChatMessageMutex.Lock()
+	ret, specificReturn := fake.deleteChatMessageReturnsOnCall[len(fake.deleteChatMessageArgsForCall)]
+	fake.deleteChatMessageArgsForCall = append(fake.deleteChatMessageArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteChatMessageStub
+	fakeReturns := fake.deleteChatMessageReturns
+	fake.recordInvocation("DeleteChatMessage", []interface{}{arg1, arg2})
+	fake.deleteChatMessageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeController) DeleteChatMessageCallCount() int {
+	fake.deleteChatMessageMutex.RLock()
+	defer fake.deleteChatMessageMutex.RUnlock()
+	return len(fake.deleteChatMessageArgsForCall)
+}
+
+func (fake *FakeController) DeleteChatMessageCalls(stub func(context.Context, string) error) {
+	fake.deleteChatMessageMutex.Lock()
+	defer fake.deleteChatMessageMutex.Unlock()
+	fake.DeleteChatMessageStub = stub
+}
+
+func (fake *FakeController) DeleteChatMessageArgsForCall(i int) (context.Context, string) {
+	fake.deleteChatMessageMutex.RLock()
+	defer fake.deleteChatMessageMutex.RUnlock()
+	argsForCall := fake.deleteChatMessageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeController) DeleteChatMessageReturns(result1 error) {
+	fake.deleteChatMessageMutex.Lock()
+	defer fake.deleteChatMessageMutex.Unlock()
+	fake.DeleteChatMessageStub = nil
+	fake.deleteChatMessageReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeController) DeleteChatMessageReturnsOnCall(i int, result1 error) {
+	fake.deleteChatMessageMutex.Lock()
+	defer fake.deleteChatMessageMutex.Unlock()
+	fake.DeleteChatMessageStub = nil
+	if fake.deleteChatMessageReturnsOnCall == nil {
+		fake.deleteChatMessageReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteChatMessageReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeController) DeleteCommentThread(arg1 context.Context, arg2 string, arg3 string, arg4 string, arg5 bool) error {
+	fake.deleteCommentThreadMutex.Lock()
+	ret, specificReturn := fake.deleteCommentThreadReturnsOnCall[len(fake.deleteCommentThreadArgsForCall)]
+	fake.deleteCommentThreadArgsForCall = append(fake.deleteCommentThreadArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 bool
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.DeleteCommentThreadStub
+	fakeReturns := fake.deleteCommentThreadReturns
+	fake.recordInvocation("DeleteCommentThread", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.deleteCommentThreadMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeController) DeleteCommentThreadCallCount() int {
+	fake.deleteCommentThreadMutex.RLock()
+	defer fake.deleteCommentThreadMutex.RUnlock()
+	return len(fake.deleteCommentThreadArgsForCall)
+}
+
+func (fake *FakeController) DeleteCommentThreadCalls(stub func(context.Context, string, string, string, bool) error) {
+	fake.deleteCommentThreadMutex.Lock()
+	defer fake.deleteCommentThreadMutex.Unlock()
+	fake.DeleteCommentThreadStub = stub
+}
+
+func (fake *FakeController) DeleteCommentThreadArgsForCall(i int) (context.Context, string, string, string, bool) {
+	fake.deleteCommentThreadMutex.RLock()
+	defer fake.deleteCommentThreadMutex.RUnlock()
+	argsForCall := fake.deleteCommentThreadArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeController) DeleteCommentThreadReturns(result1 error) {
+	fake.deleteCommentThreadMutex.Lock()
+	defer fake.deleteCommentThreadMutex.Unlock()
+	fake.DeleteCommentThreadStub = nil
+	fake.deleteCommentThreadReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeController) DeleteCommentThreadReturnsOnCall(i int, result1 error) {
+	fake.deleteCommentThreadMutex.Lock()
+	defer fake.deleteCommentThreadMutex.Unlock()
+	fake.DeleteCommentThreadStub = nil
+	if fake.deleteCommentThreadReturnsOnCall == nil {
+		fake.deleteCommentThreadReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteCommentThreadReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeController) DeleteLayer(arg1 context.Context, arg2 string, arg3 string, arg4 bool) error {
+	fake.deleteLayerMutex.Lock()
+	ret, specificReturn := fake.deleteLayerReturnsOnCall[len(fake.deleteLayerArgsForCall)]
+	fake.deleteLayerArgsForCall = append(fake.deleteLayerArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 bool
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.DeleteLayerStub
+	fakeReturns := fake.deleteLayerReturns
+	fake.recordInvocation("DeleteLayer", []interface{}{arg1, arg2, arg3, arg4})
+	fake.deleteLayerMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeController) DeleteLayerCallCount() int {
+	fake.deleteLayerMutex.RLock()
+	defer fake.deleteLayerMutex.RUnlock()
+	return len(fake.deleteLayerArgsForCall)
+}
+
+func (fake *FakeController) DeleteLayerCalls(stub func(context.Context, string, string, bool) error) {
+	fake.deleteLayerMutex.Lock()
+	defer fake.deleteLayerMutex.Unlock()
+	fake.DeleteLayerStub = stub
+}
+
+func (fake *FakeController) DeleteLayerArgsForCall(i int) (context.Context, string, string, bool) {
+	fake.deleteLayerMutex.RLock()
+	defer fake.deleteLayerMutex.RUnlock()
+	argsForCall := fake.deleteLayerArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeController) DeleteLayerReturns(result1 error) {
+	fake.deleteLayerMutex.Lock()
+	defer fake.deleteLayerMutex.Unlock()
+	fake.DeleteLayerStub = nil
+	fake.deleteLayerReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeController) DeleteLayerReturnsOnCall(i int, result1 error) {
+	fake.deleteLayerMutex.Lock()
+	defer fake.deleteLayerMutex.Unlock()
+	fake.DeleteLayerStub = nil
+	if fake.deleteLayerReturnsOnCall == nil {
+		fake.deleteLayerReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteLayerReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeController) GetAttachments(arg1 context.Context) ([]*session.Attachment, error) {
+	fake.getAttachmentsMutex.Lock()
+	ret, specificReturn := fake.getAttachmentsReturnsOnCall[len(fake.getAttachmentsArgsForCall)]
+	fake.getAttachmentsArgsForCall = append(fake.getAttachmentsArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.GetAttachmentsStub
+	fakeReturns := fake.getAttachmentsReturns
+	fake.recordInvocation("GetAttachments", []interface{}{arg1})
+	fake.getAttachmentsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeController) GetAttachmentsCallCount() int {
+	fake.getAttachmentsMutex.RLock()
+	defer fake.getAttachmentsMutex.RUnlock()
+	return len(fake.getAttachmentsArgsForCall)
+}
+
+func (fake *FakeController) GetAttachmentsCalls(stub func(context.Context) ([]*session.Attachment, error)) {
+	fake.getAttachmentsMutex.Lock()
+	defer fake.getAttachmentsMutex.Unlock()
+	fake.GetAttachmentsStub = stub
+}
+
+func (fake *FakeController) GetAttachmentsArgsForCall(i int) context.Context {
+	fake.getAttachmentsMutex.RLock()
+	defer fake.getAttachmentsMutex.RUnlock()
+	argsForCall := fake.getAttachmentsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeController) GetAttachmentsReturns(result1 []*session.Attachment, result2 error) {
+	fake.getAttachmentsMutex.Lock()
+	defer fake.getAttachmentsMutex.Unlock()
+	fake.GetAttachmentsStub = nil
+	fake.getAttachmentsReturns = struct {
+		result1 []*session.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) GetAttachmentsReturnsOnCall(i int, result1 []*session.Attachment, result2 error) {
+	fake.getAttachmentsMutex.Lock()
+	defer fake.getAttachmentsMutex.Unlock()
+	fake.GetAttachmentsStub = nil
+	if fake.getAttachmentsReturnsOnCall == nil {
+		fake.getAttachmentsReturnsOnCall = make(map[int]struct {
+			result1 []*session.Attachment
+			result2 error
+		})
+	}
+	fake.getAttachmentsReturnsOnCall[i] = struct {
+		result1 []*session.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) GetChat(arg1 context.Context) ([]*session.ChatMessage, error) {
+	fake.getChatMutex.Lock()
+	ret, specificReturn := fake.getChatReturnsOnCall[len(fake.getChatArgsForCall)]
+	fake.getChatArgsForCall = append(fake.getChatArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.GetChatStub
+	fakeReturns := fake.getChatReturns
+	fake.recordInvocation("GetChat", []interface{}{arg1})
+	fake.getChatMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeController) GetChatCallCount() int {
+	fake.getChatMutex.RLock()
+	defer fake.getChatMutex.RUnlock()
+	return len(fake.getChatArgsForCall)
+}
+
+func (fake *FakeController) GetChatCalls(stub func(context.Context) ([]*session.ChatMessage, error)) {
+	fake.getChatMutex.Lock()
+	defer fake.getChatMutex.Unlock()
+	fake.GetChatStub = stub
+}
+
+func (fake *FakeController) GetChatArgsForCall(i int) context.Context {
+	fake.getChatMutex.RLock()
+	defer fake.getChatMutex.RUnlock()
+	argsForCall := fake.getChatArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeController) GetChatReturns(result1 []*session.ChatMessage, result2 error) {
+	fake.getChatMutex.Lock()
+	defer fake.getChatMutex.Unlock()
+	fake.GetChatStub = nil
+	fake.getChatReturns = struct {
+		result1 []*session.ChatMessage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) GetChatReturnsOnCall(i int, result1 []*session.ChatMessage, result2 error) {
+	fake.getChatMutex.Lock()
+	defer fake.getChatMutex.Unlock()
+	fake.GetChatStub = nil
+	if fake.getChatReturnsOnCall == nil {
+		fake.getChatReturnsOnCall = make(map[int]struct {
+			result1 []*session.ChatMessage
+			result2 error
+		})
+	}
+	fake.getChatReturnsOnCall[i] = struct {
+		result1 []*session.ChatMessage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) GetComments(arg1 context.Context, arg2 string) ([]*session.CommentThread, error) {
+	fake.getCommentsMutex.Lock()
+	ret, specificReturn := fake.getCommentsReturnsOnCall[len(fake.getCommentsArgsForCall)]
+	fake.getCommentsArgsForCall = append(fake.getCommentsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetCommentsStub
+	fakeReturns := fake.getCommentsReturns
+	fake.recordInvocation("GetComments", []interface{}{arg1, arg2})
+	fake.getCommentsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeController) GetCommentsCallCount() int {
+	fake.getCommentsMutex.RLock()
+	defer fake.getCommentsMutex.RUnlock()
+	return len(fake.getCommentsArgsForCall)
+}
+
+func (fake *FakeController) GetCommentsCalls(stub func(context.Context, string) ([]*session.CommentThread, error)) {
+	fake.getCommentsMutex.Lock()
+	defer fake.getCommentsMutex.Unlock()
+	fake.GetCommentsStub = stub
+}
+
+func (fake *FakeController) GetCommentsArgsForCall(i int) (context.Context, string) {
+	fake.getCommentsMutex.RLock()
+	defer fake.getCommentsMutex.RUnlock()
+	argsForCall := fake.getCommentsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeController) GetCommentsReturns(result1 []*session.CommentThread, result2 error) {
+	fake.getCommentsMutex.Lock()
+	defer fake.getCommentsMutex.Unlock()
+	fake.GetCommentsStub = nil
+	fake.getCommentsReturns = struct {
+		result1 []*session.CommentThread
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) GetCommentsReturnsOnCall(i int, result1 []*session.CommentThread, result2 error) {
+	fake.getCommentsMutex.Lock()
+	defer fake.getCommentsMutex.Unlock()
+	fake.GetCommentsStub = nil
+	if fake.getCommentsReturnsOnCall == nil {
+		fake.getCommentsReturnsOnCall = make(map[int]struct {
+			result1 []*session.CommentThread
+			result2 error
+		})
+	}
+	fake.getCommentsReturnsOnCall[i] = struct {
+		result1 []*session.CommentThread
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) GetLayers(arg1 context.Context, arg2 string) (session.Layers, error) {
+	fake.getLayersMutex.Lock()
+	ret, specificReturn := fake.getLayersReturnsOnCall[len(fake.getLayersArgsForCall)]
+	fake.getLayersArgsForCall = append(fake.getLayersArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetLayersStub
+	fakeReturns := fake.getLayersReturns
+	fake.recordInvocation("GetLayers", []interface{}{arg1, arg2})
+	fake.getLayersMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeController) GetLayersCallCount() int {
+	fake.getLayersMutex.RLock()
+	defer fake.getLayersMutex.RUnlock()
+	return len(fake.getLayersArgsForCall)
+}
+
+func (fake *FakeController) GetLayersCalls(stub func(context.Context, string) (session.Layers, error)) {
+	fake.getLayersMutex.Lock()
+	defer fake.getLayersMutex.Unlock()
+	fake.GetLayersStub = stub
+}
+
+func (fake *FakeController) GetLayersArgsForCall(i int) (context.Context, string) {
+	fake.getLayersMutex.RLock()
+	defer fake.getLayersMutex.RUnlock()
+	argsForCall := fake.getLayersArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeController) GetLayersReturns(result1 session.Layers, result2 error) {
+	fake.getLayersMutex.Lock()
+	defer fake.getLayersMutex.Unlock()
+	fake.GetLayersStub = nil
+	fake.getLayersReturns = struct {
+		result1 session.Layers
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) GetLayersReturnsOnCall(i int, result1 session.Layers, result2 error) {
+	fake.getLayersMutex.Lock()
+	defer fake.getLayersMutex.Unlock()
+	fake.GetLayersStub = nil
+	if fake.getLayersReturnsOnCall == nil {
+		fake.getLayersReturnsOnCall = make(map[int]struct {
+			result1 session.Layers
+			result2 error
+		})
+	}
+	fake.getLayersReturnsOnCall[i] = struct {
+		result1 session.Layers
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) GetPage(arg1 context.Context, arg2 string, arg3 bool) (*session.Page, error) {
+	fake.getPageMutex.Lock()
+	ret, specificReturn := fake.getPageReturnsOnCall[len(fake.getPageArgsForCall)]
+	fake.getPageArgsForCall = append(fake.getPageArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 bool
+	}{arg1, arg2, arg3})
+	stub := fake.GetPageStub
+	fakeReturns := fake.getPageReturns
+	fake.recordInvocation("GetPage", []interface{}{arg1, arg2, arg3})
+	fake.getPageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeController) GetPageCallCount() int {
+	fake.getPageMutex.RLock()
+	defer fake.getPageMutex.RUnlock()
+	return len(fake.getPageArgsForCall)
+}
+
+func (fake *FakeController) GetPageCalls(stub func(context.Context, string, bool) (*session.Page, error)) {
+	fake.getPageMutex.Lock()
+	defer fake.getPageMutex.Unlock()
+	fake.GetPageStub = stub
+}
+
+func (fake *FakeController) GetPageArgsForCall(i int) (context.Context, string, bool) {
+	fake.getPageMutex.RLock()
+	defer fake.getPageMutex.RUnlock()
+	argsForCall := fake.getPageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeController) GetPageReturns(result1 *session.Page, result2 error) {
@@ -813,8 +1832,140 @@ func (fake *FakeController) GetPageSyncReturnsOnCall(i int, result1 *session.Pag
 			result2 error
 		})
 	}
-	fake.getPageSyncReturnsOnCall[i] = struct {
-		result1 *session.PageSync
+	fake.getPageSyncReturnsOnCall[i] = struct {
+		result1 *session.PageSync
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) GetStrokesInPolygon(arg1 context.Context, arg2 string, arg3 geometry.Polygon) ([]*session.Stroke, error) {
+	fake.getStrokesInPolygonMutex.Lock()
+	ret, specificReturn := fake.getStrokesInPolygonReturnsOnCall[len(fake.getStrokesInPolygonArgsForCall)]
+	fake.getStrokesInPolygonArgsForCall = append(fake.getStrokesInPolygonArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 geometry.Polygon
+	}{arg1, arg2, arg3})
+	stub := fake.GetStrokesInPolygonStub
+	fakeReturns := fake.getStrokesInPolygonReturns
+	fake.recordInvocation("GetStrokesInPolygon", []interface{}{arg1, arg2, arg3})
+	fake.getStrokesInPolygonMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeController) GetStrokesInPolygonCallCount() int {
+	fake.getStrokesInPolygonMutex.RLock()
+	defer fake.getStrokesInPolygonMutex.RUnlock()
+	return len(fake.getStrokesInPolygonArgsForCall)
+}
+
+func (fake *FakeController) GetStrokesInPolygonCalls(stub func(context.Context, string, geometry.Polygon) ([]*session.Stroke, error)) {
+	fake.getStrokesInPolygonMutex.Lock()
+	defer fake.getStrokesInPolygonMutex.Unlock()
+	fake.GetStrokesInPolygonStub = stub
+}
+
+func (fake *FakeController) GetStrokesInPolygonArgsForCall(i int) (context.Context, string, geometry.Polygon) {
+	fake.getStrokesInPolygonMutex.RLock()
+	defer fake.getStrokesInPolygonMutex.RUnlock()
+	argsForCall := fake.getStrokesInPolygonArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeController) GetStrokesInPolygonReturns(result1 []*session.Stroke, result2 error) {
+	fake.getStrokesInPolygonMutex.Lock()
+	defer fake.getStrokesInPolygonMutex.Unlock()
+	fake.GetStrokesInPolygonStub = nil
+	fake.getStrokesInPolygonReturns = struct {
+		result1 []*session.Stroke
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) GetStrokesInPolygonReturnsOnCall(i int, result1 []*session.Stroke, result2 error) {
+	fake.getStrokesInPolygonMutex.Lock()
+	defer fake.getStrokesInPolygonMutex.Unlock()
+	fake.GetStrokesInPolygonStub = nil
+	if fake.getStrokesInPolygonReturnsOnCall == nil {
+		fake.getStrokesInPolygonReturnsOnCall = make(map[int]struct {
+			result1 []*session.Stroke
+			result2 error
+		})
+	}
+	fake.getStrokesInPolygonReturnsOnCall[i] = struct {
+		result1 []*session.Stroke
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) GetStrokesInRect(arg1 context.Context, arg2 string, arg3 geometry.Rect) ([]*session.Stroke, error) {
+	fake.getStrokesInRectMutex.Lock()
+	ret, specificReturn := fake.getStrokesInRectReturnsOnCall[len(fake.getStrokesInRectArgsForCall)]
+	fake.getStrokesInRectArgsForCall = append(fake.getStrokesInRectArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 geometry.Rect
+	}{arg1, arg2, arg3})
+	stub := fake.GetStrokesInRectStub
+	fakeReturns := fake.getStrokesInRectReturns
+	fake.recordInvocation("GetStrokesInRect", []interface{}{arg1, arg2, arg3})
+	fake.getStrokesInRectMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeController) GetStrokesInRectCallCount() int {
+	fake.getStrokesInRectMutex.RLock()
+	defer fake.getStrokesInRectMutex.RUnlock()
+	return len(fake.getStrokesInRectArgsForCall)
+}
+
+func (fake *FakeController) GetStrokesInRectCalls(stub func(context.Context, string, geometry.Rect) ([]*session.Stroke, error)) {
+	fake.getStrokesInRectMutex.Lock()
+	defer fake.getStrokesInRectMutex.Unlock()
+	fake.GetStrokesInRectStub = stub
+}
+
+func (fake *FakeController) GetStrokesInRectArgsForCall(i int) (context.Context, string, geometry.Rect) {
+	fake.getStrokesInRectMutex.RLock()
+	defer fake.getStrokesInRectMutex.RUnlock()
+	argsForCall := fake.getStrokesInRectArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeController) GetStrokesInRectReturns(result1 []*session.Stroke, result2 error) {
+	fake.getStrokesInRectMutex.Lock()
+	defer fake.getStrokesInRectMutex.Unlock()
+	fake.GetStrokesInRectStub = nil
+	fake.getStrokesInRectReturns = struct {
+		result1 []*session.Stroke
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) GetStrokesInRectReturnsOnCall(i int, result1 []*session.Stroke, result2 error) {
+	fake.getStrokesInRectMutex.Lock()
+	defer fake.getStrokesInRectMutex.Unlock()
+	fake.GetStrokesInRectStub = nil
+	if fake.getStrokesInRectReturnsOnCall == nil {
+		fake.getStrokesInRectReturnsOnCall = make(map[int]struct {
+			result1 []*session.Stroke
+			result2 error
+		})
+	}
+	fake.getStrokesInRectReturnsOnCall[i] = struct {
+		result1 []*session.Stroke
 		result2 error
 	}{result1, result2}
 }
@@ -872,6 +2023,59 @@ func (fake *FakeController) GetUsersReturnsOnCall(i int, result1 map[string]*ses
 	}{result1}
 }
 
+func (fake *FakeController) HostViewport() *session.ContentViewport {
+	fake.hostViewportMutex.Lock()
+	ret, specificReturn := fake.hostViewportReturnsOnCall[len(fake.hostViewportArgsForCall)]
+	fake.hostViewportArgsForCall = append(fake.hostViewportArgsForCall, struct {
+	}{})
+	stub := fake.HostViewportStub
+	fakeReturns := fake.hostViewportReturns
+	fake.recordInvocation("HostViewport", []interface{}{})
+	fake.hostViewportMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeController) HostViewportCallCount() int {
+	fake.hostViewportMutex.RLock()
+	defer fake.hostViewportMutex.RUnlock()
+	return len(fake.hostViewportArgsForCall)
+}
+
+func (fake *FakeController) HostViewportCalls(stub func() *session.ContentViewport) {
+	fake.hostViewportMutex.Lock()
+	defer fake.hostViewportMutex.Unlock()
+	fake.HostViewportStub = stub
+}
+
+func (fake *FakeController) HostViewportReturns(result1 *session.ContentViewport) {
+	fake.hostViewportMutex.Lock()
+	defer fake.hostViewportMutex.Unlock()
+	fake.HostViewportStub = nil
+	fake.hostViewportReturns = struct {
+		result1 *session.ContentViewport
+	}{result1}
+}
+
+func (fake *FakeController) HostViewportReturnsOnCall(i int, result1 *session.ContentViewport) {
+	fake.hostViewportMutex.Lock()
+	defer fake.hostViewportMutex.Unlock()
+	fake.HostViewportStub = nil
+	if fake.hostViewportReturnsOnCall == nil {
+		fake.hostViewportReturnsOnCall = make(map[int]struct {
+			result1 *session.ContentViewport
+		})
+	}
+	fake.hostViewportReturnsOnCall[i] = struct {
+		result1 *session.ContentViewport
+	}{result1}
+}
+
 func (fake *FakeController) ID() string {
 	fake.iDMutex.Lock()
 	ret, specificReturn := fake.iDReturnsOnCall[len(fake.iDArgsForCall)]
@@ -1165,6 +2369,125 @@ func (fake *FakeController) NumUsersReturnsOnCall(i int, result1 int) {
 	}{result1}
 }
 
+func (fake *FakeController) PageThumbnail(arg1 context.Context, arg2 string, arg3 int) (*session.Thumbnail, error) {
+	fake.pageThumbnailMutex.Lock()
+	ret, specificReturn := fake.pageThumbnailReturnsOnCall[len(fake.pageThumbnailArgsForCall)]
+	fake.pageThumbnailArgsForCall = append(fake.pageThumbnailArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+	}{arg1, arg2, arg3})
+	stub := fake.PageThumbnailStub
+	fakeReturns := fake.pageThumbnailReturns
+	fake.recordInvocation("PageThumbnail", []interface{}{arg1, arg2, arg3})
+	fake.pageThumbnailMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeController) PageThumbnailCallCount() int {
+	fake.pageThumbnailMutex.RLock()
+	defer fake.pageThumbnailMutex.RUnlock()
+	return len(fake.pageThumbnailArgsForCall)
+}
+
+func (fake *FakeController) PageThumbnailCalls(stub func(context.Context, string, int) (*session.Thumbnail, error)) {
+	fake.pageThumbnailMutex.Lock()
+	defer fake.pageThumbnailMutex.Unlock()
+	fake.PageThumbnailStub = stub
+}
+
+func (fake *FakeController) PageThumbnailArgsForCall(i int) (context.Context, string, int) {
+	fake.pageThumbnailMutex.RLock()
+	defer fake.pageThumbnailMutex.RUnlock()
+	argsForCall := fake.pageThumbnailArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeController) PageThumbnailReturns(result1 *session.Thumbnail, result2 error) {
+	fake.pageThumbnailMutex.Lock()
+	defer fake.pageThumbnailMutex.Unlock()
+	fake.PageThumbnailStub = nil
+	fake.pageThumbnailReturns = struct {
+		result1 *session.Thumbnail
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) PageThumbnailReturnsOnCall(i int, result1 *session.Thumbnail, result2 error) {
+	fake.pageThumbnailMutex.Lock()
+	defer fake.pageThumbnailMutex.Unlock()
+	fake.PageThumbnailStub = nil
+	if fake.pageThumbnailReturnsOnCall == nil {
+		fake.pageThumbnailReturnsOnCall = make(map[int]struct {
+			result1 *session.Thumbnail
+			result2 error
+		})
+	}
+	fake.pageThumbnailReturnsOnCall[i] = struct {
+		result1 *session.Thumbnail
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) QueueStats() session.QueueStats {
+	fake.queueStatsMutex.Lock()
+	ret, specificReturn := fake.queueStatsReturnsOnCall[len(fake.queueStatsArgsForCall)]
+	fake.queueStatsArgsForCall = append(fake.queueStatsArgsForCall, struct {
+	}{})
+	stub := fake.QueueStatsStub
+	fakeReturns := fake.queueStatsReturns
+	fake.recordInvocation("QueueStats", []interface{}{})
+	fake.queueStatsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeController) QueueStatsCallCount() int {
+	fake.queueStatsMutex.RLock()
+	defer fake.queueStatsMutex.RUnlock()
+	return len(fake.queueStatsArgsForCall)
+}
+
+func (fake *FakeController) QueueStatsCalls(stub func() session.QueueStats) {
+	fake.queueStatsMutex.Lock()
+	defer fake.queueStatsMutex.Unlock()
+	fake.QueueStatsStub = stub
+}
+
+func (fake *FakeController) QueueStatsReturns(result1 session.QueueStats) {
+	fake.queueStatsMutex.Lock()
+	defer fake.queueStatsMutex.Unlock()
+	fake.QueueStatsStub = nil
+	fake.queueStatsReturns = struct {
+		result1 session.QueueStats
+	}{result1}
+}
+
+func (fake *FakeController) QueueStatsReturnsOnCall(i int, result1 session.QueueStats) {
+	fake.queueStatsMutex.Lock()
+	defer fake.queueStatsMutex.Unlock()
+	fake.QueueStatsStub = nil
+	if fake.queueStatsReturnsOnCall == nil {
+		fake.queueStatsReturnsOnCall = make(map[int]struct {
+			result1 session.QueueStats
+		})
+	}
+	fake.queueStatsReturnsOnCall[i] = struct {
+		result1 session.QueueStats
+	}{result1}
+}
+
 func (fake *FakeController) Receive(arg1 context.Context, arg2 *session.Message, arg3 string) error {
 	fake.receiveMutex.Lock()
 	ret, specificReturn := fake.receiveReturnsOnCall[len(fake.receiveArgsForCall)]
@@ -1228,6 +2551,206 @@ func (fake *FakeController) ReceiveReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeController) ReplyComment(arg1 context.Context, arg2 string, arg3 string, arg4 session.User, arg5 session.CommentRequest) (*session.Comment, error) {
+	fake.replyCommentMutex.Lock()
+	ret, specificReturn := fake.replyCommentReturnsOnCall[len(fake.replyCommentArgsForCall)]
+	fake.replyCommentArgsForCall = append(fake.replyCommentArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 session.User
+		arg5 session.CommentRequest
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.ReplyCommentStub
+	fakeReturns := fake.replyCommentReturns
+	fake.recordInvocation("ReplyComment", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.replyCommentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeController) ReplyCommentCallCount() int {
+	fake.replyCommentMutex.RLock()
+	defer fake.replyCommentMutex.RUnlock()
+	return len(fake.replyCommentArgsForCall)
+}
+
+func (fake *FakeController) ReplyCommentCalls(stub func(context.Context, string, string, session.User, session.CommentRequest) (*session.Comment, error)) {
+	fake.replyCommentMutex.Lock()
+	defer fake.replyCommentMutex.Unlock()
+	fake.ReplyCommentStub = stub
+}
+
+func (fake *FakeController) ReplyCommentArgsForCall(i int) (context.Context, string, string, session.User, session.CommentRequest) {
+	fake.replyCommentMutex.RLock()
+	defer fake.replyCommentMutex.RUnlock()
+	argsForCall := fake.replyCommentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeController) ReplyCommentReturns(result1 *session.Comment, result2 error) {
+	fake.replyCommentMutex.Lock()
+	defer fake.replyCommentMutex.Unlock()
+	fake.ReplyCommentStub = nil
+	fake.replyCommentReturns = struct {
+		result1 *session.Comment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) ReplyCommentReturnsOnCall(i int, result1 *session.Comment, result2 error) {
+	fake.replyCommentMutex.Lock()
+	defer fake.replyCommentMutex.Unlock()
+	fake.ReplyCommentStub = nil
+	if fake.replyCommentReturnsOnCall == nil {
+		fake.replyCommentReturnsOnCall = make(map[int]struct {
+			result1 *session.Comment
+			result2 error
+		})
+	}
+	fake.replyCommentReturnsOnCall[i] = struct {
+		result1 *session.Comment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) ResolveCommentThread(arg1 context.Context, arg2 string, arg3 string, arg4 string, arg5 bool, arg6 bool) error {
+	fake.resolveCommentThreadMutex.Lock()
+	ret, specificReturn := fake.resolveCommentThreadReturnsOnCall[len(fake.resolveCommentThreadArgsForCall)]
+	fake.resolveCommentThreadArgsForCall = append(fake.resolveCommentThreadArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 bool
+		arg6 bool
+	}{arg1, arg2, arg3, arg4, arg5, arg6})
+	stub := fake.ResolveCommentThreadStub
+	fakeReturns := fake.resolveCommentThreadReturns
+	fake.recordInvocation("ResolveCommentThread", []interface{}{arg1, arg2, arg3, arg4, arg5, arg6})
+	fake.resolveCommentThreadMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5, arg6)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeController) ResolveCommentThreadCallCount() int {
+	fake.resolveCommentThreadMutex.RLock()
+	defer fake.resolveCommentThreadMutex.RUnlock()
+	return len(fake.resolveCommentThreadArgsForCall)
+}
+
+func (fake *FakeController) ResolveCommentThreadCalls(stub func(context.Context, string, string, string, bool, bool) error) {
+	fake.resolveCommentThreadMutex.Lock()
+	defer fake.resolveCommentThreadMutex.Unlock()
+	fake.ResolveCommentThreadStub = stub
+}
+
+func (fake *FakeController) ResolveCommentThreadArgsForCall(i int) (context.Context, string, string, string, bool, bool) {
+	fake.resolveCommentThreadMutex.RLock()
+	defer fake.resolveCommentThreadMutex.RUnlock()
+	argsForCall := fake.resolveCommentThreadArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5, argsForCall.arg6
+}
+
+func (fake *FakeController) ResolveCommentThreadReturns(result1 error) {
+	fake.resolveCommentThreadMutex.Lock()
+	defer fake.resolveCommentThreadMutex.Unlock()
+	fake.ResolveCommentThreadStub = nil
+	fake.resolveCommentThreadReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeController) ResolveCommentThreadReturnsOnCall(i int, result1 error) {
+	fake.resolveCommentThreadMutex.Lock()
+	defer fake.resolveCommentThreadMutex.Unlock()
+	fake.ResolveCommentThreadStub = nil
+	if fake.resolveCommentThreadReturnsOnCall == nil {
+		fake.resolveCommentThreadReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.resolveCommentThreadReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeController) Search(arg1 context.Context, arg2 string, arg3 int) ([]*session.SearchResult, error) {
+	fake.searchMutex.Lock()
+	ret, specificReturn := fake.searchReturnsOnCall[len(fake.searchArgsForCall)]
+	fake.searchArgsForCall = append(fake.searchArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+	}{arg1, arg2, arg3})
+	stub := fake.SearchStub
+	fakeReturns := fake.searchReturns
+	fake.recordInvocation("Search", []interface{}{arg1, arg2, arg3})
+	fake.searchMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeController) SearchCallCount() int {
+	fake.searchMutex.RLock()
+	defer fake.searchMutex.RUnlock()
+	return len(fake.searchArgsForCall)
+}
+
+func (fake *FakeController) SearchCalls(stub func(context.Context, string, int) ([]*session.SearchResult, error)) {
+	fake.searchMutex.Lock()
+	defer fake.searchMutex.Unlock()
+	fake.SearchStub = stub
+}
+
+func (fake *FakeController) SearchArgsForCall(i int) (context.Context, string, int) {
+	fake.searchMutex.RLock()
+	defer fake.searchMutex.RUnlock()
+	argsForCall := fake.searchArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeController) SearchReturns(result1 []*session.SearchResult, result2 error) {
+	fake.searchMutex.Lock()
+	defer fake.searchMutex.Unlock()
+	fake.SearchStub = nil
+	fake.searchReturns = struct {
+		result1 []*session.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) SearchReturnsOnCall(i int, result1 []*session.SearchResult, result2 error) {
+	fake.searchMutex.Lock()
+	defer fake.searchMutex.Unlock()
+	fake.SearchStub = nil
+	if fake.searchReturnsOnCall == nil {
+		fake.searchReturnsOnCall = make(map[int]struct {
+			result1 []*session.SearchResult
+			result2 error
+		})
+	}
+	fake.searchReturnsOnCall[i] = struct {
+		result1 []*session.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeController) SetConfig(arg1 *session.ConfigRequest) error {
 	fake.setConfigMutex.Lock()
 	ret, specificReturn := fake.setConfigReturnsOnCall[len(fake.setConfigArgsForCall)]
@@ -1351,20 +2874,86 @@ func (fake *FakeController) SyncSessionReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeController) UpdatePages(arg1 context.Context, arg2 session.PageRequest, arg3 string) error {
+func (fake *FakeController) UpdateLayer(arg1 context.Context, arg2 string, arg3 string, arg4 session.LayerRequest, arg5 bool) error {
+	fake.updateLayerMutex.Lock()
+	ret, specificReturn := fake.updateLayerReturnsOnCall[len(fake.updateLayerArgsForCall)]
+	fake.updateLayerArgsForCall = append(fake.updateLayerArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 session.LayerRequest
+		arg5 bool
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.UpdateLayerStub
+	fakeReturns := fake.updateLayerReturns
+	fake.recordInvocation("UpdateLayer", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.updateLayerMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeController) UpdateLayerCallCount() int {
+	fake.updateLayerMutex.RLock()
+	defer fake.updateLayerMutex.RUnlock()
+	return len(fake.updateLayerArgsForCall)
+}
+
+func (fake *FakeController) UpdateLayerCalls(stub func(context.Context, string, string, session.LayerRequest, bool) error) {
+	fake.updateLayerMutex.Lock()
+	defer fake.updateLayerMutex.Unlock()
+	fake.UpdateLayerStub = stub
+}
+
+func (fake *FakeController) UpdateLayerArgsForCall(i int) (context.Context, string, string, session.LayerRequest, bool) {
+	fake.updateLayerMutex.RLock()
+	defer fake.updateLayerMutex.RUnlock()
+	argsForCall := fake.updateLayerArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeController) UpdateLayerReturns(result1 error) {
+	fake.updateLayerMutex.Lock()
+	defer fake.updateLayerMutex.Unlock()
+	fake.UpdateLayerStub = nil
+	fake.updateLayerReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeController) UpdateLayerReturnsOnCall(i int, result1 error) {
+	fake.updateLayerMutex.Lock()
+	defer fake.updateLayerMutex.Unlock()
+	fake.UpdateLayerStub = nil
+	if fake.updateLayerReturnsOnCall == nil {
+		fake.updateLayerReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updateLayerReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeController) UpdatePages(arg1 context.Context, arg2 session.PageRequest, arg3 string, arg4 bool) error {
 	fake.updatePagesMutex.Lock()
 	ret, specificReturn := fake.updatePagesReturnsOnCall[len(fake.updatePagesArgsForCall)]
 	fake.updatePagesArgsForCall = append(fake.updatePagesArgsForCall, struct {
 		arg1 context.Context
 		arg2 session.PageRequest
 		arg3 string
-	}{arg1, arg2, arg3})
+		arg4 bool
+	}{arg1, arg2, arg3, arg4})
 	stub := fake.UpdatePagesStub
 	fakeReturns := fake.updatePagesReturns
-	fake.recordInvocation("UpdatePages", []interface{}{arg1, arg2, arg3})
+	fake.recordInvocation("UpdatePages", []interface{}{arg1, arg2, arg3, arg4})
 	fake.updatePagesMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
@@ -1378,17 +2967,17 @@ func (fake *FakeController) UpdatePagesCallCount() int {
 	return len(fake.updatePagesArgsForCall)
 }
 
-func (fake *FakeController) UpdatePagesCalls(stub func(context.Context, session.PageRequest, string) error) {
+func (fake *FakeController) UpdatePagesCalls(stub func(context.Context, session.PageRequest, string, bool) error) {
 	fake.updatePagesMutex.Lock()
 	defer fake.updatePagesMutex.Unlock()
 	fake.UpdatePagesStub = stub
 }
 
-func (fake *FakeController) UpdatePagesArgsForCall(i int) (context.Context, session.PageRequest, string) {
+func (fake *FakeController) UpdatePagesArgsForCall(i int) (context.Context, session.PageRequest, string, bool) {
 	fake.updatePagesMutex.RLock()
 	defer fake.updatePagesMutex.RUnlock()
 	argsForCall := fake.updatePagesArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakeController) UpdatePagesReturns(result1 error) {
@@ -1476,6 +3065,72 @@ func (fake *FakeController) UpdateUserReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeController) UploadAttachment(arg1 context.Context, arg2 io.Reader, arg3 session.User) (*session.Attachment, error) {
+	fake.uploadAttachmentMutex.Lock()
+	ret, specificReturn := fake.uploadAttachmentReturnsOnCall[len(fake.uploadAttachmentArgsForCall)]
+	fake.uploadAttachmentArgsForCall = append(fake.uploadAttachmentArgsForCall, struct {
+		arg1 context.Context
+		arg2 io.Reader
+		arg3 session.User
+	}{arg1, arg2, arg3})
+	stub := fake.UploadAttachmentStub
+	fakeReturns := fake.uploadAttachmentReturns
+	fake.recordInvocation("UploadAttachment", []interface{}{arg1, arg2, arg3})
+	fake.uploadAttachmentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeController) UploadAttachmentCallCount() int {
+	fake.uploadAttachmentMutex.RLock()
+	defer fake.uploadAttachmentMutex.RUnlock()
+	return len(fake.uploadAttachmentArgsForCall)
+}
+
+func (fake *FakeController) UploadAttachmentCalls(stub func(context.Context, io.Reader, session.User) (*session.Attachment, error)) {
+	fake.uploadAttachmentMutex.Lock()
+	defer fake.uploadAttachmentMutex.Unlock()
+	fake.UploadAttachmentStub = stub
+}
+
+func (fake *FakeController) UploadAttachmentArgsForCall(i int) (context.Context, io.Reader, session.User) {
+	fake.uploadAttachmentMutex.RLock()
+	defer fake.uploadAttachmentMutex.RUnlock()
+	argsForCall := fake.uploadAttachmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeController) UploadAttachmentReturns(result1 *session.Attachment, result2 error) {
+	fake.uploadAttachmentMutex.Lock()
+	defer fake.uploadAttachmentMutex.Unlock()
+	fake.UploadAttachmentStub = nil
+	fake.uploadAttachmentReturns = struct {
+		result1 *session.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeController) UploadAttachmentReturnsOnCall(i int, result1 *session.Attachment, result2 error) {
+	fake.uploadAttachmentMutex.Lock()
+	defer fake.uploadAttachmentMutex.Unlock()
+	fake.UploadAttachmentStub = nil
+	if fake.uploadAttachmentReturnsOnCall == nil {
+		fake.uploadAttachmentReturnsOnCall = make(map[int]struct {
+			result1 *session.Attachment
+			result2 error
+		})
+	}
+	fake.uploadAttachmentReturnsOnCall[i] = struct {
+		result1 *session.Attachment
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeController) UserCanJoin(arg1 string) error {
 	fake.userCanJoinMutex.Lock()
 	ret, specificReturn := fake.userCanJoinReturnsOnCall[len(fake.userCanJoinArgsForCall)]
@@ -1537,12 +3192,12 @@ func (fake *FakeController) UserCanJoinReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeController) UserConnect(arg1 string, arg2 *websocket.Conn) error {
+func (fake *FakeController) UserConnect(arg1 string, arg2 *session.Conn) error {
 	fake.userConnectMutex.Lock()
 	ret, specificReturn := fake.userConnectReturnsOnCall[len(fake.userConnectArgsForCall)]
 	fake.userConnectArgsForCall = append(fake.userConnectArgsForCall, struct {
 		arg1 string
-		arg2 *websocket.Conn
+		arg2 *session.Conn
 	}{arg1, arg2})
 	stub := fake.UserConnectStub
 	fakeReturns := fake.userConnectReturns
@@ -1563,13 +3218,13 @@ func (fake *FakeController) UserConnectCallCount() int {
 	return len(fake.userConnectArgsForCall)
 }
 
-func (fake *FakeController) UserConnectCalls(stub func(string, *websocket.Conn) error) {
+func (fake *FakeController) UserConnectCalls(stub func(string, *session.Conn) error) {
 	fake.userConnectMutex.Lock()
 	defer fake.userConnectMutex.Unlock()
 	fake.UserConnectStub = stub
 }
 
-func (fake *FakeController) UserConnectArgsForCall(i int) (string, *websocket.Conn) {
+func (fake *FakeController) UserConnectArgsForCall(i int) (string, *session.Conn) {
 	fake.userConnectMutex.RLock()
 	defer fake.userConnectMutex.RUnlock()
 	argsForCall := fake.userConnectArgsForCall[i]
@@ -1632,13 +3287,82 @@ func (fake *FakeController) UserDisconnectArgsForCall(i int) (context.Context, s
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeController) VerifyAttachmentURL(arg1 string, arg2 int64, arg3 string) error {
+	fake.verifyAttachmentURLMutex.Lock()
+	ret, specificReturn := fake.verifyAttachmentURLReturnsOnCall[len(fake.verifyAttachmentURLArgsForCall)]
+	fake.verifyAttachmentURLArgsForCall = append(fake.verifyAttachmentURLArgsForCall, struct {
+		arg1 string
+		arg2 int64
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.VerifyAttachmentURLStub
+	fakeReturns := fake.verifyAttachmentURLReturns
+	fake.recordInvocation("VerifyAttachmentURL", []interface{}{arg1, arg2, arg3})
+	fake.verifyAttachmentURLMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeController) VerifyAttachmentURLCallCount() int {
+	fake.verifyAttachmentURLMutex.RLock()
+	defer fake.verifyAttachmentURLMutex.RUnlock()
+	return len(fake.verifyAttachmentURLArgsForCall)
+}
+
+func (fake *FakeController) VerifyAttachmentURLCalls(stub func(string, int64, string) error) {
+	fake.verifyAttachmentURLMutex.Lock()
+	defer fake.verifyAttachmentURLMutex.Unlock()
+	fake.VerifyAttachmentURLStub = stub
+}
+
+func (fake *FakeController) VerifyAttachmentURLArgsForCall(i int) (string, int64, string) {
+	fake.verifyAttachmentURLMutex.RLock()
+	defer fake.verifyAttachmentURLMutex.RUnlock()
+	argsForCall := fake.verifyAttachmentURLArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeController) VerifyAttachmentURLReturns(result1 error) {
+	fake.verifyAttachmentURLMutex.Lock()
+	defer fake.verifyAttachmentURLMutex.Unlock()
+	fake.VerifyAttachmentURLStub = nil
+	fake.verifyAttachmentURLReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeController) VerifyAttachmentURLReturnsOnCall(i int, result1 error) {
+	fake.verifyAttachmentURLMutex.Lock()
+	defer fake.verifyAttachmentURLMutex.Unlock()
+	fake.VerifyAttachmentURLStub = nil
+	if fake.verifyAttachmentURLReturnsOnCall == nil {
+		fake.verifyAttachmentURLReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.verifyAttachmentURLReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeController) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.addCommentThreadMutex.RLock()
+	defer fake.addCommentThreadMutex.RUnlock()
+	fake.addLayerMutex.RLock()
+	defer fake.addLayerMutex.RUnlock()
 	fake.addPagesMutex.RLock()
 	defer fake.addPagesMutex.RUnlock()
 	fake.allowMutex.RLock()
 	defer fake.allowMutex.RUnlock()
+	fake.attachmentURLMutex.RLock()
+	defer fake.attachmentURLMutex.RUnlock()
 	fake.attachmentsMutex.RLock()
 	defer fake.attachmentsMutex.RUnlock()
 	fake.broadcasterMutex.RLock()
@@ -1649,14 +3373,36 @@ func (fake *FakeController) Invocations() map[string][][]interface{} {
 	defer fake.closeAfterMutex.RUnlock()
 	fake.configMutex.RLock()
 	defer fake.configMutex.RUnlock()
+	fake.deleteAttachmentMutex.RLock()
+	defer fake.deleteAttachmentMutex.RUnlock()
+	fake.deleteChatMessageMutex.RLock()
+	defer fake.deleteChatMessageMutex.RUnlock()
+	fake.deleteCommentThreadMutex.RLock()
+	defer fake.deleteCommentThreadMutex.RUnlock()
+	fake.deleteLayerMutex.RLock()
+	defer fake.deleteLayerMutex.RUnlock()
+	fake.getAttachmentsMutex.RLock()
+	defer fake.getAttachmentsMutex.RUnlock()
+	fake.getChatMutex.RLock()
+	defer fake.getChatMutex.RUnlock()
+	fake.getCommentsMutex.RLock()
+	defer fake.getCommentsMutex.RUnlock()
+	fake.getLayersMutex.RLock()
+	defer fake.getLayersMutex.RUnlock()
 	fake.getPageMutex.RLock()
 	defer fake.getPageMutex.RUnlock()
 	fake.getPageRankMutex.RLock()
 	defer fake.getPageRankMutex.RUnlock()
 	fake.getPageSyncMutex.RLock()
 	defer fake.getPageSyncMutex.RUnlock()
+	fake.getStrokesInPolygonMutex.RLock()
+	defer fake.getStrokesInPolygonMutex.RUnlock()
+	fake.getStrokesInRectMutex.RLock()
+	defer fake.getStrokesInRectMutex.RUnlock()
 	fake.getUsersMutex.RLock()
 	defer fake.getUsersMutex.RUnlock()
+	fake.hostViewportMutex.RLock()
+	defer fake.hostViewportMutex.RUnlock()
 	fake.iDMutex.RLock()
 	defer fake.iDMutex.RUnlock()
 	fake.isValidPageMutex.RLock()
@@ -1667,22 +3413,38 @@ func (fake *FakeController) Invocations() map[string][][]interface{} {
 	defer fake.newUserMutex.RUnlock()
 	fake.numUsersMutex.RLock()
 	defer fake.numUsersMutex.RUnlock()
+	fake.pageThumbnailMutex.RLock()
+	defer fake.pageThumbnailMutex.RUnlock()
+	fake.queueStatsMutex.RLock()
+	defer fake.queueStatsMutex.RUnlock()
 	fake.receiveMutex.RLock()
 	defer fake.receiveMutex.RUnlock()
+	fake.replyCommentMutex.RLock()
+	defer fake.replyCommentMutex.RUnlock()
+	fake.resolveCommentThreadMutex.RLock()
+	defer fake.resolveCommentThreadMutex.RUnlock()
+	fake.searchMutex.RLock()
+	defer fake.searchMutex.RUnlock()
 	fake.setConfigMutex.RLock()
 	defer fake.setConfigMutex.RUnlock()
 	fake.syncSessionMutex.RLock()
 	defer fake.syncSessionMutex.RUnlock()
+	fake.updateLayerMutex.RLock()
+	defer fake.updateLayerMutex.RUnlock()
 	fake.updatePagesMutex.RLock()
 	defer fake.updatePagesMutex.RUnlock()
 	fake.updateUserMutex.RLock()
 	defer fake.updateUserMutex.RUnlock()
+	fake.uploadAttachmentMutex.RLock()
+	defer fake.uploadAttachmentMutex.RUnlock()
 	fake.userCanJoinMutex.RLock()
 	defer fake.userCanJoinMutex.RUnlock()
 	fake.userConnectMutex.RLock()
 	defer fake.userConnectMutex.RUnlock()
 	fake.userDisconnectMutex.RLock()
 	defer fake.userDisconnectMutex.RUnlock()
+	fake.verifyAttachmentURLMutex.RLock()
+	defer fake.verifyAttachmentURLMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
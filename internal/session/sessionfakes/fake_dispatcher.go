@@ -78,6 +78,16 @@ type FakeDispatcher struct {
 	numUsersReturnsOnCall map[int]struct {
 		result1 int
 	}
+	QueueStatsStub        func() session.QueueStats
+	queueStatsMutex       sync.RWMutex
+	queueStatsArgsForCall []struct {
+	}
+	queueStatsReturns struct {
+		result1 session.QueueStats
+	}
+	queueStatsReturnsOnCall map[int]struct {
+		result1 session.QueueStats
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -439,6 +449,59 @@ func (fake *FakeDispatcher) NumUsersReturnsOnCall(i int, result1 int) {
 	}{result1}
 }
 
+func (fake *FakeDispatcher) QueueStats() session.QueueStats {
+	fake.queueStatsMutex.Lock()
+	ret, specificReturn := fake.queueStatsReturnsOnCall[len(fake.queueStatsArgsForCall)]
+	fake.queueStatsArgsForCall = append(fake.queueStatsArgsForCall, struct {
+	}{})
+	stub := fake.QueueStatsStub
+	fakeReturns := fake.queueStatsReturns
+	fake.recordInvocation("QueueStats", []interface{}{})
+	fake.queueStatsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDispatcher) QueueStatsCallCount() int {
+	fake.queueStatsMutex.RLock()
+	defer fake.queueStatsMutex.RUnlock()
+	return len(fake.queueStatsArgsForCall)
+}
+
+func (fake *FakeDispatcher) QueueStatsCalls(stub func() session.QueueStats) {
+	fake.queueStatsMutex.Lock()
+	defer fake.queueStatsMutex.Unlock()
+	fake.QueueStatsStub = stub
+}
+
+func (fake *FakeDispatcher) QueueStatsReturns(result1 session.QueueStats) {
+	fake.queueStatsMutex.Lock()
+	defer fake.queueStatsMutex.Unlock()
+	fake.QueueStatsStub = nil
+	fake.queueStatsReturns = struct {
+		result1 session.QueueStats
+	}{result1}
+}
+
+func (fake *FakeDispatcher) QueueStatsReturnsOnCall(i int, result1 session.QueueStats) {
+	fake.queueStatsMutex.Lock()
+	defer fake.queueStatsMutex.Unlock()
+	fake.QueueStatsStub = nil
+	if fake.queueStatsReturnsOnCall == nil {
+		fake.queueStatsReturnsOnCall = make(map[int]struct {
+			result1 session.QueueStats
+		})
+	}
+	fake.queueStatsReturnsOnCall[i] = struct {
+		result1 session.QueueStats
+	}{result1}
+}
+
 func (fake *FakeDispatcher) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -454,6 +517,8 @@ func (fake *FakeDispatcher) Invocations() map[string][][]interface{} {
 	defer fake.numSessionsMutex.RUnlock()
 	fake.numUsersMutex.RLock()
 	defer fake.numUsersMutex.RUnlock()
+	fake.queueStatsMutex.RLock()
+	defer fake.queueStatsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
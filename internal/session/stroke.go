@@ -1,6 +1,20 @@
 package session
 
-import "github.com/boardsite-io/server/pkg/redis"
+import (
+	"math"
+	"unicode/utf8"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/boardsite-io/server/internal/config"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/geometry"
+	"github.com/boardsite-io/server/pkg/redis"
+)
+
+// StrokeTypeDelete deletes the stroke with the id. The other stroke types
+// are the tools of the client, which are configured in session.strokes.
+const StrokeTypeDelete = 0
 
 // Style declares the stroke style.
 type Style struct {
@@ -26,21 +40,221 @@ type Stroke struct {
 	Type      int       `json:"type"`
 	ID        string    `json:"id,omitempty"`
 	PageID    string    `json:"pageId,omitempty"`
+	LayerID   string    `json:"layerId,omitempty"`
 	UserID    string    `json:"userId"`
 	X         float64   `json:"x"`
 	Y         float64   `json:"y"`
 	ScaleX    float64   `json:"scaleX,omitempty"`
 	ScaleY    float64   `json:"scaleY,omitempty"`
-	Points    []float64 `json:"points,omitempty"`
+	Points    Points    `json:"points,omitempty"`
 	Style     Style     `json:"style,omitempty"`
 	Textfield Textfield `json:"textfield,omitempty"`
 }
 
 var _ redis.Stroke = (*Stroke)(nil)
 
+const maxPointsPrealloc = 1 << 12
+
+// Points declares the flattened x,y coordinates of a stroke.
+//
+// In binary encodings the coordinates are sent as float32, which
+// halves the size of the dominating part of stroke messages.
+type Points []float64
+
+var (
+	_ msgpack.CustomEncoder = (Points)(nil)
+	_ msgpack.CustomDecoder = (*Points)(nil)
+)
+
+func (p Points) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if err := enc.EncodeArrayLen(len(p)); err != nil {
+		return err
+	}
+	for _, v := range p {
+		if err := enc.EncodeFloat32(float32(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Points) DecodeMsgpack(dec *msgpack.Decoder) error {
+	n, err := dec.DecodeArrayLen()
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		*p = nil
+		return nil
+	}
+	// the length is not trusted for the initial allocation
+	capacity := n
+	if capacity > maxPointsPrealloc {
+		capacity = maxPointsPrealloc
+	}
+	points := make(Points, 0, capacity)
+	for i := 0; i < n; i++ {
+		v, err := dec.DecodeFloat64()
+		if err != nil {
+			return err
+		}
+		points = append(points, v)
+	}
+	*p = points
+	return nil
+}
+
+// validate checks the stroke against the limits. The coordinates are
+// bounded relative to the page size, unless the page size is unknown.
+func (s *Stroke) validate(limits config.StrokeLimits, size PageSize) error {
+	if s.ID == "" {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("stroke without id"))
+	}
+	if s.IsDeleted() {
+		return nil
+	}
+	if !isAllowedStrokeType(s.Type, limits.AllowedTypes) {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("stroke type %d not allowed", s.Type))
+	}
+	if limits.MaxPoints > 0 && len(s.Points) > limits.MaxPoints {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("stroke exceeds %d points", limits.MaxPoints))
+	}
+	if len(s.Points)%2 != 0 {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("odd number of point coordinates"))
+	}
+	if limits.MaxTextLength > 0 && utf8.RuneCountInString(s.Textfield.Text) > limits.MaxTextLength {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("text exceeds %d characters", limits.MaxTextLength))
+	}
+	for _, v := range []float64{
+		s.X, s.Y, s.ScaleX, s.ScaleY, s.Style.Width, s.Style.Opacity,
+		s.Textfield.FontWeight, s.Textfield.FontSize, s.Textfield.LineHeight,
+	} {
+		if !isFinite(v) {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid number"))
+		}
+	}
+	for _, color := range []string{s.Style.Color, s.Textfield.Color} {
+		if color != "" && !htmlColor.MatchString(color) {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("incorrect html color"))
+		}
+	}
+	return s.validatePoints(limits.PageMargin, size)
+}
+
+// validatePoints checks that all absolute coordinates are finite and
+// within the page bounds extended by the margin.
+func (s *Stroke) validatePoints(margin float64, size PageSize) error {
+	bounded := size.Width > 0 && size.Height > 0
+	minX, maxX := -margin*size.Width, (1+margin)*size.Width
+	minY, maxY := -margin*size.Height, (1+margin)*size.Height
+	inBounds := func(x, y float64) bool {
+		return !bounded || (x >= minX && x <= maxX && y >= minY && y <= maxY)
+	}
+
+	if !inBounds(s.X, s.Y) {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("stroke out of page bounds"))
+	}
+	scaleX, scaleY := s.scale()
+	for i := 0; i+1 < len(s.Points); i += 2 {
+		if !isFinite(s.Points[i]) || !isFinite(s.Points[i+1]) {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid number"))
+		}
+		if !inBounds(s.X+scaleX*s.Points[i], s.Y+scaleY*s.Points[i+1]) {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("stroke out of page bounds"))
+		}
+	}
+	return nil
+}
+
+func isAllowedStrokeType(strokeType int, allowed []int) bool {
+	return len(allowed) == 0 || hasStrokeType(strokeType, allowed)
+}
+
+func hasStrokeType(strokeType int, types []int) bool {
+	for _, t := range types {
+		if t == strokeType {
+			return true
+		}
+	}
+	return false
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// scale returns the scale factors of the stroke, where
+// an unset factor defaults to 1.
+func (s *Stroke) scale() (float64, float64) {
+	scaleX, scaleY := s.ScaleX, s.ScaleY
+	if scaleX == 0 {
+		scaleX = 1
+	}
+	if scaleY == 0 {
+		scaleY = 1
+	}
+	return scaleX, scaleY
+}
+
+// path returns the absolute page coordinates of the stroke points.
+// Strokes without points are represented by their position.
+func (s *Stroke) path() []geometry.Point {
+	if len(s.Points) < 2 {
+		return []geometry.Point{{X: s.X, Y: s.Y}}
+	}
+	scaleX, scaleY := s.scale()
+	path := make([]geometry.Point, len(s.Points)/2)
+	for i := range path {
+		path[i] = geometry.Point{
+			X: s.X + scaleX*s.Points[2*i],
+			Y: s.Y + scaleY*s.Points[2*i+1],
+		}
+	}
+	return path
+}
+
+// lineWidth returns the width of the drawn line in page coordinates,
+// which is scaled by the larger scale factor.
+func (s *Stroke) lineWidth() float64 {
+	scaleX, scaleY := s.scale()
+	return s.Style.Width * math.Max(math.Abs(scaleX), math.Abs(scaleY))
+}
+
+// Bounds returns the bounding box of the stroke including its width.
+func (s *Stroke) Bounds() geometry.Rect {
+	return geometry.Bounds(s.path()...).Grow(s.lineWidth() / 2)
+}
+
+// isFreehand reports whether the stroke is drawn freehand.
+func (scb *controlBlock) isFreehand(s *Stroke) bool {
+	return hasStrokeType(s.Type, scb.cfg.Strokes.FreehandTypes)
+}
+
+// isTextfield reports whether the stroke is a textfield.
+func (scb *controlBlock) isTextfield(s *Stroke) bool {
+	return hasStrokeType(s.Type, scb.cfg.Strokes.TextfieldTypes)
+}
+
+// simplify reduces the points of the freehand stroke, such that the path
+// deviates at most by the tolerance in page coordinates.
+func (s *Stroke) simplify(tolerance float64) {
+	if tolerance <= 0 || len(s.Points) < 6 {
+		return
+	}
+	indices := geometry.Simplify(s.path(), tolerance)
+	if len(indices) == len(s.Points)/2 {
+		return
+	}
+	points := make(Points, 0, 2*len(indices))
+	for _, i := range indices {
+		points = append(points, s.Points[2*i], s.Points[2*i+1])
+	}
+	s.Points = points
+}
+
 // IsDeleted verifies whether stroke is deleted or not
 func (s *Stroke) IsDeleted() bool {
-	return s.Type == 0
+	return s.Type == StrokeTypeDelete
 }
 
 // Id returns the id of the stroke
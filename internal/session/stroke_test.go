@@ -0,0 +1,202 @@
+package session_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/attachment/attachmentfakes"
+	"github.com/boardsite-io/server/internal/config"
+	"github.com/boardsite-io/server/internal/session"
+	"github.com/boardsite-io/server/internal/session/sessionfakes"
+	"github.com/boardsite-io/server/pkg/geometry"
+	"github.com/boardsite-io/server/pkg/redis"
+	"github.com/boardsite-io/server/pkg/redis/redisfakes"
+)
+
+// stroke types of the tests, which are the tools of the client in config.yaml
+const (
+	strokeTypePen       = 1
+	strokeTypeLine      = 2
+	strokeTypeCircle    = 4
+	strokeTypeRectangle = 5
+	strokeTypeTextfield = 7
+)
+
+// strokeTypes configures the freehand strokes and textfields of the tests.
+var strokeTypes = config.StrokeLimits{
+	FreehandTypes:  []int{strokeTypePen},
+	TextfieldTypes: []int{strokeTypeTextfield},
+}
+
+func newStrokeMessage(t *testing.T, codec session.Codec, strokes ...*session.Stroke) *session.Message {
+	data, err := codec.Marshal(session.NewMessage(strokes, session.MessageTypeStroke, "user1"))
+	require.NoError(t, err)
+	msg, err := codec.Unmarshal(data)
+	require.NoError(t, err)
+	return msg
+}
+
+// storeIn sends the strokes updated in the cache to the channel.
+func storeIn(cache chan []redis.Stroke) func(context.Context, string, ...redis.Stroke) error {
+	return func(_ context.Context, _ string, strokes ...redis.Stroke) error {
+		cache <- strokes
+		return nil
+	}
+}
+
+func Test_controlBlock_Receive_Strokes(t *testing.T) {
+	ctx := context.Background()
+	cfg := session.Config{ID: "sid1"}
+	cfg.Strokes = config.StrokeLimits{
+		MaxPoints:     6,
+		MaxTextLength: 5,
+		AllowedTypes:  []int{strokeTypePen, strokeTypeTextfield},
+		PageMargin:    0.5,
+	}
+	fakeCache := &redisfakes.FakeHandler{}
+	fakeCache.GetPageRankReturns([]string{"pid1"}, nil)
+	fakeCache.GetPageMetaCalls(func(_ context.Context, _, _ string, meta any) error {
+		meta.(*session.PageMeta).PageSize = session.PageSize{Width: 100, Height: 200}
+		return nil
+	})
+	fakeBroadcaster := &sessionfakes.FakeBroadcaster{}
+	scb, err := session.NewControlBlock(cfg, session.WithCache(fakeCache), session.WithAttachments(&attachmentfakes.FakeHandler{}),
+		session.WithDispatcher(&sessionfakes.FakeDispatcher{}), session.WithBroadcaster(fakeBroadcaster))
+	require.NoError(t, err)
+
+	validStroke := func() *session.Stroke {
+		return &session.Stroke{
+			Type:   strokeTypePen,
+			ID:     "stroke1",
+			PageID: "pid1",
+			UserID: "user1",
+			X:      10,
+			Y:      20,
+			Points: session.Points{0, 0, 10, 10},
+			Style:  session.Style{Color: "#00beef", Width: 3, Opacity: 1},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(s *session.Stroke)
+		wantErr bool
+	}{
+		{name: "valid stroke", modify: func(s *session.Stroke) {}},
+		{name: "deleted stroke", modify: func(s *session.Stroke) { *s = session.Stroke{ID: "stroke1", PageID: "pid1", UserID: "user1"} }},
+		{name: "within page margin", modify: func(s *session.Stroke) { s.X = -40 }},
+		{name: "missing id", modify: func(s *session.Stroke) { s.ID = "" }, wantErr: true},
+		{name: "type not allowed", modify: func(s *session.Stroke) { s.Type = strokeTypeCircle }, wantErr: true},
+		{name: "unknown type", modify: func(s *session.Stroke) { s.Type = 42 }, wantErr: true},
+		{name: "too many points", modify: func(s *session.Stroke) { s.Points = make(session.Points, 8) }, wantErr: true},
+		{name: "odd points", modify: func(s *session.Stroke) { s.Points = session.Points{1, 2, 3} }, wantErr: true},
+		{name: "text too long", modify: func(s *session.Stroke) { s.Textfield.Text = strings.Repeat("ä", 6) }, wantErr: true},
+		{name: "invalid color", modify: func(s *session.Stroke) { s.Style.Color = "red" }, wantErr: true},
+		{name: "invalid text color", modify: func(s *session.Stroke) { s.Textfield.Color = "#fff" }, wantErr: true},
+		{name: "out of bounds", modify: func(s *session.Stroke) { s.Points = session.Points{0, 0, 200, 10} }, wantErr: true},
+		{name: "scaled out of bounds", modify: func(s *session.Stroke) { s.ScaleY = 100 }, wantErr: true},
+		{name: "invalid page", modify: func(s *session.Stroke) { s.PageID = "pid2" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broadcast := make(chan session.Message, 1)
+			cache := make(chan []redis.Stroke, 1)
+			fakeBroadcaster.BroadcastReturns(broadcast)
+			fakeCache.UpdateStrokesCalls(storeIn(cache))
+			stroke := validStroke()
+			tt.modify(stroke)
+
+			err := scb.Receive(ctx, newStrokeMessage(t, session.JSONCodec, stroke), "user1")
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Len(t, broadcast, 0)
+				assert.Len(t, cache, 0)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, broadcast, 1)
+			assert.Len(t, cache, 1)
+		})
+	}
+
+	t.Run("discards only invalid strokes", func(t *testing.T) {
+		broadcast := make(chan session.Message, 1)
+		cache := make(chan []redis.Stroke, 1)
+		fakeBroadcaster.BroadcastReturns(broadcast)
+		fakeCache.UpdateStrokesCalls(storeIn(cache))
+		invalid := validStroke()
+		invalid.ID = "stroke2"
+		invalid.Points = session.Points{0, 0, math.NaN(), 1}
+
+		// JSON cannot represent NaN
+		err := scb.Receive(ctx, newStrokeMessage(t, session.MsgpackCodec, validStroke(), invalid), "user1")
+
+		assert.NoError(t, err)
+		strokes := <-cache
+		require.Len(t, strokes, 1)
+		assert.Equal(t, "stroke1", strokes[0].Id())
+	})
+}
+
+func Test_controlBlock_Receive_SimplifyStrokes(t *testing.T) {
+	ctx := context.Background()
+	const tolerance = 0.5
+	cfg := session.Config{ID: "sid1"}
+	cfg.SimplifyTolerance = tolerance
+	cfg.Strokes = strokeTypes
+	fakeCache := &redisfakes.FakeHandler{}
+	fakeCache.GetPageRankReturns([]string{"pid1"}, nil)
+	fakeBroadcaster := &sessionfakes.FakeBroadcaster{}
+	fakeBroadcaster.BroadcastReturns(make(chan session.Message, 10))
+	scb, err := session.NewControlBlock(cfg, session.WithCache(fakeCache), session.WithAttachments(&attachmentfakes.FakeHandler{}),
+		session.WithDispatcher(&sessionfakes.FakeDispatcher{}), session.WithBroadcaster(fakeBroadcaster))
+	require.NoError(t, err)
+
+	// densely sampled arc, which is scaled on the page
+	points := make(session.Points, 0, 1000)
+	for i := 0; i < 500; i++ {
+		phi := float64(i) / 500 * math.Pi
+		points = append(points, 50*math.Cos(phi), 50*math.Sin(phi))
+	}
+	pen := &session.Stroke{Type: strokeTypePen, ID: "pen", PageID: "pid1", UserID: "user1", X: 100, Y: 100, ScaleX: 2, ScaleY: 2, Points: points}
+	line := &session.Stroke{Type: strokeTypeLine, ID: "line", PageID: "pid1", UserID: "user1", Points: points}
+
+	t.Run("simplifies freehand strokes", func(t *testing.T) {
+		cache := make(chan []redis.Stroke, 1)
+		fakeCache.UpdateStrokesCalls(storeIn(cache))
+
+		err := scb.Receive(ctx, newStrokeMessage(t, session.JSONCodec, pen), "user1")
+
+		require.NoError(t, err)
+		got := (<-cache)[0].(*session.Stroke)
+		assert.Less(t, len(got.Points), len(pen.Points)/5)
+		// every original point is within the tolerance of the simplified path
+		for i := 0; i < len(pen.Points); i += 2 {
+			p := geometry.Point{X: pen.X + 2*pen.Points[i], Y: pen.Y + 2*pen.Points[i+1]}
+			dist := math.Inf(1)
+			for j := 2; j < len(got.Points); j += 2 {
+				a := geometry.Point{X: got.X + 2*got.Points[j-2], Y: got.Y + 2*got.Points[j-1]}
+				b := geometry.Point{X: got.X + 2*got.Points[j], Y: got.Y + 2*got.Points[j+1]}
+				dist = math.Min(dist, geometry.SegmentDist(p, a, b))
+			}
+			assert.LessOrEqual(t, dist, tolerance+1e-9)
+		}
+	})
+
+	t.Run("keeps other strokes", func(t *testing.T) {
+		cache := make(chan []redis.Stroke, 1)
+		fakeCache.UpdateStrokesCalls(storeIn(cache))
+
+		err := scb.Receive(ctx, newStrokeMessage(t, session.JSONCodec, line), "user1")
+
+		require.NoError(t, err)
+		got := (<-cache)[0].(*session.Stroke)
+		assert.Equal(t, len(line.Points), len(got.Points))
+	})
+}
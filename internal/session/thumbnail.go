@@ -0,0 +1,367 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"  // decode image backgrounds
+	_ "image/jpeg" // decode image backgrounds
+	"image/png"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/boardsite-io/server/internal/attachment"
+	"github.com/boardsite-io/server/pkg/geometry"
+	"github.com/boardsite-io/server/pkg/log"
+	"github.com/boardsite-io/server/pkg/raster"
+)
+
+// DefaultThumbnailWidth is the width of page thumbnails if none is requested.
+const DefaultThumbnailWidth = 256
+
+const (
+	// thumbnailMaxAspect limits the height of thumbnails relative to their width
+	thumbnailMaxAspect = 4
+	// thumbnailMaxPaint limits the pixels visited while rendering a thumbnail.
+	// The remaining strokes of pages exceeding the limit are not drawn.
+	thumbnailMaxPaint = 1 << 25
+	// maxBackgroundPixels limits the size of decoded image backgrounds
+	maxBackgroundPixels = 1 << 25
+	// paperSpacing is the distance of the lines of the paper in page coordinates
+	paperSpacing = 20
+)
+
+// Paper styles of the page background
+const (
+	paperCheckered = "checkered"
+	paperRuled     = "ruled"
+)
+
+// Text is drawn as bars above the baseline, which are sized relative to the font size.
+const (
+	defaultFontSize   = 16
+	defaultLineHeight = 1.2
+	greekCharWidth    = 0.5
+	greekHeight       = 0.5
+	greekAlpha        = 0.55
+)
+
+// thumbnailWidths are the widths in which thumbnails are rendered.
+var thumbnailWidths = []int{128, 256, 512}
+
+// thumbnailSlots limits the number of concurrently rendered thumbnails.
+var thumbnailSlots = make(chan struct{}, 2)
+
+var (
+	paperColor = color.RGBA{R: 0xd0, G: 0xd8, B: 0xe0, A: 0xff}
+	white      = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+// Thumbnail is a PNG image of a page.
+type Thumbnail struct {
+	Data []byte
+	// ETag identifies the content of the page and the width of the thumbnail
+	ETag string
+}
+
+// ThumbnailWidth returns the smallest thumbnail width which is at least the
+// requested width. Larger widths are limited to the largest thumbnail width.
+func ThumbnailWidth(width int) int {
+	if width <= 0 {
+		return DefaultThumbnailWidth
+	}
+	for _, w := range thumbnailWidths {
+		if width <= w {
+			return w
+		}
+	}
+	return thumbnailWidths[len(thumbnailWidths)-1]
+}
+
+// PageThumbnail returns the thumbnail of the page with the strokes of the visible
+// layers drawn on the background. The width is rounded to a thumbnail width.
+//
+// Thumbnails are cached until the strokes, the layers or the meta data of the
+// page change, which drop the thumbnails of the page.
+func (scb *controlBlock) PageThumbnail(ctx context.Context, pageID string, width int) (*Thumbnail, error) {
+	width = ThumbnailWidth(width)
+	key := thumbnailKey(pageID, width)
+	scb.muThumbnails.Lock()
+	cached, ok := scb.thumbnails[key]
+	// the thumbnail is outdated if the cache is invalidated while rendering
+	gen := scb.thumbnailGen
+	scb.muThumbnails.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	page, err := scb.GetPage(ctx, pageID, true)
+	if err != nil {
+		return nil, err
+	}
+	data, err := scb.renderThumbnail(ctx, page, width)
+	if err != nil {
+		return nil, err
+	}
+	// the rendering is deterministic, such that the digest identifies the content
+	digest := sha256.Sum256(data)
+	thumbnail := &Thumbnail{Data: data, ETag: strconv.Quote(hex.EncodeToString(digest[:16]))}
+
+	scb.muThumbnails.Lock()
+	if gen == scb.thumbnailGen {
+		scb.thumbnails[key] = thumbnail
+	}
+	scb.muThumbnails.Unlock()
+	return thumbnail, nil
+}
+
+func thumbnailKey(pageID string, width int) string {
+	return fmt.Sprintf("%s/%d", pageID, width)
+}
+
+// dropThumbnails removes the cached thumbnails of the pages.
+func (scb *controlBlock) dropThumbnails(pageIDs ...string) {
+	scb.muThumbnails.Lock()
+	defer scb.muThumbnails.Unlock()
+	scb.thumbnailGen++
+	for _, pid := range pageIDs {
+		for _, w := range thumbnailWidths {
+			delete(scb.thumbnails, thumbnailKey(pid, w))
+		}
+	}
+}
+
+// resetThumbnails removes the cached thumbnails of all pages.
+func (scb *controlBlock) resetThumbnails() {
+	scb.muThumbnails.Lock()
+	defer scb.muThumbnails.Unlock()
+	scb.thumbnailGen++
+	scb.thumbnails = make(map[string]*Thumbnail)
+}
+
+// renderThumbnail draws the page to a PNG image of the given width.
+//
+// Pages without a size, e.g. infinite canvases, are fitted to their strokes.
+func (scb *controlBlock) renderThumbnail(ctx context.Context, page *Page, width int) ([]byte, error) {
+	thumbnailSlots <- struct{}{}
+	defer func() { <-thumbnailSlots }()
+
+	strokes := visibleStrokes(page)
+	area := geometry.Rect{Max: geometry.Point{X: page.Meta.PageSize.Width, Y: page.Meta.PageSize.Height}}
+	if !(area.Max.X > 0 && area.Max.Y > 0) {
+		area = strokesBounds(strokes)
+	}
+	scale := float64(width) / (area.Max.X - area.Min.X)
+	height := int(math.Round((area.Max.Y - area.Min.Y) * scale))
+	if height < 1 {
+		height = 1
+	} else if height > thumbnailMaxAspect*width {
+		height = thumbnailMaxAspect * width
+	}
+	toPixels := geometry.Translate(-area.Min.X, -area.Min.Y).Then(geometry.Scale(scale, scale))
+
+	canvas := raster.NewCanvas(width, height, white)
+	scb.drawBackground(ctx, canvas, page, toPixels, scale)
+	for _, s := range strokes {
+		if canvas.Painted() > thumbnailMaxPaint {
+			break
+		}
+		drawStroke(canvas, s, toPixels, scale, scb.isTextfield(s))
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas.Image()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// visibleStrokes returns the strokes of the visible layers in drawing order,
+// starting with the base layer.
+func visibleStrokes(page *Page) []*Stroke {
+	strokes := make([]*Stroke, 0, len(*page.Strokes))
+	for _, s := range *page.Strokes {
+		if s.LayerID != "" {
+			if i := page.Layers.find(s.LayerID); i < 0 || !page.Layers[i].Visible {
+				continue
+			}
+		}
+		strokes = append(strokes, s)
+	}
+	sort.SliceStable(strokes, func(i, j int) bool {
+		return page.Layers.find(strokes[i].LayerID) < page.Layers.find(strokes[j].LayerID)
+	})
+	return strokes
+}
+
+// strokesBounds returns the bounding box of the strokes or the unit square
+// if there are no strokes.
+func strokesBounds(strokes []*Stroke) geometry.Rect {
+	if len(strokes) == 0 {
+		return geometry.Rect{Max: geometry.Point{X: 1, Y: 1}}
+	}
+	bounds := strokes[0].Bounds()
+	for _, s := range strokes[1:] {
+		b := s.Bounds()
+		bounds = geometry.Bounds(bounds.Min, bounds.Max, b.Min, b.Max)
+	}
+	// keep strokes in a single point or line visible
+	return bounds.Grow(1)
+}
+
+// drawBackground draws the page of the document of the background or the lines of the paper.
+// Backgrounds which cannot be loaded, e.g. missing attachments, are left blank.
+func (scb *controlBlock) drawBackground(ctx context.Context, canvas *raster.Canvas, page *Page, toPixels geometry.Affine, scale float64) {
+	size := page.Meta.PageSize
+	bg := page.Meta.Background
+	if bg.AttachId != "" {
+		if !canDrawBackground(bg.AttachId) {
+			log.Ctx(ctx).Warnf("thumbnail of page %s: cannot draw %s backgrounds", page.PageId, attachment.MIMEType(bg.AttachId))
+			return
+		}
+		img, err := scb.backgroundImage(bg, canvas.Image().Rect.Dx())
+		if err != nil {
+			log.Ctx(ctx).Warnf("thumbnail of page %s: background %s: %v", page.PageId, bg.AttachId, err)
+			return
+		}
+		min, max := toPixels.Apply(geometry.Point{}), toPixels.Apply(geometry.Point{X: size.Width, Y: size.Height})
+		if !(size.Width > 0 && size.Height > 0) {
+			// unsized pages are covered by the background
+			min, max = geometry.Point{}, geometry.Point{X: float64(canvas.Image().Rect.Dx()), Y: float64(canvas.Image().Rect.Dy())}
+		}
+		canvas.DrawImage(img, image.Rect(int(math.Round(min.X)), int(math.Round(min.Y)), int(math.Round(max.X)), int(math.Round(max.Y))))
+		return
+	}
+
+	if !(size.Width > 0 && size.Height > 0) || (bg.Paper != paperCheckered && bg.Paper != paperRuled) {
+		return
+	}
+	line := func(a, b geometry.Point) {
+		canvas.Stroke([]geometry.Point{toPixels.Apply(a), toPixels.Apply(b)}, scale, paperColor, 1)
+	}
+	for y := float64(paperSpacing); y < size.Height; y += paperSpacing {
+		line(geometry.Point{Y: y}, geometry.Point{X: size.Width, Y: y})
+	}
+	if bg.Paper == paperCheckered {
+		for x := float64(paperSpacing); x < size.Width; x += paperSpacing {
+			line(geometry.Point{X: x}, geometry.Point{X: x, Y: size.Height})
+		}
+	}
+}
+
+// canDrawBackground reports whether backgrounds of the type of the attachment
+// can be drawn, i.e. PDF documents and images with a registered decoder.
+func canDrawBackground(attachID string) bool {
+	switch attachment.MIMEType(attachID) {
+	case "application/pdf", "image/png", "image/jpeg", "image/gif":
+		return true
+	}
+	return false
+}
+
+// backgroundImage returns the background image of a page. The pages of PDF
+// documents are zero-based and loaded from the previews of the attachment.
+func (scb *controlBlock) backgroundImage(bg PageBackground, width int) (image.Image, error) {
+	if attachment.MIMEType(bg.AttachId) == "application/pdf" {
+		preview, err := scb.attachments.Preview(bg.AttachId, bg.PageNum+1, attachment.PreviewWidth(width))
+		if err != nil {
+			return nil, err
+		}
+		return png.Decode(preview)
+	}
+
+	file, err := scb.attachments.Get(bg.AttachId)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > maxBackgroundPixels {
+		return nil, fmt.Errorf("image exceeds %d pixels", maxBackgroundPixels)
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(file)
+	return img, err
+}
+
+// drawStroke draws the stroke along its points. Textfields are drawn as bars
+// at the positions of the words.
+func drawStroke(canvas *raster.Canvas, s *Stroke, toPixels geometry.Affine, scale float64, textfield bool) {
+	if textfield {
+		drawText(canvas, s, toPixels, scale)
+		return
+	}
+	path := s.path()
+	for i, p := range path {
+		path[i] = toPixels.Apply(p)
+	}
+	canvas.Stroke(path, s.lineWidth()*scale, parseColor(s.Style.Color), opacity(s.Style.Opacity))
+}
+
+// drawText draws a bar for each word of the textfield below its position.
+func drawText(canvas *raster.Canvas, s *Stroke, toPixels geometry.Affine, scale float64) {
+	tf := s.Textfield
+	fontSize, lineHeight := tf.FontSize, tf.LineHeight
+	if fontSize <= 0 {
+		fontSize = defaultFontSize
+	}
+	if lineHeight <= 0 {
+		lineHeight = defaultLineHeight
+	}
+	scaleX, scaleY := s.scale()
+	col := parseColor(tf.Color)
+	bar := func(line, start, end int) {
+		y := s.Y + scaleY*fontSize*(float64(line)*lineHeight+1-greekHeight/2)
+		canvas.Stroke([]geometry.Point{
+			toPixels.Apply(geometry.Point{X: s.X + scaleX*fontSize*greekCharWidth*float64(start), Y: y}),
+			toPixels.Apply(geometry.Point{X: s.X + scaleX*fontSize*greekCharWidth*float64(end), Y: y}),
+		}, math.Abs(scaleY)*fontSize*greekHeight*scale, col, greekAlpha)
+	}
+	for i, line := range strings.Split(tf.Text, "\n") {
+		start := -1
+		n := 0
+		for _, r := range line {
+			if unicode.IsSpace(r) {
+				if start >= 0 {
+					bar(i, start, n)
+					start = -1
+				}
+			} else if start < 0 {
+				start = n
+			}
+			n++
+		}
+		if start >= 0 {
+			bar(i, start, n)
+		}
+	}
+}
+
+// parseColor parses an HTML color. Invalid colors default to black.
+func parseColor(c string) color.RGBA {
+	v, err := strconv.ParseUint(strings.TrimPrefix(c, "#"), 16, 32)
+	if !htmlColor.MatchString(c) || err != nil {
+		return color.RGBA{A: 0xff}
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+}
+
+// opacity returns the opacity of a stroke, where unset opacities are opaque.
+func opacity(o float64) float64 {
+	if o <= 0 {
+		return 1
+	}
+	return math.Min(o, 1)
+}
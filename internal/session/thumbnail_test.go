@@ -0,0 +1,240 @@
+package session_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/heat1q/opt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/attachment"
+	"github.com/boardsite-io/server/internal/config"
+	"github.com/boardsite-io/server/internal/session"
+	"github.com/boardsite-io/server/internal/session/sessionfakes"
+	"github.com/boardsite-io/server/pkg/redis/redisfakes"
+)
+
+func TestThumbnailWidth(t *testing.T) {
+	assert.Equal(t, session.DefaultThumbnailWidth, session.ThumbnailWidth(0))
+	assert.Equal(t, 128, session.ThumbnailWidth(100))
+	assert.Equal(t, 256, session.ThumbnailWidth(129))
+	assert.Equal(t, 512, session.ThumbnailWidth(4000))
+}
+
+func Test_controlBlock_PageThumbnail(t *testing.T) {
+	ctx := context.Background()
+	meta := session.PageMeta{PageSize: session.PageSize{Width: 256, Height: 128}}
+	layers := session.Layers{{ID: "hidden"}}
+	strokes := []*session.Stroke{
+		// red line across the left half
+		{Type: strokeTypePen, ID: "s1", PageID: "pid1", X: 0, Y: 64, Points: session.Points{0, 0, 128, 0},
+			Style: session.Style{Color: "#ff0000", Width: 8, Opacity: 1}},
+		{Type: strokeTypePen, ID: "s2", PageID: "pid1", LayerID: "hidden", X: 192, Y: 64, Points: session.Points{0, 0, 32, 0},
+			Style: session.Style{Color: "#0000ff", Width: 8, Opacity: 1}},
+	}
+	fakeCache := &redisfakes.FakeHandler{}
+	fakeCache.GetPageRankReturns([]string{"pid1"}, nil)
+	fakeCache.GetPageMetaCalls(func(_ context.Context, _, _ string, v any) error {
+		*v.(*session.PageMeta) = meta
+		return nil
+	})
+	fakeCache.SetPageMetaCalls(func(_ context.Context, _, _ string, v any) error {
+		meta = v.(session.PageMeta)
+		return nil
+	})
+	fakeCache.GetPageLayersCalls(func(_ context.Context, _, _ string, v any) error {
+		*v.(*session.Layers) = layers
+		return nil
+	})
+	fakeCache.SetPageLayersCalls(func(_ context.Context, _, _ string, v any) error {
+		layers = v.(session.Layers)
+		return nil
+	})
+	fakeCache.GetPageStrokesCalls(func(context.Context, string, string) ([][]byte, error) {
+		data := make([][]byte, len(strokes))
+		for i, s := range strokes {
+			data[i], _ = json.Marshal(s)
+		}
+		return data, nil
+	})
+	fakeCache.ClearPageCalls(func(context.Context, string, string) error {
+		strokes = nil
+		return nil
+	})
+	fakeBroadcaster := &sessionfakes.FakeBroadcaster{}
+	fakeBroadcaster.BroadcastReturns(make(chan session.Message, 10))
+	scb, err := session.NewControlBlock(session.Config{ID: "sid1"}, session.WithCache(fakeCache),
+		session.WithDispatcher(&sessionfakes.FakeDispatcher{}), session.WithBroadcaster(fakeBroadcaster))
+	require.NoError(t, err)
+
+	thumbnail, err := scb.PageThumbnail(ctx, "pid1", 100)
+
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(thumbnail.Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 128, 64), img.Bounds())
+	red, white := color.RGBA{R: 255, A: 255}, color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	assert.Equal(t, red, color.RGBAModel.Convert(img.At(32, 32)))
+	assert.Equal(t, white, color.RGBAModel.Convert(img.At(32, 10)))
+	assert.Equal(t, white, color.RGBAModel.Convert(img.At(100, 32)), "hidden layer")
+
+	t.Run("cached", func(t *testing.T) {
+		calls := fakeCache.GetPageStrokesCallCount()
+
+		cached, err := scb.PageThumbnail(ctx, "pid1", 128)
+
+		require.NoError(t, err)
+		assert.Same(t, thumbnail, cached)
+		assert.Equal(t, calls, fakeCache.GetPageStrokesCallCount())
+	})
+
+	t.Run("other width", func(t *testing.T) {
+		other, err := scb.PageThumbnail(ctx, "pid1", 256)
+
+		require.NoError(t, err)
+		assert.NotEqual(t, thumbnail.ETag, other.ETag)
+	})
+
+	t.Run("invalidated by layers", func(t *testing.T) {
+		err := scb.UpdateLayer(ctx, "pid1", "hidden", session.LayerRequest{Visible: opt.New(true)}, true)
+		require.NoError(t, err)
+
+		updated, err := scb.PageThumbnail(ctx, "pid1", 128)
+
+		require.NoError(t, err)
+		assert.NotEqual(t, thumbnail.ETag, updated.ETag)
+		img, err := png.Decode(bytes.NewReader(updated.Data))
+		require.NoError(t, err)
+		assert.Equal(t, color.RGBA{B: 255, A: 255}, color.RGBAModel.Convert(img.At(100, 32)))
+	})
+
+	t.Run("invalidated by strokes", func(t *testing.T) {
+		before, err := scb.PageThumbnail(ctx, "pid1", 128)
+		require.NoError(t, err)
+		added := &session.Stroke{Type: strokeTypePen, ID: "s3", PageID: "pid1", UserID: "user1", X: 0, Y: 20, Points: session.Points{0, 0, 128, 0},
+			Style: session.Style{Color: "#00ff00", Width: 8, Opacity: 1}}
+		err = scb.Receive(ctx, newStrokeMessage(t, session.JSONCodec, added), "user1")
+		require.NoError(t, err)
+		strokes = append(strokes, added)
+
+		updated, err := scb.PageThumbnail(ctx, "pid1", 128)
+
+		require.NoError(t, err)
+		assert.NotEqual(t, before.ETag, updated.ETag)
+		img, err := png.Decode(bytes.NewReader(updated.Data))
+		require.NoError(t, err)
+		assert.Equal(t, color.RGBA{G: 255, A: 255}, color.RGBAModel.Convert(img.At(32, 10)))
+	})
+
+	t.Run("invalidated by meta", func(t *testing.T) {
+		before, err := scb.PageThumbnail(ctx, "pid1", 128)
+		require.NoError(t, err)
+		update := meta
+		update.Background.Paper = "checkered"
+		err = scb.UpdatePages(ctx, session.PageRequest{PageID: []string{"pid1"}, Meta: map[string]*session.PageMeta{"pid1": &update}}, "meta", false)
+		require.NoError(t, err)
+
+		updated, err := scb.PageThumbnail(ctx, "pid1", 128)
+
+		require.NoError(t, err)
+		assert.NotEqual(t, before.ETag, updated.ETag)
+		img, err := png.Decode(bytes.NewReader(updated.Data))
+		require.NoError(t, err)
+		// the lines of the paper every 20 page units
+		assert.NotEqual(t, white, color.RGBAModel.Convert(img.At(10, 5)))
+		assert.Equal(t, white, color.RGBAModel.Convert(img.At(15, 5)))
+	})
+
+	t.Run("invalidated by clearing", func(t *testing.T) {
+		before, err := scb.PageThumbnail(ctx, "pid1", 128)
+		require.NoError(t, err)
+		err = scb.UpdatePages(ctx, session.PageRequest{PageID: []string{"pid1"}}, "clear", true)
+		require.NoError(t, err)
+
+		updated, err := scb.PageThumbnail(ctx, "pid1", 128)
+
+		require.NoError(t, err)
+		assert.NotEqual(t, before.ETag, updated.ETag)
+		img, err := png.Decode(bytes.NewReader(updated.Data))
+		require.NoError(t, err)
+		assert.Equal(t, white, color.RGBAModel.Convert(img.At(32, 32)))
+	})
+}
+
+func Test_controlBlock_PageThumbnailUnsized(t *testing.T) {
+	fakeCache := &redisfakes.FakeHandler{}
+	fakeCache.GetPageStrokesReturns([][]byte{
+		[]byte(`{"type":1,"id":"s1","pageId":"pid1","x":1000,"y":1000,"points":[0,0,400,200],"style":{"color":"#000000","width":2}}`),
+	}, nil)
+	scb, err := session.NewControlBlock(session.Config{ID: "sid1"}, session.WithCache(fakeCache),
+		session.WithDispatcher(&sessionfakes.FakeDispatcher{}), session.WithBroadcaster(&sessionfakes.FakeBroadcaster{}))
+	require.NoError(t, err)
+
+	thumbnail, err := scb.PageThumbnail(context.Background(), "pid1", 128)
+
+	// the thumbnail is fitted to the strokes
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(thumbnail.Data))
+	require.NoError(t, err)
+	assert.Equal(t, 128, img.Bounds().Dx())
+	assert.InDelta(t, 64, img.Bounds().Dy(), 1)
+	assert.NotEqual(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, color.RGBAModel.Convert(img.At(64, 32)))
+}
+
+func Test_controlBlock_PageThumbnailBackground(t *testing.T) {
+	ctx := context.Background()
+	red := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for i := range red.Pix {
+		red.Pix[i] = []uint8{255, 0, 0, 255}[i%4]
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, red))
+	attachID, _, err := attachment.NewLocalHandler(t.TempDir(), "sid1").Upload(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	scb, _, _ := setupAttachmentSession(t, config.AttachmentLimits{}, attachID)
+	_, err = scb.UploadAttachment(ctx, &buf, session.User{ID: "user1"})
+	require.NoError(t, err)
+
+	thumbnail, err := scb.PageThumbnail(ctx, "pid1", 128)
+
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(thumbnail.Data))
+	require.NoError(t, err)
+	// the page is fitted to the strokes and covered by the background
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, color.RGBAModel.Convert(img.At(64, 0)))
+
+	t.Run("invalidated by deletion", func(t *testing.T) {
+		err := scb.DeleteAttachment(ctx, attachID, "host", true, true)
+		require.NoError(t, err)
+
+		updated, err := scb.PageThumbnail(ctx, "pid1", 128)
+
+		require.NoError(t, err)
+		assert.NotEqual(t, thumbnail.ETag, updated.ETag)
+		img, err := png.Decode(bytes.NewReader(updated.Data))
+		require.NoError(t, err)
+		assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, color.RGBAModel.Convert(img.At(64, 0)))
+	})
+}
+
+func Test_controlBlock_PageThumbnailUnsupportedBackground(t *testing.T) {
+	for _, ext := range []string{"webp", "svg"} {
+		t.Run(ext, func(t *testing.T) {
+			scb, _, _ := setupAttachmentSession(t, config.AttachmentLimits{}, "background."+ext)
+
+			thumbnail, err := scb.PageThumbnail(context.Background(), "pid1", 128)
+
+			// the background is left blank
+			require.NoError(t, err)
+			img, err := png.Decode(bytes.NewReader(thumbnail.Data))
+			require.NoError(t, err)
+			assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, color.RGBAModel.Convert(img.At(64, 0)))
+		})
+	}
+}
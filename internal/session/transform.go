@@ -0,0 +1,141 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/geometry"
+	"github.com/boardsite-io/server/pkg/redis"
+)
+
+// ContentTransform declares the content of transform messages.
+//
+// The affine transform is given in page coordinates.
+type ContentTransform struct {
+	PageID    string          `json:"pageId"`
+	StrokeIDs []string        `json:"strokeIds"`
+	Transform geometry.Affine `json:"transform"`
+}
+
+func (c *ContentTransform) validate() error {
+	if len(c.StrokeIDs) == 0 {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("no strokes selected"))
+	}
+	for _, v := range c.Transform {
+		if !isFinite(v) {
+			return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid number"))
+		}
+	}
+	if c.Transform.Det() == 0 {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("transform is not invertible"))
+	}
+	return nil
+}
+
+// transform applies an affine transform to stored strokes of the sender.
+//
+// Strokes of other users and strokes which violate the stroke limits after the
+// transform are skipped. Instead of the strokes, the transform with the ids
+// of the transformed strokes is broadcasted.
+func (scb *controlBlock) transform(ctx context.Context, msg *Message) error {
+	if !scb.Allow(msg.Sender) {
+		return errors.New("not allowed")
+	}
+
+	var content ContentTransform
+	if err := msg.UnmarshalContent(&content); err != nil {
+		return err
+	}
+	if err := content.validate(); err != nil {
+		return err
+	}
+	if !scb.IsValidPage(ctx, content.PageID) {
+		return libErr.ErrNotFound.Wrap(libErr.WithErrorf("page %s does not exist", content.PageID))
+	}
+
+	// overlapping edits operate on the result of each other
+	scb.muEdit.Lock()
+	defer scb.muEdit.Unlock()
+
+	strokeBytes, err := scb.cache.GetStrokes(ctx, scb.cfg.ID, content.PageID, content.StrokeIDs...)
+	if err != nil {
+		return err
+	}
+	strokes, err := parseStrokes(strokeBytes)
+	if err != nil {
+		return err
+	}
+
+	size := scb.getPageSize(ctx, content.PageID)
+	locked, err := scb.lockedLayers(ctx, content.PageID, msg.Sender)
+	if err != nil {
+		return err
+	}
+	updates := make([]redis.Stroke, 0, len(strokes))
+	applied := make([]string, 0, len(strokes))
+	var lastErr error
+	for _, s := range strokes {
+		if s.UserId() != msg.Sender { // invalid userID
+			continue
+		}
+		if _, ok := locked[s.LayerID]; ok {
+			continue
+		}
+		s.transform(content.Transform)
+		if err := s.validate(scb.cfg.Strokes, size); err != nil {
+			lastErr = err
+			continue
+		}
+		updates = append(updates, s)
+		applied = append(applied, s.ID)
+	}
+	if len(updates) == 0 {
+		if lastErr != nil {
+			return lastErr
+		}
+		return errors.New("strokes not validated")
+	}
+
+	if err := scb.cache.UpdateStrokes(ctx, scb.cfg.ID, updates...); err != nil {
+		return err
+	}
+	scb.indexStrokes(updates...)
+
+	content.StrokeIDs = applied
+	scb.broadcaster.Broadcast() <- Message{
+		Type:    MessageTypeTransform,
+		Sender:  msg.Sender,
+		Content: content,
+	}
+	return nil
+}
+
+// transform applies the affine transform to the stroke.
+//
+// Transforms without rotation or shear are applied to the position and the
+// scale of the stroke. Otherwise the linear part is applied to the points.
+// In both cases the drawn line width is scaled by the square root of the
+// determinant, i.e. the geometric mean of the scale factors.
+func (s *Stroke) transform(t geometry.Affine) {
+	width := s.lineWidth() * math.Sqrt(math.Abs(t.Det()))
+	origin := t.Apply(geometry.Point{X: s.X, Y: s.Y})
+	s.X, s.Y = origin.X, origin.Y
+
+	scaleX, scaleY := s.scale()
+	if t.IsDiagonal() {
+		s.ScaleX, s.ScaleY = t[0]*scaleX, t[3]*scaleY
+	} else {
+		// the points are relative to the scaled coordinate system of the stroke
+		for i := 0; i+1 < len(s.Points); i += 2 {
+			p := t.ApplyLinear(geometry.Point{X: scaleX * s.Points[i], Y: scaleY * s.Points[i+1]})
+			s.Points[i], s.Points[i+1] = p.X/scaleX, p.Y/scaleY
+		}
+	}
+	// the width is relative to the scale of the stroke like the points
+	scaleX, scaleY = s.scale()
+	if scale := math.Max(math.Abs(scaleX), math.Abs(scaleY)); scale > 0 {
+		s.Style.Width = width / scale
+	}
+}
@@ -0,0 +1,181 @@
+package session_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/heat1q/opt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/session"
+	"github.com/boardsite-io/server/pkg/geometry"
+)
+
+func newTransformMessage(t *testing.T, content session.ContentTransform, sender string) *session.Message {
+	data, err := session.JSONCodec.Marshal(session.NewMessage(content, session.MessageTypeTransform, sender))
+	require.NoError(t, err)
+	msg, err := session.JSONCodec.Unmarshal(data)
+	require.NoError(t, err)
+	return msg
+}
+
+// absPath returns the absolute page coordinates of the stroke.
+func absPath(s *session.Stroke) []geometry.Point {
+	scaleX, scaleY := s.ScaleX, s.ScaleY
+	if scaleX == 0 {
+		scaleX = 1
+	}
+	if scaleY == 0 {
+		scaleY = 1
+	}
+	path := make([]geometry.Point, 0, len(s.Points)/2)
+	for i := 0; i < len(s.Points); i += 2 {
+		path = append(path, geometry.Point{X: s.X + scaleX*s.Points[i], Y: s.Y + scaleY*s.Points[i+1]})
+	}
+	return path
+}
+
+// lineWidth returns the width of the drawn line of the stroke.
+func lineWidth(s *session.Stroke) float64 {
+	scaleX, scaleY := s.ScaleX, s.ScaleY
+	if scaleX == 0 {
+		scaleX = 1
+	}
+	if scaleY == 0 {
+		scaleY = 1
+	}
+	return s.Style.Width * math.Max(math.Abs(scaleX), math.Abs(scaleY))
+}
+
+func Test_controlBlock_Receive_Transform(t *testing.T) {
+	ctx := context.Background()
+	newStroke := func(id, userID string) *session.Stroke {
+		return &session.Stroke{Type: strokeTypePen, ID: id, PageID: "pid1", UserID: userID, X: 10, Y: 20, ScaleX: 2, ScaleY: 0.5, Points: session.Points{0, 0, 10, 0, 10, 10}, Style: session.Style{Width: 2}}
+	}
+
+	tests := []struct {
+		name      string
+		transform geometry.Affine
+	}{
+		{name: "translate", transform: geometry.Translate(5, -5)},
+		{name: "scale", transform: geometry.Scale(2, 3).Then(geometry.Translate(1, 1))},
+		{name: "rotate", transform: geometry.Translate(-10, -20).Then(geometry.Rotate(math.Pi / 3)).Then(geometry.Translate(10, 20))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stroke := newStroke("stroke1", "user1")
+			scb, fakeCache, broadcast := setupStrokeSession(t, stroke)
+			content := session.ContentTransform{PageID: "pid1", StrokeIDs: []string{"stroke1"}, Transform: tt.transform}
+
+			err := scb.Receive(ctx, newTransformMessage(t, content, "user1"), "user1")
+
+			require.NoError(t, err)
+			require.Equal(t, 1, fakeCache.UpdateStrokesCallCount())
+			_, _, updates := fakeCache.UpdateStrokesArgsForCall(0)
+			require.Len(t, updates, 1)
+			got := updates[0].(*session.Stroke)
+			want := absPath(stroke)
+			for i, p := range absPath(got) {
+				q := tt.transform.Apply(want[i])
+				assert.InDelta(t, q.X, p.X, 1e-9)
+				assert.InDelta(t, q.Y, p.Y, 1e-9)
+			}
+
+			msg := <-broadcast
+			assert.Equal(t, session.MessageTypeTransform, msg.Type)
+			assert.Equal(t, "user1", msg.Sender)
+			assert.Equal(t, content, msg.Content)
+		})
+	}
+
+	t.Run("scales line width alike", func(t *testing.T) {
+		transformed := func(t *testing.T, transform geometry.Affine) *session.Stroke {
+			scb, fakeCache, _ := setupStrokeSession(t, newStroke("stroke1", "user1"))
+			content := session.ContentTransform{PageID: "pid1", StrokeIDs: []string{"stroke1"}, Transform: transform}
+			err := scb.Receive(ctx, newTransformMessage(t, content, "user1"), "user1")
+			require.NoError(t, err)
+			_, _, updates := fakeCache.UpdateStrokesArgsForCall(0)
+			require.Len(t, updates, 1)
+			return updates[0].(*session.Stroke)
+		}
+
+		for _, scale := range []geometry.Affine{geometry.Scale(2, 2), geometry.Scale(3, 1), geometry.Scale(0.5, 4)} {
+			// the rotation forces the transform of the points
+			scaled := transformed(t, scale)
+			rotated := transformed(t, scale.Then(geometry.Rotate(1e-6)))
+
+			assert.InDelta(t, lineWidth(scaled), lineWidth(rotated), 1e-9)
+			assert.InDelta(t, lineWidth(newStroke("", ""))*math.Sqrt(math.Abs(scale.Det())), lineWidth(scaled), 1e-9)
+		}
+	})
+
+	t.Run("transforms stroke sent right before", func(t *testing.T) {
+		scb, fakeCache, _ := setupStrokeSession(t)
+		stroke := newStroke("stroke1", "user1")
+		content := session.ContentTransform{PageID: "pid1", StrokeIDs: []string{"stroke1"}, Transform: geometry.Translate(5, 5)}
+
+		err := scb.Receive(ctx, newStrokeMessage(t, session.JSONCodec, stroke), "user1")
+		require.NoError(t, err)
+		err = scb.Receive(ctx, newTransformMessage(t, content, "user1"), "user1")
+		require.NoError(t, err)
+
+		// the stroke is stored before it is transformed
+		require.Equal(t, 2, fakeCache.UpdateStrokesCallCount())
+		_, _, updates := fakeCache.UpdateStrokesArgsForCall(1)
+		require.Len(t, updates, 1)
+		assert.Equal(t, 15.0, updates[0].(*session.Stroke).X)
+		assert.Equal(t, 25.0, updates[0].(*session.Stroke).Y)
+		got, err := scb.GetStrokesInRect(ctx, "pid1", geometry.Rect{Min: geometry.Point{X: 14, Y: 24}, Max: geometry.Point{X: 16, Y: 26}})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, 15.0, got[0].X)
+	})
+
+	t.Run("skips strokes of other users", func(t *testing.T) {
+		scb, fakeCache, broadcast := setupStrokeSession(t, newStroke("stroke1", "user1"), newStroke("stroke2", "user2"))
+		content := session.ContentTransform{PageID: "pid1", StrokeIDs: []string{"stroke1", "stroke2"}, Transform: geometry.Translate(1, 1)}
+
+		err := scb.Receive(ctx, newTransformMessage(t, content, "user1"), "user1")
+
+		require.NoError(t, err)
+		_, _, updates := fakeCache.UpdateStrokesArgsForCall(0)
+		require.Len(t, updates, 1)
+		assert.Equal(t, "stroke1", updates[0].Id())
+		msg := <-broadcast
+		assert.Equal(t, []string{"stroke1"}, msg.Content.(session.ContentTransform).StrokeIDs)
+	})
+
+	t.Run("rejects strokes of other users", func(t *testing.T) {
+		scb, fakeCache, _ := setupStrokeSession(t, newStroke("stroke2", "user2"))
+		content := session.ContentTransform{PageID: "pid1", StrokeIDs: []string{"stroke2"}, Transform: geometry.Translate(1, 1)}
+
+		err := scb.Receive(ctx, newTransformMessage(t, content, "user1"), "user1")
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, fakeCache.UpdateStrokesCallCount())
+	})
+
+	t.Run("rejects degenerate transform", func(t *testing.T) {
+		scb, fakeCache, _ := setupStrokeSession(t, newStroke("stroke1", "user1"))
+		content := session.ContentTransform{PageID: "pid1", StrokeIDs: []string{"stroke1"}, Transform: geometry.Scale(0, 1)}
+
+		err := scb.Receive(ctx, newTransformMessage(t, content, "user1"), "user1")
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, fakeCache.UpdateStrokesCallCount())
+	})
+
+	t.Run("rejects strokes in read-only sessions", func(t *testing.T) {
+		scb, fakeCache, _ := setupStrokeSession(t, newStroke("stroke1", "user1"))
+		err := scb.SetConfig(&session.ConfigRequest{ReadOnly: opt.New(true)})
+		require.NoError(t, err)
+		content := session.ContentTransform{PageID: "pid1", StrokeIDs: []string{"stroke1"}, Transform: geometry.Translate(1, 1)}
+
+		err = scb.Receive(ctx, newTransformMessage(t, content, "user1"), "user1")
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, fakeCache.UpdateStrokesCallCount())
+	})
+}
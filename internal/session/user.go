@@ -4,9 +4,9 @@ import (
 	"context"
 	"errors"
 	"regexp"
+	"time"
 
 	"github.com/google/uuid"
-	gws "github.com/gorilla/websocket"
 
 	libErr "github.com/boardsite-io/server/pkg/errors"
 )
@@ -24,10 +24,11 @@ var (
 
 // User declares some information about connected users.
 type User struct {
-	ID    string    `json:"id"`
-	Alias string    `json:"alias"`
-	Color string    `json:"color"`
-	Conn  *gws.Conn `json:"-"`
+	ID    string `json:"id"`
+	Alias string `json:"alias"`
+	Color string `json:"color"`
+	Conn  *Conn  `json:"-"`
+	Presence
 }
 
 func (u *User) validate() error {
@@ -92,10 +93,7 @@ func (scb *controlBlock) UpdateUser(user User, userReq UserRequest) error {
 	scb.users[user.ID].Color = userReq.Color
 	scb.muUsr.Unlock()
 
-	scb.broadcaster.Broadcast() <- Message{
-		Type:    MessageTypeUserSync,
-		Content: scb.GetUsers(),
-	}
+	scb.broadcastUsers(scb.GetUsers())
 
 	return nil
 }
@@ -152,7 +150,7 @@ func (scb *controlBlock) UserCanJoin(userID string) error {
 // UserConnect adds user from the userReady state to clients.
 //
 // Broadcast that user has connected to session.
-func (scb *controlBlock) UserConnect(userID string, conn *gws.Conn) error {
+func (scb *controlBlock) UserConnect(userID string, conn *Conn) error {
 	u, err := scb.getUserReady(userID)
 	if err != nil {
 		return libErr.ErrBadRequest.Wrap(libErr.WithError(err))
@@ -164,9 +162,11 @@ func (scb *controlBlock) UserConnect(userID string, conn *gws.Conn) error {
 		scb.muUsr.Unlock()
 		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("user already connected"))
 	}
+	u.Presence = Presence{Activity: ActivityActive, lastActive: time.Now()}
 	scb.users[u.ID] = u
 	scb.numUsers++
 	numCl := scb.numUsers
+	joined := *u
 	scb.muUsr.Unlock()
 
 	// the first user to connect needs to start the session
@@ -177,7 +177,7 @@ func (scb *controlBlock) UserConnect(userID string, conn *gws.Conn) error {
 	// broadcast that user has joined
 	scb.broadcaster.Broadcast() <- Message{
 		Type:    MessageTypeUserConnected,
-		Content: u,
+		Content: &joined,
 	}
 
 	if scb.isHost(u) {
@@ -188,6 +188,16 @@ func (scb *controlBlock) UserConnect(userID string, conn *gws.Conn) error {
 		}
 	}
 
+	// late joiners can jump to the presented viewport
+	if viewport := scb.HostViewport(); viewport != nil && !scb.isHost(u) {
+		scb.broadcaster.Send() <- Message{
+			Type:     MessageTypeViewport,
+			Sender:   scb.cfg.Host,
+			Receiver: u.ID,
+			Content:  *viewport,
+		}
+	}
+
 	return nil
 }
 
@@ -204,6 +214,11 @@ func (scb *controlBlock) UserDisconnect(_ context.Context, userID string) {
 	numCl := scb.numUsers
 	scb.muUsr.Unlock()
 
+	if ok {
+		// the writer stops after the close frame
+		u.Conn.CloseWith("Closed by server")
+	}
+
 	// if session is empty after client disconnect
 	// the session needs to be set to inactive
 	if numCl == 0 {
@@ -216,11 +231,6 @@ func (scb *controlBlock) UserDisconnect(_ context.Context, userID string) {
 		Type:    MessageTypeUserDisconnected,
 		Content: u,
 	}
-
-	scb.broadcaster.Control() <- Message{
-		Receiver: userID,
-		Content:  "Closed by server",
-	}
 }
 
 func (scb *controlBlock) KickUser(userID string) error {
@@ -244,12 +254,13 @@ func (scb *controlBlock) KickUser(userID string) error {
 	return nil
 }
 
-// GetUsers returns all active users/clients in the session.
+// GetUsers returns copies of all active users/clients in the session.
 func (scb *controlBlock) GetUsers() map[string]*User {
 	users := make(map[string]*User)
 	scb.muUsr.RLock()
 	for id, u := range scb.users {
-		users[id] = u
+		user := *u
+		users[id] = &user
 	}
 	scb.muUsr.RUnlock()
 	return users
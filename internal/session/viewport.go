@@ -0,0 +1,64 @@
+package session
+
+import (
+	"context"
+
+	libErr "github.com/boardsite-io/server/pkg/errors"
+)
+
+// ContentViewport declares the visible area of a page.
+//
+// X and Y denote the pan of the view in page coordinates.
+type ContentViewport struct {
+	PageID string  `json:"pageId"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Zoom   float64 `json:"zoom"`
+}
+
+func (c *ContentViewport) validate() error {
+	if !isFinite(c.X) || !isFinite(c.Y) || !isFinite(c.Zoom) || c.Zoom <= 0 {
+		return libErr.ErrBadRequest.Wrap(libErr.WithErrorf("invalid viewport"))
+	}
+	return nil
+}
+
+// presentViewport stores the viewport of the host and broadcasts it to
+// the participants, which follow the host.
+func (scb *controlBlock) presentViewport(ctx context.Context, msg *Message) error {
+	if msg.Sender != scb.cfg.Host {
+		return libErr.ErrForbidden.Wrap(libErr.WithErrorf("only the host can present"))
+	}
+
+	var content ContentViewport
+	if err := msg.UnmarshalContent(&content); err != nil {
+		return err
+	}
+	if err := content.validate(); err != nil {
+		return err
+	}
+	if !scb.IsValidPage(ctx, content.PageID) {
+		return libErr.ErrNotFound.Wrap(libErr.WithErrorf("page %s does not exist", content.PageID))
+	}
+
+	scb.muViewport.Lock()
+	scb.hostViewport = &content
+	scb.muViewport.Unlock()
+
+	scb.broadcaster.Broadcast() <- Message{
+		Type:    MessageTypeViewport,
+		Sender:  msg.Sender,
+		Content: content,
+	}
+	return nil
+}
+
+func (scb *controlBlock) HostViewport() *ContentViewport {
+	scb.muViewport.RLock()
+	defer scb.muViewport.RUnlock()
+	if scb.hostViewport == nil {
+		return nil
+	}
+	viewport := *scb.hostViewport
+	return &viewport
+}
@@ -0,0 +1,95 @@
+package session_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/attachment/attachmentfakes"
+	"github.com/boardsite-io/server/internal/config"
+	"github.com/boardsite-io/server/internal/session"
+	"github.com/boardsite-io/server/internal/session/sessionfakes"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+	"github.com/boardsite-io/server/pkg/redis/redisfakes"
+)
+
+func newViewportMessage(t *testing.T, content session.ContentViewport, sender string) *session.Message {
+	// the msgpack codec supports non-finite numbers
+	data, err := session.MsgpackCodec.Marshal(session.NewMessage(content, session.MessageTypeViewport, sender))
+	require.NoError(t, err)
+	msg, err := session.MsgpackCodec.Unmarshal(data)
+	require.NoError(t, err)
+	return msg
+}
+
+func Test_controlBlock_Receive_Viewport(t *testing.T) {
+	ctx := context.Background()
+	valid := session.ContentViewport{PageID: "pid1", X: -10, Y: 250.5, Zoom: 1.5}
+
+	tests := []struct {
+		name    string
+		content session.ContentViewport
+		sender  string
+		wantErr error
+	}{
+		{name: "host", content: valid, sender: "host"},
+		{name: "participant", content: valid, sender: "user1", wantErr: libErr.ErrForbidden},
+		{name: "unknown page", content: session.ContentViewport{PageID: "pid2", Zoom: 1}, sender: "host", wantErr: libErr.ErrNotFound},
+		{name: "zero zoom", content: session.ContentViewport{PageID: "pid1"}, sender: "host", wantErr: libErr.ErrBadRequest},
+		{name: "infinite pan", content: session.ContentViewport{PageID: "pid1", X: math.Inf(1), Zoom: 1}, sender: "host", wantErr: libErr.ErrBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scb, _, broadcast := newTestSession(t, session.Config{ID: "sid1", Host: "host"}, withPages("pid1"))
+
+			err := scb.Receive(ctx, newViewportMessage(t, tt.content, tt.sender), tt.sender)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, scb.HostViewport())
+				assert.Len(t, broadcast, 0)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, &tt.content, scb.HostViewport())
+			msg := <-broadcast
+			assert.Equal(t, session.MessageTypeViewport, msg.Type)
+			assert.Equal(t, tt.sender, msg.Sender)
+			assert.Equal(t, tt.content, msg.Content)
+		})
+	}
+}
+
+func Test_controlBlock_UserConnect_Viewport(t *testing.T) {
+	ctx := context.Background()
+	fakeCache := &redisfakes.FakeHandler{}
+	fakeCache.GetPageRankReturns([]string{"pid1"}, nil)
+	fakeBroadcaster := &sessionfakes.FakeBroadcaster{}
+	fakeBroadcaster.BroadcastReturns(make(chan session.Message, 10))
+	send := make(chan session.Message, 10)
+	fakeBroadcaster.SendReturns(send)
+	scb, err := session.NewControlBlock(session.Config{ID: "sid1", Session: config.Session{MaxUsers: 10}}, session.WithCache(fakeCache),
+		session.WithAttachments(&attachmentfakes.FakeHandler{}), session.WithDispatcher(&sessionfakes.FakeDispatcher{}), session.WithBroadcaster(fakeBroadcaster))
+	require.NoError(t, err)
+	// the first user becomes the host
+	host, err := scb.NewUser(session.UserRequest{User: session.User{Alias: "host", Color: "#00ff00"}})
+	require.NoError(t, err)
+	require.NoError(t, scb.UserConnect(host.ID, nil))
+	<-send
+	viewport := session.ContentViewport{PageID: "pid1", X: 10, Y: 20, Zoom: 2}
+	require.NoError(t, scb.Receive(ctx, newViewportMessage(t, viewport, host.ID), host.ID))
+
+	user, err := scb.NewUser(session.UserRequest{User: session.User{Alias: "late", Color: "#00ff00"}})
+	require.NoError(t, err)
+	err = scb.UserConnect(user.ID, nil)
+
+	require.NoError(t, err)
+	msg := <-send
+	assert.Equal(t, session.MessageTypeViewport, msg.Type)
+	assert.Equal(t, user.ID, msg.Receiver)
+	assert.Equal(t, host.ID, msg.Sender)
+	assert.Equal(t, viewport, msg.Content)
+}
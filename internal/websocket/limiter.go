@@ -0,0 +1,88 @@
+package websocket
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/boardsite-io/server/internal/config"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+)
+
+// limiter limits the inbound messages of a single user.
+//
+// Each message has to pass the token bucket of the user and the
+// token bucket of its message type. Users who exceed the limits
+// more than the allowed violations per minute are regarded abusive.
+type limiter struct {
+	total      *rate.Limiter
+	types      map[string]*rate.Limiter
+	maxSize    map[string]int
+	violations *rate.Limiter
+}
+
+func newLimiter(cfg config.Websocket) *limiter {
+	l := &limiter{
+		total:   newRateLimiter(cfg.RateLimit.RateLimit),
+		types:   make(map[string]*rate.Limiter, len(cfg.RateLimit.Types)),
+		maxSize: make(map[string]int, len(cfg.RateLimit.Types)),
+	}
+	for msgType, typeCfg := range cfg.RateLimit.Types {
+		l.types[msgType] = newRateLimiter(typeCfg)
+		l.maxSize[msgType] = typeCfg.MaxSize
+	}
+	if maxViolations := cfg.RateLimit.MaxViolations; maxViolations > 0 {
+		l.violations = rate.NewLimiter(rate.Every(time.Minute/time.Duration(maxViolations)), maxViolations)
+	}
+	return l
+}
+
+func newRateLimiter(cfg config.RateLimit) *rate.Limiter {
+	if cfg.Rate <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	burst := cfg.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(cfg.Rate), burst)
+}
+
+// allow checks whether a message with given type and size in bytes
+// can be processed.
+//
+// Tokens are only spent if the message passes both buckets, i.e. the
+// token of the message type is returned if the total bucket is empty.
+func (l *limiter) allow(msgType string, size int) error {
+	if maxSize := l.maxSize[msgType]; maxSize > 0 && size > maxSize {
+		return libErr.From(libErr.MessageSizeExceeded).Wrap(
+			libErr.WithErrorf("message of type %s exceeds %d bytes", msgType, maxSize))
+	}
+	now := time.Now()
+	var reserved *rate.Reservation
+	if typeLimiter, ok := l.types[msgType]; ok {
+		reserved = typeLimiter.ReserveN(now, 1)
+		if !reserved.OK() || reserved.DelayFrom(now) > 0 {
+			reserved.CancelAt(now)
+			return libErr.From(libErr.RateLimitExceeded).Wrap(
+				libErr.WithErrorf("rate limit of message type %s exceeded", msgType))
+		}
+	}
+	if !l.total.AllowN(now, 1) {
+		if reserved != nil {
+			reserved.CancelAt(now)
+		}
+		return libErr.From(libErr.RateLimitExceeded).Wrap(
+			libErr.WithErrorf("rate limit exceeded"))
+	}
+	return nil
+}
+
+// violate records a violation of the limits and reports
+// whether the user has become abusive.
+func (l *limiter) violate() bool {
+	if l.violations == nil {
+		return false
+	}
+	return !l.violations.Allow()
+}
@@ -4,17 +4,28 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	gws "github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 
+	"github.com/boardsite-io/server/internal/config"
 	"github.com/boardsite-io/server/internal/session"
 	"github.com/boardsite-io/server/pkg/log"
 )
 
+const (
+	defaultMaxMessageSize = 1 << 20
+)
+
+// QueryKeyEncoding selects the message encoding for clients
+// that cannot negotiate a subprotocol.
+const QueryKeyEncoding = "encoding"
+
 var upgrader = gws.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	Subprotocols:    []string{session.SubprotocolMsgpack, session.SubprotocolJSON},
 	// already checked by CORS middleware
 	CheckOrigin: func(r *http.Request) bool {
 		return true
@@ -26,11 +37,30 @@ func upgrade(c echo.Context) (*gws.Conn, error) {
 	return upgrader.Upgrade(c.Response().Writer, c.Request(), nil)
 }
 
-func onClientConnect(ctx context.Context, scb session.Controller, userID string, conn *gws.Conn) error {
+// negotiateCodec selects the message codec of a connection.
+//
+// A negotiated subprotocol takes precedence over the encoding query parameter.
+// Defaults to JSON.
+func negotiateCodec(c echo.Context, conn *gws.Conn) (session.Codec, error) {
+	if codec, ok := session.CodecByName(conn.Subprotocol()); ok {
+		return codec, nil
+	}
+	encoding := c.QueryParam(QueryKeyEncoding)
+	if encoding == "" {
+		return session.JSONCodec, nil
+	}
+	codec, ok := session.CodecByName(encoding)
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding: %s", encoding)
+	}
+	return codec, nil
+}
+
+func onClientConnect(ctx context.Context, scb session.Controller, userID string, conn *session.Conn) error {
 	if err := scb.UserConnect(userID, conn); err != nil {
 		return err
 	}
-	log.Ctx(ctx).Infof("session %s :: %s (%s) connected", scb.ID(), userID, conn.RemoteAddr().String())
+	log.Ctx(ctx).Infof("session %s :: %s (%s) connected using %s", scb.ID(), userID, conn.RemoteAddr().String(), conn.Codec().Name())
 	return nil
 }
 
@@ -39,40 +69,86 @@ func onClientDisconnect(ctx context.Context, scb session.Controller, userID stri
 	log.Ctx(ctx).Infof("session %s :: %s (%s) disconnected", scb.ID(), userID, conn.RemoteAddr().String())
 }
 
+// setReadLimits limits the size of incoming messages and requires the client
+// to answer the pings of the server within the pong timeout, which defaults
+// to twice the ping interval. Otherwise, the connection is regarded as dead.
+func setReadLimits(conn *gws.Conn, cfg config.Websocket) {
+	maxMessageSize := cfg.MaxMessageSize
+	if maxMessageSize <= 0 {
+		maxMessageSize = defaultMaxMessageSize
+	}
+	conn.SetReadLimit(maxMessageSize)
+	extendReadDeadline(conn, cfg)
+	conn.SetPongHandler(func(string) error {
+		extendReadDeadline(conn, cfg)
+		return nil
+	})
+}
+
+func extendReadDeadline(conn *gws.Conn, cfg config.Websocket) {
+	pongTimeout := cfg.PongTimeout
+	if pongTimeout <= 0 {
+		pongTimeout = 2 * cfg.PingInterval
+		if cfg.PingInterval <= 0 {
+			pongTimeout = 2 * config.DefaultPingInterval
+		}
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(pongTimeout))
+}
+
 // Subscribe subscribes to the websocket connection
-func Subscribe(c echo.Context, scb session.Controller, userID string) error {
+func Subscribe(c echo.Context, cfg config.Websocket, scb session.Controller, userID string) error {
 	ctx := c.Request().Context()
 	conn, err := upgrade(c)
 	if err != nil {
 		return err
 	}
 
-	if err := onClientConnect(ctx, scb, userID, conn); err != nil {
+	codec, err := negotiateCodec(c, conn)
+	if err != nil {
 		_ = conn.WriteMessage(gws.CloseMessage, gws.FormatCloseMessage(gws.CloseNormalClosure, fmt.Sprintf("%v", err)))
 		_ = conn.Close()
 		return err
 	}
+
+	sessionConn := session.NewConn(conn, codec, cfg)
+	if err := onClientConnect(ctx, scb, userID, sessionConn); err != nil {
+		sessionConn.CloseWith(fmt.Sprintf("%v", err))
+		return err
+	}
+	// the connection is closed by the session on disconnect,
+	// otherwise it is closed without a reason
+	defer sessionConn.CloseWith("")
 	defer onClientDisconnect(ctx, scb, userID, conn)
 
+	setReadLimits(conn, cfg)
+	lim := newLimiter(cfg)
+
 	for {
 		_, data, err := conn.ReadMessage()
 		if err != nil {
-			break // socket closed
+			break // socket closed or timed out
 		}
+		extendReadDeadline(conn, cfg)
 
-		msg, err := session.UnmarshalMessage(data)
+		msg, err := codec.Unmarshal(data)
 		if err != nil {
 			continue
 		}
 
+		if err := lim.allow(msg.Type, len(data)); err != nil {
+			scb.Broadcaster().Send() <- session.NewErrorMessage(err, userID)
+			if lim.violate() {
+				log.Ctx(ctx).Warnf("session %s :: disconnect %s: too many violations: %v", scb.ID(), userID, err)
+				break
+			}
+			continue
+		}
+
 		// sanitize received data
 		if err := scb.Receive(ctx, msg, userID); err != nil {
 			log.Ctx(ctx).Warnf("session %s :: error receive message from %s: %v", scb.ID(), msg.Sender, err)
-			scb.Broadcaster().Send() <- session.Message{
-				Type:     "error",
-				Receiver: userID,
-				Content:  err.Error(),
-			}
+			scb.Broadcaster().Send() <- session.NewErrorMessage(err, userID)
 		}
 	}
 	return nil
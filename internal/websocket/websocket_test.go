@@ -0,0 +1,189 @@
+package websocket_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gws "github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/config"
+	"github.com/boardsite-io/server/internal/session"
+	"github.com/boardsite-io/server/internal/session/sessionfakes"
+	"github.com/boardsite-io/server/internal/websocket"
+	libErr "github.com/boardsite-io/server/pkg/errors"
+)
+
+func setupServer(t *testing.T, cfg config.Websocket) (*sessionfakes.FakeController, string) {
+	scb := &sessionfakes.FakeController{}
+	e := echo.New()
+	e.GET("/b/:id/users/:userId/socket", func(c echo.Context) error {
+		return websocket.Subscribe(c, cfg, scb, c.Param("userId"))
+	})
+	s := httptest.NewServer(e)
+	t.Cleanup(s.Close)
+	return scb, "ws" + strings.TrimPrefix(s.URL, "http") + "/b/sid/users/user1/socket"
+}
+
+func dial(t *testing.T, url string, subprotocols ...string) *gws.Conn {
+	dialer := *gws.DefaultDialer
+	dialer.Subprotocols = subprotocols
+	conn, _, err := dialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestSubscribe(t *testing.T) {
+	cfg := config.Websocket{
+		PingInterval:   20 * time.Millisecond,
+		PongTimeout:    100 * time.Millisecond,
+		WriteTimeout:   100 * time.Millisecond,
+		MaxMessageSize: 64,
+	}
+
+	t.Run("negotiates codec", func(t *testing.T) {
+		scb, url := setupServer(t, cfg)
+		conn := dial(t, url, session.SubprotocolMsgpack)
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		assert.Eventually(t, func() bool { return scb.UserConnectCallCount() == 1 }, time.Second, 10*time.Millisecond)
+		userID, sessionConn := scb.UserConnectArgsForCall(0)
+		assert.Equal(t, "user1", userID)
+		assert.Equal(t, session.MsgpackCodec, sessionConn.Codec())
+	})
+
+	t.Run("keeps responsive connection", func(t *testing.T) {
+		scb, url := setupServer(t, cfg)
+		conn := dial(t, url)
+		// reading answers the pings of the server
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		time.Sleep(5 * cfg.PongTimeout)
+
+		assert.Equal(t, 1, scb.UserConnectCallCount())
+		assert.Equal(t, 0, scb.UserDisconnectCallCount())
+	})
+
+	t.Run("disconnects dead connection", func(t *testing.T) {
+		scb, url := setupServer(t, cfg)
+		// never reads, hence pings remain unanswered
+		_ = dial(t, url)
+
+		assert.Eventually(t, func() bool { return scb.UserDisconnectCallCount() == 1 }, time.Second, 10*time.Millisecond)
+		_, userID := scb.UserDisconnectArgsForCall(0)
+		assert.Equal(t, "user1", userID)
+	})
+
+	t.Run("disconnects on message size exceeded", func(t *testing.T) {
+		scb, url := setupServer(t, cfg)
+		conn := dial(t, url)
+
+		err := conn.WriteMessage(gws.TextMessage, []byte(strings.Repeat("a", 2*int(cfg.MaxMessageSize))))
+
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool { return scb.UserDisconnectCallCount() == 1 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, 0, scb.ReceiveCallCount())
+	})
+}
+
+func TestSubscribe_RateLimit(t *testing.T) {
+	cfg := config.Websocket{}
+	cfg.RateLimit.MaxViolations = 2
+	cfg.RateLimit.Types = map[string]config.RateLimit{
+		session.MessageTypeMouseMove: {Rate: 0.001, Burst: 1, MaxSize: 64},
+	}
+	scb, url := setupServer(t, cfg)
+	broadcaster := &sessionfakes.FakeBroadcaster{}
+	send := make(chan session.Message, 10)
+	broadcaster.SendReturns(send)
+	scb.BroadcasterReturns(broadcaster)
+	conn := dial(t, url)
+
+	for i := 0; i < 5; i++ {
+		err := conn.WriteJSON(session.NewMessage(session.ContentMouseMove{X: 1, Y: 2}, session.MessageTypeMouseMove, "user1"))
+		require.NoError(t, err)
+	}
+
+	assert.Eventually(t, func() bool { return scb.UserDisconnectCallCount() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, scb.ReceiveCallCount())
+	require.Equal(t, 3, len(send))
+	msg := <-send
+	assert.Equal(t, session.MessageTypeError, msg.Type)
+	assert.Equal(t, "user1", msg.Receiver)
+	assert.Equal(t, libErr.RateLimitExceeded, msg.Content.(session.ContentError).Code)
+}
+
+func TestSubscribe_RateLimitTotal(t *testing.T) {
+	cfg := config.Websocket{}
+	cfg.RateLimit.Rate = 10
+	cfg.RateLimit.Burst = 1
+	cfg.RateLimit.Types = map[string]config.RateLimit{
+		session.MessageTypeMouseMove: {Rate: 0.001, Burst: 1},
+	}
+	scb, url := setupServer(t, cfg)
+	broadcaster := &sessionfakes.FakeBroadcaster{}
+	send := make(chan session.Message, 10)
+	broadcaster.SendReturns(send)
+	scb.BroadcasterReturns(broadcaster)
+	conn := dial(t, url)
+	mouseMove := session.NewMessage(session.ContentMouseMove{X: 1, Y: 2}, session.MessageTypeMouseMove, "user1")
+
+	// the second message exceeds the total limit
+	require.NoError(t, conn.WriteJSON(session.NewMessage(session.ContentChat{Text: "hi"}, session.MessageTypeChat, "user1")))
+	require.NoError(t, conn.WriteJSON(mouseMove))
+	select {
+	case msg := <-send:
+		assert.Equal(t, libErr.RateLimitExceeded, msg.Content.(session.ContentError).Code)
+	case <-time.After(time.Second):
+		t.Fatal("no error message sent")
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	// the token of the message type was not spent by the rejected message
+	require.NoError(t, conn.WriteJSON(mouseMove))
+
+	assert.Eventually(t, func() bool { return scb.ReceiveCallCount() == 2 }, time.Second, 10*time.Millisecond)
+	assert.Empty(t, send)
+}
+
+func TestSubscribe_SizeLimit(t *testing.T) {
+	cfg := config.Websocket{}
+	cfg.RateLimit.Types = map[string]config.RateLimit{
+		session.MessageTypeMouseMove: {MaxSize: 16},
+	}
+	scb, url := setupServer(t, cfg)
+	broadcaster := &sessionfakes.FakeBroadcaster{}
+	send := make(chan session.Message, 10)
+	broadcaster.SendReturns(send)
+	scb.BroadcasterReturns(broadcaster)
+	conn := dial(t, url)
+
+	err := conn.WriteJSON(session.NewMessage(session.ContentMouseMove{X: 1, Y: 2}, session.MessageTypeMouseMove, "user1"))
+
+	require.NoError(t, err)
+	select {
+	case msg := <-send:
+		assert.Equal(t, libErr.MessageSizeExceeded, msg.Content.(session.ContentError).Code)
+	case <-time.After(time.Second):
+		t.Fatal("no error message sent")
+	}
+	assert.Equal(t, 0, scb.ReceiveCallCount())
+	assert.Equal(t, 0, scb.UserDisconnectCallCount())
+}
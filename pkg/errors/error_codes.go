@@ -13,6 +13,9 @@ const (
 	MaxNumberOfUsersReached
 	BadUsername
 	WrongPassword
+	MessageSizeExceeded
+	AttachmentQuotaExceeded
+	AttachmentReferenced
 )
 
 // Server error codes
@@ -27,4 +30,7 @@ var codeStatusMap = map[Code]int{
 	MaxNumberOfUsersReached: http.StatusBadRequest,
 	BadUsername:             http.StatusBadRequest,
 	WrongPassword:           http.StatusBadRequest,
+	MessageSizeExceeded:     http.StatusRequestEntityTooLarge,
+	AttachmentQuotaExceeded: http.StatusRequestEntityTooLarge,
+	AttachmentReferenced:    http.StatusConflict,
 }
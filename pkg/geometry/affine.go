@@ -0,0 +1,69 @@
+package geometry
+
+import "math"
+
+// Affine declares the affine transform with the matrix
+//
+//	| a c e |
+//	| b d f |
+//	| 0 0 1 |
+//
+// in the order [a, b, c, d, e, f] as used by SVG and the canvas API.
+type Affine [6]float64
+
+// Identity is the identity transform.
+var Identity = Affine{1, 0, 0, 1, 0, 0}
+
+// Translate returns a translation by (dx, dy).
+func Translate(dx, dy float64) Affine {
+	return Affine{1, 0, 0, 1, dx, dy}
+}
+
+// Scale returns a scaling with the origin as fixed point.
+func Scale(sx, sy float64) Affine {
+	return Affine{sx, 0, 0, sy, 0, 0}
+}
+
+// Rotate returns a counter-clockwise rotation by theta radians about the origin.
+func Rotate(theta float64) Affine {
+	sin, cos := math.Sincos(theta)
+	return Affine{cos, sin, -sin, cos, 0, 0}
+}
+
+// Then returns the transform which applies t followed by o.
+func (t Affine) Then(o Affine) Affine {
+	return Affine{
+		o[0]*t[0] + o[2]*t[1],
+		o[1]*t[0] + o[3]*t[1],
+		o[0]*t[2] + o[2]*t[3],
+		o[1]*t[2] + o[3]*t[3],
+		o[0]*t[4] + o[2]*t[5] + o[4],
+		o[1]*t[4] + o[3]*t[5] + o[5],
+	}
+}
+
+// Apply transforms the point.
+func (t Affine) Apply(p Point) Point {
+	return Point{
+		X: t[0]*p.X + t[2]*p.Y + t[4],
+		Y: t[1]*p.X + t[3]*p.Y + t[5],
+	}
+}
+
+// ApplyLinear transforms the vector, i.e. without translation.
+func (t Affine) ApplyLinear(p Point) Point {
+	return Point{
+		X: t[0]*p.X + t[2]*p.Y,
+		Y: t[1]*p.X + t[3]*p.Y,
+	}
+}
+
+// Det returns the determinant of the linear part.
+func (t Affine) Det() float64 {
+	return t[0]*t[3] - t[1]*t[2]
+}
+
+// IsDiagonal reports whether the linear part neither rotates nor shears.
+func (t Affine) IsDiagonal() bool {
+	return t[1] == 0 && t[2] == 0
+}
@@ -0,0 +1,40 @@
+package geometry_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/boardsite-io/server/pkg/geometry"
+)
+
+func TestAffine_Apply(t *testing.T) {
+	p := geometry.Point{X: 1, Y: 2}
+
+	tests := []struct {
+		name string
+		t    geometry.Affine
+		want geometry.Point
+	}{
+		{name: "identity", t: geometry.Identity, want: p},
+		{name: "translate", t: geometry.Translate(3, -1), want: geometry.Point{X: 4, Y: 1}},
+		{name: "scale", t: geometry.Scale(2, 3), want: geometry.Point{X: 2, Y: 6}},
+		{name: "rotate", t: geometry.Rotate(math.Pi / 2), want: geometry.Point{X: -2, Y: 1}},
+		{name: "scale then translate", t: geometry.Scale(2, 2).Then(geometry.Translate(1, 1)), want: geometry.Point{X: 3, Y: 5}},
+		{name: "translate then scale", t: geometry.Translate(1, 1).Then(geometry.Scale(2, 2)), want: geometry.Point{X: 4, Y: 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.t.Apply(p)
+			assert.InDelta(t, tt.want.X, got.X, 1e-9)
+			assert.InDelta(t, tt.want.Y, got.Y, 1e-9)
+		})
+	}
+}
+
+func TestAffine_IsDiagonal(t *testing.T) {
+	assert.True(t, geometry.Scale(2, 3).Then(geometry.Translate(1, 1)).IsDiagonal())
+	assert.False(t, geometry.Rotate(0.1).IsDiagonal())
+	assert.InDelta(t, 6, geometry.Scale(2, 3).Det(), 1e-9)
+}
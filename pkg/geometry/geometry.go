@@ -0,0 +1,305 @@
+// Package geometry provides planar primitives for stroke computations.
+package geometry
+
+import "math"
+
+// Point declares a point in the plane.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Sub returns the vector p - q.
+func (p Point) Sub(q Point) Point {
+	return Point{X: p.X - q.X, Y: p.Y - q.Y}
+}
+
+// Dist returns the euclidean distance between p and q.
+func (p Point) Dist(q Point) float64 {
+	return math.Hypot(p.X-q.X, p.Y-q.Y)
+}
+
+// Rect declares an axis aligned rectangle.
+type Rect struct {
+	Min Point `json:"min"`
+	Max Point `json:"max"`
+}
+
+// Bounds returns the smallest rectangle containing all points.
+func Bounds(points ...Point) Rect {
+	if len(points) == 0 {
+		return Rect{}
+	}
+	r := Rect{Min: points[0], Max: points[0]}
+	for _, p := range points[1:] {
+		r.Min.X = math.Min(r.Min.X, p.X)
+		r.Min.Y = math.Min(r.Min.Y, p.Y)
+		r.Max.X = math.Max(r.Max.X, p.X)
+		r.Max.Y = math.Max(r.Max.Y, p.Y)
+	}
+	return r
+}
+
+// Empty reports whether the rectangle has a negative extent.
+func (r Rect) Empty() bool {
+	return r.Min.X > r.Max.X || r.Min.Y > r.Max.Y
+}
+
+// Intersects reports whether the rectangles share at least one point.
+func (r Rect) Intersects(o Rect) bool {
+	return r.Min.X <= o.Max.X && o.Min.X <= r.Max.X &&
+		r.Min.Y <= o.Max.Y && o.Min.Y <= r.Max.Y
+}
+
+// Contains reports whether the point lies in the rectangle.
+func (r Rect) Contains(p Point) bool {
+	return p.X >= r.Min.X && p.X <= r.Max.X && p.Y >= r.Min.Y && p.Y <= r.Max.Y
+}
+
+// Grow returns the rectangle grown by d on each side.
+func (r Rect) Grow(d float64) Rect {
+	return Rect{
+		Min: Point{X: r.Min.X - d, Y: r.Min.Y - d},
+		Max: Point{X: r.Max.X + d, Y: r.Max.Y + d},
+	}
+}
+
+// Corners returns the corners of the rectangle in counter-clockwise order.
+func (r Rect) Corners() Polygon {
+	return Polygon{r.Min, {X: r.Max.X, Y: r.Min.Y}, r.Max, {X: r.Min.X, Y: r.Max.Y}}
+}
+
+// Polygon declares a closed polygon by its vertices.
+type Polygon []Point
+
+// Bounds returns the bounding box of the polygon.
+func (pg Polygon) Bounds() Rect {
+	return Bounds(pg...)
+}
+
+// Contains reports whether the point lies inside the polygon
+// according to the even-odd rule.
+func (pg Polygon) Contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(pg)-1; i < len(pg); j, i = i, i+1 {
+		a, b := pg[i], pg[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) &&
+			p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// IntersectsSegment reports whether the segment ab crosses an edge of the polygon.
+func (pg Polygon) IntersectsSegment(a, b Point) bool {
+	for i, j := 0, len(pg)-1; i < len(pg); j, i = i, i+1 {
+		if SegmentsIntersect(a, b, pg[j], pg[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// IntersectsPolyline reports whether any vertex of the polyline lies inside
+// the polygon or any of its segments crosses the polygon.
+func (pg Polygon) IntersectsPolyline(line []Point) bool {
+	if len(pg) < 3 {
+		return false
+	}
+	for i, p := range line {
+		if pg.Contains(p) {
+			return true
+		}
+		if i > 0 && pg.IntersectsSegment(line[i-1], p) {
+			return true
+		}
+	}
+	return false
+}
+
+// SegmentsIntersect reports whether the segments ab and cd intersect.
+func SegmentsIntersect(a, b, c, d Point) bool {
+	d1 := cross(c, d, a)
+	d2 := cross(c, d, b)
+	d3 := cross(a, b, c)
+	d4 := cross(a, b, d)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) &&
+		((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && onSegment(c, d, a)) ||
+		(d2 == 0 && onSegment(c, d, b)) ||
+		(d3 == 0 && onSegment(a, b, c)) ||
+		(d4 == 0 && onSegment(a, b, d))
+}
+
+// SegmentDist returns the distance of p to the segment ab.
+func SegmentDist(p, a, b Point) float64 {
+	ab := b.Sub(a)
+	l2 := ab.X*ab.X + ab.Y*ab.Y
+	if l2 == 0 {
+		return p.Dist(a)
+	}
+	t := ((p.X-a.X)*ab.X + (p.Y-a.Y)*ab.Y) / l2
+	t = math.Max(0, math.Min(1, t))
+	return p.Dist(Point{X: a.X + t*ab.X, Y: a.Y + t*ab.Y})
+}
+
+// SegmentsDist returns the distance between the segments ab and cd.
+func SegmentsDist(a, b, c, d Point) float64 {
+	if SegmentsIntersect(a, b, c, d) {
+		return 0
+	}
+	return math.Min(
+		math.Min(SegmentDist(a, c, d), SegmentDist(b, c, d)),
+		math.Min(SegmentDist(c, a, b), SegmentDist(d, a, b)),
+	)
+}
+
+// PolylineDist returns the distance of p to the polyline.
+func PolylineDist(p Point, line []Point) float64 {
+	switch len(line) {
+	case 0:
+		return math.Inf(1)
+	case 1:
+		return p.Dist(line[0])
+	}
+	dist := math.Inf(1)
+	for i := 1; i < len(line); i++ {
+		dist = math.Min(dist, SegmentDist(p, line[i-1], line[i]))
+	}
+	return dist
+}
+
+// PolylineSegmentDist returns the distance of the segment ab to the polyline.
+func PolylineSegmentDist(a, b Point, line []Point) float64 {
+	switch len(line) {
+	case 0:
+		return math.Inf(1)
+	case 1:
+		return SegmentDist(line[0], a, b)
+	}
+	dist := math.Inf(1)
+	for i := 1; i < len(line); i++ {
+		dist = math.Min(dist, SegmentsDist(a, b, line[i-1], line[i]))
+	}
+	return dist
+}
+
+// CapsuleInterval returns the interval [t0, t1] of the parameters in [0, 1] of
+// the points a + t(b-a) whose distance to the segment cd is at most r, i.e. which
+// lie in the capsule around cd. ok is false if the segment ab misses the capsule.
+func CapsuleInterval(a, b, c, d Point, r float64) (t0, t1 float64, ok bool) {
+	t0, t1 = math.Inf(1), math.Inf(-1)
+	include := func(lo, hi float64) {
+		if lo <= hi {
+			t0, t1 = math.Min(t0, lo), math.Max(t1, hi)
+		}
+	}
+	// the capsule is the union of the discs at its ends and the rectangle
+	// in between, which is convex, such that the intervals overlap
+	include(discInterval(a, b, c, r))
+	include(discInterval(a, b, d, r))
+	cd := d.Sub(c)
+	if l := math.Hypot(cd.X, cd.Y); l > 0 {
+		ab, ca := b.Sub(a), a.Sub(c)
+		// the projection onto cd and the signed distance to cd are linear in t
+		lo, hi := clipLinear(math.Inf(-1), math.Inf(1), (ca.X*cd.X+ca.Y*cd.Y)/l, (ab.X*cd.X+ab.Y*cd.Y)/l, 0, l)
+		lo, hi = clipLinear(lo, hi, (cd.X*ca.Y-cd.Y*ca.X)/l, (cd.X*ab.Y-cd.Y*ab.X)/l, -r, r)
+		include(lo, hi)
+	}
+	t0, t1 = math.Max(t0, 0), math.Min(t1, 1)
+	return t0, t1, t0 <= t1
+}
+
+// discInterval returns the parameters of the points a + t(b-a) within the
+// distance r of the center. The interval is empty if lo > hi.
+func discInterval(a, b, center Point, r float64) (lo, hi float64) {
+	ab, ca := b.Sub(a), a.Sub(center)
+	qa := ab.X*ab.X + ab.Y*ab.Y
+	qb := 2 * (ab.X*ca.X + ab.Y*ca.Y)
+	qc := ca.X*ca.X + ca.Y*ca.Y - r*r
+	if qa == 0 {
+		if qc <= 0 {
+			return math.Inf(-1), math.Inf(1)
+		}
+		return 1, 0
+	}
+	disc := qb*qb - 4*qa*qc
+	if disc < 0 {
+		return 1, 0
+	}
+	sqrt := math.Sqrt(disc)
+	return (-qb - sqrt) / (2 * qa), (-qb + sqrt) / (2 * qa)
+}
+
+// clipLinear restricts the interval [lo, hi] to the parameters t
+// with min <= f0 + t*df <= max.
+func clipLinear(lo, hi, f0, df, min, max float64) (float64, float64) {
+	if df == 0 {
+		if f0 < min || f0 > max {
+			return 1, 0
+		}
+		return lo, hi
+	}
+	ta, tb := (min-f0)/df, (max-f0)/df
+	if ta > tb {
+		ta, tb = tb, ta
+	}
+	return math.Max(lo, ta), math.Min(hi, tb)
+}
+
+// cross returns the z component of the cross product (b-a) x (p-a).
+func cross(a, b, p Point) float64 {
+	return (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+}
+
+// onSegment reports whether the collinear point p lies on the segment ab.
+func onSegment(a, b, p Point) bool {
+	return math.Min(a.X, b.X) <= p.X && p.X <= math.Max(a.X, b.X) &&
+		math.Min(a.Y, b.Y) <= p.Y && p.Y <= math.Max(a.Y, b.Y)
+}
+
+// Simplify reduces the vertices of the polyline with the Ramer-Douglas-Peucker
+// algorithm and returns the indices of the retained vertices in order.
+//
+// Each removed vertex has a distance of at most tolerance to the simplified
+// polyline. The first and the last vertex are always retained.
+func Simplify(line []Point, tolerance float64) []int {
+	if len(line) < 3 || tolerance <= 0 {
+		indices := make([]int, len(line))
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices
+	}
+
+	keep := make([]bool, len(line))
+	keep[0], keep[len(line)-1] = true, true
+	// iterative to bound the stack for long lines
+	stack := [][2]int{{0, len(line) - 1}}
+	for len(stack) > 0 {
+		first, last := stack[len(stack)-1][0], stack[len(stack)-1][1]
+		stack = stack[:len(stack)-1]
+
+		maxDist, index := 0.0, -1
+		for i := first + 1; i < last; i++ {
+			if d := SegmentDist(line[i], line[first], line[last]); d > maxDist {
+				maxDist, index = d, i
+			}
+		}
+		if index >= 0 && maxDist > tolerance {
+			keep[index] = true
+			stack = append(stack, [2]int{first, index}, [2]int{index, last})
+		}
+	}
+
+	indices := make([]int, 0, len(line))
+	for i, k := range keep {
+		if k {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
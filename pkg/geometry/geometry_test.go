@@ -0,0 +1,152 @@
+package geometry_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/boardsite-io/server/pkg/geometry"
+)
+
+func TestBounds(t *testing.T) {
+	got := geometry.Bounds(geometry.Point{X: 1, Y: 5}, geometry.Point{X: -2, Y: 3}, geometry.Point{X: 4, Y: -1})
+
+	assert.Equal(t, geometry.Rect{Min: geometry.Point{X: -2, Y: -1}, Max: geometry.Point{X: 4, Y: 5}}, got)
+}
+
+func TestRect_Intersects(t *testing.T) {
+	r := geometry.Rect{Min: geometry.Point{X: 0, Y: 0}, Max: geometry.Point{X: 10, Y: 10}}
+
+	tests := []struct {
+		name string
+		o    geometry.Rect
+		want bool
+	}{
+		{name: "overlapping", o: geometry.Rect{Min: geometry.Point{X: 5, Y: 5}, Max: geometry.Point{X: 15, Y: 15}}, want: true},
+		{name: "contained", o: geometry.Rect{Min: geometry.Point{X: 2, Y: 2}, Max: geometry.Point{X: 3, Y: 3}}, want: true},
+		{name: "touching", o: geometry.Rect{Min: geometry.Point{X: 10, Y: 0}, Max: geometry.Point{X: 20, Y: 10}}, want: true},
+		{name: "disjoint", o: geometry.Rect{Min: geometry.Point{X: 11, Y: 0}, Max: geometry.Point{X: 20, Y: 10}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, r.Intersects(tt.o))
+			assert.Equal(t, tt.want, tt.o.Intersects(r))
+		})
+	}
+}
+
+func TestPolygon_Contains(t *testing.T) {
+	// concave polygon shaped like a U
+	pg := geometry.Polygon{{X: 0, Y: 0}, {X: 30, Y: 0}, {X: 30, Y: 30}, {X: 20, Y: 30}, {X: 20, Y: 10}, {X: 10, Y: 10}, {X: 10, Y: 30}, {X: 0, Y: 30}}
+
+	assert.True(t, pg.Contains(geometry.Point{X: 5, Y: 25}))
+	assert.True(t, pg.Contains(geometry.Point{X: 15, Y: 5}))
+	assert.False(t, pg.Contains(geometry.Point{X: 15, Y: 20}))
+	assert.False(t, pg.Contains(geometry.Point{X: 40, Y: 5}))
+}
+
+func TestPolygon_IntersectsPolyline(t *testing.T) {
+	pg := geometry.Rect{Min: geometry.Point{X: 0, Y: 0}, Max: geometry.Point{X: 10, Y: 10}}.Corners()
+
+	tests := []struct {
+		name string
+		line []geometry.Point
+		want bool
+	}{
+		{name: "inside", line: []geometry.Point{{X: 2, Y: 2}, {X: 3, Y: 3}}, want: true},
+		{name: "crossing", line: []geometry.Point{{X: -5, Y: 5}, {X: 15, Y: 5}}, want: true},
+		{name: "outside", line: []geometry.Point{{X: -5, Y: -5}, {X: -5, Y: 15}}, want: false},
+		{name: "empty", line: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, pg.IntersectsPolyline(tt.line))
+		})
+	}
+}
+
+func TestSegmentDist(t *testing.T) {
+	a, b := geometry.Point{X: 0, Y: 0}, geometry.Point{X: 10, Y: 0}
+
+	assert.InDelta(t, 3, geometry.SegmentDist(geometry.Point{X: 5, Y: 3}, a, b), 1e-9)
+	assert.InDelta(t, 5, geometry.SegmentDist(geometry.Point{X: 13, Y: 4}, a, b), 1e-9)
+	assert.InDelta(t, 2, geometry.SegmentDist(geometry.Point{X: 0, Y: 2}, a, a), 1e-9)
+}
+
+func TestCapsuleInterval(t *testing.T) {
+	// horizontal segment from (0,0) to (100,0)
+	a, b := geometry.Point{X: 0, Y: 0}, geometry.Point{X: 100, Y: 0}
+	tests := []struct {
+		name       string
+		c, d       geometry.Point
+		r          float64
+		want0      float64
+		want1      float64
+		wantMissed bool
+	}{
+		{name: "crossing", c: geometry.Point{X: 50, Y: -10}, d: geometry.Point{X: 50, Y: 10}, r: 5, want0: 0.45, want1: 0.55},
+		{name: "end disc", c: geometry.Point{X: 50, Y: 3}, d: geometry.Point{X: 50, Y: 10}, r: 5, want0: 0.46, want1: 0.54},
+		{name: "point", c: geometry.Point{X: 20, Y: 0}, d: geometry.Point{X: 20, Y: 0}, r: 5, want0: 0.15, want1: 0.25},
+		{name: "along", c: geometry.Point{X: -50, Y: 1}, d: geometry.Point{X: 30, Y: 1}, r: 2, want0: 0, want1: 0.3 + math.Sqrt(3)/100},
+		{name: "missed", c: geometry.Point{X: 50, Y: 6}, d: geometry.Point{X: 50, Y: 10}, r: 5, wantMissed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t0, t1, ok := geometry.CapsuleInterval(a, b, tt.c, tt.d, tt.r)
+
+			if tt.wantMissed {
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			assert.InDelta(t, tt.want0, t0, 1e-9)
+			assert.InDelta(t, tt.want1, t1, 1e-9)
+		})
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	const tolerance = 0.5
+	// densely sampled sine wave
+	line := make([]geometry.Point, 1000)
+	for i := range line {
+		x := float64(i) / 10
+		line[i] = geometry.Point{X: x, Y: 10 * math.Sin(x/5)}
+	}
+
+	indices := geometry.Simplify(line, tolerance)
+
+	assert.Less(t, len(indices), len(line)/10)
+	assert.Equal(t, 0, indices[0])
+	assert.Equal(t, len(line)-1, indices[len(indices)-1])
+	// every vertex is close to the segment of the simplified line it was replaced by
+	for k := 1; k < len(indices); k++ {
+		a, b := line[indices[k-1]], line[indices[k]]
+		for i := indices[k-1]; i <= indices[k]; i++ {
+			assert.LessOrEqual(t, geometry.SegmentDist(line[i], a, b), tolerance)
+		}
+	}
+}
+
+func TestSimplify_Collinear(t *testing.T) {
+	line := []geometry.Point{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 2}, {X: 3, Y: 3}}
+
+	assert.Equal(t, []int{0, 3}, geometry.Simplify(line, 0.01))
+	assert.Equal(t, []int{0, 1, 2, 3}, geometry.Simplify(line, 0))
+}
+
+func TestPolylineDist(t *testing.T) {
+	line := []geometry.Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}}
+
+	assert.InDelta(t, 2, geometry.PolylineDist(geometry.Point{X: 12, Y: 5}, line), 1e-9)
+	assert.InDelta(t, 5, geometry.PolylineDist(geometry.Point{X: 3, Y: 4}, line[:1]), 1e-9)
+	assert.True(t, math.IsInf(geometry.PolylineDist(geometry.Point{}, nil), 1))
+}
+
+func TestPolylineSegmentDist(t *testing.T) {
+	line := []geometry.Point{{X: 0, Y: 0}, {X: 10, Y: 0}}
+
+	assert.InDelta(t, 0, geometry.PolylineSegmentDist(geometry.Point{X: 5, Y: -5}, geometry.Point{X: 5, Y: 5}, line), 1e-9)
+	assert.InDelta(t, 3, geometry.PolylineSegmentDist(geometry.Point{X: -5, Y: 3}, geometry.Point{X: 15, Y: 3}, line), 1e-9)
+}
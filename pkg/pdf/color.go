@@ -0,0 +1,199 @@
+package pdf
+
+import "math"
+
+type colorKind int
+
+const (
+	colorGray colorKind = iota
+	colorRGB
+	colorCMYK
+	colorLab
+	colorIndexed
+	// colorTint are separation and DeviceN color spaces, which are
+	// approximated by the amount of black ink
+	colorTint
+	colorPattern
+)
+
+type colorSpace struct {
+	kind colorKind
+	n    int
+	// base, hival and lookup declare indexed color spaces
+	base   *colorSpace
+	hival  int
+	lookup []byte
+}
+
+var (
+	deviceGray = &colorSpace{kind: colorGray, n: 1}
+	deviceRGB  = &colorSpace{kind: colorRGB, n: 3}
+	deviceCMYK = &colorSpace{kind: colorCMYK, n: 4}
+	// patternColor approximates patterns and shadings
+	patternColor = rgb{0.8, 0.8, 0.8}
+)
+
+// colorSpace returns the color space of the object, which is a name
+// of a device color space, a name of the resources or an array.
+func (d *Document) colorSpace(o Object, resources Dict, depth int) *colorSpace {
+	o = d.Resolve(o)
+	if depth > 8 {
+		return deviceGray
+	}
+	if name, ok := o.(Name); ok {
+		switch name {
+		case "DeviceGray", "G", "CalGray":
+			return deviceGray
+		case "DeviceRGB", "RGB", "CalRGB":
+			return deviceRGB
+		case "DeviceCMYK", "CMYK":
+			return deviceCMYK
+		case "Pattern":
+			return &colorSpace{kind: colorPattern}
+		}
+		if spaces, ok := d.Resolve(resources["ColorSpace"]).(Dict); ok {
+			if cs, ok := spaces[name]; ok {
+				return d.colorSpace(cs, resources, depth+1)
+			}
+		}
+		return deviceGray
+	}
+
+	arr, ok := o.(Array)
+	if !ok || len(arr) == 0 {
+		return deviceGray
+	}
+	family, _ := d.Resolve(arr[0]).(Name)
+	switch family {
+	case "ICCBased":
+		if len(arr) > 1 {
+			if s, ok := d.Resolve(arr[1]).(*Stream); ok {
+				if alt, ok := s.Dict["Alternate"]; ok {
+					return d.colorSpace(alt, resources, depth+1)
+				}
+				switch n, _ := integer(d.Resolve(s.Dict["N"])); n {
+				case 3:
+					return deviceRGB
+				case 4:
+					return deviceCMYK
+				}
+			}
+		}
+		return deviceGray
+	case "Indexed", "I":
+		if len(arr) < 4 {
+			return deviceGray
+		}
+		base := d.colorSpace(arr[1], resources, depth+1)
+		hival, _ := integer(d.Resolve(arr[2]))
+		var lookup []byte
+		switch l := d.Resolve(arr[3]).(type) {
+		case String:
+			lookup = l
+		case *Stream:
+			lookup, _, _ = d.Decode(l)
+		}
+		if base.n == 0 || hival < 0 || hival > 255 {
+			return deviceGray
+		}
+		return &colorSpace{kind: colorIndexed, n: 1, base: base, hival: hival, lookup: lookup}
+	case "Separation":
+		return &colorSpace{kind: colorTint, n: 1}
+	case "DeviceN":
+		if len(arr) > 1 {
+			if names, ok := d.Resolve(arr[1]).(Array); ok && len(names) > 0 {
+				return &colorSpace{kind: colorTint, n: len(names)}
+			}
+		}
+		return &colorSpace{kind: colorTint, n: 1}
+	case "Lab":
+		return &colorSpace{kind: colorLab, n: 3}
+	case "Pattern":
+		return &colorSpace{kind: colorPattern}
+	case "CalRGB":
+		return deviceRGB
+	}
+	return deviceGray
+}
+
+// defaultDecode returns the range of the components of image samples.
+func (cs *colorSpace) defaultDecode(bpc int) []float64 {
+	ranges := make([]float64, 0, 2*cs.n)
+	for i := 0; i < cs.n; i++ {
+		switch cs.kind {
+		case colorIndexed:
+			ranges = append(ranges, 0, float64(int(1)<<bpc-1))
+		case colorLab:
+			if i == 0 {
+				ranges = append(ranges, 0, 100)
+			} else {
+				ranges = append(ranges, -100, 100)
+			}
+		default:
+			ranges = append(ranges, 0, 1)
+		}
+	}
+	return ranges
+}
+
+// rgb converts the components of the color space.
+func (cs *colorSpace) rgb(c []float64) rgb {
+	comp := func(i int) float64 {
+		if i < len(c) {
+			return c[i]
+		}
+		return 0
+	}
+	switch cs.kind {
+	case colorRGB:
+		return rgb{clamp01(comp(0)), clamp01(comp(1)), clamp01(comp(2))}
+	case colorCMYK:
+		k := clamp01(comp(3))
+		return rgb{
+			(1 - clamp01(comp(0))) * (1 - k),
+			(1 - clamp01(comp(1))) * (1 - k),
+			(1 - clamp01(comp(2))) * (1 - k),
+		}
+	case colorLab:
+		l := clamp01(comp(0) / 100)
+		return rgb{l, l, l}
+	case colorIndexed:
+		i := int(math.Round(comp(0)))
+		if i < 0 || i > cs.hival {
+			i = 0
+		}
+		n := cs.base.n
+		if (i+1)*n > len(cs.lookup) {
+			return rgb{}
+		}
+		base := make([]float64, n)
+		for k := range base {
+			base[k] = float64(cs.lookup[i*n+k]) / 255
+		}
+		if cs.base.kind == colorLab {
+			base[0] *= 100
+		}
+		return cs.base.rgb(base)
+	case colorTint:
+		t := 0.0
+		for i := 0; i < cs.n; i++ {
+			t = math.Max(t, comp(i))
+		}
+		g := 1 - clamp01(t)
+		return rgb{g, g, g}
+	case colorPattern:
+		return patternColor
+	}
+	g := clamp01(comp(0))
+	return rgb{g, g, g}
+}
+
+func clamp01(f float64) float64 {
+	if f < 0 || math.IsNaN(f) {
+		return 0
+	}
+	if f > 1 {
+		return 1
+	}
+	return f
+}
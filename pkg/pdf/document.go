@@ -0,0 +1,512 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+const (
+	maxPages     = 10000
+	maxRefChain  = 16
+	maxXrefCount = 1 << 22
+)
+
+var (
+	ErrEncrypted = errors.New("pdf: encrypted documents are not supported")
+	ErrMalformed = errors.New("pdf: malformed document")
+	ErrPageRange = errors.New("pdf: page out of range")
+
+	objHeaderExp = regexp.MustCompile(`(\d+)[ \t\r\n\f\x00]+(\d+)[ \t\r\n\f\x00]+obj\b`)
+)
+
+type xrefEntry struct {
+	offset int
+	// stream is the object stream of compressed objects
+	stream     int
+	compressed bool
+}
+
+type objStream struct {
+	data    []byte
+	offsets map[int]int
+}
+
+// Document is a parsed PDF document. Objects are read on demand.
+//
+// A Document must not be used concurrently.
+type Document struct {
+	data    []byte
+	xref    map[int]xrefEntry
+	trailer Dict
+	cache   map[int]Object
+	loading map[int]bool
+	streams map[int]*objStream
+	pages   []*Page
+}
+
+// Page declares a page of the document with its inherited attributes.
+type Page struct {
+	Dict      Dict
+	Resources Dict
+	// Box is the visible area of the page [llx lly urx ury]
+	Box [4]float64
+	// Rotate is the clockwise rotation of the page in degrees
+	Rotate int
+}
+
+// Open parses the document. Documents with damaged cross-reference
+// tables are read by scanning for their objects.
+func Open(data []byte) (*Document, error) {
+	d := &Document{
+		data:    data,
+		xref:    make(map[int]xrefEntry),
+		cache:   make(map[int]Object),
+		loading: make(map[int]bool),
+		streams: make(map[int]*objStream),
+	}
+	if err := d.readXref(); err != nil || d.trailer["Root"] == nil {
+		d.xref = make(map[int]xrefEntry)
+		d.trailer = nil
+		d.scan()
+	}
+	if d.trailer["Encrypt"] != nil {
+		return nil, ErrEncrypted
+	}
+	d.loadPages()
+	if len(d.pages) == 0 {
+		return nil, ErrMalformed
+	}
+	return d, nil
+}
+
+// NumPages returns the number of pages.
+func (d *Document) NumPages() int {
+	return len(d.pages)
+}
+
+// Page returns the page with the zero-based index.
+func (d *Document) Page(i int) (*Page, error) {
+	if i < 0 || i >= len(d.pages) {
+		return nil, ErrPageRange
+	}
+	return d.pages[i], nil
+}
+
+// Resolve follows indirect references and returns the referenced object.
+func (d *Document) Resolve(o Object) Object {
+	for i := 0; i < maxRefChain; i++ {
+		ref, ok := o.(Ref)
+		if !ok {
+			return o
+		}
+		o = d.object(ref.Num)
+	}
+	return nil
+}
+
+func (d *Document) object(n int) Object {
+	if o, ok := d.cache[n]; ok {
+		return o
+	}
+	entry, ok := d.xref[n]
+	if !ok || d.loading[n] {
+		return nil
+	}
+	d.loading[n] = true
+	defer delete(d.loading, n)
+
+	var o Object
+	if entry.compressed {
+		o = d.compressedObject(entry.stream, n)
+	} else if num, obj, err := d.parseObjectAt(entry.offset); err == nil && num == n {
+		o = obj
+	}
+	d.cache[n] = o
+	return o
+}
+
+// parseObjectAt parses the indirect object "num gen obj ... endobj" at the offset.
+func (d *Document) parseObjectAt(offset int) (int, Object, error) {
+	if offset < 0 || offset >= len(d.data) {
+		return 0, nil, ErrMalformed
+	}
+	l := &lexer{data: d.data, pos: offset}
+	n, _ := l.token()
+	gen, _ := l.token()
+	kw, _ := l.token()
+	num, ok := integer(n)
+	if _, isNum := gen.(float64); !ok || !isNum || kw != keyword("obj") {
+		return 0, nil, ErrMalformed
+	}
+	l.refs = true
+	obj, err := l.object()
+	if err != nil {
+		return 0, nil, err
+	}
+	dict, ok := obj.(Dict)
+	if !ok {
+		return num, obj, nil
+	}
+	if tok, err := l.token(); err != nil || tok != keyword("stream") {
+		return num, obj, nil
+	}
+	return num, &Stream{Dict: dict, raw: d.streamData(l.pos, dict)}, nil
+}
+
+// streamData returns the raw data of the stream starting after the stream keyword.
+func (d *Document) streamData(start int, dict Dict) []byte {
+	if start < len(d.data) && d.data[start] == '\r' {
+		start++
+	}
+	if start < len(d.data) && d.data[start] == '\n' {
+		start++
+	}
+	rest := d.data[start:]
+	if length, ok := integer(d.Resolve(dict["Length"])); ok && length >= 0 && length <= len(rest) {
+		after := bytes.TrimLeft(rest[length:], "\x00\t\n\f\r ")
+		if bytes.HasPrefix(after, []byte("endstream")) {
+			return rest[:length]
+		}
+	}
+	// the length is wrong, hence search for the end of the stream
+	end := bytes.Index(rest, []byte("endstream"))
+	if end < 0 {
+		return rest
+	}
+	data := rest[:end]
+	if bytes.HasSuffix(data, []byte("\r\n")) {
+		return data[:len(data)-2]
+	}
+	return bytes.TrimSuffix(bytes.TrimSuffix(data, []byte("\n")), []byte("\r"))
+}
+
+// compressedObject returns the object n of the object stream.
+func (d *Document) compressedObject(streamNum, n int) Object {
+	stm, err := d.objStream(streamNum)
+	if err != nil {
+		return nil
+	}
+	offset, ok := stm.offsets[n]
+	if !ok {
+		return nil
+	}
+	l := &lexer{data: stm.data, pos: offset, refs: true}
+	obj, err := l.object()
+	if err != nil {
+		return nil
+	}
+	return obj
+}
+
+func (d *Document) objStream(n int) (*objStream, error) {
+	if stm, ok := d.streams[n]; ok {
+		return stm, nil
+	}
+	d.streams[n] = &objStream{}
+	s, ok := d.Resolve(Ref{Num: n}).(*Stream)
+	if !ok {
+		return nil, ErrMalformed
+	}
+	data, _, err := d.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	count, _ := integer(d.Resolve(s.Dict["N"]))
+	first, _ := integer(d.Resolve(s.Dict["First"]))
+	if first < 0 || first > len(data) {
+		return nil, ErrMalformed
+	}
+	stm := &objStream{data: data, offsets: make(map[int]int)}
+	l := &lexer{data: data[:first]}
+	for i := 0; i < count; i++ {
+		numTok, err1 := l.token()
+		offTok, err2 := l.token()
+		num, ok1 := integer(numTok)
+		off, ok2 := integer(offTok)
+		if err1 != nil || err2 != nil || !ok1 || !ok2 || first+off > len(data) {
+			break
+		}
+		stm.offsets[num] = first + off
+	}
+	d.streams[n] = stm
+	return stm, nil
+}
+
+// readXref reads the cross-reference sections starting at the last startxref.
+func (d *Document) readXref() error {
+	tail := d.data
+	if len(tail) > 1024 {
+		tail = tail[len(tail)-1024:]
+	}
+	i := bytes.LastIndex(tail, []byte("startxref"))
+	if i < 0 {
+		return ErrMalformed
+	}
+	l := &lexer{data: d.data, pos: len(d.data) - len(tail) + i + len("startxref")}
+	tok, _ := l.token()
+	offset, ok := integer(tok)
+	if !ok {
+		return ErrMalformed
+	}
+
+	seen := make(map[int]bool)
+	for !seen[offset] && len(seen) < 64 {
+		seen[offset] = true
+		trailer, err := d.readXrefSection(offset)
+		if err != nil {
+			return err
+		}
+		if d.trailer == nil {
+			d.trailer = trailer
+		}
+		if stm, ok := integer(trailer["XRefStm"]); ok {
+			_, _ = d.readXrefSection(stm)
+		}
+		if offset, ok = integer(trailer["Prev"]); !ok {
+			break
+		}
+	}
+	return nil
+}
+
+// readXrefSection reads a cross-reference table or stream and returns
+// its trailer. Entries of newer sections, which are read first, take precedence.
+func (d *Document) readXrefSection(offset int) (Dict, error) {
+	if offset < 0 || offset >= len(d.data) {
+		return nil, ErrMalformed
+	}
+	l := &lexer{data: d.data, pos: offset}
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != keyword("xref") {
+		return d.readXrefStream(offset)
+	}
+	for {
+		tok, err := l.token()
+		if err != nil {
+			return nil, err
+		}
+		if tok == keyword("trailer") {
+			l.refs = true
+			obj, err := l.object()
+			if err != nil {
+				return nil, err
+			}
+			trailer, ok := obj.(Dict)
+			if !ok {
+				return nil, ErrMalformed
+			}
+			return trailer, nil
+		}
+		start, ok1 := integer(tok)
+		countTok, _ := l.token()
+		count, ok2 := integer(countTok)
+		if !ok1 || !ok2 || start < 0 || count < 0 || count > maxXrefCount {
+			return nil, ErrMalformed
+		}
+		for i := 0; i < count; i++ {
+			offTok, _ := l.token()
+			_, _ = l.token()
+			kind, _ := l.token()
+			off, ok := integer(offTok)
+			if !ok {
+				return nil, ErrMalformed
+			}
+			if _, exists := d.xref[start+i]; !exists && kind == keyword("n") {
+				d.xref[start+i] = xrefEntry{offset: off}
+			}
+		}
+	}
+}
+
+func (d *Document) readXrefStream(offset int) (Dict, error) {
+	_, obj, err := d.parseObjectAt(offset)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := obj.(*Stream)
+	if !ok {
+		return nil, ErrMalformed
+	}
+	data, _, err := d.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	w, ok := numbers(s.Dict["W"])
+	if !ok || len(w) != 3 {
+		return nil, ErrMalformed
+	}
+	width := 0
+	for _, v := range w {
+		if v < 0 || v > 8 {
+			return nil, ErrMalformed
+		}
+		width += int(v)
+	}
+	size, _ := integer(s.Dict["Size"])
+	index, ok := numbers(s.Dict["Index"])
+	if !ok {
+		index = []float64{0, float64(size)}
+	}
+
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		start, count := int(index[i]), int(index[i+1])
+		for j := 0; j < count && pos+width <= len(data); j++ {
+			var fields [3]int
+			for k, n := range w {
+				for b := 0; b < int(n); b++ {
+					fields[k] = fields[k]<<8 | int(data[pos])
+					pos++
+				}
+			}
+			if w[0] == 0 {
+				fields[0] = 1
+			}
+			if _, exists := d.xref[start+j]; exists {
+				continue
+			}
+			switch fields[0] {
+			case 1:
+				d.xref[start+j] = xrefEntry{offset: fields[1]}
+			case 2:
+				d.xref[start+j] = xrefEntry{compressed: true, stream: fields[1]}
+			}
+		}
+	}
+	return s.Dict, nil
+}
+
+// scan recovers the objects and the trailer of damaged documents.
+func (d *Document) scan() {
+	for _, m := range objHeaderExp.FindAllSubmatchIndex(d.data, -1) {
+		n, err := strconv.Atoi(string(d.data[m[2]:m[3]]))
+		if err == nil {
+			d.xref[n] = xrefEntry{offset: m[0]}
+		}
+	}
+
+	nums := make([]int, 0, len(d.xref))
+	for n := range d.xref {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	for _, n := range nums {
+		s, ok := d.object(n).(*Stream)
+		if !ok || s.Dict["Type"] != Name("ObjStm") {
+			continue
+		}
+		if stm, err := d.objStream(n); err == nil {
+			for num := range stm.offsets {
+				if _, exists := d.xref[num]; !exists {
+					d.xref[num] = xrefEntry{compressed: true, stream: n}
+				}
+			}
+		}
+	}
+	var catalog Object
+	for n := range d.xref {
+		if dict, ok := d.object(n).(Dict); ok && dict["Type"] == Name("Catalog") {
+			catalog = Ref{Num: n}
+		}
+	}
+
+	d.trailer = Dict{}
+	if i := bytes.LastIndex(d.data, []byte("trailer")); i >= 0 {
+		l := &lexer{data: d.data, pos: i + len("trailer"), refs: true}
+		if obj, err := l.object(); err == nil {
+			if trailer, ok := obj.(Dict); ok {
+				d.trailer = trailer
+			}
+		}
+	}
+	if _, ok := d.Resolve(d.trailer["Root"]).(Dict); !ok && catalog != nil {
+		d.trailer["Root"] = catalog
+	}
+}
+
+// loadPages reads the page tree.
+func (d *Document) loadPages() {
+	root, _ := d.Resolve(d.trailer["Root"]).(Dict)
+	if root == nil {
+		return
+	}
+	visited := make(map[int]bool)
+	d.walkPages(root["Pages"], Page{Box: [4]float64{0, 0, 612, 792}}, visited, 0)
+}
+
+func (d *Document) walkPages(o Object, inherited Page, visited map[int]bool, depth int) {
+	if ref, ok := o.(Ref); ok {
+		if visited[ref.Num] {
+			return
+		}
+		visited[ref.Num] = true
+	}
+	node, ok := d.Resolve(o).(Dict)
+	if !ok || depth > maxNesting || len(d.pages) >= maxPages {
+		return
+	}
+
+	page := inherited
+	page.Dict = node
+	if res, ok := d.Resolve(node["Resources"]).(Dict); ok {
+		page.Resources = res
+	}
+	if box, ok := d.rect(node["MediaBox"]); ok {
+		page.Box = box
+	}
+	if rotate, ok := integer(d.Resolve(node["Rotate"])); ok {
+		page.Rotate = ((rotate % 360) + 360) % 360 / 90 * 90
+	}
+
+	kids, isNode := d.Resolve(node["Kids"]).(Array)
+	if !isNode || node["Type"] == Name("Page") {
+		if crop, ok := d.rect(node["CropBox"]); ok {
+			page.Box = intersect(page.Box, crop)
+		}
+		if page.Box[2]-page.Box[0] < 1 || page.Box[3]-page.Box[1] < 1 {
+			return
+		}
+		p := page
+		d.pages = append(d.pages, &p)
+		return
+	}
+	for _, kid := range kids {
+		d.walkPages(kid, page, visited, depth+1)
+	}
+}
+
+// rect returns the normalized rectangle of the array.
+func (d *Document) rect(o Object) ([4]float64, bool) {
+	arr, ok := d.Resolve(o).(Array)
+	if !ok || len(arr) != 4 {
+		return [4]float64{}, false
+	}
+	var r [4]float64
+	for i, v := range arr {
+		if r[i], ok = num(d.Resolve(v)); !ok || math.IsInf(r[i], 0) || math.IsNaN(r[i]) {
+			return [4]float64{}, false
+		}
+	}
+	return [4]float64{
+		math.Min(r[0], r[2]), math.Min(r[1], r[3]),
+		math.Max(r[0], r[2]), math.Max(r[1], r[3]),
+	}, true
+}
+
+func intersect(a, b [4]float64) [4]float64 {
+	r := [4]float64{
+		math.Max(a[0], b[0]), math.Max(a[1], b[1]),
+		math.Min(a[2], b[2]), math.Min(a[3], b[3]),
+	}
+	if r[2] <= r[0] || r[3] <= r[1] {
+		return a
+	}
+	return r
+}
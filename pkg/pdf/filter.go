@@ -0,0 +1,199 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/lzw"
+	"compress/zlib"
+	"encoding/ascii85"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxStreamSize limits the size of decoded streams.
+const maxStreamSize = 64 << 20
+
+var (
+	ErrUnsupportedFilter = errors.New("pdf: unsupported filter")
+	errStreamSize        = errors.New("pdf: stream exceeds size limit")
+)
+
+// filters returns the filters and their parameters of the stream.
+func (d *Document) filters(s *Stream) ([]Name, []Dict) {
+	var names []Name
+	var params []Dict
+	switch f := d.Resolve(s.Dict["Filter"]).(type) {
+	case Name:
+		names = []Name{f}
+		p, _ := d.Resolve(s.Dict["DecodeParms"]).(Dict)
+		params = []Dict{p}
+	case Array:
+		ps, _ := d.Resolve(s.Dict["DecodeParms"]).(Array)
+		for i, v := range f {
+			name, _ := d.Resolve(v).(Name)
+			names = append(names, name)
+			var p Dict
+			if i < len(ps) {
+				p, _ = d.Resolve(ps[i]).(Dict)
+			}
+			params = append(params, p)
+		}
+	}
+	return names, params
+}
+
+// Decode returns the decoded data of the stream.
+//
+// Image filters such as DCTDecode are not decoded, instead the data is
+// returned with the remaining filter. Other unsupported filters result
+// in ErrUnsupportedFilter.
+func (d *Document) Decode(s *Stream) ([]byte, Name, error) {
+	names, params := d.filters(s)
+	data := s.raw
+	for i, name := range names {
+		var err error
+		switch name {
+		case "FlateDecode", "Fl":
+			data, err = inflate(data)
+			if err == nil {
+				data, err = d.unpredict(data, params[i])
+			}
+		case "LZWDecode", "LZW":
+			data, err = readAll(lzw.NewReader(bytes.NewReader(data), lzw.MSB, 8))
+			if err == nil {
+				data, err = d.unpredict(data, params[i])
+			}
+		case "ASCIIHexDecode", "AHx":
+			data, err = decodeHex(data)
+		case "ASCII85Decode", "A85":
+			data, err = decodeASCII85(data)
+		case "DCTDecode", "DCT":
+			if i == len(names)-1 {
+				return data, name, nil
+			}
+			return nil, "", ErrUnsupportedFilter
+		default:
+			return nil, "", fmt.Errorf("%w: %s", ErrUnsupportedFilter, name)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return data, "", nil
+}
+
+// readAll reads at most maxStreamSize bytes. Truncated data is returned
+// as far as it could be read, since damaged streams are common.
+func readAll(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxStreamSize+1))
+	if len(data) > maxStreamSize {
+		return nil, errStreamSize
+	}
+	if err != nil && len(data) == 0 {
+		return nil, err
+	}
+	return data, nil
+}
+
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		// some writers omit the zlib header
+		return readAll(flate.NewReader(bytes.NewReader(data)))
+	}
+	defer zr.Close()
+	return readAll(zr)
+}
+
+func decodeHex(data []byte) ([]byte, error) {
+	l := lexer{data: append(append([]byte{}, data...), '>')}
+	s, err := l.hexString()
+	if err != nil {
+		return nil, err
+	}
+	return s.(String), nil
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	clean := make([]byte, 0, len(data))
+	for _, b := range data {
+		if !isWhite(b) {
+			clean = append(clean, b)
+		}
+	}
+	return readAll(ascii85.NewDecoder(bytes.NewReader(clean)))
+}
+
+// unpredict reverses the PNG predictors of the decode parameters.
+func (d *Document) unpredict(data []byte, params Dict) ([]byte, error) {
+	predictor, _ := integer(d.Resolve(params["Predictor"]))
+	if predictor < 10 {
+		if predictor == 2 {
+			return nil, fmt.Errorf("%w: TIFF predictor", ErrUnsupportedFilter)
+		}
+		return data, nil
+	}
+	colors, bpc, columns := 1, 8, 1
+	if v, ok := integer(d.Resolve(params["Colors"])); ok && v > 0 && v <= 32 {
+		colors = v
+	}
+	if v, ok := integer(d.Resolve(params["BitsPerComponent"])); ok && v > 0 && v <= 16 {
+		bpc = v
+	}
+	if v, ok := integer(d.Resolve(params["Columns"])); ok && v > 0 && v <= 1<<20 {
+		columns = v
+	}
+	bpp := (colors*bpc + 7) / 8
+	rowSize := (colors*bpc*columns + 7) / 8
+
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, rowSize)
+	for len(data) > rowSize {
+		filter, row := data[0], append([]byte{}, data[1:rowSize+1]...)
+		data = data[rowSize+1:]
+		for i := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = row[i-bpp], prev[i-bpp]
+			}
+			up := prev[i]
+			switch filter {
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
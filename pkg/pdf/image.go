@@ -0,0 +1,170 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+)
+
+// maxImagePixels limits the size of decoded images.
+const maxImagePixels = 16_000_000
+
+var errImage = errors.New("pdf: unsupported image")
+
+// imageXObject decodes the image to a non-premultiplied image. Stencil
+// masks are painted with the fill color.
+func (d *Document) imageXObject(s *Stream, resources Dict, fill rgb) (*image.NRGBA, error) {
+	w, _ := integer(d.Resolve(s.Dict["Width"]))
+	h, _ := integer(d.Resolve(s.Dict["Height"]))
+	if w <= 0 || h <= 0 || w*h > maxImagePixels {
+		return nil, errImage
+	}
+	data, filter, err := d.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var img *image.NRGBA
+	if filter != "" {
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		if err != nil || cfg.Width*cfg.Height > maxImagePixels {
+			return nil, errImage
+		}
+		decoded, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		img = image.NewNRGBA(decoded.Bounds())
+		draw.Draw(img, img.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+	} else if mask, _ := d.Resolve(s.Dict["ImageMask"]).(bool); mask {
+		decode, _ := numbers(d.Resolve(s.Dict["Decode"]))
+		// samples with 0 are painted unless the decoding is inverted
+		paint := 0.0
+		if len(decode) == 2 && decode[0] > decode[1] {
+			paint = 1
+		}
+		rows, err := newSampleRows(data, w, h, 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		img = image.NewNRGBA(image.Rect(0, 0, w, h))
+		c := toNRGBA(fill, 1)
+		for y := 0; y < h; y++ {
+			row := rows.row(y)
+			for x := 0; x < w; x++ {
+				if rows.sample(row, x) == paint {
+					copy(img.Pix[img.PixOffset(x, y):], []uint8{c.R, c.G, c.B, c.A})
+				}
+			}
+		}
+		return img, nil
+	} else {
+		if img, err = d.rawImage(s, data, w, h, resources); err != nil {
+			return nil, err
+		}
+	}
+
+	if smask, ok := d.Resolve(s.Dict["SMask"]).(*Stream); ok {
+		d.applySoftMask(img, smask)
+	}
+	return img, nil
+}
+
+// rawImage converts the samples of an unencoded image.
+func (d *Document) rawImage(s *Stream, data []byte, w, h int, resources Dict) (*image.NRGBA, error) {
+	bpc, ok := integer(d.Resolve(s.Dict["BitsPerComponent"]))
+	if !ok {
+		bpc = 8
+	}
+	cs := d.colorSpace(s.Dict["ColorSpace"], resources, 0)
+	if cs.n == 0 {
+		return nil, errImage
+	}
+	decode, ok := numbers(d.Resolve(s.Dict["Decode"]))
+	if !ok || len(decode) != 2*cs.n {
+		decode = cs.defaultDecode(bpc)
+	}
+	rows, err := newSampleRows(data, w, h, cs.n, bpc)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	maxVal := float64(int(1)<<bpc - 1)
+	comps := make([]float64, cs.n)
+	for y := 0; y < h; y++ {
+		row := rows.row(y)
+		for x := 0; x < w; x++ {
+			for k := range comps {
+				v := rows.sample(row, x*cs.n+k)
+				comps[k] = decode[2*k] + v/maxVal*(decode[2*k+1]-decode[2*k])
+			}
+			c := toNRGBA(cs.rgb(comps), 1)
+			copy(img.Pix[img.PixOffset(x, y):], []uint8{c.R, c.G, c.B, c.A})
+		}
+	}
+	return img, nil
+}
+
+// sampleRows reads the packed samples of the rows of an image.
+type sampleRows struct {
+	data    []byte
+	rowSize int
+	bpc     int
+}
+
+func newSampleRows(data []byte, w, h, n, bpc int) (*sampleRows, error) {
+	switch bpc {
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, errImage
+	}
+	rowSize := (w*n*bpc + 7) / 8
+	if len(data) < rowSize*h {
+		// pad truncated images
+		data = append(data, make([]byte, rowSize*h-len(data))...)
+	}
+	return &sampleRows{data: data, rowSize: rowSize, bpc: bpc}, nil
+}
+
+func (r *sampleRows) row(y int) []byte {
+	return r.data[y*r.rowSize : (y+1)*r.rowSize]
+}
+
+// sample returns the i-th sample of the row.
+func (r *sampleRows) sample(row []byte, i int) float64 {
+	switch r.bpc {
+	case 8:
+		return float64(row[i])
+	case 16:
+		return float64(int(row[2*i])<<8 | int(row[2*i+1]))
+	}
+	bit := i * r.bpc
+	return float64(row[bit/8] >> (8 - r.bpc - bit%8) & (1<<r.bpc - 1))
+}
+
+// applySoftMask sets the alpha of the image to the gray levels of the mask.
+func (d *Document) applySoftMask(img *image.NRGBA, smask *Stream) {
+	mask, err := d.imageXObject(&Stream{Dict: Dict{
+		"Width":            smask.Dict["Width"],
+		"Height":           smask.Dict["Height"],
+		"BitsPerComponent": smask.Dict["BitsPerComponent"],
+		"ColorSpace":       Name("DeviceGray"),
+		"Filter":           smask.Dict["Filter"],
+		"DecodeParms":      smask.Dict["DecodeParms"],
+		"Decode":           smask.Dict["Decode"],
+	}, raw: smask.raw}, nil, rgb{})
+	if err != nil {
+		return
+	}
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	mw, mh := mask.Rect.Dx(), mask.Rect.Dy()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			m := mask.PixOffset(x*mw/w, y*mh/h)
+			img.Pix[img.PixOffset(x, y)+3] = mask.Pix[m]
+		}
+	}
+}
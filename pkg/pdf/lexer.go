@@ -0,0 +1,299 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// maxNesting limits the nesting of arrays and dictionaries.
+const maxNesting = 64
+
+var errSyntax = errors.New("pdf: syntax error")
+
+// lexer reads the tokens and objects of PDF files and content streams.
+type lexer struct {
+	data []byte
+	pos  int
+	// refs enables parsing of indirect references, which do not
+	// occur in content streams
+	refs bool
+}
+
+func isWhite(b byte) bool {
+	return b == 0 || b == '\t' || b == '\n' || b == '\f' || b == '\r' || b == ' '
+}
+
+func isDelim(b byte) bool {
+	switch b {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func isRegular(b byte) bool {
+	return !isWhite(b) && !isDelim(b)
+}
+
+// skipSpace skips whitespace and comments.
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		b := l.data[l.pos]
+		if b == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isWhite(b) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// token returns the next token, which is a number, a String, a Name or a keyword.
+// Delimiters of arrays and dictionaries are returned as keywords.
+func (l *lexer) token() (Object, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	b := l.data[l.pos]
+	switch {
+	case b == '(':
+		l.pos++
+		return l.literalString()
+	case b == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return keyword("<<"), nil
+		}
+		l.pos++
+		return l.hexString()
+	case b == '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return keyword(">>"), nil
+		}
+		l.pos++
+		return nil, errSyntax
+	case b == '[' || b == ']' || b == '{' || b == '}':
+		l.pos++
+		return keyword(b), nil
+	case b == ')':
+		l.pos++
+		return nil, errSyntax
+	case b == '/':
+		l.pos++
+		return l.name(), nil
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && isRegular(l.data[l.pos]) {
+		l.pos++
+	}
+	word := l.data[start:l.pos]
+	if c := word[0]; c == '+' || c == '-' || c == '.' || '0' <= c && c <= '9' {
+		if f, err := strconv.ParseFloat(string(word), 64); err == nil {
+			return f, nil
+		}
+		// malformed numbers such as "--1" are read as zero
+		return float64(0), nil
+	}
+	return keyword(word), nil
+}
+
+func (l *lexer) name() Name {
+	var buf []byte
+	for l.pos < len(l.data) && isRegular(l.data[l.pos]) {
+		b := l.data[l.pos]
+		l.pos++
+		if b == '#' && l.pos+1 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos:l.pos+2]), 16, 8); err == nil {
+				b = byte(v)
+				l.pos += 2
+			}
+		}
+		buf = append(buf, b)
+	}
+	return Name(buf)
+}
+
+func (l *lexer) literalString() (Object, error) {
+	var buf []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		b := l.data[l.pos]
+		l.pos++
+		switch b {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return String(buf), nil
+			}
+		case '\r':
+			// end of lines are read as line feeds
+			if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+				l.pos++
+			}
+			b = '\n'
+		case '\\':
+			if l.pos >= len(l.data) {
+				return nil, errSyntax
+			}
+			b = l.data[l.pos]
+			l.pos++
+			switch b {
+			case 'n':
+				b = '\n'
+			case 'r':
+				b = '\r'
+			case 't':
+				b = '\t'
+			case 'b':
+				b = '\b'
+			case 'f':
+				b = '\f'
+			case '\r', '\n':
+				// line continuation
+				if b == '\r' && l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			default:
+				if '0' <= b && b <= '7' {
+					v := int(b - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && '0' <= l.data[l.pos] && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					b = byte(v)
+				}
+			}
+		}
+		buf = append(buf, b)
+	}
+	return nil, errSyntax
+}
+
+func (l *lexer) hexString() (Object, error) {
+	end := bytes.IndexByte(l.data[l.pos:], '>')
+	if end < 0 {
+		return nil, errSyntax
+	}
+	digits := make([]byte, 0, end)
+	for _, b := range l.data[l.pos : l.pos+end] {
+		if !isWhite(b) {
+			digits = append(digits, b)
+		}
+	}
+	l.pos += end + 1
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	buf := make([]byte, len(digits)/2)
+	for i := range buf {
+		v, err := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		if err != nil {
+			return nil, errSyntax
+		}
+		buf[i] = byte(v)
+	}
+	return String(buf), nil
+}
+
+// object reads the next object.
+func (l *lexer) object() (Object, error) {
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+	return l.objectFrom(tok, 0)
+}
+
+// objectFrom reads the object starting with the token.
+func (l *lexer) objectFrom(tok Object, depth int) (Object, error) {
+	if depth > maxNesting {
+		return nil, errSyntax
+	}
+	switch t := tok.(type) {
+	case float64:
+		if l.refs && t >= 0 && t == float64(int(t)) {
+			return l.ref(t), nil
+		}
+		return t, nil
+
+	case keyword:
+		switch t {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		case "[":
+			arr := Array{}
+			for {
+				tok, err := l.token()
+				if err != nil {
+					return nil, err
+				}
+				if tok == keyword("]") {
+					return arr, nil
+				}
+				v, err := l.objectFrom(tok, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, v)
+			}
+		case "<<":
+			dict := Dict{}
+			for {
+				tok, err := l.token()
+				if err != nil {
+					return nil, err
+				}
+				if tok == keyword(">>") {
+					return dict, nil
+				}
+				key, ok := tok.(Name)
+				if !ok {
+					return nil, errSyntax
+				}
+				tok, err = l.token()
+				if err != nil {
+					return nil, err
+				}
+				if tok == keyword(">>") {
+					// tolerate a missing value
+					return dict, nil
+				}
+				v, err := l.objectFrom(tok, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = v
+			}
+		}
+	}
+	return tok, nil
+}
+
+// ref reads an indirect reference "num gen R" if the number is followed
+// by the generation and the R keyword.
+func (l *lexer) ref(n float64) Object {
+	pos := l.pos
+	gen, err := l.token()
+	if g, ok := gen.(float64); err == nil && ok && g >= 0 && g == float64(int(g)) {
+		if tok, err := l.token(); err == nil && tok == keyword("R") {
+			return Ref{Num: int(n), Gen: int(g)}
+		}
+	}
+	l.pos = pos
+	return n
+}
@@ -0,0 +1,65 @@
+// Package pdf reads PDF documents and renders their pages to raster images.
+//
+// The renderer is meant for previews: paths, images and form XObjects are
+// rendered, while text is drawn as bars at the positions of the words,
+// since fonts are not rasterized.
+package pdf
+
+// Object is one of nil, bool, float64, String, Name, Array, Dict, *Stream or Ref.
+type Object interface{}
+
+// Name is a PDF name object without the leading slash.
+type Name string
+
+// String is a PDF string object.
+type String []byte
+
+// Array is a PDF array object.
+type Array []Object
+
+// Dict is a PDF dictionary object.
+type Dict map[Name]Object
+
+// Ref is an indirect reference to an object.
+type Ref struct {
+	Num, Gen int
+}
+
+// Stream is a stream object with its encoded data.
+type Stream struct {
+	Dict Dict
+	raw  []byte
+}
+
+// keyword is a bare keyword, e.g. an operator of a content stream.
+type keyword string
+
+// num returns the number of the object.
+func num(o Object) (float64, bool) {
+	f, ok := o.(float64)
+	return f, ok
+}
+
+// integer returns the number of the object truncated to an int.
+func integer(o Object) (int, bool) {
+	f, ok := o.(float64)
+	if !ok || f != f || f > 1<<31 || f < -1<<31 {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// numbers returns the numbers of an array, which must only contain numbers.
+func numbers(o Object) ([]float64, bool) {
+	a, ok := o.(Array)
+	if !ok {
+		return nil, false
+	}
+	nums := make([]float64, len(a))
+	for i, v := range a {
+		if nums[i], ok = num(v); !ok {
+			return nil, false
+		}
+	}
+	return nums, true
+}
@@ -0,0 +1,231 @@
+package pdf_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/pkg/pdf"
+)
+
+// buildPDF returns a document with the objects numbered from 1 and a
+// cross-reference table. The first object is the catalog.
+func buildPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objects))
+	for i, o := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func stream(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+// singlePage returns the objects of a document with a 200x100 page.
+func singlePage(pageAttrs, content string, more ...string) []string {
+	return append([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 200 100] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R " + pageAttrs + " >>",
+		stream("", content),
+	}, more...)
+}
+
+func render(t *testing.T, data []byte, width int) *image.RGBA {
+	doc, err := pdf.Open(data)
+	require.NoError(t, err)
+	img, err := doc.Render(0, width)
+	require.NoError(t, err)
+	return img
+}
+
+func assertColor(t *testing.T, img *image.RGBA, x, y int, want color.RGBA) {
+	got := img.RGBAAt(x, y)
+	assert.Equal(t, want, got, "pixel %d,%d", x, y)
+}
+
+var (
+	white = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	red   = color.RGBA{R: 255, A: 255}
+	blue  = color.RGBA{B: 255, A: 255}
+)
+
+func TestRender_Paths(t *testing.T) {
+	// red left half, blue triangle in the upper right corner
+	data := buildPDF(singlePage("", "1 0 0 rg 0 0 100 100 re f 0 0 1 rg 200 100 m 100 100 l 200 0 l h f")...)
+
+	img := render(t, data, 400)
+
+	require.Equal(t, image.Rect(0, 0, 400, 200), img.Bounds())
+	assertColor(t, img, 10, 10, red)
+	assertColor(t, img, 190, 190, red)
+	assertColor(t, img, 390, 10, blue)
+	assertColor(t, img, 210, 190, white)
+}
+
+func TestRender_Rotate(t *testing.T) {
+	data := buildPDF(singlePage("/Rotate 90", "1 0 0 rg 0 0 100 100 re f")...)
+
+	img := render(t, data, 100)
+
+	// the left half is rotated to the top
+	require.Equal(t, image.Rect(0, 0, 100, 200), img.Bounds())
+	assertColor(t, img, 50, 10, red)
+	assertColor(t, img, 50, 190, white)
+}
+
+func TestRender_Stroke(t *testing.T) {
+	data := buildPDF(singlePage("", "0 0 1 RG 10 w 0 50 m 200 50 l S")...)
+
+	img := render(t, data, 200)
+
+	assertColor(t, img, 100, 50, blue)
+	assertColor(t, img, 100, 40, white)
+}
+
+func TestRender_FormAndClip(t *testing.T) {
+	data := buildPDF(singlePage(
+		"/Resources << /XObject << /F 5 0 R >> >>",
+		"q 0 0 50 100 re W n /F Do Q",
+		stream("/Type /XObject /Subtype /Form /BBox [0 0 200 100]", "1 0 0 rg 0 0 200 100 re f"),
+	)...)
+
+	img := render(t, data, 200)
+
+	assertColor(t, img, 25, 50, red)
+	assertColor(t, img, 75, 50, white)
+}
+
+func TestRender_Image(t *testing.T) {
+	// 2x1 image with a red and a blue pixel scaled to the page
+	pixels := string([]byte{255, 0, 0, 0, 0, 255})
+	data := buildPDF(singlePage(
+		"/Resources << /XObject << /Im 5 0 R >> >>",
+		"q 200 0 0 100 0 0 cm /Im Do Q",
+		stream("/Type /XObject /Subtype /Image /Width 2 /Height 1 /ColorSpace /DeviceRGB /BitsPerComponent 8", pixels),
+	)...)
+
+	img := render(t, data, 200)
+
+	assertColor(t, img, 50, 50, red)
+	assertColor(t, img, 150, 50, blue)
+}
+
+func TestRender_Text(t *testing.T) {
+	data := buildPDF(singlePage(
+		"/Resources << /Font << /F1 5 0 R >> >>",
+		"BT /F1 20 Tf 10 60 Td (Hello world) Tj 3 Tr 0 -40 Td (Invisible) Tj ET",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	)...)
+
+	img := render(t, data, 200)
+
+	// the words are drawn as bars above the baseline
+	assert.NotEqual(t, white, img.RGBAAt(20, 35))
+	assert.Equal(t, white, img.RGBAAt(60, 35), "space between the words")
+	assert.NotEqual(t, white, img.RGBAAt(80, 35))
+	assert.Equal(t, white, img.RGBAAt(20, 75), "invisible text")
+}
+
+func TestOpen_XrefStream(t *testing.T) {
+	var content bytes.Buffer
+	zw := zlib.NewWriter(&content)
+	_, _ = zw.Write([]byte("0 0 1 rg 0 0 200 100 re f"))
+	_ = zw.Close()
+
+	// catalog, pages and page are compressed in the object stream 5
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 200 100] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+	}
+	var header, body strings.Builder
+	for i, o := range objects {
+		fmt.Fprintf(&header, "%d %d ", i+1, body.Len())
+		body.WriteString(o + "\n")
+	}
+	objStm := header.String() + body.String()
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	off4 := buf.Len()
+	fmt.Fprintf(&buf, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n", content.Len(), content.Bytes())
+	off5 := buf.Len()
+	fmt.Fprintf(&buf, "5 0 obj\n%s\nendobj\n", stream(fmt.Sprintf("/Type /ObjStm /N 3 /First %d", header.Len()), objStm))
+	off6 := buf.Len()
+
+	var xref bytes.Buffer
+	entry := func(kind byte, field uint16, index byte) {
+		xref.WriteByte(kind)
+		_ = binary.Write(&xref, binary.BigEndian, field)
+		xref.WriteByte(index)
+	}
+	entry(0, 0, 255)
+	for i := range objects {
+		entry(2, 5, byte(i))
+	}
+	entry(1, uint16(off4), 0)
+	entry(1, uint16(off5), 0)
+	entry(1, uint16(off6), 0)
+	fmt.Fprintf(&buf, "6 0 obj\n%s\nendobj\nstartxref\n%d\n%%%%EOF\n",
+		stream("/Type /XRef /Size 7 /W [1 2 1] /Root 1 0 R", xref.String()), off6)
+
+	img := render(t, buf.Bytes(), 20)
+
+	assertColor(t, img, 10, 5, blue)
+}
+
+func TestOpen_DamagedXref(t *testing.T) {
+	data := buildPDF(singlePage("", "1 0 0 rg 0 0 200 100 re f")...)
+	// shift all objects, which invalidates the offsets of the table
+	data = append([]byte("%PDF-1.7\n% padding\n"), data[len("%PDF-1.7\n"):]...)
+
+	img := render(t, data, 20)
+
+	assertColor(t, img, 10, 5, red)
+}
+
+func TestOpen_Errors(t *testing.T) {
+	_, err := pdf.Open([]byte("not a pdf"))
+	assert.ErrorIs(t, err, pdf.ErrMalformed)
+
+	encrypted := buildPDF(singlePage("", "")...)
+	encrypted = bytes.Replace(encrypted, []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Encrypt << >>"), 1)
+	_, err = pdf.Open(encrypted)
+	assert.ErrorIs(t, err, pdf.ErrEncrypted)
+
+	doc, err := pdf.Open(buildPDF(singlePage("", "")...))
+	require.NoError(t, err)
+	assert.Equal(t, 1, doc.NumPages())
+	_, err = doc.Render(1, 100)
+	assert.ErrorIs(t, err, pdf.ErrPageRange)
+}
+
+func TestRender_TooComplex(t *testing.T) {
+	content := strings.Repeat("0 0 200 100 re f\n", 100)
+	doc, err := pdf.Open(buildPDF(singlePage("", content)...))
+	require.NoError(t, err)
+
+	img, err := doc.Render(0, 100)
+
+	assert.ErrorIs(t, err, pdf.ErrTooComplex)
+	assert.NotNil(t, img)
+}
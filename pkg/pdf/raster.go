@@ -0,0 +1,359 @@
+package pdf
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// subsamples is the number of sample rows per pixel row for anti-aliasing.
+const subsamples = 4
+
+type point struct{ x, y float64 }
+
+// matrix is an affine transformation [a b c d e f] mapping (x, y)
+// to (a*x + c*y + e, b*x + d*y + f).
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns the transformation m followed by n.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func (m matrix) apply(p point) point {
+	return point{m[0]*p.x + m[2]*p.y + m[4], m[1]*p.x + m[3]*p.y + m[5]}
+}
+
+func (m matrix) invert() (matrix, bool) {
+	det := m[0]*m[3] - m[1]*m[2]
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return matrix{}, false
+	}
+	return matrix{
+		m[3] / det, -m[1] / det,
+		-m[2] / det, m[0] / det,
+		(m[2]*m[5] - m[3]*m[4]) / det,
+		(m[1]*m[4] - m[0]*m[5]) / det,
+	}, true
+}
+
+// scale returns the average scaling factor of the transformation.
+func (m matrix) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// rgb is a color with components in [0, 1].
+type rgb [3]float64
+
+// rect is a rectangle of device pixels.
+type rect struct{ x0, y0, x1, y1 int }
+
+func (r rect) intersect(o rect) rect {
+	r = rect{maxInt(r.x0, o.x0), maxInt(r.y0, o.y0), minInt(r.x1, o.x1), minInt(r.y1, o.y1)}
+	if r.x1 < r.x0 {
+		r.x1 = r.x0
+	}
+	if r.y1 < r.y0 {
+		r.y1 = r.y0
+	}
+	return r
+}
+
+func (r rect) empty() bool {
+	return r.x1 <= r.x0 || r.y1 <= r.y0
+}
+
+// bounds returns the pixels covered by the points.
+func bounds(points []point) rect {
+	if len(points) == 0 {
+		return rect{}
+	}
+	x0, y0, x1, y1 := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		x0, y0 = math.Min(x0, p.x), math.Min(y0, p.y)
+		x1, y1 = math.Max(x1, p.x), math.Max(y1, p.y)
+	}
+	return rect{clampInt(x0), clampInt(y0), clampInt(math.Ceil(x1)), clampInt(math.Ceil(y1))}
+}
+
+func clampInt(f float64) int {
+	switch {
+	case math.IsNaN(f) || f < -1<<24:
+		return -1 << 24
+	case f > 1<<24:
+		return 1 << 24
+	}
+	return int(math.Floor(f))
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// canvas is an opaque image with a white background.
+type canvas struct {
+	img   *image.RGBA
+	cover []float64
+}
+
+func newCanvas(width, height int) *canvas {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	return &canvas{img: img, cover: make([]float64, width+1)}
+}
+
+func (c *canvas) bounds() rect {
+	return rect{0, 0, c.img.Rect.Dx(), c.img.Rect.Dy()}
+}
+
+// blend paints the color with the alpha over the pixel.
+func (c *canvas) blend(x, y int, col rgb, alpha float64) {
+	if alpha <= 0 {
+		return
+	}
+	if alpha > 1 {
+		alpha = 1
+	}
+	i := c.img.PixOffset(x, y)
+	pix := c.img.Pix[i : i+3 : i+3]
+	for k := range pix {
+		pix[k] = uint8(float64(pix[k])*(1-alpha) + col[k]*255*alpha + 0.5)
+	}
+}
+
+type edge struct {
+	x0, y0, x1, y1 float64
+	dir            int
+}
+
+type crossing struct {
+	x   float64
+	dir int
+}
+
+// fill paints the area of the closed polygons.
+func (c *canvas) fill(polygons [][]point, evenOdd bool, clip rect, col rgb, alpha float64) {
+	var edges []edge
+	var all []point
+	for _, poly := range polygons {
+		for i := range poly {
+			p, q := poly[i], poly[(i+1)%len(poly)]
+			if p.y == q.y || math.IsNaN(p.x+p.y+q.x+q.y) {
+				continue
+			}
+			if p.y < q.y {
+				edges = append(edges, edge{p.x, p.y, q.x, q.y, 1})
+			} else {
+				edges = append(edges, edge{q.x, q.y, p.x, p.y, -1})
+			}
+		}
+		all = append(all, poly...)
+	}
+	area := bounds(all).intersect(clip).intersect(c.bounds())
+	if len(edges) == 0 || area.empty() {
+		return
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].y0 < edges[j].y0 })
+
+	var active []edge
+	var crossings []crossing
+	next := 0
+	for py := area.y0; py < area.y1; py++ {
+		cover := c.cover[area.x0 : area.x1+1]
+		for i := range cover {
+			cover[i] = 0
+		}
+		for s := 0; s < subsamples; s++ {
+			sy := float64(py) + (float64(s)+0.5)/subsamples
+			for next < len(edges) && edges[next].y0 <= sy {
+				active = append(active, edges[next])
+				next++
+			}
+			crossings = crossings[:0]
+			kept := active[:0]
+			for _, e := range active {
+				if e.y1 <= sy {
+					continue
+				}
+				kept = append(kept, e)
+				if e.y0 <= sy {
+					crossings = append(crossings, crossing{e.x0 + (sy-e.y0)*(e.x1-e.x0)/(e.y1-e.y0), e.dir})
+				}
+			}
+			active = kept
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+			winding := 0
+			for i, cr := range crossings {
+				winding += cr.dir
+				inside := winding != 0
+				if evenOdd {
+					inside = winding%2 != 0
+				}
+				if inside && i+1 < len(crossings) {
+					c.span(cover, area, cr.x, crossings[i+1].x)
+				}
+			}
+		}
+		for i, v := range cover[:len(cover)-1] {
+			if v > 0 {
+				c.blend(area.x0+i, py, col, math.Min(v, 1)*alpha)
+			}
+		}
+	}
+}
+
+// span adds the coverage of a sample row between xa and xb.
+func (c *canvas) span(cover []float64, area rect, xa, xb float64) {
+	const w = 1.0 / subsamples
+	xa = math.Max(xa, float64(area.x0))
+	xb = math.Min(xb, float64(area.x1))
+	if xb <= xa {
+		return
+	}
+	ia, ib := int(xa), int(xb)
+	if ia == ib {
+		cover[ia-area.x0] += (xb - xa) * w
+		return
+	}
+	cover[ia-area.x0] += (float64(ia+1) - xa) * w
+	for i := ia + 1; i < ib; i++ {
+		cover[i-area.x0] += w
+	}
+	cover[ib-area.x0] += (xb - float64(ib)) * w
+}
+
+// stroke paints the outline of the polylines with the line width in pixels.
+// Line joins are approximated by octagons, dashes are not supported.
+func (c *canvas) stroke(lines [][]point, width float64, clip rect, col rgb, alpha float64) {
+	if width < 1 {
+		// hairlines are drawn with one pixel and reduced opacity
+		alpha *= math.Max(width, 0.25)
+		width = 1
+	}
+	half := width / 2
+	var polygons [][]point
+	for _, line := range lines {
+		for i := 0; i+1 < len(line); i++ {
+			p, q := line[i], line[i+1]
+			dx, dy := q.x-p.x, q.y-p.y
+			l := math.Hypot(dx, dy)
+			if l == 0 {
+				continue
+			}
+			nx, ny := -dy/l*half, dx/l*half
+			polygons = append(polygons, oriented([]point{
+				{p.x + nx, p.y + ny}, {q.x + nx, q.y + ny},
+				{q.x - nx, q.y - ny}, {p.x - nx, p.y - ny},
+			}))
+			if i > 0 && width > 2 {
+				polygons = append(polygons, octagon(p, half))
+			}
+		}
+	}
+	c.fill(polygons, false, clip, col, alpha)
+}
+
+// oriented returns the polygon with a positive signed area, such that
+// overlapping polygons are united by the nonzero winding rule.
+func oriented(poly []point) []point {
+	area := 0.0
+	for i := range poly {
+		p, q := poly[i], poly[(i+1)%len(poly)]
+		area += p.x*q.y - q.x*p.y
+	}
+	if area < 0 {
+		for i, j := 0, len(poly)-1; i < j; i, j = i+1, j-1 {
+			poly[i], poly[j] = poly[j], poly[i]
+		}
+	}
+	return poly
+}
+
+func octagon(center point, radius float64) []point {
+	poly := make([]point, 8)
+	for i := range poly {
+		a := float64(i) * math.Pi / 4
+		poly[i] = point{center.x + radius*math.Cos(a), center.y + radius*math.Sin(a)}
+	}
+	return oriented(poly)
+}
+
+// drawImage paints the image mapped from the unit square with the transformation.
+func (c *canvas) drawImage(img *image.NRGBA, m matrix, clip rect, alpha float64) {
+	inv, ok := m.invert()
+	if !ok {
+		return
+	}
+	corners := []point{m.apply(point{0, 0}), m.apply(point{1, 0}), m.apply(point{0, 1}), m.apply(point{1, 1})}
+	area := bounds(corners).intersect(clip).intersect(c.bounds())
+	if area.empty() {
+		return
+	}
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	// sample downscaled images multiple times per pixel
+	n := 1
+	if float64(w*h) > 4*float64((area.x1-area.x0)*(area.y1-area.y0)) {
+		n = 3
+	}
+
+	for py := area.y0; py < area.y1; py++ {
+		for px := area.x0; px < area.x1; px++ {
+			var sum rgb
+			var a float64
+			for sy := 0; sy < n; sy++ {
+				for sx := 0; sx < n; sx++ {
+					u := inv.apply(point{float64(px) + (float64(sx)+0.5)/float64(n), float64(py) + (float64(sy)+0.5)/float64(n)})
+					if u.x < 0 || u.x >= 1 || u.y < 0 || u.y >= 1 {
+						continue
+					}
+					ix := minInt(int(u.x*float64(w)), w-1)
+					iy := minInt(int((1-u.y)*float64(h)), h-1)
+					i := img.PixOffset(ix, iy)
+					pa := float64(img.Pix[i+3]) / 255
+					for k := range sum {
+						sum[k] += float64(img.Pix[i+k]) / 255 * pa
+					}
+					a += pa
+				}
+			}
+			if a == 0 {
+				continue
+			}
+			col := rgb{sum[0] / a, sum[1] / a, sum[2] / a}
+			c.blend(px, py, col, a/float64(n*n)*alpha)
+		}
+	}
+}
+
+// toNRGBA converts the color to a non-premultiplied color.
+func toNRGBA(col rgb, alpha float64) color.NRGBA {
+	return color.NRGBA{
+		R: uint8(col[0]*255 + 0.5),
+		G: uint8(col[1]*255 + 0.5),
+		B: uint8(col[2]*255 + 0.5),
+		A: uint8(alpha*255 + 0.5),
+	}
+}
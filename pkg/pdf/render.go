@@ -0,0 +1,630 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"math"
+)
+
+const (
+	// maxOperators limits the operators executed for rendering a page
+	maxOperators = 1_000_000
+	// maxPaintedPixels limits the painted pixels relative to the image size
+	maxPaintedPixels = 64
+	maxFormDepth     = 12
+	maxStateDepth    = 64
+	maxRenderSize    = 4096
+	// curveSegments is the number of line segments of flattened curves
+	curveSegments = 16
+)
+
+var ErrTooComplex = errors.New("pdf: page exceeds rendering limits")
+
+type subpath struct {
+	points []point
+	closed bool
+}
+
+type graphicsState struct {
+	ctm         matrix
+	clip        rect
+	fillCS      *colorSpace
+	strokeCS    *colorSpace
+	fill        rgb
+	stroke      rgb
+	fillAlpha   float64
+	strokeAlpha float64
+	lineWidth   float64
+
+	font       *font
+	fontSize   float64
+	charSpace  float64
+	wordSpace  float64
+	hScale     float64
+	leading    float64
+	rise       float64
+	renderMode int
+}
+
+type renderer struct {
+	doc     *Document
+	canvas  *canvas
+	ops     int
+	budget  int
+	images  map[int]*image.NRGBA
+	fonts   map[int]*font
+	stopped bool
+}
+
+// Render renders the page with the zero-based index to an image with the
+// given width. The height follows from the aspect ratio of the page.
+//
+// Pages which exceed the rendering limits are rendered partially and
+// returned with ErrTooComplex.
+func (d *Document) Render(i, width int) (*image.RGBA, error) {
+	page, err := d.Page(i)
+	if err != nil {
+		return nil, err
+	}
+	if width <= 0 || width > maxRenderSize {
+		return nil, errors.New("pdf: invalid width")
+	}
+	x0, y0, x1, y1 := page.Box[0], page.Box[1], page.Box[2], page.Box[3]
+	pw, ph := x1-x0, y1-y0
+	if page.Rotate == 90 || page.Rotate == 270 {
+		pw, ph = ph, pw
+	}
+	s := float64(width) / pw
+	height := int(math.Round(ph * s))
+	if height < 1 {
+		height = 1
+	}
+	if height > maxRenderSize {
+		return nil, errors.New("pdf: invalid aspect ratio")
+	}
+
+	var base matrix
+	switch page.Rotate {
+	case 90:
+		base = matrix{0, s, s, 0, -y0 * s, -x0 * s}
+	case 180:
+		base = matrix{-s, 0, 0, s, x1 * s, -y0 * s}
+	case 270:
+		base = matrix{0, -s, -s, 0, y1 * s, x1 * s}
+	default:
+		base = matrix{s, 0, 0, -s, -x0 * s, y1 * s}
+	}
+
+	r := &renderer{
+		doc:    d,
+		canvas: newCanvas(width, height),
+		budget: maxPaintedPixels * width * height,
+		images: make(map[int]*image.NRGBA),
+		fonts:  make(map[int]*font),
+	}
+	gs := graphicsState{
+		ctm:         base,
+		clip:        r.canvas.bounds(),
+		fillCS:      deviceGray,
+		strokeCS:    deviceGray,
+		fillAlpha:   1,
+		strokeAlpha: 1,
+		lineWidth:   1,
+		hScale:      1,
+	}
+	r.run(d.contents(page.Dict["Contents"]), page.Resources, gs, 0)
+	if r.stopped {
+		return r.canvas.img, ErrTooComplex
+	}
+	return r.canvas.img, nil
+}
+
+// contents returns the concatenated content streams.
+func (d *Document) contents(o Object) []byte {
+	var streams []Object
+	switch c := d.Resolve(o).(type) {
+	case *Stream:
+		streams = []Object{c}
+	case Array:
+		streams = c
+	}
+	var buf bytes.Buffer
+	for _, s := range streams {
+		if stream, ok := d.Resolve(s).(*Stream); ok {
+			if data, _, err := d.Decode(stream); err == nil {
+				buf.Write(data)
+				buf.WriteByte('\n')
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+// paint accounts for the painted area and reports whether painting is within the budget.
+func (r *renderer) paint(area int) bool {
+	r.budget -= area
+	if r.budget < 0 {
+		r.stopped = true
+	}
+	return !r.stopped
+}
+
+// resource returns the named resource of the category.
+func (r *renderer) resource(resources Dict, category, name Name) Object {
+	dict, _ := r.doc.Resolve(resources[category]).(Dict)
+	return r.doc.Resolve(dict[name])
+}
+
+// run executes the operators of the content stream.
+func (r *renderer) run(content []byte, resources Dict, gs graphicsState, depth int) {
+	l := &lexer{data: content}
+	var (
+		stack    []graphicsState
+		operands []Object
+		path     []subpath
+		clip     bool
+		// text matrix and text line matrix
+		tm, tlm matrix
+	)
+	current := func() *subpath {
+		if len(path) == 0 {
+			return nil
+		}
+		return &path[len(path)-1]
+	}
+	lineTo := func(p point) {
+		if sp := current(); sp != nil {
+			sp.points = append(sp.points, gs.ctm.apply(p))
+		}
+	}
+	endPath := func() {
+		if clip {
+			var points []point
+			for _, sp := range path {
+				points = append(points, sp.points...)
+			}
+			gs.clip = gs.clip.intersect(bounds(points))
+			clip = false
+		}
+		path = path[:0]
+	}
+
+	for !r.stopped {
+		tok, err := l.token()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			operands = operands[:0]
+			continue
+		}
+		op, isOp := tok.(keyword)
+		if !isOp || op == "[" || op == "<<" || op == "true" || op == "false" || op == "null" {
+			obj, err := l.objectFrom(tok, 0)
+			if err == nil && len(operands) < 64 {
+				operands = append(operands, obj)
+			}
+			continue
+		}
+		if r.ops++; r.ops > maxOperators {
+			r.stopped = true
+			return
+		}
+		nums := make([]float64, 0, len(operands))
+		for _, o := range operands {
+			if f, ok := num(o); ok {
+				nums = append(nums, f)
+			}
+		}
+		arg := func(i int) float64 {
+			if i < len(nums) {
+				return nums[i]
+			}
+			return 0
+		}
+		name := func() Name {
+			for i := len(operands) - 1; i >= 0; i-- {
+				if n, ok := operands[i].(Name); ok {
+					return n
+				}
+			}
+			return ""
+		}
+
+		switch op {
+		// graphics state
+		case "q":
+			if len(stack) < maxStateDepth {
+				stack = append(stack, gs)
+			}
+		case "Q":
+			if len(stack) > 0 {
+				gs = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if len(nums) == 6 {
+				gs.ctm = matrix{nums[0], nums[1], nums[2], nums[3], nums[4], nums[5]}.mul(gs.ctm)
+			}
+		case "w":
+			gs.lineWidth = arg(0)
+		case "gs":
+			if ext, ok := r.resource(resources, "ExtGState", name()).(Dict); ok {
+				if v, ok := num(r.doc.Resolve(ext["ca"])); ok {
+					gs.fillAlpha = clamp01(v)
+				}
+				if v, ok := num(r.doc.Resolve(ext["CA"])); ok {
+					gs.strokeAlpha = clamp01(v)
+				}
+				if v, ok := num(r.doc.Resolve(ext["LW"])); ok {
+					gs.lineWidth = v
+				}
+			}
+
+		// colors
+		case "g":
+			gs.fillCS, gs.fill = deviceGray, deviceGray.rgb(nums)
+		case "G":
+			gs.strokeCS, gs.stroke = deviceGray, deviceGray.rgb(nums)
+		case "rg":
+			gs.fillCS, gs.fill = deviceRGB, deviceRGB.rgb(nums)
+		case "RG":
+			gs.strokeCS, gs.stroke = deviceRGB, deviceRGB.rgb(nums)
+		case "k":
+			gs.fillCS, gs.fill = deviceCMYK, deviceCMYK.rgb(nums)
+		case "K":
+			gs.strokeCS, gs.stroke = deviceCMYK, deviceCMYK.rgb(nums)
+		case "cs":
+			gs.fillCS = r.doc.colorSpace(name(), resources, 0)
+			gs.fill = gs.fillCS.rgb(gs.fillCS.defaultColor())
+		case "CS":
+			gs.strokeCS = r.doc.colorSpace(name(), resources, 0)
+			gs.stroke = gs.strokeCS.rgb(gs.strokeCS.defaultColor())
+		case "sc", "scn":
+			gs.fill = gs.fillCS.rgb(nums)
+		case "SC", "SCN":
+			gs.stroke = gs.strokeCS.rgb(nums)
+
+		// path construction
+		case "m":
+			path = append(path, subpath{points: []point{gs.ctm.apply(point{arg(0), arg(1)})}})
+		case "l":
+			lineTo(point{arg(0), arg(1)})
+		case "c", "v", "y":
+			sp := current()
+			if sp == nil || len(sp.points) == 0 {
+				break
+			}
+			p0 := sp.points[len(sp.points)-1]
+			var p1, p2, p3 point
+			switch op {
+			case "c":
+				p1, p2, p3 = gs.ctm.apply(point{arg(0), arg(1)}), gs.ctm.apply(point{arg(2), arg(3)}), gs.ctm.apply(point{arg(4), arg(5)})
+			case "v":
+				p1, p2, p3 = p0, gs.ctm.apply(point{arg(0), arg(1)}), gs.ctm.apply(point{arg(2), arg(3)})
+			case "y":
+				p1, p3 = gs.ctm.apply(point{arg(0), arg(1)}), gs.ctm.apply(point{arg(2), arg(3)})
+				p2 = p3
+			}
+			sp.points = append(sp.points, flattenCurve(p0, p1, p2, p3)...)
+		case "h":
+			if sp := current(); sp != nil {
+				sp.closed = true
+			}
+		case "re":
+			x, y, w, h := arg(0), arg(1), arg(2), arg(3)
+			path = append(path, subpath{points: []point{
+				gs.ctm.apply(point{x, y}), gs.ctm.apply(point{x + w, y}),
+				gs.ctm.apply(point{x + w, y + h}), gs.ctm.apply(point{x, y + h}),
+			}, closed: true})
+
+		// path painting
+		case "f", "F", "f*", "B", "B*", "b", "b*", "S", "s":
+			if op == "b" || op == "b*" || op == "s" {
+				if sp := current(); sp != nil {
+					sp.closed = true
+				}
+			}
+			if op != "S" && op != "s" {
+				r.fillPath(path, op == "f*" || op == "B*" || op == "b*", &gs)
+			}
+			if op != "f" && op != "F" && op != "f*" {
+				r.strokePath(path, &gs)
+			}
+			endPath()
+		case "n":
+			endPath()
+		case "W", "W*":
+			clip = true
+
+		// external objects
+		case "Do":
+			r.xObject(name(), resources, gs, depth)
+		case "BI":
+			r.inlineImage(l, resources, gs)
+
+		// text
+		case "BT":
+			tm, tlm = identity, identity
+		case "Tf":
+			gs.font = r.font(resources, name())
+			gs.fontSize = arg(0)
+		case "Tc":
+			gs.charSpace = arg(0)
+		case "Tw":
+			gs.wordSpace = arg(0)
+		case "Tz":
+			gs.hScale = arg(0) / 100
+		case "TL":
+			gs.leading = arg(0)
+		case "Ts":
+			gs.rise = arg(0)
+		case "Tr":
+			gs.renderMode = int(arg(0))
+		case "Td", "TD":
+			if op == "TD" {
+				gs.leading = -arg(1)
+			}
+			tlm = matrix{1, 0, 0, 1, arg(0), arg(1)}.mul(tlm)
+			tm = tlm
+		case "Tm":
+			if len(nums) == 6 {
+				tlm = matrix{nums[0], nums[1], nums[2], nums[3], nums[4], nums[5]}
+				tm = tlm
+			}
+		case "T*":
+			tlm = matrix{1, 0, 0, 1, 0, -gs.leading}.mul(tlm)
+			tm = tlm
+		case "Tj", "'", "\"":
+			if op != "Tj" {
+				if op == "\"" && len(nums) >= 2 {
+					gs.wordSpace, gs.charSpace = nums[0], nums[1]
+				}
+				tlm = matrix{1, 0, 0, 1, 0, -gs.leading}.mul(tlm)
+				tm = tlm
+			}
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].(String); ok {
+					r.showText(s, &tm, &gs)
+				}
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				arr, _ := operands[len(operands)-1].(Array)
+				for _, item := range arr {
+					switch v := item.(type) {
+					case String:
+						r.showText(v, &tm, &gs)
+					case float64:
+						tx := -v / 1000 * gs.fontSize * gs.hScale
+						tm = matrix{1, 0, 0, 1, tx, 0}.mul(tm)
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+// defaultColor returns the initial color of the color space.
+func (cs *colorSpace) defaultColor() []float64 {
+	switch cs.kind {
+	case colorCMYK:
+		return []float64{0, 0, 0, 1}
+	case colorTint:
+		return []float64{1}
+	}
+	return nil
+}
+
+// flattenCurve returns the points of the cubic Bézier curve without the start point.
+func flattenCurve(p0, p1, p2, p3 point) []point {
+	points := make([]point, curveSegments)
+	for i := 1; i <= curveSegments; i++ {
+		t := float64(i) / curveSegments
+		u := 1 - t
+		a, b, c, d := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+		points[i-1] = point{
+			a*p0.x + b*p1.x + c*p2.x + d*p3.x,
+			a*p0.y + b*p1.y + c*p2.y + d*p3.y,
+		}
+	}
+	return points
+}
+
+func (r *renderer) fillPath(path []subpath, evenOdd bool, gs *graphicsState) {
+	polygons := make([][]point, 0, len(path))
+	var all []point
+	for _, sp := range path {
+		if len(sp.points) > 2 {
+			polygons = append(polygons, sp.points)
+			all = append(all, sp.points...)
+		}
+	}
+	area := bounds(all).intersect(gs.clip)
+	if len(polygons) == 0 || area.empty() || !r.paint((area.x1-area.x0)*(area.y1-area.y0)) {
+		return
+	}
+	r.canvas.fill(polygons, evenOdd, gs.clip, gs.fill, gs.fillAlpha)
+}
+
+func (r *renderer) strokePath(path []subpath, gs *graphicsState) {
+	lines := make([][]point, 0, len(path))
+	var all []point
+	for _, sp := range path {
+		line := sp.points
+		if sp.closed && len(line) > 1 {
+			line = append(line[:len(line):len(line)], line[0])
+		}
+		lines = append(lines, line)
+		all = append(all, line...)
+	}
+	width := gs.lineWidth * gs.ctm.scale()
+	pad := int(width) + 1
+	area := bounds(all)
+	area = rect{area.x0 - pad, area.y0 - pad, area.x1 + pad, area.y1 + pad}.intersect(gs.clip)
+	if area.empty() || !r.paint((area.x1-area.x0)*(area.y1-area.y0)) {
+		return
+	}
+	r.canvas.stroke(lines, width, gs.clip, gs.stroke, gs.strokeAlpha)
+}
+
+// xObject draws the image or form XObject.
+func (r *renderer) xObject(name Name, resources Dict, gs graphicsState, depth int) {
+	dict, _ := r.doc.Resolve(resources["XObject"]).(Dict)
+	ref := dict[name]
+	s, ok := r.doc.Resolve(ref).(*Stream)
+	if !ok {
+		return
+	}
+	switch r.doc.Resolve(s.Dict["Subtype"]) {
+	case Name("Image"):
+		refNum := -1
+		if ref, ok := ref.(Ref); ok {
+			refNum = ref.Num
+		}
+		img, cached := r.images[refNum]
+		if !cached {
+			var err error
+			if img, err = r.doc.imageXObject(s, resources, gs.fill); err != nil {
+				img = nil
+			}
+			// stencil masks depend on the fill color
+			if mask, _ := r.doc.Resolve(s.Dict["ImageMask"]).(bool); refNum >= 0 && !mask {
+				r.images[refNum] = img
+			}
+		}
+		r.drawImage(img, gs)
+
+	case Name("Form"):
+		if depth >= maxFormDepth {
+			return
+		}
+		data, _, err := r.doc.Decode(s)
+		if err != nil {
+			return
+		}
+		if m, ok := numbers(r.doc.Resolve(s.Dict["Matrix"])); ok && len(m) == 6 {
+			gs.ctm = matrix{m[0], m[1], m[2], m[3], m[4], m[5]}.mul(gs.ctm)
+		}
+		if box, ok := r.doc.rect(s.Dict["BBox"]); ok {
+			gs.clip = gs.clip.intersect(bounds([]point{
+				gs.ctm.apply(point{box[0], box[1]}), gs.ctm.apply(point{box[2], box[1]}),
+				gs.ctm.apply(point{box[0], box[3]}), gs.ctm.apply(point{box[2], box[3]}),
+			}))
+		}
+		formResources := resources
+		if res, ok := r.doc.Resolve(s.Dict["Resources"]).(Dict); ok {
+			formResources = res
+		}
+		r.run(data, formResources, gs, depth+1)
+	}
+}
+
+func (r *renderer) drawImage(img *image.NRGBA, gs graphicsState) {
+	if img == nil {
+		return
+	}
+	corners := []point{
+		gs.ctm.apply(point{0, 0}), gs.ctm.apply(point{1, 0}),
+		gs.ctm.apply(point{0, 1}), gs.ctm.apply(point{1, 1}),
+	}
+	area := bounds(corners).intersect(gs.clip)
+	if area.empty() || !r.paint(9*(area.x1-area.x0)*(area.y1-area.y0)) {
+		return
+	}
+	r.canvas.drawImage(img, gs.ctm, gs.clip, gs.fillAlpha)
+}
+
+// inlineImageKeys maps the abbreviated keys of inline images.
+var inlineImageKeys = map[Name]Name{
+	"BPC": "BitsPerComponent",
+	"CS":  "ColorSpace",
+	"D":   "Decode",
+	"DP":  "DecodeParms",
+	"F":   "Filter",
+	"H":   "Height",
+	"IM":  "ImageMask",
+	"W":   "Width",
+}
+
+// inlineImageNames maps the abbreviated names of inline images.
+var inlineImageNames = map[Name]Name{
+	"AHx":  "ASCIIHexDecode",
+	"A85":  "ASCII85Decode",
+	"LZW":  "LZWDecode",
+	"Fl":   "FlateDecode",
+	"DCT":  "DCTDecode",
+	"G":    "DeviceGray",
+	"RGB":  "DeviceRGB",
+	"CMYK": "DeviceCMYK",
+	"I":    "Indexed",
+}
+
+// inlineImage reads the inline image "BI ... ID data EI" and draws it.
+func (r *renderer) inlineImage(l *lexer, resources Dict, gs graphicsState) {
+	dict := Dict{}
+	for {
+		tok, err := l.token()
+		if err != nil {
+			return
+		}
+		if tok == keyword("ID") {
+			break
+		}
+		key, ok := tok.(Name)
+		if !ok {
+			continue
+		}
+		v, err := l.object()
+		if err != nil {
+			return
+		}
+		if full, ok := inlineImageKeys[key]; ok {
+			key = full
+		}
+		switch n := v.(type) {
+		case Name:
+			if full, ok := inlineImageNames[n]; ok {
+				v = full
+			}
+		case Array:
+			for i, item := range n {
+				if name, ok := item.(Name); ok {
+					if full, ok := inlineImageNames[name]; ok {
+						n[i] = full
+					}
+				}
+			}
+		}
+		dict[key] = v
+	}
+	// a single whitespace separates the data
+	start := l.pos + 1
+	if start > len(l.data) {
+		return
+	}
+	end := start
+	for {
+		i := bytes.Index(l.data[end:], []byte("EI"))
+		if i < 0 {
+			l.pos = len(l.data)
+			return
+		}
+		end += i
+		after := end + 2
+		if isWhite(l.data[end-1]) && (after == len(l.data) || isWhite(l.data[after])) {
+			break
+		}
+		end += 2
+	}
+	l.pos = end + 2
+
+	s := &Stream{Dict: dict, raw: l.data[start:maxInt(start, end-1)]}
+	img, err := r.doc.imageXObject(s, resources, gs.fill)
+	if err == nil {
+		r.drawImage(img, gs)
+	}
+}
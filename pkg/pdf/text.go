@@ -0,0 +1,177 @@
+package pdf
+
+// Text is drawn as bars above the baseline, which are sized relative to the font size.
+const (
+	greekBase   = 0.05
+	greekHeight = 0.5
+	greekAlpha  = 0.55
+)
+
+// font declares the glyph widths of a font in text space units.
+type font struct {
+	widths       map[int]float64
+	defaultWidth float64
+	// twoByte fonts are composite fonts with two byte codes
+	twoByte bool
+}
+
+// defaultFont is used for text without a valid font.
+var defaultFont = &font{defaultWidth: 0.5}
+
+// font returns the named font of the resources.
+func (r *renderer) font(resources Dict, name Name) *font {
+	fonts, _ := r.doc.Resolve(resources["Font"]).(Dict)
+	ref, isRef := fonts[name].(Ref)
+	if f, ok := r.fonts[ref.Num]; isRef && ok {
+		return f
+	}
+	f := r.readFont(r.doc.Resolve(fonts[name]))
+	if isRef {
+		r.fonts[ref.Num] = f
+	}
+	return f
+}
+
+// readFont reads the widths of the font dictionary.
+func (r *renderer) readFont(o Object) *font {
+	dict, ok := o.(Dict)
+	if !ok {
+		return defaultFont
+	}
+	f := &font{widths: make(map[int]float64), defaultWidth: 0.5}
+	d := r.doc
+
+	if d.Resolve(dict["Subtype"]) == Name("Type0") {
+		f.twoByte = true
+		f.defaultWidth = 1
+		descendants, _ := d.Resolve(dict["DescendantFonts"]).(Array)
+		if len(descendants) == 0 {
+			return f
+		}
+		cid, _ := d.Resolve(descendants[0]).(Dict)
+		if dw, ok := num(d.Resolve(cid["DW"])); ok {
+			f.defaultWidth = dw / 1000
+		}
+		w, _ := d.Resolve(cid["W"]).(Array)
+		for i := 0; i+1 < len(w); {
+			first, ok := integer(d.Resolve(w[i]))
+			if !ok {
+				break
+			}
+			if list, ok := d.Resolve(w[i+1]).(Array); ok {
+				for k, v := range list {
+					if width, ok := num(d.Resolve(v)); ok && len(f.widths) < 1<<16 {
+						f.widths[first+k] = width / 1000
+					}
+				}
+				i += 2
+				continue
+			}
+			if i+2 >= len(w) {
+				break
+			}
+			last, ok1 := integer(d.Resolve(w[i+1]))
+			width, ok2 := num(d.Resolve(w[i+2]))
+			if !ok1 || !ok2 || last < first || last-first > 1<<16 {
+				break
+			}
+			for c := first; c <= last && len(f.widths) < 1<<16; c++ {
+				f.widths[c] = width / 1000
+			}
+			i += 3
+		}
+		return f
+	}
+
+	// glyph space is scaled by the font matrix of Type3 fonts
+	scale := 0.001
+	if fm, ok := numbers(d.Resolve(dict["FontMatrix"])); ok && len(fm) == 6 {
+		scale = fm[0]
+	}
+	if desc, ok := d.Resolve(dict["FontDescriptor"]).(Dict); ok {
+		if mw, ok := num(d.Resolve(desc["MissingWidth"])); ok && mw > 0 {
+			f.defaultWidth = mw * scale
+		}
+	}
+	first, _ := integer(d.Resolve(dict["FirstChar"]))
+	widths, _ := d.Resolve(dict["Widths"]).(Array)
+	for i, v := range widths {
+		if width, ok := num(d.Resolve(v)); ok && i < 256 {
+			f.widths[first+i] = width * scale
+		}
+	}
+	return f
+}
+
+func (f *font) width(code int) float64 {
+	if w, ok := f.widths[code]; ok {
+		return w
+	}
+	return f.defaultWidth
+}
+
+// showText advances the text matrix by the glyphs of the string and draws
+// bars at the positions of the words.
+func (r *renderer) showText(s String, tm *matrix, gs *graphicsState) {
+	f := gs.font
+	if f == nil {
+		f = defaultFont
+	}
+	var codes []int
+	if f.twoByte {
+		for i := 0; i+1 < len(s); i += 2 {
+			codes = append(codes, int(s[i])<<8|int(s[i+1]))
+		}
+	} else {
+		for _, b := range s {
+			codes = append(codes, int(b))
+		}
+	}
+
+	x, wordStart := 0.0, -1.0
+	flush := func() {
+		if wordStart >= 0 {
+			r.greek(wordStart, x-gs.charSpace*gs.hScale, *tm, gs)
+			wordStart = -1
+		}
+	}
+	for _, code := range codes {
+		w := f.width(code)
+		space := !f.twoByte && code == ' '
+		if space || w == 0 {
+			flush()
+		} else if wordStart < 0 {
+			wordStart = x
+		}
+		adv := w*gs.fontSize + gs.charSpace
+		if space {
+			adv += gs.wordSpace
+		}
+		x += adv * gs.hScale
+	}
+	flush()
+	*tm = matrix{1, 0, 0, 1, x, 0}.mul(*tm)
+}
+
+// greek draws a bar for the text between x0 and x1 of the text line.
+func (r *renderer) greek(x0, x1 float64, tm matrix, gs *graphicsState) {
+	// invisible text, e.g. the text layer of scanned documents
+	if gs.renderMode == 3 || gs.renderMode == 7 || x1 <= x0 {
+		return
+	}
+	m := matrix{1, 0, 0, 1, 0, gs.rise}.mul(tm).mul(gs.ctm)
+	y0, y1 := greekBase*gs.fontSize, (greekBase+greekHeight)*gs.fontSize
+	bar := [][]point{{
+		m.apply(point{x0, y0}), m.apply(point{x1, y0}),
+		m.apply(point{x1, y1}), m.apply(point{x0, y1}),
+	}}
+	area := bounds(bar[0]).intersect(gs.clip)
+	if area.empty() || !r.paint((area.x1-area.x0)*(area.y1-area.y0)) {
+		return
+	}
+	col, alpha := gs.fill, gs.fillAlpha
+	if gs.renderMode == 1 || gs.renderMode == 5 {
+		col, alpha = gs.stroke, gs.strokeAlpha
+	}
+	r.canvas.fill(bar, false, gs.clip, col, alpha*greekAlpha)
+}
@@ -0,0 +1,191 @@
+// Package raster paints anti-aliased polylines and images on opaque RGBA images.
+package raster
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/boardsite-io/server/pkg/geometry"
+)
+
+// Canvas is an opaque image on which polylines and images are painted.
+type Canvas struct {
+	img   *image.RGBA
+	cover []float32
+	// painted counts the pixels visited while painting
+	painted int64
+}
+
+// NewCanvas returns a canvas of the given size filled with the background color.
+func NewCanvas(width, height int, background color.RGBA) *Canvas {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	background.A = 0xff
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = background.R, background.G, background.B, background.A
+	}
+	return &Canvas{img: img}
+}
+
+// Image returns the painted image.
+func (c *Canvas) Image() *image.RGBA {
+	return c.img
+}
+
+// Painted returns the number of pixels visited while painting, which
+// measures the effort spent on the canvas.
+func (c *Canvas) Painted() int64 {
+	return c.painted
+}
+
+// Stroke paints the polyline with round caps and joins. The width is given in
+// pixels, the alpha in [0, 1]. A single point is painted as a dot.
+//
+// Overlapping segments of the polyline are painted once, such that
+// translucent polylines have a uniform opacity.
+func (c *Canvas) Stroke(points []geometry.Point, width float64, col color.RGBA, alpha float64) {
+	if len(points) == 0 || !(width > 0) || !(alpha > 0) {
+		return
+	}
+	if width < 1 {
+		// hairlines are drawn with one pixel and reduced opacity
+		alpha *= math.Max(width, 0.25)
+		width = 1
+	}
+	half := width / 2
+	area := pixelBounds(geometry.Bounds(points...).Grow(half + 1)).Intersect(c.img.Rect)
+	if area.Empty() {
+		return
+	}
+	cover := c.coverBuffer(area.Dx() * area.Dy())
+
+	segment := func(p, q geometry.Point) {
+		r := pixelBounds(geometry.Bounds(p, q).Grow(half + 1)).Intersect(area)
+		c.painted += int64(r.Dx() * r.Dy())
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			row := cover[(y-area.Min.Y)*area.Dx():]
+			for x := r.Min.X; x < r.Max.X; x++ {
+				center := geometry.Point{X: float64(x) + 0.5, Y: float64(y) + 0.5}
+				v := float32(half + 0.5 - geometry.SegmentDist(center, p, q))
+				if v > 1 {
+					v = 1
+				}
+				if i := x - area.Min.X; v > row[i] {
+					row[i] = v
+				}
+			}
+		}
+	}
+	if len(points) == 1 {
+		segment(points[0], points[0])
+	}
+	for i := 0; i+1 < len(points); i++ {
+		segment(points[i], points[i+1])
+	}
+
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			if v := cover[(y-area.Min.Y)*area.Dx()+x-area.Min.X]; v > 0 {
+				c.blend(x, y, col, float64(v)*alpha)
+			}
+		}
+	}
+}
+
+// DrawImage paints the image scaled to the rectangle. Each pixel is the
+// average of the image pixels it covers.
+func (c *Canvas) DrawImage(src image.Image, r image.Rectangle) {
+	sb := src.Bounds()
+	area := r.Intersect(c.img.Rect)
+	if area.Empty() || sb.Empty() {
+		return
+	}
+	c.painted += int64(area.Dx() * area.Dy())
+	scaleX := float64(sb.Dx()) / float64(r.Dx())
+	scaleY := float64(sb.Dy()) / float64(r.Dy())
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		sy0, sy1 := sourceRange(y-r.Min.Y, scaleY, sb.Min.Y, sb.Max.Y)
+		for x := area.Min.X; x < area.Max.X; x++ {
+			sx0, sx1 := sourceRange(x-r.Min.X, scaleX, sb.Min.X, sb.Max.X)
+			var sum [4]float64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					sum[0] += float64(cr)
+					sum[1] += float64(cg)
+					sum[2] += float64(cb)
+					sum[3] += float64(ca)
+				}
+			}
+			if sum[3] == 0 {
+				continue
+			}
+			// the colors are premultiplied by alpha
+			col := color.RGBA{
+				R: uint8(sum[0] / sum[3] * 0xff),
+				G: uint8(sum[1] / sum[3] * 0xff),
+				B: uint8(sum[2] / sum[3] * 0xff),
+			}
+			c.blend(x, y, col, sum[3]/float64((sy1-sy0)*(sx1-sx0))/0xffff)
+		}
+	}
+}
+
+// sourceRange returns the source pixels covered by the destination pixel i.
+func sourceRange(i int, scale float64, min, max int) (int, int) {
+	s0 := min + int(float64(i)*scale)
+	s1 := min + int(math.Ceil(float64(i+1)*scale))
+	if s0 >= max {
+		s0 = max - 1
+	}
+	if s1 <= s0 {
+		s1 = s0 + 1
+	}
+	if s1 > max {
+		s1 = max
+	}
+	return s0, s1
+}
+
+// blend paints the color with the alpha over the pixel.
+func (c *Canvas) blend(x, y int, col color.RGBA, alpha float64) {
+	if alpha <= 0 {
+		return
+	}
+	if alpha > 1 {
+		alpha = 1
+	}
+	i := c.img.PixOffset(x, y)
+	pix := c.img.Pix[i : i+3 : i+3]
+	for k, v := range [3]uint8{col.R, col.G, col.B} {
+		pix[k] = uint8(float64(pix[k])*(1-alpha) + float64(v)*alpha + 0.5)
+	}
+}
+
+// coverBuffer returns a zeroed buffer of size n.
+func (c *Canvas) coverBuffer(n int) []float32 {
+	if cap(c.cover) < n {
+		c.cover = make([]float32, n)
+	}
+	cover := c.cover[:n]
+	for i := range cover {
+		cover[i] = 0
+	}
+	return cover
+}
+
+// pixelBounds returns the pixels covered by the rectangle.
+func pixelBounds(r geometry.Rect) image.Rectangle {
+	return image.Rect(clampInt(math.Floor(r.Min.X)), clampInt(math.Floor(r.Min.Y)),
+		clampInt(math.Ceil(r.Max.X)), clampInt(math.Ceil(r.Max.Y)))
+}
+
+func clampInt(f float64) int {
+	switch {
+	case math.IsNaN(f) || f < -1<<24:
+		return -1 << 24
+	case f > 1<<24:
+		return 1 << 24
+	}
+	return int(f)
+}
@@ -0,0 +1,82 @@
+package raster_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/boardsite-io/server/pkg/geometry"
+	"github.com/boardsite-io/server/pkg/raster"
+)
+
+var (
+	white = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	red   = color.RGBA{R: 255, A: 255}
+	blue  = color.RGBA{B: 255, A: 255}
+)
+
+func TestCanvas_Stroke(t *testing.T) {
+	c := raster.NewCanvas(100, 50, white)
+
+	c.Stroke([]geometry.Point{{X: 10, Y: 10}, {X: 90, Y: 10}, {X: 90, Y: 40}}, 4, red, 1)
+
+	img := c.Image()
+	assert.Equal(t, red, img.RGBAAt(50, 10))
+	assert.Equal(t, red, img.RGBAAt(90, 25))
+	assert.Equal(t, white, img.RGBAAt(50, 14))
+	assert.Equal(t, white, img.RGBAAt(50, 30))
+	// round caps
+	assert.Equal(t, red, img.RGBAAt(9, 10))
+	assert.Equal(t, white, img.RGBAAt(6, 10))
+	assert.Greater(t, c.Painted(), int64(0))
+}
+
+func TestCanvas_StrokeTranslucent(t *testing.T) {
+	c := raster.NewCanvas(20, 20, white)
+
+	// the segments overlap at the joint, which is painted once
+	c.Stroke([]geometry.Point{{X: 2, Y: 10}, {X: 10, Y: 10}, {X: 18, Y: 10}}, 6, blue, 0.5)
+
+	img := c.Image()
+	assert.Equal(t, img.RGBAAt(5, 10), img.RGBAAt(10, 10))
+	assert.Equal(t, color.RGBA{R: 128, G: 128, B: 255, A: 255}, img.RGBAAt(10, 10))
+}
+
+func TestCanvas_StrokeDot(t *testing.T) {
+	c := raster.NewCanvas(20, 20, white)
+
+	c.Stroke([]geometry.Point{{X: 10, Y: 10}}, 6, red, 1)
+
+	assert.Equal(t, red, c.Image().RGBAAt(10, 10))
+	assert.Equal(t, white, c.Image().RGBAAt(10, 16))
+}
+
+func TestCanvas_DrawImage(t *testing.T) {
+	// 2x1 image with a red and a blue pixel
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.SetRGBA(0, 0, red)
+	src.SetRGBA(1, 0, blue)
+	c := raster.NewCanvas(40, 20, white)
+
+	c.DrawImage(src, image.Rect(10, 0, 30, 10))
+
+	img := c.Image()
+	assert.Equal(t, red, img.RGBAAt(12, 5))
+	assert.Equal(t, blue, img.RGBAAt(28, 5))
+	assert.Equal(t, white, img.RGBAAt(5, 5))
+	assert.Equal(t, white, img.RGBAAt(20, 15))
+}
+
+func TestCanvas_DrawImageDownscaled(t *testing.T) {
+	// the pixels of the image are averaged, transparent pixels reduce the coverage
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.SetRGBA(0, 0, red)
+	src.SetRGBA(1, 1, red)
+	c := raster.NewCanvas(1, 1, color.RGBA{})
+
+	c.DrawImage(src, image.Rect(0, 0, 1, 1))
+
+	assert.Equal(t, color.RGBA{R: 128, A: 255}, c.Image().RGBAAt(0, 0))
+}
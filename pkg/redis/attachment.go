@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// getAttachmentsKey returns the redis key for the attachment meta data of a session.
+func getAttachmentsKey(sessionId string) string {
+	return sessionId + ".attachments"
+}
+
+func (h *handler) GetAttachments(ctx context.Context, sessionId string) ([][]byte, error) {
+	return redis.ByteSlices(h.Do(ctx, "HVALS", getAttachmentsKey(sessionId)))
+}
+
+func (h *handler) SetAttachment(ctx context.Context, sessionId, attachId string, meta any) error {
+	bytes, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	_, err = h.Do(ctx, "HSET", getAttachmentsKey(sessionId), attachId, bytes)
+	return err
+}
+
+func (h *handler) DeleteAttachment(ctx context.Context, sessionId, attachId string) (bool, error) {
+	n, err := redis.Int(h.Do(ctx, "HDEL", getAttachmentsKey(sessionId), attachId))
+	return n > 0, err
+}
+
+func (h *handler) ClearAttachments(ctx context.Context, sessionId string) error {
+	_, err := h.Do(ctx, "DEL", getAttachmentsKey(sessionId))
+	return err
+}
@@ -0,0 +1,46 @@
+package redis_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/session"
+)
+
+func Test_handler_Attachments(t *testing.T) {
+	ctx := context.Background()
+	mr, h := setupHandler(t)
+	defer mr.Close()
+	defer h.ClosePool()
+	sid := "sid"
+
+	a := session.Attachment{AttachID: "hash.png", Hash: "hash", MIMEType: "image/png", Size: 42, UserID: "user1"}
+	require.NoError(t, h.SetAttachment(ctx, sid, a.AttachID, a))
+	require.NoError(t, h.SetAttachment(ctx, sid, "other.pdf", session.Attachment{AttachID: "other.pdf"}))
+
+	attachments, err := h.GetAttachments(ctx, sid)
+	assert.NoError(t, err)
+	require.Len(t, attachments, 2)
+
+	deleted, err := h.DeleteAttachment(ctx, sid, "other.pdf")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = h.DeleteAttachment(ctx, sid, "other.pdf")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+	attachments, err = h.GetAttachments(ctx, sid)
+	assert.NoError(t, err)
+	require.Len(t, attachments, 1)
+	var got session.Attachment
+	require.NoError(t, json.Unmarshal(attachments[0], &got))
+	assert.Equal(t, a, got)
+
+	require.NoError(t, h.ClearAttachments(ctx, sid))
+	attachments, err = h.GetAttachments(ctx, sid)
+	assert.NoError(t, err)
+	assert.Empty(t, attachments)
+}
@@ -30,12 +30,17 @@ type Handler interface {
 	// Creates a JSON encoding for each slice entry which
 	// is stored to the database.
 	// Delete the stroke with given id if stroke type is set to delete.
+	// All updates are applied in a single transaction.
 	UpdateStrokes(ctx context.Context, sessionId string, strokes ...Stroke) error
 	// GetPageStrokes Fetches all strokes of the specified page.
 	//
 	// Preserves the JSON encoding of Redis and returns an array of
 	// a stringified stroke objects.
 	GetPageStrokes(ctx context.Context, sessionID, pageID string) ([][]byte, error)
+	// GetStrokes fetches the strokes with given ids of the specified page.
+	//
+	// Strokes which do not exist are omitted.
+	GetStrokes(ctx context.Context, sessionID, pageID string, strokeIDs ...string) ([][]byte, error)
 	// GetPageRank returns a list of all pageIDs for the current session.
 	//
 	// The PageIDs are maintained in a list in redis since the ordering is important
@@ -44,15 +49,54 @@ type Handler interface {
 	GetPageMeta(ctx context.Context, sessionId, pageId string, meta any) error
 	// SetPageMeta sets the page meta data
 	SetPageMeta(ctx context.Context, sessionId, pageId string, meta any) error
+	// GetPageLayers returns the layers of a page. Layers remain unchanged if the page has no layers.
+	GetPageLayers(ctx context.Context, sessionId, pageId string, layers any) error
+	// SetPageLayers sets the layers of a page
+	SetPageLayers(ctx context.Context, sessionId, pageId string, layers any) error
 	// AddPage adds a page with pageID at position index.
 	//
-	// Other pages are moved and their score is reassigned
-	// when pages are added in between
+	// The page is appended if the index is out of range. Pages are ordered by
+	// fractional scores, such that other pages remain unchanged.
 	AddPage(ctx context.Context, sessionID, newPageID string, index int, meta any) error
-	// DeletePage deletes a page and the respective strokes on the page and remove the PageID from the list.
+	// MovePage moves a page to position index.
+	//
+	// The page is moved to the end if the index is out of range.
+	MovePage(ctx context.Context, sessionID, pageID string, index int) error
+	// DuplicatePage copies the meta data, layers and strokes of a page to a new page at position index.
+	//
+	// The page id of the copied strokes is set to the new page id.
+	DuplicatePage(ctx context.Context, sessionID, pageID, newPageID string, index int) error
+	// DeletePage deletes a page including its strokes and comments and remove the PageID from the list.
+	//
+	// AddPage, MovePage and DeletePage are applied atomically.
 	DeletePage(ctx context.Context, sessionID, pageID string) error
 	// ClearPage removes all strokes with given pageID.
 	ClearPage(ctx context.Context, sessionID, pageID string) error
+	// AppendChat appends a message to the chat history of the session.
+	//
+	// Only the latest maxLen messages are kept if maxLen is positive.
+	AppendChat(ctx context.Context, sessionID string, message any, maxLen int) error
+	// GetChat returns the JSON encoded chat history of the session in order.
+	GetChat(ctx context.Context, sessionID string) ([][]byte, error)
+	// RemoveChat removes the message with given encoding from the chat history
+	// and reports whether the message was found.
+	RemoveChat(ctx context.Context, sessionID string, message []byte) (bool, error)
+	// ClearChat deletes the chat history of the session.
+	ClearChat(ctx context.Context, sessionID string) error
+	// GetComments returns the JSON encoded comment threads of a page in no particular order.
+	GetComments(ctx context.Context, sessionID, pageID string) ([][]byte, error)
+	// SetComment adds or replaces the comment thread with given id of a page.
+	SetComment(ctx context.Context, sessionID, pageID, threadID string, thread any) error
+	// DeleteComment deletes a comment thread of a page and reports whether the thread was found.
+	DeleteComment(ctx context.Context, sessionID, pageID, threadID string) (bool, error)
+	// GetAttachments returns the JSON encoded meta data of the attachments of the session in no particular order.
+	GetAttachments(ctx context.Context, sessionID string) ([][]byte, error)
+	// SetAttachment adds or replaces the meta data of the attachment with given id.
+	SetAttachment(ctx context.Context, sessionID, attachID string, meta any) error
+	// DeleteAttachment deletes the meta data of an attachment and reports whether the attachment was found.
+	DeleteAttachment(ctx context.Context, sessionID, attachID string) (bool, error)
+	// ClearAttachments deletes the meta data of all attachments of the session.
+	ClearAttachments(ctx context.Context, sessionID string) error
 	ClosePool() error
 }
 
@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// getChatKey returns the redis key for the chat history of a session.
+func getChatKey(sessionId string) string {
+	return sessionId + ".chat"
+}
+
+func (h *handler) AppendChat(ctx context.Context, sessionId string, message any, maxLen int) error {
+	bytes, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	conn, err := h.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	key := getChatKey(sessionId)
+	if err := conn.Send("MULTI"); err != nil {
+		return err
+	}
+	if err := conn.Send("RPUSH", key, bytes); err != nil {
+		return err
+	}
+	if maxLen > 0 { // keep the latest messages
+		if err := conn.Send("LTRIM", key, -maxLen, -1); err != nil {
+			return err
+		}
+	}
+	_, err = conn.Do("EXEC")
+	return err
+}
+
+func (h *handler) GetChat(ctx context.Context, sessionId string) ([][]byte, error) {
+	return redis.ByteSlices(h.Do(ctx, "LRANGE", getChatKey(sessionId), 0, -1))
+}
+
+func (h *handler) RemoveChat(ctx context.Context, sessionId string, message []byte) (bool, error) {
+	n, err := redis.Int(h.Do(ctx, "LREM", getChatKey(sessionId), 1, message))
+	return n > 0, err
+}
+
+func (h *handler) ClearChat(ctx context.Context, sessionId string) error {
+	_, err := h.Do(ctx, "DEL", getChatKey(sessionId))
+	return err
+}
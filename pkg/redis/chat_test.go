@@ -0,0 +1,54 @@
+package redis_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/session"
+)
+
+func Test_handler_Chat(t *testing.T) {
+	ctx := context.Background()
+	mr, h := setupHandler(t)
+	defer mr.Close()
+	defer h.ClosePool()
+	sid := "sid"
+	const maxLen = 3
+
+	for i := 0; i < 5; i++ {
+		err := h.AppendChat(ctx, sid, session.ChatMessage{ID: fmt.Sprintf("msg%d", i), Text: "hello"}, maxLen)
+		require.NoError(t, err)
+	}
+
+	history, err := h.GetChat(ctx, sid)
+	assert.NoError(t, err)
+	// only the latest messages are kept
+	ids := make([]string, len(history))
+	for i, m := range history {
+		var message session.ChatMessage
+		require.NoError(t, json.Unmarshal(m, &message))
+		ids[i] = message.ID
+	}
+	assert.Equal(t, []string{"msg2", "msg3", "msg4"}, ids)
+
+	removed, err := h.RemoveChat(ctx, sid, history[1])
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = h.RemoveChat(ctx, sid, history[1])
+	assert.NoError(t, err)
+	assert.False(t, removed)
+	history, err = h.GetChat(ctx, sid)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	err = h.ClearChat(ctx, sid)
+	assert.NoError(t, err)
+	history, err = h.GetChat(ctx, sid)
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+}
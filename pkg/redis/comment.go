@@ -0,0 +1,32 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// getCommentsKey returns the redis key for the comment threads of a page.
+func getCommentsKey(sessionId, pageId string) string {
+	return fmt.Sprintf("%s.%s.comments", sessionId, pageId)
+}
+
+func (h *handler) GetComments(ctx context.Context, sessionId, pageId string) ([][]byte, error) {
+	return redis.ByteSlices(h.Do(ctx, "HVALS", getCommentsKey(sessionId, pageId)))
+}
+
+func (h *handler) SetComment(ctx context.Context, sessionId, pageId, threadId string, thread any) error {
+	bytes, err := json.Marshal(thread)
+	if err != nil {
+		return err
+	}
+	_, err = h.Do(ctx, "HSET", getCommentsKey(sessionId, pageId), threadId, bytes)
+	return err
+}
+
+func (h *handler) DeleteComment(ctx context.Context, sessionId, pageId, threadId string) (bool, error) {
+	n, err := redis.Int(h.Do(ctx, "HDEL", getCommentsKey(sessionId, pageId), threadId))
+	return n > 0, err
+}
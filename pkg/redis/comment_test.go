@@ -0,0 +1,47 @@
+package redis_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boardsite-io/server/internal/session"
+)
+
+func Test_handler_Comments(t *testing.T) {
+	ctx := context.Background()
+	mr, h := setupHandler(t)
+	defer mr.Close()
+	defer h.ClosePool()
+	sid, pid := "sid", "pid1"
+	require.NoError(t, h.AddPage(ctx, sid, pid, -1, nil))
+
+	thread := session.CommentThread{ID: "thread1", PageID: pid, X: 1, Y: 2, Comments: []*session.Comment{{ID: "comment1", Text: "hello"}}}
+	require.NoError(t, h.SetComment(ctx, sid, pid, thread.ID, thread))
+	thread.Resolved = true
+	require.NoError(t, h.SetComment(ctx, sid, pid, thread.ID, thread))
+
+	threads, err := h.GetComments(ctx, sid, pid)
+	assert.NoError(t, err)
+	require.Len(t, threads, 1)
+	var got session.CommentThread
+	require.NoError(t, json.Unmarshal(threads[0], &got))
+	assert.Equal(t, thread, got)
+
+	deleted, err := h.DeleteComment(ctx, sid, pid, thread.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = h.DeleteComment(ctx, sid, pid, thread.ID)
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+
+	// comments are deleted with the page
+	require.NoError(t, h.SetComment(ctx, sid, pid, thread.ID, thread))
+	require.NoError(t, h.DeletePage(ctx, sid, pid))
+	threads, err = h.GetComments(ctx, sid, pid)
+	assert.NoError(t, err)
+	assert.Empty(t, threads)
+}
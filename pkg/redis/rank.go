@@ -0,0 +1,132 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// The page rank is a sorted set of page ids. Pages are ordered by fractional
+// scores, i.e. a page inserted in between two pages receives the mean of their
+// scores, such that no other page has to be updated. Once the precision of the
+// scores is exhausted, the scores of the page rank are reassigned to 0..n-1.
+//
+// All updates of the page rank are performed by scripts, which are executed
+// atomically by Redis.
+
+// rankInsertLua defines the function insert(key, page, index), which inserts
+// the page at position index or appends it if the index is out of range.
+// Pages which are part of the rank already remain unchanged.
+const rankInsertLua = `
+local function score_of(key, index)
+	return tonumber(redis.call('ZRANGE', key, index, index, 'WITHSCORES')[2])
+end
+
+local function insert(key, page, index)
+	if redis.call('ZSCORE', key, page) then
+		return 0
+	end
+	local n = redis.call('ZCARD', key)
+	local score
+	if n == 0 then
+		score = 0
+	elseif index < 0 or index >= n then
+		score = score_of(key, -1) + 1
+	elseif index == 0 then
+		score = score_of(key, 0) - 1
+	else
+		local lo, hi = score_of(key, index - 1), score_of(key, index)
+		score = lo + (hi - lo) / 2
+		if score <= lo or score >= hi then
+			-- precision exhausted
+			local pages = redis.call('ZRANGE', key, 0, -1)
+			for i, p in ipairs(pages) do
+				redis.call('ZADD', key, i - 1, p)
+			end
+			score = index - 0.5
+		end
+	end
+	redis.call('ZADD', key, string.format('%.17g', score), page)
+	return 1
+end
+`
+
+// addPageScript inserts the page into the rank and sets its meta data if given.
+//
+// KEYS: rank, meta
+// ARGV: page, index, meta
+var addPageScript = redis.NewScript(2, rankInsertLua+`
+if ARGV[3] ~= '' then
+	redis.call('SET', KEYS[2], ARGV[3])
+end
+return insert(KEYS[1], ARGV[1], tonumber(ARGV[2]))
+`)
+
+// movePageScript moves a page of the rank to position index.
+//
+// KEYS: rank
+// ARGV: page, index
+var movePageScript = redis.NewScript(1, rankInsertLua+`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return redis.error_reply('page does not exist')
+end
+return insert(KEYS[1], ARGV[1], tonumber(ARGV[2]))
+`)
+
+// deletePageScript removes the page from the rank and deletes its data.
+//
+// KEYS: rank, strokes, meta, layers, comments
+// ARGV: page
+var deletePageScript = redis.NewScript(5, `
+redis.call('DEL', KEYS[2], KEYS[3], KEYS[4], KEYS[5])
+return redis.call('ZREM', KEYS[1], ARGV[1])
+`)
+
+func (h *handler) AddPage(ctx context.Context, sessionId, newpageId string, index int, meta any) error {
+	var pMeta []byte
+	if meta != nil {
+		var err error
+		if pMeta, err = json.Marshal(meta); err != nil {
+			return err
+		}
+	}
+
+	conn, err := h.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = addPageScript.Do(conn, getPageRankKey(sessionId), getPageMetaKey(sessionId, newpageId), newpageId, index, pMeta)
+	return err
+}
+
+func (h *handler) MovePage(ctx context.Context, sessionId, pageId string, index int) error {
+	conn, err := h.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = movePageScript.Do(conn, getPageRankKey(sessionId), pageId, index)
+	return err
+}
+
+func (h *handler) DeletePage(ctx context.Context, sessionId, pageId string) error {
+	conn, err := h.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = deletePageScript.Do(conn,
+		getPageRankKey(sessionId),
+		getStrokesKey(sessionId, pageId),
+		getPageMetaKey(sessionId, pageId),
+		getPageLayersKey(sessionId, pageId),
+		getCommentsKey(sessionId, pageId),
+		pageId,
+	)
+	return err
+}
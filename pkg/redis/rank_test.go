@@ -0,0 +1,140 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_handler_AddPage_ExhaustsPrecision(t *testing.T) {
+	ctx := context.Background()
+	mr, h := setupHandler(t)
+	defer mr.Close()
+	defer h.ClosePool()
+	sid := "sid"
+	const n = 200
+
+	require.NoError(t, h.AddPage(ctx, sid, "first", -1, nil))
+	require.NoError(t, h.AddPage(ctx, sid, "last", -1, nil))
+	// every page is inserted right after the first page, which
+	// halves the gap between the neighboring scores each time
+	want := make([]string, n+2)
+	want[0], want[n+1] = "first", "last"
+	for i := 0; i < n; i++ {
+		pid := fmt.Sprintf("pid%d", i)
+		require.NoError(t, h.AddPage(ctx, sid, pid, 1, nil))
+		want[n-i] = pid
+	}
+
+	pageRank, err := h.GetPageRank(ctx, sid)
+	assert.NoError(t, err)
+	assert.Equal(t, want, pageRank)
+}
+
+func Test_handler_AddPage_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	mr, h := setupHandler(t)
+	defer mr.Close()
+	defer h.ClosePool()
+	sid := "sid"
+	const (
+		workers = 8
+		pages   = 25
+	)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < pages; i++ {
+				// even workers append, odd workers prepend their pages
+				index := -1
+				if w%2 == 1 {
+					index = 0
+				}
+				assert.NoError(t, h.AddPage(ctx, sid, fmt.Sprintf("w%d-%d", w, i), index, nil))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	pageRank, err := h.GetPageRank(ctx, sid)
+	require.NoError(t, err)
+	assert.Len(t, pageRank, workers*pages)
+	position := make(map[string]int, len(pageRank))
+	for i, pid := range pageRank {
+		position[pid] = i
+	}
+	// the pages of each worker retain their relative order
+	for w := 0; w < workers; w++ {
+		for i := 1; i < pages; i++ {
+			prev, cur := position[fmt.Sprintf("w%d-%d", w, i-1)], position[fmt.Sprintf("w%d-%d", w, i)]
+			if w%2 == 0 {
+				assert.Less(t, prev, cur)
+			} else {
+				assert.Greater(t, prev, cur)
+			}
+		}
+	}
+}
+
+func Test_handler_MovePage_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	mr, h := setupHandler(t)
+	defer mr.Close()
+	defer h.ClosePool()
+	sid := "sid"
+	const (
+		numPages = 20
+		workers  = 8
+		moves    = 50
+	)
+	want := make([]string, numPages)
+	for i := range want {
+		want[i] = fmt.Sprintf("pid%d", i)
+		require.NoError(t, h.AddPage(ctx, sid, want[i], -1, nil))
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < moves; i++ {
+				pid := want[(w*moves+i)%numPages]
+				assert.NoError(t, h.MovePage(ctx, sid, pid, (i*7)%numPages))
+			}
+		}(w)
+	}
+	// pages are added and deleted during the moves
+	for i := 0; i < moves; i++ {
+		pid := fmt.Sprintf("tmp%d", i)
+		require.NoError(t, h.AddPage(ctx, sid, pid, i%numPages, nil))
+		require.NoError(t, h.DeletePage(ctx, sid, pid))
+	}
+	wg.Wait()
+
+	pageRank, err := h.GetPageRank(ctx, sid)
+	assert.NoError(t, err)
+	// no page is lost or duplicated
+	assert.ElementsMatch(t, want, pageRank)
+}
+
+func Test_handler_MovePage_Unknown(t *testing.T) {
+	ctx := context.Background()
+	mr, h := setupHandler(t)
+	defer mr.Close()
+	defer h.ClosePool()
+
+	require.NoError(t, h.AddPage(ctx, "sid", "pid1", -1, nil))
+
+	assert.Error(t, h.MovePage(ctx, "sid", "pid2", 0))
+	pageRank, err := h.GetPageRank(ctx, "sid")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pid1"}, pageRank)
+}
@@ -25,6 +25,44 @@ type FakeHandler struct {
 	addPageReturnsOnCall map[int]struct {
 		result1 error
 	}
+	AppendChatStub        func(context.Context, string, any, int) error
+	appendChatMutex       sync.RWMutex
+	appendChatArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 any
+		arg4 int
+	}
+	appendChatReturns struct {
+		result1 error
+	}
+	appendChatReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ClearAttachmentsStub        func(context.Context, string) error
+	clearAttachmentsMutex       sync.RWMutex
+	clearAttachmentsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	clearAttachmentsReturns struct {
+		result1 error
+	}
+	clearAttachmentsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ClearChatStub        func(context.Context, string) error
+	clearChatMutex       sync.RWMutex
+	clearChatArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	clearChatReturns struct {
+		result1 error
+	}
+	clearChatReturnsOnCall map[int]struct {
+		result1 error
+	}
 	ClearPageStub        func(context.Context, string, string) error
 	clearPageMutex       sync.RWMutex
 	clearPageArgsForCall []struct {
@@ -72,6 +110,37 @@ type FakeHandler struct {
 	deleteReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteAttachmentStub        func(context.Context, string, string) (bool, error)
+	deleteAttachmentMutex       sync.RWMutex
+	deleteAttachmentArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	deleteAttachmentReturns struct {
+		result1 bool
+		result2 error
+	}
+	deleteAttachmentReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	DeleteCommentStub        func(context.Context, string, string, string) (bool, error)
+	deleteCommentMutex       sync.RWMutex
+	deleteCommentArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+	}
+	deleteCommentReturns struct {
+		result1 bool
+		result2 error
+	}
+	deleteCommentReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
 	DeletePageStub        func(context.Context, string, string) error
 	deletePageMutex       sync.RWMutex
 	deletePageArgsForCall []struct {
@@ -85,6 +154,21 @@ type FakeHandler struct {
 	deletePageReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DuplicatePageStub        func(context.Context, string, string, string, int) error
+	duplicatePageMutex       sync.RWMutex
+	duplicatePageArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 int
+	}
+	duplicatePageReturns struct {
+		result1 error
+	}
+	duplicatePageReturnsOnCall map[int]struct {
+		result1 error
+	}
 	GetStub        func(context.Context, string) (any, error)
 	getMutex       sync.RWMutex
 	getArgsForCall []struct {
@@ -99,6 +183,63 @@ type FakeHandler struct {
 		result1 any
 		result2 error
 	}
+	GetAttachmentsStub        func(context.Context, string) ([][]byte, error)
+	getAttachmentsMutex       sync.RWMutex
+	getAttachmentsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	getAttachmentsReturns struct {
+		result1 [][]byte
+		result2 error
+	}
+	getAttachmentsReturnsOnCall map[int]struct {
+		result1 [][]byte
+		result2 error
+	}
+	GetChatStub        func(context.Context, string) ([][]byte, error)
+	getChatMutex       sync.RWMutex
+	getChatArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	getChatReturns struct {
+		result1 [][]byte
+		result2 error
+	}
+	getChatReturnsOnCall map[int]struct {
+		result1 [][]byte
+		result2 error
+	}
+	GetCommentsStub        func(context.Context, string, string) ([][]byte, error)
+	getCommentsMutex       sync.RWMutex
+	getCommentsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	getCommentsReturns struct {
+		result1 [][]byte
+		result2 error
+	}
+	getCommentsReturnsOnCall map[int]struct {
+		result1 [][]byte
+		result2 error
+	}
+	GetPageLayersStub        func(context.Context, string, string, any) error
+	getPageLayersMutex       sync.RWMutex
+	getPageLayersArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 any
+	}
+	getPageLayersReturns struct {
+		result1 error
+	}
+	getPageLayersReturnsOnCall map[int]struct {
+		result1 error
+	}
 	GetPageMetaStub        func(context.Context, string, string, any) error
 	getPageMetaMutex       sync.RWMutex
 	getPageMetaArgsForCall []struct {
@@ -142,6 +283,36 @@ type FakeHandler struct {
 		result1 [][]byte
 		result2 error
 	}
+	GetStrokesStub        func(context.Context, string, string, ...string) ([][]byte, error)
+	getStrokesMutex       sync.RWMutex
+	getStrokesArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 []string
+	}
+	getStrokesReturns struct {
+		result1 [][]byte
+		result2 error
+	}
+	getStrokesReturnsOnCall map[int]struct {
+		result1 [][]byte
+		result2 error
+	}
+	MovePageStub        func(context.Context, string, string, int) error
+	movePageMutex       sync.RWMutex
+	movePageArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 int
+	}
+	movePageReturns struct {
+		result1 error
+	}
+	movePageReturnsOnCall map[int]struct {
+		result1 error
+	}
 	PutStub        func(context.Context, string, any, time.Duration) error
 	putMutex       sync.RWMutex
 	putArgsForCall []struct {
@@ -156,6 +327,64 @@ type FakeHandler struct {
 	putReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RemoveChatStub        func(context.Context, string, []byte) (bool, error)
+	removeChatMutex       sync.RWMutex
+	removeChatArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 []byte
+	}
+	removeChatReturns struct {
+		result1 bool
+		result2 error
+	}
+	removeChatReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	SetAttachmentStub        func(context.Context, string, string, any) error
+	setAttachmentMutex       sync.RWMutex
+	setAttachmentArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 any
+	}
+	setAttachmentReturns struct {
+		result1 error
+	}
+	setAttachmentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetCommentStub        func(context.Context, string, string, string, any) error
+	setCommentMutex       sync.RWMutex
+	setCommentArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 any
+	}
+	setCommentReturns struct {
+		result1 error
+	}
+	setCommentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetPageLayersStub        func(context.Context, string, string, any) error
+	setPageLayersMutex       sync.RWMutex
+	setPageLayersArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 any
+	}
+	setPageLayersReturns struct {
+		result1 error
+	}
+	setPageLayersReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SetPageMetaStub        func(context.Context, string, string, any) error
 	setPageMetaMutex       sync.RWMutex
 	setPageMetaArgsForCall []struct {
@@ -252,6 +481,194 @@ func (fake *FakeHandler) AddPageReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeHandler) AppendChat(arg1 context.Context, arg2 string, arg3 any, arg4 int) error {
+	fake.appendChatMutex.Lock()
+	ret, specificReturn := fake.appendChatReturnsOnCall[len(fake.appendChatArgsForCall)]
+	fake.appendChatArgsForCall = append(fake.appendChatArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 any
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.AppendChatStub
+	fakeReturns := fake.appendChatReturns
+	fake.recordInvocation("AppendChat", []interface{}{arg1, arg2, arg3, arg4})
+	fake.appendChatMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHandler) AppendChatCallCount() int {
+	fake.appendChatMutex.RLock()
+	defer fake.appendChatMutex.RUnlock()
+	return len(fake.appendChatArgsForCall)
+}
+
+func (fake *FakeHandler) AppendChatCalls(stub func(context.Context, string, any, int) error) {
+	fake.appendChatMutex.Lock()
+	defer fake.appendChatMutex.Unlock()
+	fake.AppendChatStub = stub
+}
+
+func (fake *FakeHandler) AppendChatArgsForCall(i int) (context.Context, string, any, int) {
+	fake.appendChatMutex.RLock()
+	defer fake.appendChatMutex.RUnlock()
+	argsForCall := fake.appendChatArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeHandler) AppendChatReturns(result1 error) {
+	fake.appendChatMutex.Lock()
+	defer fake.appendChatMutex.Unlock()
+	fake.AppendChatStub = nil
+	fake.appendChatReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) AppendChatReturnsOnCall(i int, result1 error) {
+	fake.appendChatMutex.Lock()
+	defer fake.appendChatMutex.Unlock()
+	fake.AppendChatStub = nil
+	if fake.appendChatReturnsOnCall == nil {
+		fake.appendChatReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.appendChatReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) ClearAttachments(arg1 context.Context, arg2 string) error {
+	fake.clearAttachmentsMutex.Lock()
+	ret, specificReturn := fake.clearAttachmentsReturnsOnCall[len(fake.clearAttachmentsArgsForCall)]
+	fake.clearAttachmentsArgsForCall = append(fake.clearAttachmentsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ClearAttachmentsStub
+	fakeReturns := fake.clearAttachmentsReturns
+	fake.recordInvocation("ClearAttachments", []interface{}{arg1, arg2})
+	fake.clearAttachmentsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHandler) ClearAttachmentsCallCount() int {
+	fake.clearAttachmentsMutex.RLock()
+	defer fake.clearAttachmentsMutex.RUnlock()
+	return len(fake.clearAttachmentsArgsForCall)
+}
+
+func (fake *FakeHandler) ClearAttachmentsCalls(stub func(context.Context, string) error) {
+	fake.clearAttachmentsMutex.Lock()
+	defer fake.clearAttachmentsMutex.Unlock()
+	fake.ClearAttachmentsStub = stub
+}
+
+func (fake *FakeHandler) ClearAttachmentsArgsForCall(i int) (context.Context, string) {
+	fake.clearAttachmentsMutex.RLock()
+	defer fake.clearAttachmentsMutex.RUnlock()
+	argsForCall := fake.clearAttachmentsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeHandler) ClearAttachmentsReturns(result1 error) {
+	fake.clearAttachmentsMutex.Lock()
+	defer fake.clearAttachmentsMutex.Unlock()
+	fake.ClearAttachmentsStub = nil
+	fake.clearAttachmentsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) ClearAttachmentsReturnsOnCall(i int, result1 error) {
+	fake.clearAttachmentsMutex.Lock()
+	defer fake.clearAttachmentsMutex.Unlock()
+	fake.ClearAttachmentsStub = nil
+	if fake.clearAttachmentsReturnsOnCall == nil {
+		fake.clearAttachmentsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.clearAttachmentsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) ClearChat(arg1 context.Context, arg2 string) error {
+	fake.clearChatMutex.Lock()
+	ret, specificReturn := fake.clearChatReturnsOnCall[len(fake.clearChatArgsForCall)]
+	fake.clearChatArgsForCall = append(fake.clearChatArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ClearChatStub
+	fakeReturns := fake.clearChatReturns
+	fake.recordInvocation("ClearChat", []interface{}{arg1, arg2})
+	fake.clearChatMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHandler) ClearChatCallCount() int {
+	fake.clearChatMutex.RLock()
+	defer fake.clearChatMutex.RUnlock()
+	return len(fake.clearChatArgsForCall)
+}
+
+func (fake *FakeHandler) ClearChatCalls(stub func(context.Context, string) error) {
+	fake.clearChatMutex.Lock()
+	defer fake.clearChatMutex.Unlock()
+	fake.ClearChatStub = stub
+}
+
+func (fake *FakeHandler) ClearChatArgsForCall(i int) (context.Context, string) {
+	fake.clearChatMutex.RLock()
+	defer fake.clearChatMutex.RUnlock()
+	argsForCall := fake.clearChatArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeHandler) ClearChatReturns(result1 error) {
+	fake.clearChatMutex.Lock()
+	defer fake.clearChatMutex.Unlock()
+	fake.ClearChatStub = nil
+	fake.clearChatReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) ClearChatReturnsOnCall(i int, result1 error) {
+	fake.clearChatMutex.Lock()
+	defer fake.clearChatMutex.Unlock()
+	fake.ClearChatStub = nil
+	if fake.clearChatReturnsOnCall == nil {
+		fake.clearChatReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.clearChatReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeHandler) ClearPage(arg1 context.Context, arg2 string, arg3 string) error {
 	fake.clearPageMutex.Lock()
 	ret, specificReturn := fake.clearPageReturnsOnCall[len(fake.clearPageArgsForCall)]
@@ -492,49 +909,182 @@ func (fake *FakeHandler) DeleteReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeHandler) DeletePage(arg1 context.Context, arg2 string, arg3 string) error {
-	fake.deletePageMutex.Lock()
-	ret, specificReturn := fake.deletePageReturnsOnCall[len(fake.deletePageArgsForCall)]
-	fake.deletePageArgsForCall = append(fake.deletePageArgsForCall, struct {
+func (fake *FakeHandler) DeleteAttachment(arg1 context.Context, arg2 string, arg3 string) (bool, error) {
+	fake.deleteAttachmentMutex.Lock()
+	ret, specificReturn := fake.deleteAttachmentReturnsOnCall[len(fake.deleteAttachmentArgsForCall)]
+	fake.deleteAttachmentArgsForCall = append(fake.deleteAttachmentArgsForCall, struct {
 		arg1 context.Context
 		arg2 string
 		arg3 string
 	}{arg1, arg2, arg3})
-	stub := fake.DeletePageStub
-	fakeReturns := fake.deletePageReturns
-	fake.recordInvocation("DeletePage", []interface{}{arg1, arg2, arg3})
-	fake.deletePageMutex.Unlock()
+	stub := fake.DeleteAttachmentStub
+	fakeReturns := fake.deleteAttachmentReturns
+	fake.recordInvocation("DeleteAttachment", []interface{}{arg1, arg2, arg3})
+	fake.deleteAttachmentMutex.Unlock()
 	if stub != nil {
 		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
-	return fakeReturns.result1
+	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeHandler) DeletePageCallCount() int {
-	fake.deletePageMutex.RLock()
-	defer fake.deletePageMutex.RUnlock()
-	return len(fake.deletePageArgsForCall)
+func (fake *FakeHandler) DeleteAttachmentCallCount() int {
+	fake.deleteAttachmentMutex.RLock()
+	defer fake.deleteAttachmentMutex.RUnlock()
+	return len(fake.deleteAttachmentArgsForCall)
 }
 
-func (fake *FakeHandler) DeletePageCalls(stub func(context.Context, string, string) error) {
-	fake.deletePageMutex.Lock()
-	defer fake.deletePageMutex.Unlock()
-	fake.DeletePageStub = stub
+func (fake *FakeHandler) DeleteAttachmentCalls(stub func(context.Context, string, string) (bool, error)) {
+	fake.deleteAttachmentMutex.Lock()
+	defer fake.deleteAttachmentMutex.Unlock()
+	fake.DeleteAttachmentStub = stub
 }
 
-func (fake *FakeHandler) DeletePageArgsForCall(i int) (context.Context, string, string) {
-	fake.deletePageMutex.RLock()
-	defer fake.deletePageMutex.RUnlock()
-	argsForCall := fake.deletePageArgsForCall[i]
+func (fake *FakeHandler) DeleteAttachmentArgsForCall(i int) (context.Context, string, string) {
+	fake.deleteAttachmentMutex.RLock()
+	defer fake.deleteAttachmentMutex.RUnlock()
+	argsForCall := fake.deleteAttachmentArgsForCall[i]
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeHandler) DeletePageReturns(result1 error) {
-	fake.deletePageMutex.Lock()
-	defer fake.deletePageMutex.Unlock()
+func (fake *FakeHandler) DeleteAttachmentReturns(result1 bool, result2 error) {
+	fake.deleteAttachmentMutex.Lock()
+	defer fake.deleteAttachmentMutex.Unlock()
+	fake.DeleteAttachmentStub = nil
+	fake.deleteAttachmentReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) DeleteAttachmentReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.deleteAttachmentMutex.Lock()
+	defer fake.deleteAttachmentMutex.Unlock()
+	fake.DeleteAttachmentStub = nil
+	if fake.deleteAttachmentReturnsOnCall == nil {
+		fake.deleteAttachmentReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.deleteAttachmentReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) DeleteComment(arg1 context.Context, arg2 string, arg3 string, arg4 string) (bool, error) {
+	fake.deleteCommentMutex.Lock()
+	ret, specificReturn := fake.deleteCommentReturnsOnCall[len(fake.deleteCommentArgsForCall)]
+	fake.deleteCommentArgsForCall = append(fake.deleteCommentArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.DeleteCommentStub
+	fakeReturns := fake.deleteCommentReturns
+	fake.recordInvocation("DeleteComment", []interface{}{arg1, arg2, arg3, arg4})
+	fake.deleteCommentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHandler) DeleteCommentCallCount() int {
+	fake.deleteCommentMutex.RLock()
+	defer fake.deleteCommentMutex.RUnlock()
+	return len(fake.deleteCommentArgsForCall)
+}
+
+func (fake *FakeHandler) DeleteCommentCalls(stub func(context.Context, string, string, string) (bool, error)) {
+	fake.deleteCommentMutex.Lock()
+	defer fake.deleteCommentMutex.Unlock()
+	fake.DeleteCommentStub = stub
+}
+
+func (fake *FakeHandler) DeleteCommentArgsForCall(i int) (context.Context, string, string, string) {
+	fake.deleteCommentMutex.RLock()
+	defer fake.deleteCommentMutex.RUnlock()
+	argsForCall := fake.deleteCommentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeHandler) DeleteCommentReturns(result1 bool, result2 error) {
+	fake.deleteCommentMutex.Lock()
+	defer fake.deleteCommentMutex.Unlock()
+	fake.DeleteCommentStub = nil
+	fake.deleteCommentReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) DeleteCommentReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.deleteCommentMutex.Lock()
+	defer fake.deleteCommentMutex.Unlock()
+	fake.DeleteCommentStub = nil
+	if fake.deleteCommentReturnsOnCall == nil {
+		fake.deleteCommentReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.deleteCommentReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) DeletePage(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.deletePageMutex.Lock()
+	ret, specificReturn := fake.deletePageReturnsOnCall[len(fake.deletePageArgsForCall)]
+	fake.deletePageArgsForCall = append(fake.deletePageArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.DeletePageStub
+	fakeReturns := fake.deletePageReturns
+	fake.recordInvocation("DeletePage", []interface{}{arg1, arg2, arg3})
+	fake.deletePageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHandler) DeletePageCallCount() int {
+	fake.deletePageMutex.RLock()
+	defer fake.deletePageMutex.RUnlock()
+	return len(fake.deletePageArgsForCall)
+}
+
+func (fake *FakeHandler) DeletePageCalls(stub func(context.Context, string, string) error) {
+	fake.deletePageMutex.Lock()
+	defer fake.deletePageMutex.Unlock()
+	fake.DeletePageStub = stub
+}
+
+func (fake *FakeHandler) DeletePageArgsForCall(i int) (context.Context, string, string) {
+	fake.deletePageMutex.RLock()
+	defer fake.deletePageMutex.RUnlock()
+	argsForCall := fake.deletePageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeHandler) DeletePageReturns(result1 error) {
+	fake.deletePageMutex.Lock()
+	defer fake.deletePageMutex.Unlock()
 	fake.DeletePageStub = nil
 	fake.deletePageReturns = struct {
 		result1 error
@@ -555,6 +1105,71 @@ func (fake *FakeHandler) DeletePageReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeHandler) DuplicatePage(arg1 context.Context, arg2 string, arg3 string, arg4 string, arg5 int) error {
+	fake.duplicatePageMutex.Lock()
+	ret, specificReturn := fake.duplicatePageReturnsOnCall[len(fake.duplicatePageArgsForCall)]
+	fake.duplicatePageArgsForCall = append(fake.duplicatePageArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 int
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.DuplicatePageStub
+	fakeReturns := fake.duplicatePageReturns
+	fake.recordInvocation("DuplicatePage", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.duplicatePageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHandler) DuplicatePageCallCount() int {
+	fake.duplicatePageMutex.RLock()
+	defer fake.duplicatePageMutex.RUnlock()
+	return len(fake.duplicatePageArgsForCall)
+}
+
+func (fake *FakeHandler) DuplicatePageCalls(stub func(context.Context, string, string, string, int) error) {
+	fake.duplicatePageMutex.Lock()
+	defer fake.duplicatePageMutex.Unlock()
+	fake.DuplicatePageStub = stub
+}
+
+func (fake *FakeHandler) DuplicatePageArgsForCall(i int) (context.Context, string, string, string, int) {
+	fake.duplicatePageMutex.RLock()
+	defer fake.duplicatePageMutex.RUnlock()
+	argsForCall := fake.duplicatePageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeHandler) DuplicatePageReturns(result1 error) {
+	fake.duplicatePageMutex.Lock()
+	defer fake.duplicatePageMutex.Unlock()
+	fake.DuplicatePageStub = nil
+	fake.duplicatePageReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) DuplicatePageReturnsOnCall(i int, result1 error) {
+	fake.duplicatePageMutex.Lock()
+	defer fake.duplicatePageMutex.Unlock()
+	fake.DuplicatePageStub = nil
+	if fake.duplicatePageReturnsOnCall == nil {
+		fake.duplicatePageReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.duplicatePageReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeHandler) Get(arg1 context.Context, arg2 string) (any, error) {
 	fake.getMutex.Lock()
 	ret, specificReturn := fake.getReturnsOnCall[len(fake.getArgsForCall)]
@@ -620,6 +1235,266 @@ func (fake *FakeHandler) GetReturnsOnCall(i int, result1 any, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakeHandler) GetAttachments(arg1 context.Context, arg2 string) ([][]byte, error) {
+	fake.getAttachmentsMutex.Lock()
+	ret, specificReturn := fake.getAttachmentsReturnsOnCall[len(fake.getAttachmentsArgsForCall)]
+	fake.getAttachmentsArgsForCall = append(fake.getAttachmentsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetAttachmentsStub
+	fakeReturns := fake.getAttachmentsReturns
+	fake.recordInvocation("GetAttachments", []interface{}{arg1, arg2})
+	fake.getAttachmentsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHandler) GetAttachmentsCallCount() int {
+	fake.getAttachmentsMutex.RLock()
+	defer fake.getAttachmentsMutex.RUnlock()
+	return len(fake.getAttachmentsArgsForCall)
+}
+
+func (fake *FakeHandler) GetAttachmentsCalls(stub func(context.Context, string) ([][]byte, error)) {
+	fake.getAttachmentsMutex.Lock()
+	defer fake.getAttachmentsMutex.Unlock()
+	fake.GetAttachmentsStub = stub
+}
+
+func (fake *FakeHandler) GetAttachmentsArgsForCall(i int) (context.Context, string) {
+	fake.getAttachmentsMutex.RLock()
+	defer fake.getAttachmentsMutex.RUnlock()
+	argsForCall := fake.getAttachmentsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeHandler) GetAttachmentsReturns(result1 [][]byte, result2 error) {
+	fake.getAttachmentsMutex.Lock()
+	defer fake.getAttachmentsMutex.Unlock()
+	fake.GetAttachmentsStub = nil
+	fake.getAttachmentsReturns = struct {
+		result1 [][]byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) GetAttachmentsReturnsOnCall(i int, result1 [][]byte, result2 error) {
+	fake.getAttachmentsMutex.Lock()
+	defer fake.getAttachmentsMutex.Unlock()
+	fake.GetAttachmentsStub = nil
+	if fake.getAttachmentsReturnsOnCall == nil {
+		fake.getAttachmentsReturnsOnCall = make(map[int]struct {
+			result1 [][]byte
+			result2 error
+		})
+	}
+	fake.getAttachmentsReturnsOnCall[i] = struct {
+		result1 [][]byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) GetChat(arg1 context.Context, arg2 string) ([][]byte, error) {
+	fake.getChatMutex.Lock()
+	ret, specificReturn := fake.getChatReturnsOnCall[len(fake.getChatArgsForCall)]
+	fake.getChatArgsForCall = append(fake.getChatArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetChatStub
+	fakeReturns := fake.getChatReturns
+	fake.recordInvocation("GetChat", []interface{}{arg1, arg2})
+	fake.getChatMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHandler) GetChatCallCount() int {
+	fake.getChatMutex.RLock()
+	defer fake.getChatMutex.RUnlock()
+	return len(fake.getChatArgsForCall)
+}
+
+func (fake *FakeHandler) GetChatCalls(stub func(context.Context, string) ([][]byte, error)) {
+	fake.getChatMutex.Lock()
+	defer fake.getChatMutex.Unlock()
+	fake.GetChatStub = stub
+}
+
+func (fake *FakeHandler) GetChatArgsForCall(i int) (context.Context, string) {
+	fake.getChatMutex.RLock()
+	defer fake.getChatMutex.RUnlock()
+	argsForCall := fake.getChatArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeHandler) GetChatReturns(result1 [][]byte, result2 error) {
+	fake.getChatMutex.Lock()
+	defer fake.getChatMutex.Unlock()
+	fake.GetChatStub = nil
+	fake.getChatReturns = struct {
+		result1 [][]byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) GetChatReturnsOnCall(i int, result1 [][]byte, result2 error) {
+	fake.getChatMutex.Lock()
+	defer fake.getChatMutex.Unlock()
+	fake.GetChatStub = nil
+	if fake.getChatReturnsOnCall == nil {
+		fake.getChatReturnsOnCall = make(map[int]struct {
+			result1 [][]byte
+			result2 error
+		})
+	}
+	fake.getChatReturnsOnCall[i] = struct {
+		result1 [][]byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) GetComments(arg1 context.Context, arg2 string, arg3 string) ([][]byte, error) {
+	fake.getCommentsMutex.Lock()
+	ret, specificReturn := fake.getCommentsReturnsOnCall[len(fake.getCommentsArgsForCall)]
+	fake.getCommentsArgsForCall = append(fake.getCommentsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.GetCommentsStub
+	fakeReturns := fake.getCommentsReturns
+	fake.recordInvocation("GetComments", []interface{}{arg1, arg2, arg3})
+	fake.getCommentsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHandler) GetCommentsCallCount() int {
+	fake.getCommentsMutex.RLock()
+	defer fake.getCommentsMutex.RUnlock()
+	return len(fake.getCommentsArgsForCall)
+}
+
+func (fake *FakeHandler) GetCommentsCalls(stub func(context.Context, string, string) ([][]byte, error)) {
+	fake.getCommentsMutex.Lock()
+	defer fake.getCommentsMutex.Unlock()
+	fake.GetCommentsStub = stub
+}
+
+func (fake *FakeHandler) GetCommentsArgsForCall(i int) (context.Context, string, string) {
+	fake.getCommentsMutex.RLock()
+	defer fake.getCommentsMutex.RUnlock()
+	argsForCall := fake.getCommentsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeHandler) GetCommentsReturns(result1 [][]byte, result2 error) {
+	fake.getCommentsMutex.Lock()
+	defer fake.getCommentsMutex.Unlock()
+	fake.GetCommentsStub = nil
+	fake.getCommentsReturns = struct {
+		result1 [][]byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) GetCommentsReturnsOnCall(i int, result1 [][]byte, result2 error) {
+	fake.getCommentsMutex.Lock()
+	defer fake.getCommentsMutex.Unlock()
+	fake.GetCommentsStub = nil
+	if fake.getCommentsReturnsOnCall == nil {
+		fake.getCommentsReturnsOnCall = make(map[int]struct {
+			result1 [][]byte
+			result2 error
+		})
+	}
+	fake.getCommentsReturnsOnCall[i] = struct {
+		result1 [][]byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) GetPageLayers(arg1 context.Context, arg2 string, arg3 string, arg4 any) error {
+	fake.getPageLayersMutex.Lock()
+	ret, specificReturn := fake.getPageLayersReturnsOnCall[len(fake.getPageLayersArgsForCall)]
+	fake.getPageLayersArgsForCall = append(fake.getPageLayersArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 any
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.GetPageLayersStub
+	fakeReturns := fake.getPageLayersReturns
+	fake.recordInvocation("GetPageLayers", []interface{}{arg1, arg2, arg3, arg4})
+	fake.getPageLayersMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHandler) GetPageLayersCallCount() int {
+	fake.getPageLayersMutex.RLock()
+	defer fake.getPageLayersMutex.RUnlock()
+	return len(fake.getPageLayersArgsForCall)
+}
+
+func (fake *FakeHandler) GetPageLayersCalls(stub func(context.Context, string, string, any) error) {
+	fake.getPageLayersMutex.Lock()
+	defer fake.getPageLayersMutex.Unlock()
+	fake.GetPageLayersStub = stub
+}
+
+func (fake *FakeHandler) GetPageLayersArgsForCall(i int) (context.Context, string, string, any) {
+	fake.getPageLayersMutex.RLock()
+	defer fake.getPageLayersMutex.RUnlock()
+	argsForCall := fake.getPageLayersArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeHandler) GetPageLayersReturns(result1 error) {
+	fake.getPageLayersMutex.Lock()
+	defer fake.getPageLayersMutex.Unlock()
+	fake.GetPageLayersStub = nil
+	fake.getPageLayersReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) GetPageLayersReturnsOnCall(i int, result1 error) {
+	fake.getPageLayersMutex.Lock()
+	defer fake.getPageLayersMutex.Unlock()
+	fake.GetPageLayersStub = nil
+	if fake.getPageLayersReturnsOnCall == nil {
+		fake.getPageLayersReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.getPageLayersReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeHandler) GetPageMeta(arg1 context.Context, arg2 string, arg3 string, arg4 any) error {
 	fake.getPageMetaMutex.Lock()
 	ret, specificReturn := fake.getPageMetaReturnsOnCall[len(fake.getPageMetaArgsForCall)]
@@ -815,6 +1690,137 @@ func (fake *FakeHandler) GetPageStrokesReturnsOnCall(i int, result1 [][]byte, re
 	}{result1, result2}
 }
 
+func (fake *FakeHandler) GetStrokes(arg1 context.Context, arg2 string, arg3 string, arg4 ...string) ([][]byte, error) {
+	fake.getStrokesMutex.Lock()
+	ret, specificReturn := fake.getStrokesReturnsOnCall[len(fake.getStrokesArgsForCall)]
+	fake.getStrokesArgsForCall = append(fake.getStrokesArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 []string
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.GetStrokesStub
+	fakeReturns := fake.getStrokesReturns
+	fake.recordInvocation("GetStrokes", []interface{}{arg1, arg2, arg3, arg4})
+	fake.getStrokesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHandler) GetStrokesCallCount() int {
+	fake.getStrokesMutex.RLock()
+	defer fake.getStrokesMutex.RUnlock()
+	return len(fake.getStrokesArgsForCall)
+}
+
+func (fake *FakeHandler) GetStrokesCalls(stub func(context.Context, string, string, ...string) ([][]byte, error)) {
+	fake.getStrokesMutex.Lock()
+	defer fake.getStrokesMutex.Unlock()
+	fake.GetStrokesStub = stub
+}
+
+func (fake *FakeHandler) GetStrokesArgsForCall(i int) (context.Context, string, string, []string) {
+	fake.getStrokesMutex.RLock()
+	defer fake.getStrokesMutex.RUnlock()
+	argsForCall := fake.getStrokesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeHandler) GetStrokesReturns(result1 [][]byte, result2 error) {
+	fake.getStrokesMutex.Lock()
+	defer fake.getStrokesMutex.Unlock()
+	fake.GetStrokesStub = nil
+	fake.getStrokesReturns = struct {
+		result1 [][]byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) GetStrokesReturnsOnCall(i int, result1 [][]byte, result2 error) {
+	fake.getStrokesMutex.Lock()
+	defer fake.getStrokesMutex.Unlock()
+	fake.GetStrokesStub = nil
+	if fake.getStrokesReturnsOnCall == nil {
+		fake.getStrokesReturnsOnCall = make(map[int]struct {
+			result1 [][]byte
+			result2 error
+		})
+	}
+	fake.getStrokesReturnsOnCall[i] = struct {
+		result1 [][]byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) MovePage(arg1 context.Context, arg2 string, arg3 string, arg4 int) error {
+	fake.movePageMutex.Lock()
+	ret, specificReturn := fake.movePageReturnsOnCall[len(fake.movePageArgsForCall)]
+	fake.movePageArgsForCall = append(fake.movePageArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.MovePageStub
+	fakeReturns := fake.movePageReturns
+	fake.recordInvocation("MovePage", []interface{}{arg1, arg2, arg3, arg4})
+	fake.movePageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHandler) MovePageCallCount() int {
+	fake.movePageMutex.RLock()
+	defer fake.movePageMutex.RUnlock()
+	return len(fake.movePageArgsForCall)
+}
+
+func (fake *FakeHandler) MovePageCalls(stub func(context.Context, string, string, int) error) {
+	fake.movePageMutex.Lock()
+	defer fake.movePageMutex.Unlock()
+	fake.MovePageStub = stub
+}
+
+func (fake *FakeHandler) MovePageArgsForCall(i int) (context.Context, string, string, int) {
+	fake.movePageMutex.RLock()
+	defer fake.movePageMutex.RUnlock()
+	argsForCall := fake.movePageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeHandler) MovePageReturns(result1 error) {
+	fake.movePageMutex.Lock()
+	defer fake.movePageMutex.Unlock()
+	fake.MovePageStub = nil
+	fake.movePageReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) MovePageReturnsOnCall(i int, result1 error) {
+	fake.movePageMutex.Lock()
+	defer fake.movePageMutex.Unlock()
+	fake.MovePageStub = nil
+	if fake.movePageReturnsOnCall == nil {
+		fake.movePageReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.movePageReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeHandler) Put(arg1 context.Context, arg2 string, arg3 any, arg4 time.Duration) error {
 	fake.putMutex.Lock()
 	ret, specificReturn := fake.putReturnsOnCall[len(fake.putArgsForCall)]
@@ -879,6 +1885,270 @@ func (fake *FakeHandler) PutReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeHandler) RemoveChat(arg1 context.Context, arg2 string, arg3 []byte) (bool, error) {
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.removeChatMutex.Lock()
+	ret, specificReturn := fake.removeChatReturnsOnCall[len(fake.removeChatArgsForCall)]
+	fake.removeChatArgsForCall = append(fake.removeChatArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 []byte
+	}{arg1, arg2, arg3Copy})
+	stub := fake.RemoveChatStub
+	fakeReturns := fake.removeChatReturns
+	fake.recordInvocation("RemoveChat", []interface{}{arg1, arg2, arg3Copy})
+	fake.removeChatMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHandler) RemoveChatCallCount() int {
+	fake.removeChatMutex.RLock()
+	defer fake.removeChatMutex.RUnlock()
+	return len(fake.removeChatArgsForCall)
+}
+
+func (fake *FakeHandler) RemoveChatCalls(stub func(context.Context, string, []byte) (bool, error)) {
+	fake.removeChatMutex.Lock()
+	defer fake.removeChatMutex.Unlock()
+	fake.RemoveChatStub = stub
+}
+
+func (fake *FakeHandler) RemoveChatArgsForCall(i int) (context.Context, string, []byte) {
+	fake.removeChatMutex.RLock()
+	defer fake.removeChatMutex.RUnlock()
+	argsForCall := fake.removeChatArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeHandler) RemoveChatReturns(result1 bool, result2 error) {
+	fake.removeChatMutex.Lock()
+	defer fake.removeChatMutex.Unlock()
+	fake.RemoveChatStub = nil
+	fake.removeChatReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) RemoveChatReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.removeChatMutex.Lock()
+	defer fake.removeChatMutex.Unlock()
+	fake.RemoveChatStub = nil
+	if fake.removeChatReturnsOnCall == nil {
+		fake.removeChatReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.removeChatReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeHandler) SetAttachment(arg1 context.Context, arg2 string, arg3 string, arg4 any) error {
+	fake.setAttachmentMutex.Lock()
+	ret, specificReturn := fake.setAttachmentReturnsOnCall[len(fake.setAttachmentArgsForCall)]
+	fake.setAttachmentArgsForCall = append(fake.setAttachmentArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 any
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.SetAttachmentStub
+	fakeReturns := fake.setAttachmentReturns
+	fake.recordInvocation("SetAttachment", []interface{}{arg1, arg2, arg3, arg4})
+	fake.setAttachmentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHandler) SetAttachmentCallCount() int {
+	fake.setAttachmentMutex.RLock()
+	defer fake.setAttachmentMutex.RUnlock()
+	return len(fake.setAttachmentArgsForCall)
+}
+
+func (fake *FakeHandler) SetAttachmentCalls(stub func(context.Context, string, string, any) error) {
+	fake.setAttachmentMutex.Lock()
+	defer fake.setAttachmentMutex.Unlock()
+	fake.SetAttachmentStub = stub
+}
+
+func (fake *FakeHandler) SetAttachmentArgsForCall(i int) (context.Context, string, string, any) {
+	fake.setAttachmentMutex.RLock()
+	defer fake.setAttachmentMutex.RUnlock()
+	argsForCall := fake.setAttachmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeHandler) SetAttachmentReturns(result1 error) {
+	fake.setAttachmentMutex.Lock()
+	defer fake.setAttachmentMutex.Unlock()
+	fake.SetAttachmentStub = nil
+	fake.setAttachmentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) SetAttachmentReturnsOnCall(i int, result1 error) {
+	fake.setAttachmentMutex.Lock()
+	defer fake.setAttachmentMutex.Unlock()
+	fake.SetAttachmentStub = nil
+	if fake.setAttachmentReturnsOnCall == nil {
+		fake.setAttachmentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setAttachmentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) SetComment(arg1 context.Context, arg2 string, arg3 string, arg4 string, arg5 any) error {
+	fake.setCommentMutex.Lock()
+	ret, specificReturn := fake.setCommentReturnsOnCall[len(fake.setCommentArgsForCall)]
+	fake.setCommentArgsForCall = append(fake.setCommentArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 any
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.SetCommentStub
+	fakeReturns := fake.setCommentReturns
+	fake.recordInvocation("SetComment", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.setCommentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHandler) SetCommentCallCount() int {
+	fake.setCommentMutex.RLock()
+	defer fake.setCommentMutex.RUnlock()
+	return len(fake.setCommentArgsForCall)
+}
+
+func (fake *FakeHandler) SetCommentCalls(stub func(context.Context, string, string, string, any) error) {
+	fake.setCommentMutex.Lock()
+	defer fake.setCommentMutex.Unlock()
+	fake.SetCommentStub = stub
+}
+
+func (fake *FakeHandler) SetCommentArgsForCall(i int) (context.Context, string, string, string, any) {
+	fake.setCommentMutex.RLock()
+	defer fake.setCommentMutex.RUnlock()
+	argsForCall := fake.setCommentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeHandler) SetCommentReturns(result1 error) {
+	fake.setCommentMutex.Lock()
+	defer fake.setCommentMutex.Unlock()
+	fake.SetCommentStub = nil
+	fake.setCommentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) SetCommentReturnsOnCall(i int, result1 error) {
+	fake.setCommentMutex.Lock()
+	defer fake.setCommentMutex.Unlock()
+	fake.SetCommentStub = nil
+	if fake.setCommentReturnsOnCall == nil {
+		fake.setCommentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setCommentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) SetPageLayers(arg1 context.Context, arg2 string, arg3 string, arg4 any) error {
+	fake.setPageLayersMutex.Lock()
+	ret, specificReturn := fake.setPageLayersReturnsOnCall[len(fake.setPageLayersArgsForCall)]
+	fake.setPageLayersArgsForCall = append(fake.setPageLayersArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 any
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.SetPageLayersStub
+	fakeReturns := fake.setPageLayersReturns
+	fake.recordInvocation("SetPageLayers", []interface{}{arg1, arg2, arg3, arg4})
+	fake.setPageLayersMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHandler) SetPageLayersCallCount() int {
+	fake.setPageLayersMutex.RLock()
+	defer fake.setPageLayersMutex.RUnlock()
+	return len(fake.setPageLayersArgsForCall)
+}
+
+func (fake *FakeHandler) SetPageLayersCalls(stub func(context.Context, string, string, any) error) {
+	fake.setPageLayersMutex.Lock()
+	defer fake.setPageLayersMutex.Unlock()
+	fake.SetPageLayersStub = stub
+}
+
+func (fake *FakeHandler) SetPageLayersArgsForCall(i int) (context.Context, string, string, any) {
+	fake.setPageLayersMutex.RLock()
+	defer fake.setPageLayersMutex.RUnlock()
+	argsForCall := fake.setPageLayersArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeHandler) SetPageLayersReturns(result1 error) {
+	fake.setPageLayersMutex.Lock()
+	defer fake.setPageLayersMutex.Unlock()
+	fake.SetPageLayersStub = nil
+	fake.setPageLayersReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeHandler) SetPageLayersReturnsOnCall(i int, result1 error) {
+	fake.setPageLayersMutex.Lock()
+	defer fake.setPageLayersMutex.Unlock()
+	fake.SetPageLayersStub = nil
+	if fake.setPageLayersReturnsOnCall == nil {
+		fake.setPageLayersReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setPageLayersReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeHandler) SetPageMeta(arg1 context.Context, arg2 string, arg3 string, arg4 any) error {
 	fake.setPageMetaMutex.Lock()
 	ret, specificReturn := fake.setPageMetaReturnsOnCall[len(fake.setPageMetaArgsForCall)]
@@ -1011,6 +2281,12 @@ func (fake *FakeHandler) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.addPageMutex.RLock()
 	defer fake.addPageMutex.RUnlock()
+	fake.appendChatMutex.RLock()
+	defer fake.appendChatMutex.RUnlock()
+	fake.clearAttachmentsMutex.RLock()
+	defer fake.clearAttachmentsMutex.RUnlock()
+	fake.clearChatMutex.RLock()
+	defer fake.clearChatMutex.RUnlock()
 	fake.clearPageMutex.RLock()
 	defer fake.clearPageMutex.RUnlock()
 	fake.clearSessionMutex.RLock()
@@ -1019,18 +2295,44 @@ func (fake *FakeHandler) Invocations() map[string][][]interface{} {
 	defer fake.closePoolMutex.RUnlock()
 	fake.deleteMutex.RLock()
 	defer fake.deleteMutex.RUnlock()
+	fake.deleteAttachmentMutex.RLock()
+	defer fake.deleteAttachmentMutex.RUnlock()
+	fake.deleteCommentMutex.RLock()
+	defer fake.deleteCommentMutex.RUnlock()
 	fake.deletePageMutex.RLock()
 	defer fake.deletePageMutex.RUnlock()
+	fake.duplicatePageMutex.RLock()
+	defer fake.duplicatePageMutex.RUnlock()
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
+	fake.getAttachmentsMutex.RLock()
+	defer fake.getAttachmentsMutex.RUnlock()
+	fake.getChatMutex.RLock()
+	defer fake.getChatMutex.RUnlock()
+	fake.getCommentsMutex.RLock()
+	defer fake.getCommentsMutex.RUnlock()
+	fake.getPageLayersMutex.RLock()
+	defer fake.getPageLayersMutex.RUnlock()
 	fake.getPageMetaMutex.RLock()
 	defer fake.getPageMetaMutex.RUnlock()
 	fake.getPageRankMutex.RLock()
 	defer fake.getPageRankMutex.RUnlock()
 	fake.getPageStrokesMutex.RLock()
 	defer fake.getPageStrokesMutex.RUnlock()
+	fake.getStrokesMutex.RLock()
+	defer fake.getStrokesMutex.RUnlock()
+	fake.movePageMutex.RLock()
+	defer fake.movePageMutex.RUnlock()
 	fake.putMutex.RLock()
 	defer fake.putMutex.RUnlock()
+	fake.removeChatMutex.RLock()
+	defer fake.removeChatMutex.RUnlock()
+	fake.setAttachmentMutex.RLock()
+	defer fake.setAttachmentMutex.RUnlock()
+	fake.setCommentMutex.RLock()
+	defer fake.setCommentMutex.RUnlock()
+	fake.setPageLayersMutex.RLock()
+	defer fake.setPageLayersMutex.RUnlock()
 	fake.setPageMetaMutex.RLock()
 	defer fake.setPageMetaMutex.RUnlock()
 	fake.updateStrokesMutex.RLock()
@@ -3,11 +3,18 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/gomodule/redigo/redis"
 )
 
+// maxTxAttempts bounds the attempts of optimistic transactions on contended keys
+const maxTxAttempts = 16
+
+// ErrTxAborted indicates that an optimistic transaction was aborted too often.
+var ErrTxAborted = errors.New("transaction aborted")
+
 type Stroke interface {
 	Id() string
 	PageId() string
@@ -29,6 +36,11 @@ func getPageMetaKey(sessionId, pageId string) string {
 	return fmt.Sprintf("%s.%s.meta", sessionId, pageId)
 }
 
+// getPageLayersKey returns the redis key for the layers of a page.
+func getPageLayersKey(sessionId, pageId string) string {
+	return fmt.Sprintf("%s.%s.layers", sessionId, pageId)
+}
+
 func (h *handler) UpdateStrokes(ctx context.Context, sessionId string, strokes ...Stroke) error {
 	conn, err := h.pool.GetContext(ctx)
 	if err != nil {
@@ -36,6 +48,10 @@ func (h *handler) UpdateStrokes(ctx context.Context, sessionId string, strokes .
 	}
 	defer conn.Close()
 
+	// apply all updates atomically
+	if err := conn.Send("MULTI"); err != nil {
+		return err
+	}
 	for _, s := range strokes {
 		pid := getStrokesKey(sessionId, s.PageId())
 		if s.IsDeleted() {
@@ -53,7 +69,8 @@ func (h *handler) UpdateStrokes(ctx context.Context, sessionId string, strokes .
 		}
 
 	}
-	return conn.Flush()
+	_, err = conn.Do("EXEC")
+	return err
 }
 
 func (h *handler) GetPageStrokes(ctx context.Context, sessionId, pageId string) ([][]byte, error) {
@@ -75,6 +92,30 @@ func (h *handler) GetPageStrokes(ctx context.Context, sessionId, pageId string)
 	return redis.ByteSlices(h.Do(ctx, "HMGET", query...))
 }
 
+func (h *handler) GetStrokes(ctx context.Context, sessionId, pageId string, strokeIds ...string) ([][]byte, error) {
+	if len(strokeIds) == 0 {
+		return [][]byte{}, nil
+	}
+
+	query := make([]any, 1, len(strokeIds)+1)
+	query[0] = getStrokesKey(sessionId, pageId)
+	for _, id := range strokeIds {
+		query = append(query, id)
+	}
+
+	resp, err := redis.ByteSlices(h.Do(ctx, "HMGET", query...))
+	if err != nil {
+		return nil, err
+	}
+	strokes := resp[:0]
+	for _, s := range resp {
+		if s != nil {
+			strokes = append(strokes, s)
+		}
+	}
+	return strokes, nil
+}
+
 func (h *handler) GetPageRank(ctx context.Context, sessionId string) ([]string, error) {
 	pages, err := redis.Strings(h.Do(ctx, "ZRANGE", getPageRankKey(sessionId), 0, -1))
 	if err != nil {
@@ -103,90 +144,137 @@ func (h *handler) SetPageMeta(ctx context.Context, sessionId, pageId string, met
 	return err
 }
 
-func (h *handler) AddPage(ctx context.Context, sessionId, newpageId string, index int, meta any) error {
-	conn, err := h.pool.GetContext(ctx)
+func (h *handler) GetPageLayers(ctx context.Context, sessionId, pageId string, layers any) error {
+	resp, err := redis.Bytes(h.Do(ctx, "GET", getPageLayersKey(sessionId, pageId)))
+	if errors.Is(err, redis.ErrNil) { // page without layers
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
+	return json.Unmarshal(resp, layers)
+}
+
+func (h *handler) SetPageLayers(ctx context.Context, sessionId, pageId string, layers any) error {
+	pLayers, err := json.Marshal(layers)
+	if err != nil {
+		return err
+	}
+	_, err = h.Do(ctx, "SET", getPageLayersKey(sessionId, pageId), pLayers)
+	return err
+}
 
-	if meta != nil {
-		pMeta, err := json.Marshal(meta)
+func (h *handler) DuplicatePage(ctx context.Context, sessionId, pageId, newPageId string, index int) error {
+	pageRankKey := getPageRankKey(sessionId)
+	strokesKey := getStrokesKey(sessionId, pageId)
+	metaKey := getPageMetaKey(sessionId, pageId)
+	layersKey := getPageLayersKey(sessionId, pageId)
+
+	return h.watchTx(ctx, []string{pageRankKey, strokesKey, metaKey, layersKey}, func(conn redis.Conn) error {
+		if _, err := redis.Float64(conn.Do("ZSCORE", pageRankKey, pageId)); err != nil {
+			return fmt.Errorf("page %s does not exist: %w", pageId, err)
+		}
+		if _, err := redis.Float64(conn.Do("ZSCORE", pageRankKey, newPageId)); !errors.Is(err, redis.ErrNil) {
+			return fmt.Errorf("page %s already exists", newPageId)
+		}
+		strokes, err := redis.StringMap(conn.Do("HGETALL", strokesKey))
 		if err != nil {
 			return err
 		}
-		if _, err := conn.Do(
-			"SET", getPageMetaKey(sessionId, newpageId), pMeta); err != nil {
+		meta, err := redis.Bytes(conn.Do("GET", metaKey))
+		if err != nil && !errors.Is(err, redis.ErrNil) {
+			return err
+		}
+		layers, err := redis.Bytes(conn.Do("GET", layersKey))
+		if err != nil && !errors.Is(err, redis.ErrNil) {
 			return err
 		}
-	}
-
-	// get all pageIds
-	pageRankKey := getPageRankKey(sessionId)
-	pageRank, err := h.GetPageRank(ctx, sessionId)
-	if err != nil {
-		return err
-	}
-	if len(pageRank) > 0 {
-		var score, diff, prevIndex int
 
-		if index >= 0 && index < len(pageRank) { // add page in between
-			// increment scores of proceeding pages
-			for _, pid := range pageRank[index:] {
-				if err := conn.Send(
-					"ZINCRBY", pageRankKey, 1, pid); err != nil {
-					return err
-				}
-			}
-			if err := conn.Flush(); err != nil {
+		newStrokes := make([]any, 1, 2*len(strokes)+1)
+		newStrokes[0] = getStrokesKey(sessionId, newPageId)
+		for id, s := range strokes {
+			stroke, err := setPageId([]byte(s), newPageId)
+			if err != nil {
 				return err
 			}
-			prevIndex = index
-			diff = -1
-		} else { // append page at the end
-			prevIndex = len(pageRank) - 1
-			diff = 1
+			newStrokes = append(newStrokes, id, stroke)
 		}
 
-		// get score of preceding page
-		score, err = redis.Int(conn.Do("ZSCORE", pageRankKey, pageRank[prevIndex]))
-		if err != nil {
+		if err := conn.Send("MULTI"); err != nil {
 			return err
 		}
-		if _, err := conn.Do(
-			"ZADD", pageRankKey, "NX", score+diff, newpageId); err != nil {
+		// remove any leftovers of the new page
+		if err := conn.Send("DEL", newStrokes[0], getPageMetaKey(sessionId, newPageId), getPageLayersKey(sessionId, newPageId),
+			getCommentsKey(sessionId, newPageId)); err != nil {
 			return err
 		}
-	} else { // no pages exist yet
-		if _, err := conn.Do("ZADD", pageRankKey, "NX", 0, newpageId); err != nil {
-			return err
+		if meta != nil {
+			if err := conn.Send("SET", getPageMetaKey(sessionId, newPageId), meta); err != nil {
+				return err
+			}
 		}
-	}
-	return nil
+		if layers != nil {
+			if err := conn.Send("SET", getPageLayersKey(sessionId, newPageId), layers); err != nil {
+				return err
+			}
+		}
+		if len(strokes) > 0 {
+			if err := conn.Send("HSET", newStrokes...); err != nil {
+				return err
+			}
+		}
+		return addPageScript.Send(conn, pageRankKey, getPageMetaKey(sessionId, newPageId), newPageId, index, "")
+	})
 }
 
-func (h *handler) DeletePage(ctx context.Context, sessionId, pageId string) error {
+// watchTx runs an optimistic transaction on the watched keys.
+//
+// The function reads the current state and queues its commands after MULTI.
+// The transaction is retried if any of the watched keys were modified in
+// the meantime.
+func (h *handler) watchTx(ctx context.Context, keys []string, fn func(conn redis.Conn) error) error {
 	conn, err := h.pool.GetContext(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	if err := conn.Send(
-		"DEL",
-		getStrokesKey(sessionId, pageId),
-		getPageMetaKey(sessionId, pageId),
-	); err != nil {
-		return err
+	watch := make([]any, len(keys))
+	for i, k := range keys {
+		watch[i] = k
 	}
-	if err := conn.Send(
-		"ZREM",
-		getPageRankKey(sessionId),
-		pageId,
-	); err != nil {
-		return err
+	for i := 0; i < maxTxAttempts; i++ {
+		if _, err := conn.Do("WATCH", watch...); err != nil {
+			return err
+		}
+		if err := fn(conn); err != nil {
+			_, _ = conn.Do("DISCARD")
+			_, _ = conn.Do("UNWATCH")
+			return err
+		}
+		reply, err := conn.Do("EXEC")
+		if err != nil {
+			return err
+		}
+		if reply != nil { // not aborted
+			return nil
+		}
+	}
+	return ErrTxAborted
+}
+
+// setPageId sets the page id of a JSON encoded stroke.
+func setPageId(stroke []byte, pageId string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(stroke, &fields); err != nil {
+		return nil, err
+	}
+	pid, err := json.Marshal(pageId)
+	if err != nil {
+		return nil, err
 	}
-	return conn.Flush()
+	fields["pageId"] = pid
+	return json.Marshal(fields)
 }
 
 func (h *handler) ClearSession(ctx context.Context, sessionId string) error {
@@ -199,10 +287,11 @@ func (h *handler) ClearSession(ctx context.Context, sessionId string) error {
 		return nil
 	}
 
-	query := make([]any, 1, len(pageRank)*2+1)
+	query := make([]any, 1, len(pageRank)*4+1)
 	query[0] = getPageRankKey(sessionId)
 	for _, pid := range pageRank {
-		query = append(query, getStrokesKey(sessionId, pid), getPageMetaKey(sessionId, pid))
+		query = append(query, getStrokesKey(sessionId, pid), getPageMetaKey(sessionId, pid), getPageLayersKey(sessionId, pid),
+			getCommentsKey(sessionId, pid))
 	}
 
 	_, err = h.Do(ctx, "DEL", query...)
@@ -122,6 +122,26 @@ func Test_handler_GetStrokesRaw(t *testing.T) {
 	assert.Equal(t, want, &got)
 }
 
+func Test_handler_GetStrokes(t *testing.T) {
+	ctx := context.Background()
+	mr, h := setupHandler(t)
+	defer mr.Close()
+	defer h.ClosePool()
+	sid := "sid"
+	pageId := "pageId"
+	err := h.UpdateStrokes(ctx, sid, genStroke("stroke1", pageId, 1), genStroke("stroke2", pageId, 1))
+	assert.NoError(t, err)
+
+	strokes, err := h.GetStrokes(ctx, sid, pageId, "stroke2", "unknown")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(strokes))
+	var got session.Stroke
+	err = json.Unmarshal(strokes[0], &got)
+	assert.NoError(t, err)
+	assert.Equal(t, genStroke("stroke2", pageId, 1), &got)
+}
+
 func Test_handler_AddPage(t *testing.T) {
 	ctx := context.Background()
 	mr, h := setupHandler(t)
@@ -152,6 +172,87 @@ func Test_handler_AddPage(t *testing.T) {
 	}
 }
 
+func Test_handler_MovePage(t *testing.T) {
+	ctx := context.Background()
+	mr, h := setupHandler(t)
+	defer mr.Close()
+	defer h.ClosePool()
+	sid := "sid"
+	for _, pid := range []string{"pid1", "pid2", "pid3", "pid4"} {
+		assert.NoError(t, h.AddPage(ctx, sid, pid, -1, nil))
+	}
+
+	tests := []struct {
+		pid   string
+		index int
+		want  []string
+	}{
+		{"pid1", 2, []string{"pid2", "pid3", "pid1", "pid4"}},
+		{"pid4", 0, []string{"pid4", "pid2", "pid3", "pid1"}},
+		{"pid2", -1, []string{"pid4", "pid3", "pid1", "pid2"}},
+		{"pid3", 999, []string{"pid4", "pid1", "pid2", "pid3"}},
+		{"pid3", 3, []string{"pid4", "pid1", "pid2", "pid3"}},
+	}
+	for _, test := range tests {
+		err := h.MovePage(ctx, sid, test.pid, test.index)
+		assert.NoError(t, err)
+		pids, err := h.GetPageRank(ctx, sid)
+		assert.NoError(t, err)
+		assert.Equal(t, test.want, pids, "pageRank is not correct")
+	}
+
+	// pages can still be added in between
+	assert.NoError(t, h.AddPage(ctx, sid, "pid5", 1, nil))
+	pids, err := h.GetPageRank(ctx, sid)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pid4", "pid5", "pid1", "pid2", "pid3"}, pids)
+
+	assert.Error(t, h.MovePage(ctx, sid, "unknown", 0))
+}
+
+func Test_handler_DuplicatePage(t *testing.T) {
+	ctx := context.Background()
+	mr, h := setupHandler(t)
+	defer mr.Close()
+	defer h.ClosePool()
+	sid := "sid"
+	meta := session.PageMeta{PageSize: session.PageSize{Width: 786, Height: 1024}, Background: session.PageBackground{Paper: "doc"}}
+	layers := session.Layers{{ID: "layer1", Name: "ink", Visible: true}}
+	assert.NoError(t, h.AddPage(ctx, sid, "pid1", -1, &meta))
+	assert.NoError(t, h.AddPage(ctx, sid, "pid2", -1, nil))
+	assert.NoError(t, h.SetPageLayers(ctx, sid, "pid1", layers))
+	assert.NoError(t, h.UpdateStrokes(ctx, sid, genStroke("stroke1", "pid1", 1), genStroke("stroke2", "pid1", 2)))
+
+	err := h.DuplicatePage(ctx, sid, "pid1", "pid3", 1)
+
+	assert.NoError(t, err)
+	pids, err := h.GetPageRank(ctx, sid)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pid1", "pid3", "pid2"}, pids)
+	var gotMeta session.PageMeta
+	assert.NoError(t, h.GetPageMeta(ctx, sid, "pid3", &gotMeta))
+	assert.Equal(t, meta, gotMeta)
+	var gotLayers session.Layers
+	assert.NoError(t, h.GetPageLayers(ctx, sid, "pid3", &gotLayers))
+	assert.Equal(t, layers, gotLayers)
+	strokes, err := h.GetStrokes(ctx, sid, "pid3", "stroke1", "stroke2")
+	assert.NoError(t, err)
+	assert.Len(t, strokes, 2)
+	for _, s := range strokes {
+		var got session.Stroke
+		assert.NoError(t, json.Unmarshal(s, &got))
+		want := genStroke(got.ID, "pid3", got.Type)
+		assert.Equal(t, want, &got)
+	}
+	// the original page is unchanged
+	strokes, err = h.GetPageStrokes(ctx, sid, "pid1")
+	assert.NoError(t, err)
+	assert.Len(t, strokes, 2)
+
+	assert.Error(t, h.DuplicatePage(ctx, sid, "pid1", "pid2", 0), "page exists")
+	assert.Error(t, h.DuplicatePage(ctx, sid, "unknown", "pid4", 0), "page does not exist")
+}
+
 func Test_handler_Get_SetPageMeta(t *testing.T) {
 	ctx := context.Background()
 	mr, h := setupHandler(t)
@@ -183,6 +284,41 @@ func Test_handler_Get_SetPageMeta(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
+func Test_handler_Get_SetPageLayers(t *testing.T) {
+	ctx := context.Background()
+	mr, h := setupHandler(t)
+	defer mr.Close()
+	defer h.ClosePool()
+	sid := "sid"
+	pageId := "pageId"
+	want := session.Layers{
+		{ID: "layer1", Name: "sketch", Visible: true},
+		{ID: "layer2", Name: "ink", Locked: true},
+	}
+
+	err := h.AddPage(ctx, sid, pageId, -1, nil)
+	assert.NoError(t, err)
+
+	var got session.Layers
+	err = h.GetPageLayers(ctx, sid, pageId, &got)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	err = h.SetPageLayers(ctx, sid, pageId, want)
+	assert.NoError(t, err)
+
+	err = h.GetPageLayers(ctx, sid, pageId, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	err = h.DeletePage(ctx, sid, pageId)
+	assert.NoError(t, err)
+	got = nil
+	err = h.GetPageLayers(ctx, sid, pageId, &got)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
 func Test_handler_DeletePage(t *testing.T) {
 	ctx := context.Background()
 	mr, h := setupHandler(t)
@@ -0,0 +1,261 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// ErrNotFound indicates that the object does not exist.
+var ErrNotFound = errors.New("s3: object not found")
+
+// Config declares the bucket and the credentials of the client.
+type Config struct {
+	// Endpoint is the base URL of the S3-compatible service
+	Endpoint string
+	Region   string
+	Bucket   string
+	// PathStyle addresses the bucket in the path instead of the host name
+	PathStyle bool
+	Credentials
+}
+
+// Client is a minimal client for objects of an S3-compatible bucket.
+type Client struct {
+	cfg      Config
+	endpoint *url.URL
+	http     *http.Client
+	now      func() time.Time
+}
+
+type Option = func(c *Client)
+
+// WithHTTPClient sets the http.Client of the requests.
+// This functional argument is passed to New.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.http = client
+	}
+}
+
+// New creates a new client for the bucket.
+func New(cfg Config, options ...Option) (*Client, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3: invalid endpoint: %w", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint: %s", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3: bucket required")
+	}
+	c := &Client{
+		cfg:      cfg,
+		endpoint: endpoint,
+		http:     &http.Client{Timeout: defaultTimeout},
+		now:      time.Now,
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c, nil
+}
+
+// objectURL returns the URL of the object key with the query.
+func (c *Client) objectURL(key string, query url.Values) *url.URL {
+	u := *c.endpoint
+	basePath := strings.TrimSuffix(u.Path, "/")
+	if c.cfg.PathStyle {
+		basePath += "/" + escape(c.cfg.Bucket)
+	} else {
+		u.Host = c.cfg.Bucket + "." + u.Host
+	}
+	u.RawPath = basePath + "/" + escapePath(key)
+	u.Path, _ = url.PathUnescape(u.RawPath)
+	u.RawQuery = query.Encode()
+	return &u
+}
+
+// do sends the signed request and returns the response for successful status codes.
+func (c *Client) do(ctx context.Context, method, key string, query url.Values, body []byte, header http.Header) (*http.Response, error) {
+	return c.send(ctx, method, key, query, bytes.NewReader(body), int64(len(body)), HashPayload(body), header)
+}
+
+// send sends the signed request with a body of given size and payload hash
+// and returns the response for successful status codes.
+func (c *Client) send(ctx context.Context, method, key string, query url.Values, body io.Reader, size int64, payloadHash string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(key, query).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	for name, v := range header {
+		req.Header[name] = v
+	}
+	Sign(req, payloadHash, c.cfg.Credentials, c.cfg.Region, c.now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	var s3Err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	_ = xml.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&s3Err)
+	return nil, fmt.Errorf("s3: %s %s: %s %s %s", method, key, resp.Status, s3Err.Code, s3Err.Message)
+}
+
+// PutObject stores the data with the content type under the key.
+func (c *Client) PutObject(ctx context.Context, key string, data []byte, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := c.do(ctx, http.MethodPut, key, nil, data, header)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// PutObjectReader stores the data of the reader with the content type under
+// the key. The size and the hex encoded SHA256 hash of the data must be
+// known in advance, since the data is streamed.
+func (c *Client) PutObjectReader(ctx context.Context, key string, r io.Reader, size int64, payloadHash, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := c.send(ctx, http.MethodPut, key, nil, r, size, payloadHash, header)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// ObjectInfo declares the metadata of an object.
+type ObjectInfo struct {
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// HeadObject returns the metadata of the object.
+func (c *Client) HeadObject(ctx context.Context, key string) (ObjectInfo, error) {
+	resp, err := c.do(ctx, http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer resp.Body.Close()
+	info := ObjectInfo{
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	info.LastModified, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+	return info, nil
+}
+
+// GetObjectRange returns the data of the object starting at the offset.
+//
+// The caller needs to close the returned body.
+func (c *Client) GetObjectRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	resp, err := c.do(ctx, http.MethodGet, key, nil, nil, header)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusPartialContent && offset > 0 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3: %s %s: range not supported", http.MethodGet, key)
+	}
+	return resp.Body, nil
+}
+
+// GetObject returns the data and the content type of the object.
+//
+// The caller needs to close the returned body.
+func (c *Client) GetObject(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	resp, err := c.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.Body, resp.Header.Get("Content-Type"), nil
+}
+
+// DeleteObject deletes the object. Deleting an object which does not exist succeeds.
+func (c *Client) DeleteObject(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// ListObjects returns the keys of all objects starting with the prefix.
+func (c *Client) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := c.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result ListBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("s3: list objects: %w", err)
+		}
+		for _, o := range result.Contents {
+			keys = append(keys, o.Key)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// ListBucketResult declares the response of ListObjectsV2 requests.
+type ListBucketResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Contents              []Object `xml:"Contents"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken,omitempty"`
+}
+
+// Object declares an entry of ListObjectsV2 responses.
+type Object struct {
+	Key  string `xml:"Key"`
+	Size int    `xml:"Size"`
+}
@@ -0,0 +1,155 @@
+// Package s3test provides an in-memory stand-in of an S3-compatible
+// service for tests.
+package s3test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boardsite-io/server/pkg/s3"
+)
+
+const (
+	Region = "us-east-1"
+	Bucket = "bucket"
+)
+
+// Credentials are the credentials accepted by the server.
+var Credentials = s3.Credentials{AccessKey: "access", SecretKey: "secret"}
+
+type object struct {
+	data         []byte
+	contentType  string
+	lastModified time.Time
+}
+
+// Server serves the objects of a single bucket addressed in path style.
+//
+// Requests are authenticated by their signature.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.RWMutex
+	objects map[string]object
+}
+
+// NewServer starts a new server. The caller needs to close the server.
+func NewServer() *Server {
+	s := &Server{objects: make(map[string]object)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Config returns the client config for the server.
+func (s *Server) Config() s3.Config {
+	return s3.Config{
+		Endpoint:    s.URL,
+		Region:      Region,
+		Bucket:      Bucket,
+		PathStyle:   true,
+		Credentials: Credentials,
+	}
+}
+
+// Keys returns the sorted keys of all stored objects.
+func (s *Server) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !authorized(r) {
+		writeError(w, http.StatusForbidden, "SignatureDoesNotMatch")
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key, _ := strings.Cut(path, "/")
+	if bucket != Bucket {
+		writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && key == "" && r.URL.Query().Get("list-type") == "2":
+		s.list(w, r.URL.Query().Get("prefix"))
+	case r.Method == http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil || s3.HashPayload(data) != r.Header.Get(s3.HeaderContentSHA256) {
+			writeError(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch")
+			return
+		}
+		s.mu.Lock()
+		s.objects[key] = object{data: data, contentType: r.Header.Get("Content-Type"), lastModified: time.Now()}
+		s.mu.Unlock()
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.mu.RLock()
+		o, ok := s.objects[key]
+		s.mu.RUnlock()
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", o.contentType)
+		http.ServeContent(w, r, "", o.lastModified, bytes.NewReader(o.data))
+	case r.Method == http.MethodDelete:
+		s.mu.Lock()
+		delete(s.objects, key)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, prefix string) {
+	var result s3.ListBucketResult
+	for _, k := range s.Keys() {
+		if strings.HasPrefix(k, prefix) {
+			result.Contents = append(result.Contents, s3.Object{Key: k})
+		}
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+// authorized verifies the signature of the request.
+func authorized(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	_, signed, ok := strings.Cut(auth, "SignedHeaders=")
+	if !ok {
+		return false
+	}
+	signed, _, _ = strings.Cut(signed, ",")
+
+	// only the signed headers are part of the signature
+	req := r.Clone(r.Context())
+	req.Header = http.Header{}
+	for _, name := range strings.Split(signed, ";") {
+		if name != "host" {
+			req.Header[http.CanonicalHeaderKey(name)] = r.Header.Values(name)
+		}
+	}
+	return s3.Authorization(req, Credentials, Region) == auth
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+	}{Code: code})
+}